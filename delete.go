@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/object"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+)
+
+const deleteUsage = `
+Usage:	timecraft delete <resource type> <resource ids...> [options]
+
+   The delete command removes resources from the time machine registry.
+
+   Deleting a process removes its log manifest, log segments and the profiles
+   that were generated from its records. Modules, configs and runtimes may be
+   shared by multiple processes, they are not deleted directly but collected by
+   'timecraft gc' when no processes reference them anymore.
+
+   The command prints the ids of the resources that were deleted.
+
+Examples:
+
+   $ timecraft delete process f6e9acbc-0543-47df-9413-b99f569cfa3b
+   f6e9acbc-0543-47df-9413-b99f569cfa3b
+
+Options:
+   -c, --config path  Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+   -h, --help         Show this usage information
+`
+
+// del implements the "delete" command; the function is not named after the
+// command to avoid shadowing the builtin.
+func del(ctx context.Context, args []string) error {
+	flagSet := newFlagSet("timecraft delete", deleteUsage)
+
+	args, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		perror(`expected a resource type as argument`)
+		return exitCode(2)
+	}
+
+	resource, err := findResource("delete", args[0])
+	if err != nil {
+		return err
+	}
+	if resource.delete == nil {
+		return fmt.Errorf(`resources of type %s cannot be deleted, use 'timecraft gc' to remove the ones which are not used anymore`, resource.typ)
+	}
+	resourceIDs := args[1:]
+	if len(resourceIDs) == 0 {
+		return fmt.Errorf(`no resources were specified, use 'timecraft delete %s <resources ids...>'`, resource.typ)
+	}
+	config, err := timecraft.LoadConfig()
+	if err != nil {
+		return err
+	}
+	registry, err := timecraft.OpenRegistry(config)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range resourceIDs {
+		deletedID, err := resource.delete(ctx, registry, id)
+		if err != nil {
+			return err
+		}
+		fmt.Println(deletedID)
+	}
+	return nil
+}
+
+func deleteProcess(ctx context.Context, reg *timemachine.Registry, id string) (string, error) {
+	processID, err := parseProcessID(id)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := reg.LookupLogManifest(ctx, processID)
+	if err != nil {
+		return "", err
+	}
+	if err := deleteProcessProfiles(ctx, reg, processID); err != nil {
+		return "", err
+	}
+	// The log is deleted before the process object so the process remains
+	// visible if we fail to delete it.
+	if err := reg.DeleteLog(ctx, processID); err != nil {
+		return "", err
+	}
+	if manifest.Process != nil {
+		if err := reg.DeleteResource(ctx, manifest.Process.Digest); err != nil {
+			return "", err
+		}
+	}
+	return processID.String(), nil
+}
+
+func deleteProcessProfiles(ctx context.Context, reg *timemachine.Registry, processID format.UUID) error {
+	r := reg.ListResources(ctx, format.TypeTimecraftProfile, timemachine.Since(time.Unix(0, 0)), object.Tag{
+		Name:  "timecraft.process.id",
+		Value: processID.String(),
+	})
+	profiles, err := stream.ReadAll[*format.Descriptor](r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if err := reg.DeleteResource(ctx, profile.Digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteProfile(ctx context.Context, reg *timemachine.Registry, id string) (string, error) {
+	desc, err := reg.LookupDescriptor(ctx, format.ParseHash(id))
+	if err != nil {
+		return "", err
+	}
+	if desc.MediaType != format.TypeTimecraftProfile {
+		return "", fmt.Errorf("%s: not a profile (%s)", id, desc.MediaType)
+	}
+	if err := reg.DeleteResource(ctx, desc.Digest); err != nil {
+		return "", err
+	}
+	return desc.Digest.Short(), nil
+}
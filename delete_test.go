@@ -0,0 +1,59 @@
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+var del = tests{
+	"show the delete command help with the short option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "delete", "-h")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft delete ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"show the delete command help with the long option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "delete", "--help")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft delete ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"delete without a resource type": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "delete")
+		assert.Equal(t, exitCode, 2)
+		assert.Equal(t, stdout, "")
+		assert.HasPrefix(t, stderr, "expected a resource type as argument")
+	},
+
+	"modules cannot be deleted": func(t *testing.T) {
+		_, stderr, exitCode := timecraft(t, "delete", "module", "9d7b7563baf3")
+		assert.Equal(t, exitCode, 1)
+		assert.True(t, strings.Contains(stderr, "timecraft gc"))
+	},
+
+	"delete process after run": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "sleeping for 1ns\n")
+
+		stdout, stderr, exitCode := timecraft(t, "delete", "process", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, processID)
+		assert.Equal(t, stderr, "")
+
+		procID, stderr, exitCode := timecraft(t, "get", "proc", "-q")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, procID, "")
+		assert.Equal(t, stderr, "")
+	},
+
+	"deleting a process which does not exist causes an error": func(t *testing.T) {
+		_, stderr, exitCode := timecraft(t, "delete", "process", "f6e9acbc-0543-47df-9413-b99f569cfa3b")
+		assert.Equal(t, exitCode, 1)
+		assert.HasPrefix(t, stderr, "ERR: timecraft delete: process has no records")
+	},
+}
@@ -18,6 +18,7 @@ import (
 	"unicode/utf8"
 
 	pprof "github.com/google/pprof/profile"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stealthrocket/timecraft/format"
 	"github.com/stealthrocket/timecraft/internal/print/human"
 	"github.com/stealthrocket/timecraft/internal/print/jsonprint"
@@ -160,6 +161,7 @@ func describeConfig(ctx context.Context, reg *timemachine.Registry, id string, c
 			runtime: runtime,
 			version: version,
 		},
+		image:   imageName(c.Image),
 		modules: make([]moduleDescriptor, len(c.Modules)),
 		args:    c.Args,
 		env:     c.Env,
@@ -264,11 +266,13 @@ func describeProcess(ctx context.Context, reg *timemachine.Registry, id string,
 
 	desc := &processDescriptor{
 		id:        processID,
+		parentID:  p.ParentID,
 		startTime: human.Time(p.StartTime.In(time.Local)),
 		runtime: runtimeDescriptor{
 			runtime: runtime,
 			version: version,
 		},
+		image:   imageName(c.Image),
 		modules: make([]moduleDescriptor, len(c.Modules)),
 		args:    c.Args,
 		env:     c.Env,
@@ -455,9 +459,20 @@ func lookupProcessByLogID(ctx context.Context, reg *timemachine.Registry, id str
 	return processID, manifest.Process, process, nil
 }
 
+func imageName(image *format.Descriptor) string {
+	if image == nil {
+		return ""
+	}
+	if ref, ok := image.Annotations[ocispec.AnnotationRefName]; ok {
+		return fmt.Sprintf("%s (%s)", image.Digest, ref)
+	}
+	return image.Digest.String()
+}
+
 type configDescriptor struct {
 	id      string
 	runtime runtimeDescriptor
+	image   string
 	modules []moduleDescriptor
 	args    []string
 	env     []string
@@ -466,6 +481,9 @@ type configDescriptor struct {
 func (desc *configDescriptor) Format(w fmt.State, _ rune) {
 	fmt.Fprintf(w, "ID:      %s\n", desc.id)
 	fmt.Fprintf(w, "Runtime: %s (%s)\n", desc.runtime.runtime, desc.runtime.version)
+	if desc.image != "" {
+		fmt.Fprintf(w, "Image:   %s\n", desc.image)
+	}
 	fmt.Fprintf(w, "Modules:\n")
 	for _, module := range desc.modules {
 		fmt.Fprintf(w, "  %s: %s (%v)\n", module.id, module.name, module.size)
@@ -594,8 +612,10 @@ func (desc *moduleDescriptor) Format(w fmt.State, _ rune) {
 
 type processDescriptor struct {
 	id        format.UUID
+	parentID  *format.UUID
 	startTime human.Time
 	runtime   runtimeDescriptor
+	image     string
 	modules   []moduleDescriptor
 	args      []string
 	env       []string
@@ -604,8 +624,14 @@ type processDescriptor struct {
 
 func (desc *processDescriptor) Format(w fmt.State, _ rune) {
 	fmt.Fprintf(w, "ID:      %s\n", desc.id)
+	if desc.parentID != nil {
+		fmt.Fprintf(w, "Parent:  %s\n", desc.parentID)
+	}
 	fmt.Fprintf(w, "Start:   %s, %s\n", desc.startTime, time.Time(desc.startTime).Format(time.RFC1123))
 	fmt.Fprintf(w, "Runtime: %s (%s)\n", desc.runtime.runtime, desc.runtime.version)
+	if desc.image != "" {
+		fmt.Fprintf(w, "Image:   %s\n", desc.image)
+	}
 	fmt.Fprintf(w, "Modules:\n")
 	for _, module := range desc.modules {
 		fmt.Fprintf(w, "  %s: %s (%v)\n", module.id, module.name, module.size)
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/debug/logdiff"
+	"github.com/stealthrocket/timecraft/internal/print/human"
+	"github.com/stealthrocket/timecraft/internal/print/jsonprint"
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/timecraft/internal/print/yamlprint"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+)
+
+const diffUsage = `
+Usage:	timecraft diff [options] <process a> <process b>
+
+   The diff command compares the system calls recorded in the logs of two
+   processes, typically two executions of the same module, and reports where
+   the executions diverged.
+
+   System calls are aligned when they are calls to the same function with the
+   same parameters. Buffers filled by the host, like the data returned by
+   fd_read or random_get, are compared as results of the calls. After the first
+   divergence, the logs are realigned on the closest pair of identical calls
+   found in the next system calls of each log (see --window).
+
+   The output reports the first divergence and a summary of the system calls
+   which differed between the two logs.
+
+Options:
+   -c, --config path    Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+   -h, --help           Show this usage information
+   -o, --output format  Output format, one of: text, json, yaml
+   -w, --window count   Number of system calls to look ahead when realigning the logs (default to 256)
+`
+
+func diff(ctx context.Context, args []string) error {
+	var (
+		output = outputFormat("text")
+		window = human.Count(logdiff.DefaultWindow)
+	)
+
+	flagSet := newFlagSet("timecraft diff", diffUsage)
+	customVar(flagSet, &output, "o", "output")
+	customVar(flagSet, &window, "w", "window")
+
+	args, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return errors.New(`expected exactly two process ids as arguments`)
+	}
+	if window < 1 {
+		return errors.New(`the window must contain at least one system call`)
+	}
+
+	var processIDs [2]format.UUID
+	for i, arg := range args {
+		if processIDs[i], err = parseProcessID(arg); err != nil {
+			return err
+		}
+	}
+
+	config, err := timecraft.LoadConfig()
+	if err != nil {
+		return err
+	}
+	registry, err := timecraft.OpenRegistry(config)
+	if err != nil {
+		return err
+	}
+
+	var readers [2]*wasicall.Reader
+	for i, processID := range processIDs {
+		records, _, err := timecraft.NewReplay(registry, nil, processID).RecordReader(ctx)
+		if err != nil {
+			return err
+		}
+		defer records.Close()
+		readers[i] = wasicall.NewReader(records)
+	}
+
+	d, err := logdiff.Compare(readers[0], readers[1], int(window))
+	if err != nil {
+		return err
+	}
+	result := &logDiff{
+		ProcessA: processIDs[0],
+		ProcessB: processIDs[1],
+		Diff:     d,
+	}
+
+	var writer stream.WriteCloser[*logDiff]
+	switch output {
+	case "json":
+		writer = jsonprint.NewWriter[*logDiff](os.Stdout)
+	case "yaml":
+		writer = yamlprint.NewWriter[*logDiff](os.Stdout)
+	default:
+		writer = textprint.NewWriter[*logDiff](os.Stdout)
+	}
+	defer writer.Close()
+
+	_, err = writer.Write([]*logDiff{result})
+	return err
+}
+
+type logDiff struct {
+	ProcessA format.UUID `json:"processA" yaml:"processA"`
+	ProcessB format.UUID `json:"processB" yaml:"processB"`
+
+	*logdiff.Diff `yaml:",inline"`
+}
+
+func (d *logDiff) Format(w fmt.State, _ rune) {
+	fmt.Fprintf(w, "A: %s (%d system calls)\n", d.ProcessA, d.CallsA)
+	fmt.Fprintf(w, "B: %s (%d system calls)\n", d.ProcessB, d.CallsB)
+	fmt.Fprintf(w, "\n")
+
+	if d.Divergence == nil {
+		fmt.Fprintf(w, "The logs contain the same %d system calls", d.Aligned)
+		if d.AlignedResults != 0 {
+			fmt.Fprintf(w, ", %d of which returned different results", d.AlignedResults)
+		}
+		fmt.Fprintf(w, ".\n")
+	} else {
+		fmt.Fprintf(w, "First divergence:\n")
+		for _, c := range []struct {
+			name string
+			call *logdiff.Call
+		}{
+			{"A", d.Divergence.A},
+			{"B", d.Divergence.B},
+		} {
+			if c.call == nil {
+				fmt.Fprintf(w, "  %s: end of log\n", c.name)
+			} else {
+				fmt.Fprintf(w, "  %s: #%d %s\n", c.name, c.call.Offset, c.call)
+			}
+		}
+	}
+
+	if len(d.Syscalls) != 0 {
+		fmt.Fprintf(w, "\n")
+		table := textprint.NewTableWriter[logdiff.Stat](w)
+		_, _ = table.Write(d.Syscalls)
+		_ = table.Close()
+	}
+}
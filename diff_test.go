@@ -0,0 +1,86 @@
+package main_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+var diff = tests{
+	"show the diff command help with the short option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "diff", "-h")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft diff ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"show the diff command help with the long option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "diff", "--help")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft diff ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"diff requires two process ids": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "diff", "a47b1c1e-4f5c-4b8a-9d9f-2e5b5c7e7d3a")
+		assert.Equal(t, exitCode, 1)
+		assert.Equal(t, stdout, "")
+		assert.HasPrefix(t, stderr, "ERR: timecraft diff: expected exactly two process ids as arguments")
+	},
+
+	"a log does not diverge from itself": func(t *testing.T) {
+		processID := runEcho(t, "hello")
+
+		stdout, stderr, exitCode := timecraft(t, "diff", processID, processID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stderr, "")
+		assert.HasPrefix(t, stdout, "A: "+processID+" (")
+		assert.True(t, strings.Contains(stdout, "The logs contain the same "))
+	},
+
+	"the first divergence between two executions is reported": func(t *testing.T) {
+		processA := runEcho(t, "hello")
+		processB := runEcho(t, "world")
+
+		stdout, stderr, exitCode := timecraft(t, "diff", processA, processB)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stderr, "")
+		assert.True(t, strings.Contains(stdout, "First divergence:\n"))
+
+		stdout, _, exitCode = timecraft(t, "diff", "-o", "json", processA, processB)
+		assert.Equal(t, exitCode, 0)
+
+		var result struct {
+			ProcessA   string    `json:"processA"`
+			Divergence *struct{} `json:"divergence"`
+			Syscalls   []struct {
+				Syscall string `json:"syscall"`
+				OnlyA   int    `json:"onlyA"`
+				OnlyB   int    `json:"onlyB"`
+			} `json:"syscalls"`
+		}
+		assert.OK(t, json.Unmarshal([]byte(stdout), &result))
+		assert.Equal(t, result.ProcessA, processA)
+		assert.True(t, result.Divergence != nil)
+
+		// The runtime may make a different number of calls to the clock, but the
+		// writes to stdout must always be reported as different.
+		writes := false
+		for _, s := range result.Syscalls {
+			if s.Syscall == "FDWrite" {
+				assert.Equal(t, s.OnlyA, 1)
+				assert.Equal(t, s.OnlyB, 1)
+				writes = true
+			}
+		}
+		assert.True(t, writes)
+	},
+}
+
+func runEcho(t *testing.T, args ...string) string {
+	_, processID, exitCode := timecraft(t, append([]string{"run", "--", "./testdata/go/echo.wasm", "-n"}, args...)...)
+	assert.Equal(t, exitCode, 0)
+	return strings.TrimSpace(processID)
+}
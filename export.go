@@ -1,13 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 
 	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/debug/tracing"
+	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
 )
 
 const exportUsage = `
@@ -21,22 +30,62 @@ Usage:	timecraft export <resource type> <resource id> <output file> [options]
    a path on the file system. The special value "-" may be set to write the
    resource to stdout.
 
+   The "pcap" resource type synthesizes network packets from the socket
+   operations recorded for a process and writes them in the PCAP-NG format,
+   which can be opened with tools like Wireshark:
+
+   $ timecraft export pcap <process id> out.pcapng
+
+   The "har" resource type writes the HTTP exchanges of a process as an HTTP
+   Archive, which can be loaded in browser developer tools:
+
+   $ timecraft export har <process id> out.har
+
+   The "otlp" resource type converts the HTTP requests served and made by a
+   process into OpenTelemetry spans, nested under a span representing the
+   process itself. Trace contexts found in traceparent headers of the recorded
+   traffic are propagated to the spans. The spans are written in the OTLP/JSON
+   format, or pushed to a collector when an endpoint is set, in which case the
+   output file may be omitted:
+
+   $ timecraft export otlp <process id> out.json
+   $ timecraft export otlp <process id> --endpoint http://localhost:4318
+
 Options:
-   -c, --config path  Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
-   -h, --help         Show this usage information
+   -c, --config path   Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+   -e, --endpoint url  URL of an OTLP/HTTP collector to push spans to (otlp only)
+   -h, --help          Show this usage information
 `
 
 func export(ctx context.Context, args []string) error {
+	var (
+		endpoint = ""
+	)
 	flagSet := newFlagSet("timecraft export", exportUsage)
+	stringVar(flagSet, &endpoint, "e", "endpoint")
 
 	args, err := parseFlags(flagSet, args)
 	if err != nil {
 		return err
 	}
+	if len(args) == 2 && args[0] == "otlp" && endpoint != "" {
+		args = append(args, "")
+	}
 	if len(args) != 3 {
 		perrorf(`Expected resource type, id, and output file as argument` + useCmd("export"))
 		return exitCode(2)
 	}
+	if args[0] == "otlp" {
+		return exportOTLP(ctx, args[1], args[2], endpoint)
+	}
+	if endpoint != "" {
+		perrorf(`The --endpoint option is only supported when exporting otlp traces` + useCmd("export"))
+		return exitCode(2)
+	}
+	switch args[0] {
+	case "pcap", "pcapng", "har":
+		return exportTrace(ctx, args[0], args[1], args[2])
+	}
 	resource, err := findResource("describe", args[0])
 	if err != nil {
 		perror(err)
@@ -84,16 +133,225 @@ func export(ctx context.Context, args []string) error {
 	}
 	defer r.Close()
 
-	w := io.Writer(os.Stdout)
-	if outputFile := args[2]; outputFile != "-" {
-		f, err := os.Create(outputFile)
+	w, err := createExportFile(args[2])
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func exportTrace(ctx context.Context, typ, processID, outputFile string) error {
+	id, err := parseProcessID(processID)
+	if err != nil {
+		return err
+	}
+	config, err := timecraft.LoadConfig()
+	if err != nil {
+		return err
+	}
+	registry, err := timecraft.OpenRegistry(config)
+	if err != nil {
+		return err
+	}
+	manifest, err := registry.LookupLogManifest(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	logSegment, err := registry.ReadLog(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	defer logSegment.Close()
+
+	logReader := timemachine.NewLogReader(logSegment, manifest)
+	defer logReader.Close()
+
+	w, err := createExportFile(outputFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	events := &tracing.EventReader{
+		Records: timemachine.NewLogRecordReader(logReader),
+	}
+
+	switch typ {
+	case "har":
+		har := tracing.NewHARWriter(w, timecraft.Version())
+		_, err = stream.Copy[tracing.Exchange](har, &tracing.ExchangeReader{
+			Messages: &tracing.MessageReader{
+				Events: events,
+				Protos: []tracing.ConnProtocol{
+					tracing.HTTP1(),
+					tracing.HTTP2(),
+				},
+			},
+		})
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		w = f
+		return har.Close()
+	default:
+		pcap := tracing.NewPcapWriter(w)
+		_, err = stream.Copy[tracing.Event](pcap, events)
+		if err != nil {
+			return err
+		}
+		return pcap.Close()
 	}
+}
 
-	_, err = io.Copy(w, r)
-	return err
+func exportOTLP(ctx context.Context, processID, outputFile, endpoint string) error {
+	id, err := parseProcessID(processID)
+	if err != nil {
+		return err
+	}
+	config, err := timecraft.LoadConfig()
+	if err != nil {
+		return err
+	}
+	registry, err := timecraft.OpenRegistry(config)
+	if err != nil {
+		return err
+	}
+	manifest, err := registry.LookupLogManifest(ctx, id)
+	if err != nil {
+		return err
+	}
+	process, err := registry.LookupProcess(ctx, manifest.Process.Digest)
+	if err != nil {
+		return err
+	}
+	processConfig, err := registry.LookupConfig(ctx, process.Config.Digest)
+	if err != nil {
+		return err
+	}
+
+	logSegment, err := registry.ReadLog(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	defer logSegment.Close()
+
+	logReader := timemachine.NewLogReader(logSegment, manifest)
+	defer logReader.Close()
+
+	otlp := &tracing.OTLPWriter{
+		Process: tracing.OTLPProcess{
+			ID:        manifest.ProcessID,
+			StartTime: manifest.StartTime,
+			EndTime:   manifest.StartTime,
+		},
+	}
+	if len(processConfig.Args) > 0 {
+		otlp.Process.Name = path.Base(processConfig.Args[0])
+	}
+
+	// The process span ends with the last record of the log, and carries the
+	// exit code if the guest called proc_exit.
+	var codec wasicall.Codec
+	records := timemachine.NewLogRecordReader(logReader)
+	observer := stream.ReaderFunc(func(values []timemachine.Record) (int, error) {
+		n, err := records.Read(values)
+		for _, record := range values[:n] {
+			otlp.Process.EndTime = record.Time
+			if wasicall.SyscallID(record.FunctionID) == wasicall.ProcExit {
+				exitCode, _, err := codec.DecodeProcExit(record.FunctionCall)
+				if err != nil {
+					return 0, err
+				}
+				code := int(exitCode)
+				otlp.Process.ExitCode = &code
+			}
+		}
+		return n, err
+	})
+
+	_, err = stream.Copy[tracing.Exchange](otlp, &tracing.ExchangeReader{
+		Messages: &tracing.MessageReader{
+			Events: &tracing.EventReader{Records: observer},
+			Protos: []tracing.ConnProtocol{
+				tracing.HTTP1(),
+				tracing.HTTP2(),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if endpoint != "" {
+		buffer := new(bytes.Buffer)
+		otlp.Output = buffer
+		if err := otlp.Close(); err != nil {
+			return err
+		}
+		if outputFile != "" {
+			if err := writeExportFile(outputFile, buffer.Bytes()); err != nil {
+				return err
+			}
+		}
+		return pushOTLP(ctx, endpoint, buffer.Bytes())
+	}
+
+	w, err := createExportFile(outputFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	otlp.Output = w
+	return otlp.Close()
 }
+
+func pushOTLP(ctx context.Context, endpoint string, body []byte) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("malformed collector endpoint: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("collector responded with %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func writeExportFile(path string, data []byte) error {
+	w, err := createExportFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func createExportFile(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
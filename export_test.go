@@ -1,7 +1,16 @@
 package main_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -63,4 +72,185 @@ var export = tests{
 		assert.OK(t, err)
 		assert.True(t, moduleData == string(sleepWasm))
 	},
+
+	"export the network traces of a process": func(t *testing.T) {
+		processID := runHTTPClient(t, "/export", "hello")
+
+		tests := []struct {
+			resource string
+			check    func(*testing.T, []byte)
+		}{
+			{
+				resource: "pcap",
+				check: func(t *testing.T, b []byte) {
+					packets := readPcapNG(t, b)
+					assert.True(t, len(packets) > 0)
+					assert.True(t, slices.ContainsFunc(packets, func(p []byte) bool {
+						return bytes.HasPrefix(tcpPayload(p), []byte("GET /export HTTP/1.1\r\n"))
+					}))
+					assert.True(t, slices.ContainsFunc(packets, func(p []byte) bool {
+						return bytes.HasPrefix(tcpPayload(p), []byte("HTTP/1.1 200 OK\r\n"))
+					}))
+				},
+			},
+
+			{
+				resource: "har",
+				check: func(t *testing.T, b []byte) {
+					var har struct {
+						Log struct {
+							Version string
+							Creator struct{ Name string }
+							Entries []struct {
+								Request struct {
+									Method string
+									URL    string
+								}
+								Response struct {
+									Status  int
+									Content struct{ Text string }
+								}
+							}
+						}
+					}
+					assert.OK(t, json.Unmarshal(b, &har))
+					assert.Equal(t, har.Log.Version, "1.2")
+					assert.Equal(t, har.Log.Creator.Name, "timecraft")
+					assert.Equal(t, len(har.Log.Entries), 1)
+
+					entry := har.Log.Entries[0]
+					assert.Equal(t, entry.Request.Method, "GET")
+					assert.True(t, strings.HasSuffix(entry.Request.URL, "/export"))
+					assert.Equal(t, entry.Response.Status, 200)
+					assert.Equal(t, entry.Response.Content.Text, "hello")
+				},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.resource, func(t *testing.T) {
+				output := filepath.Join(t.TempDir(), "out."+test.resource)
+				stdout, stderr, exitCode := timecraft(t, "export", test.resource, processID, output)
+				assert.Equal(t, exitCode, 0)
+				assert.Equal(t, stdout, "")
+				assert.Equal(t, stderr, "")
+
+				b, err := os.ReadFile(output)
+				assert.OK(t, err)
+				test.check(t, b)
+			})
+		}
+	},
+
+	"export the spans of a process as otlp": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "sleeping for 1ns\n")
+		assert.NotEqual(t, processID, "")
+
+		otlpData, stderr, exitCode := timecraft(t, "export", "otlp", strings.TrimSuffix(processID, "\n"), "-")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stderr, "")
+		assert.HasPrefix(t, otlpData, `{
+  "resourceSpans": [`)
+		assert.True(t, strings.Contains(otlpData, `"name": "sleep.wasm"`))
+	},
+
+	"push the spans of a process to an otlp collector": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "sleeping for 1ns\n")
+		assert.NotEqual(t, processID, "")
+
+		requests := make(chan *http.Request, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests <- r
+		}))
+		defer server.Close()
+
+		stdout, stderr, exitCode := timecraft(t, "export", "otlp", strings.TrimSuffix(processID, "\n"), "--endpoint", server.URL)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "")
+		assert.Equal(t, stderr, "")
+
+		req := <-requests
+		assert.Equal(t, req.Method, "POST")
+		assert.Equal(t, req.URL.Path, "/v1/traces")
+		assert.Equal(t, req.Header.Get("Content-Type"), "application/json")
+	},
+
+	"export with an endpoint for a resource that is not a trace": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "export", "profile", "74080192e42e", "-", "--endpoint", "http://localhost:4318")
+		assert.Equal(t, exitCode, 2)
+		assert.Equal(t, stdout, "")
+		assert.HasPrefix(t, stderr, "The --endpoint option is only supported when exporting otlp traces")
+	},
+
+	"export with an endpoint for a trace that is not otlp": func(t *testing.T) {
+		for _, typ := range []string{"pcap", "pcapng", "har"} {
+			stdout, stderr, exitCode := timecraft(t, "export", typ, "74080192e42e", "-", "--endpoint", "http://localhost:4318")
+			assert.Equal(t, exitCode, 2)
+			assert.Equal(t, stdout, "")
+			assert.HasPrefix(t, stderr, "The --endpoint option is only supported when exporting otlp traces")
+		}
+	},
+}
+
+// runHTTPClient runs a module sending a GET request to the given path of an
+// HTTP server responding with body, and returns the ID of the process.
+func runHTTPClient(t *testing.T, path, body string) string {
+	// Guest modules cannot reach the loopback interface of the host, the
+	// server must listen on an external address.
+	l, err := net.Listen("tcp", net.JoinHostPort(externalIP(t), "0"))
+	assert.OK(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	server.Listener = l
+	server.Start()
+	defer server.Close()
+
+	stdout, processID, exitCode := timecraft(t, "run", "./testdata/go/get.wasm", server.URL+path)
+	assert.Equal(t, exitCode, 0)
+	assert.Equal(t, stdout, body+"\n")
+	assert.NotEqual(t, processID, "")
+	return strings.TrimSuffix(processID, "\n")
+}
+
+// readPcapNG decodes the blocks of a PCAP-NG capture, and returns the packets
+// of its enhanced packet blocks.
+func readPcapNG(t *testing.T, b []byte) (packets [][]byte) {
+	var blockTypes []uint32
+	for len(b) > 0 {
+		assert.True(t, len(b) >= 12)
+		blockType := binary.LittleEndian.Uint32(b[0:])
+		blockSize := binary.LittleEndian.Uint32(b[4:])
+		assert.True(t, blockSize >= 12 && blockSize%4 == 0 && int(blockSize) <= len(b))
+		assert.Equal(t, binary.LittleEndian.Uint32(b[blockSize-4:]), blockSize)
+
+		body := b[8 : blockSize-4]
+		if blockType == 6 { // enhanced packet block
+			capturedLength := binary.LittleEndian.Uint32(body[12:])
+			packets = append(packets, body[20:20+capturedLength])
+		}
+		blockTypes = append(blockTypes, blockType)
+		b = b[blockSize:]
+	}
+	// Section header block, followed by the interface description block.
+	assert.True(t, len(blockTypes) >= 2)
+	assert.Equal(t, blockTypes[0], 0x0A0D0D0A)
+	assert.Equal(t, blockTypes[1], 1)
+	return packets
+}
+
+// tcpPayload returns the payload of a raw IPv4 packet carrying a TCP segment.
+func tcpPayload(packet []byte) []byte {
+	if len(packet) < 20 || packet[0]>>4 != 4 || packet[9] != 6 {
+		return nil
+	}
+	segment := packet[int(packet[0]&0xF)*4:]
+	if len(segment) < 20 {
+		return nil
+	}
+	return segment[int(segment[12]>>4)*4:]
 }
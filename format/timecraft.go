@@ -148,10 +148,11 @@ func (r *Runtime) UnmarshalResource(b []byte) error {
 }
 
 type Config struct {
-	Runtime *Descriptor   `json:"runtime"       yaml:"runtime"`
-	Modules []*Descriptor `json:"modules"       yaml:"modules"`
-	Args    []string      `json:"args"          yaml:"args"`
-	Env     []string      `json:"env,omitempty" yaml:"env,omitempty"`
+	Runtime *Descriptor   `json:"runtime"         yaml:"runtime"`
+	Modules []*Descriptor `json:"modules"         yaml:"modules"`
+	Args    []string      `json:"args"            yaml:"args"`
+	Env     []string      `json:"env,omitempty"   yaml:"env,omitempty"`
+	Image   *Descriptor   `json:"image,omitempty" yaml:"image,omitempty"`
 }
 
 func (c *Config) ContentType() MediaType {
@@ -167,9 +168,10 @@ func (c *Config) UnmarshalResource(b []byte) error {
 }
 
 type Process struct {
-	ID        UUID        `json:"id"        yaml:"id"`
-	StartTime time.Time   `json:"startTime" yaml:"startTime"`
-	Config    *Descriptor `json:"config"    yaml:"config"`
+	ID        UUID        `json:"id"                 yaml:"id"`
+	StartTime time.Time   `json:"startTime"          yaml:"startTime"`
+	Config    *Descriptor `json:"config"             yaml:"config"`
+	ParentID  *UUID       `json:"parentID,omitempty" yaml:"parentID,omitempty"`
 }
 
 func (p *Process) ContentType() MediaType {
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/object"
+	"github.com/stealthrocket/timecraft/internal/print/human"
+	"github.com/stealthrocket/timecraft/internal/print/jsonprint"
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/timecraft/internal/print/yamlprint"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+)
+
+const gcUsage = `
+Usage:	timecraft gc [options]
+
+   The gc command deletes resources of the time machine registry which are not
+   used anymore.
+
+   Process records are the roots of the registry: the modules, configs and
+   runtimes referenced by processes, and the profiles generated from their
+   records, are retained while all other resources are deleted.
+
+   When --older-than is set, processes which started before the retention
+   period are deleted as well, along with the resources that were only used
+   by those processes.
+
+   Resources created during the grace period are never deleted, even if no
+   process references them, because processes which are starting may not
+   have recorded their manifest yet.
+
+Examples:
+
+   $ timecraft gc --dry-run --older-than 2w
+   TYPE     ID                                    SIZE
+   process  f6e9acbc-0543-47df-9413-b99f569cfa3b  1.21 MiB
+   config   8e4a0e76ec1f                          312 B
+   module   9d7b7563baf3                          6.82 MiB
+   3 resources would be deleted (8.03 MiB)
+
+Options:
+   -c, --config path            Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+   -n, --dry-run                Show the resources that would be deleted without deleting them
+       --grace-period duration  Only delete resources created more than this duration ago (default to 1h)
+   -h, --help                   Show this usage information
+       --older-than duration    Also delete processes that started more than this duration ago
+   -o, --output format          Output format, one of: text, json, yaml
+`
+
+type gcResource struct {
+	Type string      `json:"type" yaml:"type" text:"TYPE"`
+	ID   string      `json:"id"   yaml:"id"   text:"ID"`
+	Size human.Bytes `json:"size" yaml:"size" text:"SIZE"`
+}
+
+type gcGarbage struct {
+	resource gcResource
+	delete   func(context.Context, *timemachine.Registry) error
+}
+
+func gc(ctx context.Context, args []string) error {
+	var (
+		dryRun      = false
+		gracePeriod = human.Duration(time.Hour)
+		olderThan   = human.Duration(0)
+		output      = outputFormat("text")
+	)
+
+	flagSet := newFlagSet("timecraft gc", gcUsage)
+	boolVar(flagSet, &dryRun, "n", "dry-run")
+	customVar(flagSet, &gracePeriod, "grace-period")
+	customVar(flagSet, &olderThan, "older-than")
+	customVar(flagSet, &output, "o", "output")
+
+	args, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		perrorf(`Unexpected arguments: %q`, args)
+		return exitCode(2)
+	}
+	if olderThan < 0 {
+		return errors.New(`the retention period passed to --older-than cannot be negative`)
+	}
+	if gracePeriod < 0 {
+		return errors.New(`the grace period passed to --grace-period cannot be negative`)
+	}
+
+	config, err := timecraft.LoadConfig()
+	if err != nil {
+		return err
+	}
+	registry, err := timecraft.OpenRegistry(config)
+	if err != nil {
+		return err
+	}
+
+	var retention time.Time
+	if olderThan > 0 {
+		retention = time.Now().Add(-time.Duration(olderThan))
+	}
+
+	garbage, err := collectGarbage(ctx, registry, retention, time.Now().Add(-time.Duration(gracePeriod)))
+	if err != nil {
+		return err
+	}
+
+	resources := make([]gcResource, len(garbage))
+	size := human.Bytes(0)
+	for i, g := range garbage {
+		if !dryRun {
+			if err := g.delete(ctx, registry); err != nil {
+				return err
+			}
+		}
+		resources[i] = g.resource
+		size += g.resource.Size
+	}
+
+	var writer stream.WriteCloser[gcResource]
+	switch output {
+	case "json":
+		writer = jsonprint.NewWriter[gcResource](os.Stdout)
+	case "yaml":
+		writer = yamlprint.NewWriter[gcResource](os.Stdout)
+	default:
+		writer = textprint.NewTableWriter[gcResource](os.Stdout)
+	}
+	if _, err := writer.Write(resources); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if output == "text" {
+		summary := "resources"
+		if len(resources) == 1 {
+			summary = "resource"
+		}
+		if dryRun {
+			summary += " would be deleted"
+		} else {
+			summary += " deleted"
+		}
+		fmt.Printf("%d %s (%s)\n", len(resources), summary, size)
+	}
+	return nil
+}
+
+// collectGarbage returns the list of resources of the registry which are not
+// reachable from processes that started after the retention time. Resources
+// created after the grace time are retained.
+//
+// Expired processes come first in the list so their logs are deleted before the
+// objects that they reference; if the deletion is interrupted, running gc again
+// resumes where it stopped.
+func collectGarbage(ctx context.Context, reg *timemachine.Registry, retention, grace time.Time) ([]gcGarbage, error) {
+	r := reg.ListLogManifests(ctx)
+	manifests, err := stream.ReadAll[*format.Manifest](r)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(manifests, func(m1, m2 *format.Manifest) int {
+		return m1.StartTime.Compare(m2.StartTime)
+	})
+
+	garbage := []gcGarbage{}
+	liveObjects := make(map[format.Hash]struct{})
+	liveProcesses := make(map[string]struct{})
+	// Process objects of expired processes are deleted with their log, they
+	// are tracked here to avoid listing them a second time.
+	collected := make(map[format.Hash]struct{})
+
+	for _, m := range manifests {
+		if m.StartTime.Before(retention) {
+			garbage = append(garbage, gcProcess(m))
+			if m.Process != nil {
+				collected[m.Process.Digest] = struct{}{}
+			}
+			continue
+		}
+
+		liveProcesses[m.ProcessID.String()] = struct{}{}
+		if m.Process == nil {
+			continue
+		}
+		liveObjects[m.Process.Digest] = struct{}{}
+
+		process, err := reg.LookupProcess(ctx, m.Process.Digest)
+		if err != nil {
+			if errors.Is(err, object.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		liveObjects[process.Config.Digest] = struct{}{}
+
+		config, err := reg.LookupConfig(ctx, process.Config.Digest)
+		if err != nil {
+			if errors.Is(err, object.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		liveObjects[config.Runtime.Digest] = struct{}{}
+		for _, module := range config.Modules {
+			liveObjects[module.Digest] = struct{}{}
+		}
+	}
+
+	for _, resource := range [...]struct {
+		typ       string
+		mediaType format.MediaType
+	}{
+		{"process", format.TypeTimecraftProcess},
+		{"config", format.TypeTimecraftConfig},
+		{"runtime", format.TypeTimecraftRuntime},
+		{"module", format.TypeTimecraftModule},
+		{"profile", format.TypeTimecraftProfile},
+	} {
+		r := reg.ListResources(ctx, resource.mediaType, timemachine.Until(grace))
+		descriptors, err := stream.ReadAll[*format.Descriptor](r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		slices.SortFunc(descriptors, func(d1, d2 *format.Descriptor) int {
+			return cmp.Compare(d1.Digest.String(), d2.Digest.String())
+		})
+
+		for _, desc := range descriptors {
+			if resource.mediaType == format.TypeTimecraftProfile {
+				if _, live := liveProcesses[desc.Annotations["timecraft.process.id"]]; live {
+					continue
+				}
+			} else if _, live := liveObjects[desc.Digest]; live {
+				continue
+			}
+			if _, done := collected[desc.Digest]; done {
+				continue
+			}
+			digest := desc.Digest
+			garbage = append(garbage, gcGarbage{
+				resource: gcResource{
+					Type: resource.typ,
+					ID:   digest.Short(),
+					Size: human.Bytes(desc.Size),
+				},
+				delete: func(ctx context.Context, reg *timemachine.Registry) error {
+					return reg.DeleteResource(ctx, digest)
+				},
+			})
+		}
+	}
+
+	return garbage, nil
+}
+
+func gcProcess(m *format.Manifest) gcGarbage {
+	g := gcGarbage{
+		resource: gcResource{
+			Type: "process",
+			ID:   m.ProcessID.String(),
+		},
+		delete: func(ctx context.Context, reg *timemachine.Registry) error {
+			if err := reg.DeleteLog(ctx, m.ProcessID); err != nil {
+				return err
+			}
+			if m.Process != nil {
+				return reg.DeleteResource(ctx, m.Process.Digest)
+			}
+			return nil
+		},
+	}
+	for _, segment := range m.Segments {
+		g.resource.Size += human.Bytes(segment.Size)
+	}
+	return g
+}
@@ -0,0 +1,101 @@
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+var gc = tests{
+	"show the gc command help with the short option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "gc", "-h")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft gc ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"show the gc command help with the long option": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "gc", "--help")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft gc ")
+		assert.Equal(t, stderr, "")
+	},
+
+	"gc on an empty time machine": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "gc")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "TYPE  ID  SIZE\n0 resources deleted (0)\n")
+		assert.Equal(t, stderr, "")
+	},
+
+	"gc retains the resources of live processes": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+
+		stdout, _, exitCode := timecraft(t, "gc")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.HasSuffix(stdout, "0 resources deleted (0)\n"))
+
+		procID, _, exitCode := timecraft(t, "get", "proc", "-q")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, procID, processID)
+	},
+
+	"gc deletes the resources of deleted processes": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+
+		_, _, exitCode = timecraft(t, "delete", "process", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+
+		stdout, _, exitCode := timecraft(t, "gc", "--dry-run", "--grace-period", "0")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(stdout, "3 resources would be deleted"))
+
+		stdout, _, exitCode = timecraft(t, "gc", "--grace-period", "0")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(stdout, "3 resources deleted"))
+
+		for _, resource := range []string{"config", "module", "runtime"} {
+			id, _, exitCode := timecraft(t, "get", resource, "-q")
+			assert.Equal(t, exitCode, 0)
+			assert.Equal(t, id, "")
+		}
+	},
+
+	"gc retains the resources created during the grace period": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+
+		_, _, exitCode = timecraft(t, "delete", "process", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+
+		stdout, _, exitCode := timecraft(t, "gc")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.HasSuffix(stdout, "0 resources deleted (0)\n"))
+
+		for _, resource := range []string{"config", "module", "runtime"} {
+			id, _, exitCode := timecraft(t, "get", resource, "-q")
+			assert.Equal(t, exitCode, 0)
+			assert.NotEqual(t, id, "")
+		}
+	},
+
+	"gc deletes processes older than the retention period": func(t *testing.T) {
+		_, _, exitCode := timecraft(t, "run", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 0)
+
+		stdout, _, exitCode := timecraft(t, "gc", "--older-than", "1h")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(stdout, "0 resources deleted"))
+
+		stdout, _, exitCode = timecraft(t, "gc", "--older-than", "1ns", "-o", "json")
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(stdout, `"type": "process"`))
+
+		procID, _, exitCode := timecraft(t, "get", "proc", "-q")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, procID, "")
+	},
+}
@@ -42,6 +42,9 @@ const (
 	// TimecraftServicePollTasksProcedure is the fully-qualified name of the TimecraftService's
 	// PollTasks RPC.
 	TimecraftServicePollTasksProcedure = "/timecraft.server.v1.TimecraftService/PollTasks"
+	// TimecraftServiceWatchTasksProcedure is the fully-qualified name of the TimecraftService's
+	// WatchTasks RPC.
+	TimecraftServiceWatchTasksProcedure = "/timecraft.server.v1.TimecraftService/WatchTasks"
 	// TimecraftServiceDiscardTasksProcedure is the fully-qualified name of the TimecraftService's
 	// DiscardTasks RPC.
 	TimecraftServiceDiscardTasksProcedure = "/timecraft.server.v1.TimecraftService/DiscardTasks"
@@ -63,6 +66,7 @@ type TimecraftServiceClient interface {
 	SubmitTasks(context.Context, *connect.Request[v1.SubmitTasksRequest]) (*connect.Response[v1.SubmitTasksResponse], error)
 	LookupTasks(context.Context, *connect.Request[v1.LookupTasksRequest]) (*connect.Response[v1.LookupTasksResponse], error)
 	PollTasks(context.Context, *connect.Request[v1.PollTasksRequest]) (*connect.Response[v1.PollTasksResponse], error)
+	WatchTasks(context.Context, *connect.Request[v1.WatchTasksRequest]) (*connect.ServerStreamForClient[v1.WatchTasksResponse], error)
 	DiscardTasks(context.Context, *connect.Request[v1.DiscardTasksRequest]) (*connect.Response[v1.DiscardTasksResponse], error)
 	// Process management.
 	ProcessID(context.Context, *connect.Request[v1.ProcessIDRequest]) (*connect.Response[v1.ProcessIDResponse], error)
@@ -97,6 +101,11 @@ func NewTimecraftServiceClient(httpClient connect.HTTPClient, baseURL string, op
 			baseURL+TimecraftServicePollTasksProcedure,
 			opts...,
 		),
+		watchTasks: connect.NewClient[v1.WatchTasksRequest, v1.WatchTasksResponse](
+			httpClient,
+			baseURL+TimecraftServiceWatchTasksProcedure,
+			opts...,
+		),
 		discardTasks: connect.NewClient[v1.DiscardTasksRequest, v1.DiscardTasksResponse](
 			httpClient,
 			baseURL+TimecraftServiceDiscardTasksProcedure,
@@ -130,6 +139,7 @@ type timecraftServiceClient struct {
 	submitTasks  *connect.Client[v1.SubmitTasksRequest, v1.SubmitTasksResponse]
 	lookupTasks  *connect.Client[v1.LookupTasksRequest, v1.LookupTasksResponse]
 	pollTasks    *connect.Client[v1.PollTasksRequest, v1.PollTasksResponse]
+	watchTasks   *connect.Client[v1.WatchTasksRequest, v1.WatchTasksResponse]
 	discardTasks *connect.Client[v1.DiscardTasksRequest, v1.DiscardTasksResponse]
 	processID    *connect.Client[v1.ProcessIDRequest, v1.ProcessIDResponse]
 	spawn        *connect.Client[v1.SpawnRequest, v1.SpawnResponse]
@@ -152,6 +162,11 @@ func (c *timecraftServiceClient) PollTasks(ctx context.Context, req *connect.Req
 	return c.pollTasks.CallUnary(ctx, req)
 }
 
+// WatchTasks calls timecraft.server.v1.TimecraftService.WatchTasks.
+func (c *timecraftServiceClient) WatchTasks(ctx context.Context, req *connect.Request[v1.WatchTasksRequest]) (*connect.ServerStreamForClient[v1.WatchTasksResponse], error) {
+	return c.watchTasks.CallServerStream(ctx, req)
+}
+
 // DiscardTasks calls timecraft.server.v1.TimecraftService.DiscardTasks.
 func (c *timecraftServiceClient) DiscardTasks(ctx context.Context, req *connect.Request[v1.DiscardTasksRequest]) (*connect.Response[v1.DiscardTasksResponse], error) {
 	return c.discardTasks.CallUnary(ctx, req)
@@ -183,6 +198,7 @@ type TimecraftServiceHandler interface {
 	SubmitTasks(context.Context, *connect.Request[v1.SubmitTasksRequest]) (*connect.Response[v1.SubmitTasksResponse], error)
 	LookupTasks(context.Context, *connect.Request[v1.LookupTasksRequest]) (*connect.Response[v1.LookupTasksResponse], error)
 	PollTasks(context.Context, *connect.Request[v1.PollTasksRequest]) (*connect.Response[v1.PollTasksResponse], error)
+	WatchTasks(context.Context, *connect.Request[v1.WatchTasksRequest], *connect.ServerStream[v1.WatchTasksResponse]) error
 	DiscardTasks(context.Context, *connect.Request[v1.DiscardTasksRequest]) (*connect.Response[v1.DiscardTasksResponse], error)
 	// Process management.
 	ProcessID(context.Context, *connect.Request[v1.ProcessIDRequest]) (*connect.Response[v1.ProcessIDResponse], error)
@@ -213,6 +229,11 @@ func NewTimecraftServiceHandler(svc TimecraftServiceHandler, opts ...connect.Han
 		svc.PollTasks,
 		opts...,
 	)
+	timecraftServiceWatchTasksHandler := connect.NewServerStreamHandler(
+		TimecraftServiceWatchTasksProcedure,
+		svc.WatchTasks,
+		opts...,
+	)
 	timecraftServiceDiscardTasksHandler := connect.NewUnaryHandler(
 		TimecraftServiceDiscardTasksProcedure,
 		svc.DiscardTasks,
@@ -246,6 +267,8 @@ func NewTimecraftServiceHandler(svc TimecraftServiceHandler, opts ...connect.Han
 			timecraftServiceLookupTasksHandler.ServeHTTP(w, r)
 		case TimecraftServicePollTasksProcedure:
 			timecraftServicePollTasksHandler.ServeHTTP(w, r)
+		case TimecraftServiceWatchTasksProcedure:
+			timecraftServiceWatchTasksHandler.ServeHTTP(w, r)
 		case TimecraftServiceDiscardTasksProcedure:
 			timecraftServiceDiscardTasksHandler.ServeHTTP(w, r)
 		case TimecraftServiceProcessIDProcedure:
@@ -277,6 +300,10 @@ func (UnimplementedTimecraftServiceHandler) PollTasks(context.Context, *connect.
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("timecraft.server.v1.TimecraftService.PollTasks is not implemented"))
 }
 
+func (UnimplementedTimecraftServiceHandler) WatchTasks(context.Context, *connect.Request[v1.WatchTasksRequest], *connect.ServerStream[v1.WatchTasksResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("timecraft.server.v1.TimecraftService.WatchTasks is not implemented"))
+}
+
 func (UnimplementedTimecraftServiceHandler) DiscardTasks(context.Context, *connect.Request[v1.DiscardTasksRequest]) (*connect.Response[v1.DiscardTasksResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("timecraft.server.v1.TimecraftService.DiscardTasks is not implemented"))
 }
@@ -78,6 +78,58 @@ func (TaskState) EnumDescriptor() ([]byte, []int) {
 	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{0}
 }
 
+type TaskPriority int32
+
+const (
+	TaskPriority_TASK_PRIORITY_UNSPECIFIED TaskPriority = 0 // required by buf lint
+	TaskPriority_TASK_PRIORITY_LOW         TaskPriority = 1
+	TaskPriority_TASK_PRIORITY_NORMAL      TaskPriority = 2
+	TaskPriority_TASK_PRIORITY_HIGH        TaskPriority = 3
+)
+
+// Enum value maps for TaskPriority.
+var (
+	TaskPriority_name = map[int32]string{
+		0: "TASK_PRIORITY_UNSPECIFIED",
+		1: "TASK_PRIORITY_LOW",
+		2: "TASK_PRIORITY_NORMAL",
+		3: "TASK_PRIORITY_HIGH",
+	}
+	TaskPriority_value = map[string]int32{
+		"TASK_PRIORITY_UNSPECIFIED": 0,
+		"TASK_PRIORITY_LOW":         1,
+		"TASK_PRIORITY_NORMAL":      2,
+		"TASK_PRIORITY_HIGH":        3,
+	}
+)
+
+func (x TaskPriority) Enum() *TaskPriority {
+	p := new(TaskPriority)
+	*p = x
+	return p
+}
+
+func (x TaskPriority) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskPriority) Descriptor() protoreflect.EnumDescriptor {
+	return file_timecraft_server_v1_timecraft_proto_enumTypes[1].Descriptor()
+}
+
+func (TaskPriority) Type() protoreflect.EnumType {
+	return &file_timecraft_server_v1_timecraft_proto_enumTypes[1]
+}
+
+func (x TaskPriority) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskPriority.Descriptor instead.
+func (TaskPriority) EnumDescriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{1}
+}
+
 type TaskRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -87,7 +139,19 @@ type TaskRequest struct {
 	// Types that are assignable to Input:
 	//
 	//	*TaskRequest_HttpRequest
+	//	*TaskRequest_FunctionCall
 	Input isTaskRequest_Input `protobuf_oneof:"input"`
+	// Time limit for each attempt at executing the task. Zero means the
+	// default of 1 minute.
+	ExecutionTimeoutNs int64 `protobuf:"varint,3,opt,name=execution_timeout_ns,json=executionTimeoutNs,proto3" json:"execution_timeout_ns,omitempty"`
+	// Maximum amount of time the task can be queued for before it expires.
+	// Zero means the default of 10 minutes.
+	QueueTimeoutNs int64        `protobuf:"varint,4,opt,name=queue_timeout_ns,json=queueTimeoutNs,proto3" json:"queue_timeout_ns,omitempty"`
+	RetryPolicy    *RetryPolicy `protobuf:"bytes,5,opt,name=retry_policy,json=retryPolicy,proto3" json:"retry_policy,omitempty"`
+	Priority       TaskPriority `protobuf:"varint,6,opt,name=priority,proto3,enum=timecraft.server.v1.TaskPriority" json:"priority,omitempty"`
+	// Submitting a task with the same idempotency key as a task which has not
+	// been discarded returns the identifier of the existing task.
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 }
 
 func (x *TaskRequest) Reset() {
@@ -143,6 +207,48 @@ func (x *TaskRequest) GetHttpRequest() *HTTPRequest {
 	return nil
 }
 
+func (x *TaskRequest) GetFunctionCall() *FunctionCall {
+	if x, ok := x.GetInput().(*TaskRequest_FunctionCall); ok {
+		return x.FunctionCall
+	}
+	return nil
+}
+
+func (x *TaskRequest) GetExecutionTimeoutNs() int64 {
+	if x != nil {
+		return x.ExecutionTimeoutNs
+	}
+	return 0
+}
+
+func (x *TaskRequest) GetQueueTimeoutNs() int64 {
+	if x != nil {
+		return x.QueueTimeoutNs
+	}
+	return 0
+}
+
+func (x *TaskRequest) GetRetryPolicy() *RetryPolicy {
+	if x != nil {
+		return x.RetryPolicy
+	}
+	return nil
+}
+
+func (x *TaskRequest) GetPriority() TaskPriority {
+	if x != nil {
+		return x.Priority
+	}
+	return TaskPriority_TASK_PRIORITY_UNSPECIFIED
+}
+
+func (x *TaskRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
 type isTaskRequest_Input interface {
 	isTaskRequest_Input()
 }
@@ -151,8 +257,81 @@ type TaskRequest_HttpRequest struct {
 	HttpRequest *HTTPRequest `protobuf:"bytes,2,opt,name=http_request,json=httpRequest,proto3,oneof"`
 }
 
+type TaskRequest_FunctionCall struct {
+	FunctionCall *FunctionCall `protobuf:"bytes,8,opt,name=function_call,json=functionCall,proto3,oneof"`
+}
+
 func (*TaskRequest_HttpRequest) isTaskRequest_Input() {}
 
+func (*TaskRequest_FunctionCall) isTaskRequest_Input() {}
+
+type RetryPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of attempts at executing the task. Zero means the task
+	// is attempted once.
+	MaxAttempts int32 `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	// Delays between attempts grow exponentially from min_delay_ns to
+	// max_delay_ns.
+	MinDelayNs int64 `protobuf:"varint,2,opt,name=min_delay_ns,json=minDelayNs,proto3" json:"min_delay_ns,omitempty"`
+	MaxDelayNs int64 `protobuf:"varint,3,opt,name=max_delay_ns,json=maxDelayNs,proto3" json:"max_delay_ns,omitempty"`
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetMinDelayNs() int64 {
+	if x != nil {
+		return x.MinDelayNs
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetMaxDelayNs() int64 {
+	if x != nil {
+		return x.MaxDelayNs
+	}
+	return 0
+}
+
 type TaskResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -165,13 +344,14 @@ type TaskResponse struct {
 	// Types that are assignable to Output:
 	//
 	//	*TaskResponse_HttpResponse
+	//	*TaskResponse_FunctionResult
 	Output isTaskResponse_Output `protobuf_oneof:"output"`
 }
 
 func (x *TaskResponse) Reset() {
 	*x = TaskResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[1]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -184,7 +364,7 @@ func (x *TaskResponse) String() string {
 func (*TaskResponse) ProtoMessage() {}
 
 func (x *TaskResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[1]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -197,7 +377,7 @@ func (x *TaskResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TaskResponse.ProtoReflect.Descriptor instead.
 func (*TaskResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{1}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *TaskResponse) GetTaskId() string {
@@ -242,6 +422,13 @@ func (x *TaskResponse) GetHttpResponse() *HTTPResponse {
 	return nil
 }
 
+func (x *TaskResponse) GetFunctionResult() *FunctionResult {
+	if x, ok := x.GetOutput().(*TaskResponse_FunctionResult); ok {
+		return x.FunctionResult
+	}
+	return nil
+}
+
 type isTaskResponse_Output interface {
 	isTaskResponse_Output()
 }
@@ -250,8 +437,14 @@ type TaskResponse_HttpResponse struct {
 	HttpResponse *HTTPResponse `protobuf:"bytes,5,opt,name=http_response,json=httpResponse,proto3,oneof"`
 }
 
+type TaskResponse_FunctionResult struct {
+	FunctionResult *FunctionResult `protobuf:"bytes,6,opt,name=function_result,json=functionResult,proto3,oneof"`
+}
+
 func (*TaskResponse_HttpResponse) isTaskResponse_Output() {}
 
+func (*TaskResponse_FunctionResult) isTaskResponse_Output() {}
+
 type ModuleSpec struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -267,7 +460,7 @@ type ModuleSpec struct {
 func (x *ModuleSpec) Reset() {
 	*x = ModuleSpec{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[2]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -280,7 +473,7 @@ func (x *ModuleSpec) String() string {
 func (*ModuleSpec) ProtoMessage() {}
 
 func (x *ModuleSpec) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[2]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -293,7 +486,7 @@ func (x *ModuleSpec) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ModuleSpec.ProtoReflect.Descriptor instead.
 func (*ModuleSpec) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{2}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ModuleSpec) GetPath() string {
@@ -346,7 +539,7 @@ type HTTPRequest struct {
 func (x *HTTPRequest) Reset() {
 	*x = HTTPRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[3]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -359,7 +552,7 @@ func (x *HTTPRequest) String() string {
 func (*HTTPRequest) ProtoMessage() {}
 
 func (x *HTTPRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[3]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -372,7 +565,7 @@ func (x *HTTPRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HTTPRequest.ProtoReflect.Descriptor instead.
 func (*HTTPRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{3}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *HTTPRequest) GetMethod() string {
@@ -423,7 +616,7 @@ type HTTPResponse struct {
 func (x *HTTPResponse) Reset() {
 	*x = HTTPResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[4]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -436,7 +629,7 @@ func (x *HTTPResponse) String() string {
 func (*HTTPResponse) ProtoMessage() {}
 
 func (x *HTTPResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[4]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -449,7 +642,7 @@ func (x *HTTPResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HTTPResponse.ProtoReflect.Descriptor instead.
 func (*HTTPResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{4}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *HTTPResponse) GetStatusCode() int32 {
@@ -473,6 +666,135 @@ func (x *HTTPResponse) GetBody() []byte {
 	return nil
 }
 
+type FunctionCall struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of the exported function to call, and arguments passed to the
+	// module. They override those of the task module when not empty.
+	Function string   `protobuf:"bytes,1,opt,name=function,proto3" json:"function,omitempty"`
+	Args     []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Data read by the module from its standard input.
+	Stdin []byte `protobuf:"bytes,3,opt,name=stdin,proto3" json:"stdin,omitempty"`
+}
+
+func (x *FunctionCall) Reset() {
+	*x = FunctionCall{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FunctionCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunctionCall) ProtoMessage() {}
+
+func (x *FunctionCall) ProtoReflect() protoreflect.Message {
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunctionCall.ProtoReflect.Descriptor instead.
+func (*FunctionCall) Descriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FunctionCall) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *FunctionCall) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *FunctionCall) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+type FunctionResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExitCode int32  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Stdout   []byte `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr   []byte `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+}
+
+func (x *FunctionResult) Reset() {
+	*x = FunctionResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FunctionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FunctionResult) ProtoMessage() {}
+
+func (x *FunctionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FunctionResult.ProtoReflect.Descriptor instead.
+func (*FunctionResult) Descriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FunctionResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *FunctionResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *FunctionResult) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
 type Header struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -485,7 +807,7 @@ type Header struct {
 func (x *Header) Reset() {
 	*x = Header{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[5]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -498,7 +820,7 @@ func (x *Header) String() string {
 func (*Header) ProtoMessage() {}
 
 func (x *Header) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[5]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -511,7 +833,7 @@ func (x *Header) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Header.ProtoReflect.Descriptor instead.
 func (*Header) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{5}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Header) GetName() string {
@@ -539,7 +861,7 @@ type SubmitTasksRequest struct {
 func (x *SubmitTasksRequest) Reset() {
 	*x = SubmitTasksRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[6]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -552,7 +874,7 @@ func (x *SubmitTasksRequest) String() string {
 func (*SubmitTasksRequest) ProtoMessage() {}
 
 func (x *SubmitTasksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[6]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -565,7 +887,7 @@ func (x *SubmitTasksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitTasksRequest.ProtoReflect.Descriptor instead.
 func (*SubmitTasksRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{6}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SubmitTasksRequest) GetRequests() []*TaskRequest {
@@ -586,7 +908,7 @@ type SubmitTasksResponse struct {
 func (x *SubmitTasksResponse) Reset() {
 	*x = SubmitTasksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[7]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -599,7 +921,7 @@ func (x *SubmitTasksResponse) String() string {
 func (*SubmitTasksResponse) ProtoMessage() {}
 
 func (x *SubmitTasksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[7]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -612,7 +934,7 @@ func (x *SubmitTasksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitTasksResponse.ProtoReflect.Descriptor instead.
 func (*SubmitTasksResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{7}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SubmitTasksResponse) GetTaskId() []string {
@@ -633,7 +955,7 @@ type LookupTasksRequest struct {
 func (x *LookupTasksRequest) Reset() {
 	*x = LookupTasksRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[8]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -646,7 +968,7 @@ func (x *LookupTasksRequest) String() string {
 func (*LookupTasksRequest) ProtoMessage() {}
 
 func (x *LookupTasksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[8]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -659,7 +981,7 @@ func (x *LookupTasksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LookupTasksRequest.ProtoReflect.Descriptor instead.
 func (*LookupTasksRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{8}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *LookupTasksRequest) GetTaskId() []string {
@@ -680,7 +1002,7 @@ type LookupTasksResponse struct {
 func (x *LookupTasksResponse) Reset() {
 	*x = LookupTasksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[9]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -693,7 +1015,7 @@ func (x *LookupTasksResponse) String() string {
 func (*LookupTasksResponse) ProtoMessage() {}
 
 func (x *LookupTasksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[9]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -706,7 +1028,7 @@ func (x *LookupTasksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LookupTasksResponse.ProtoReflect.Descriptor instead.
 func (*LookupTasksResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{9}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *LookupTasksResponse) GetResponses() []*TaskResponse {
@@ -728,7 +1050,7 @@ type PollTasksRequest struct {
 func (x *PollTasksRequest) Reset() {
 	*x = PollTasksRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[10]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -741,7 +1063,7 @@ func (x *PollTasksRequest) String() string {
 func (*PollTasksRequest) ProtoMessage() {}
 
 func (x *PollTasksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[10]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -754,7 +1076,7 @@ func (x *PollTasksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PollTasksRequest.ProtoReflect.Descriptor instead.
 func (*PollTasksRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{10}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *PollTasksRequest) GetBatchSize() int32 {
@@ -782,7 +1104,7 @@ type PollTasksResponse struct {
 func (x *PollTasksResponse) Reset() {
 	*x = PollTasksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[11]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -795,7 +1117,7 @@ func (x *PollTasksResponse) String() string {
 func (*PollTasksResponse) ProtoMessage() {}
 
 func (x *PollTasksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[11]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -808,7 +1130,7 @@ func (x *PollTasksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PollTasksResponse.ProtoReflect.Descriptor instead.
 func (*PollTasksResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{11}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *PollTasksResponse) GetResponses() []*TaskResponse {
@@ -818,6 +1140,102 @@ func (x *PollTasksResponse) GetResponses() []*TaskResponse {
 	return nil
 }
 
+type WatchTasksRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Tasks to watch. When empty, all the tasks submitted by the process are
+	// watched, including the tasks submitted after the call.
+	TaskId []string `protobuf:"bytes,1,rep,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+}
+
+func (x *WatchTasksRequest) Reset() {
+	*x = WatchTasksRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTasksRequest) ProtoMessage() {}
+
+func (x *WatchTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTasksRequest.ProtoReflect.Descriptor instead.
+func (*WatchTasksRequest) Descriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchTasksRequest) GetTaskId() []string {
+	if x != nil {
+		return x.TaskId
+	}
+	return nil
+}
+
+type WatchTasksResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Response *TaskResponse `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+}
+
+func (x *WatchTasksResponse) Reset() {
+	*x = WatchTasksResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTasksResponse) ProtoMessage() {}
+
+func (x *WatchTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTasksResponse.ProtoReflect.Descriptor instead.
+func (*WatchTasksResponse) Descriptor() ([]byte, []int) {
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchTasksResponse) GetResponse() *TaskResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
 type DiscardTasksRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -829,7 +1247,7 @@ type DiscardTasksRequest struct {
 func (x *DiscardTasksRequest) Reset() {
 	*x = DiscardTasksRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[12]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -842,7 +1260,7 @@ func (x *DiscardTasksRequest) String() string {
 func (*DiscardTasksRequest) ProtoMessage() {}
 
 func (x *DiscardTasksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[12]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -855,7 +1273,7 @@ func (x *DiscardTasksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiscardTasksRequest.ProtoReflect.Descriptor instead.
 func (*DiscardTasksRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{12}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *DiscardTasksRequest) GetTaskId() []string {
@@ -874,7 +1292,7 @@ type DiscardTasksResponse struct {
 func (x *DiscardTasksResponse) Reset() {
 	*x = DiscardTasksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[13]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -887,7 +1305,7 @@ func (x *DiscardTasksResponse) String() string {
 func (*DiscardTasksResponse) ProtoMessage() {}
 
 func (x *DiscardTasksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[13]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -900,7 +1318,7 @@ func (x *DiscardTasksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiscardTasksResponse.ProtoReflect.Descriptor instead.
 func (*DiscardTasksResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{13}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{18}
 }
 
 type ProcessIDRequest struct {
@@ -912,7 +1330,7 @@ type ProcessIDRequest struct {
 func (x *ProcessIDRequest) Reset() {
 	*x = ProcessIDRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[14]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -925,7 +1343,7 @@ func (x *ProcessIDRequest) String() string {
 func (*ProcessIDRequest) ProtoMessage() {}
 
 func (x *ProcessIDRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[14]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -938,7 +1356,7 @@ func (x *ProcessIDRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessIDRequest.ProtoReflect.Descriptor instead.
 func (*ProcessIDRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{14}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{19}
 }
 
 type ProcessIDResponse struct {
@@ -952,7 +1370,7 @@ type ProcessIDResponse struct {
 func (x *ProcessIDResponse) Reset() {
 	*x = ProcessIDResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[15]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -965,7 +1383,7 @@ func (x *ProcessIDResponse) String() string {
 func (*ProcessIDResponse) ProtoMessage() {}
 
 func (x *ProcessIDResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[15]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -978,7 +1396,7 @@ func (x *ProcessIDResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessIDResponse.ProtoReflect.Descriptor instead.
 func (*ProcessIDResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{15}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *ProcessIDResponse) GetProcessId() string {
@@ -999,7 +1417,7 @@ type SpawnRequest struct {
 func (x *SpawnRequest) Reset() {
 	*x = SpawnRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[16]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1012,7 +1430,7 @@ func (x *SpawnRequest) String() string {
 func (*SpawnRequest) ProtoMessage() {}
 
 func (x *SpawnRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[16]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1025,7 +1443,7 @@ func (x *SpawnRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SpawnRequest.ProtoReflect.Descriptor instead.
 func (*SpawnRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{16}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *SpawnRequest) GetModule() *ModuleSpec {
@@ -1047,7 +1465,7 @@ type SpawnResponse struct {
 func (x *SpawnResponse) Reset() {
 	*x = SpawnResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[17]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1060,7 +1478,7 @@ func (x *SpawnResponse) String() string {
 func (*SpawnResponse) ProtoMessage() {}
 
 func (x *SpawnResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[17]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1073,7 +1491,7 @@ func (x *SpawnResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SpawnResponse.ProtoReflect.Descriptor instead.
 func (*SpawnResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{17}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *SpawnResponse) GetProcessId() string {
@@ -1101,7 +1519,7 @@ type KillRequest struct {
 func (x *KillRequest) Reset() {
 	*x = KillRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[18]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1114,7 +1532,7 @@ func (x *KillRequest) String() string {
 func (*KillRequest) ProtoMessage() {}
 
 func (x *KillRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[18]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1127,7 +1545,7 @@ func (x *KillRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KillRequest.ProtoReflect.Descriptor instead.
 func (*KillRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{18}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *KillRequest) GetProcessId() string {
@@ -1146,7 +1564,7 @@ type KillResponse struct {
 func (x *KillResponse) Reset() {
 	*x = KillResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[19]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1159,7 +1577,7 @@ func (x *KillResponse) String() string {
 func (*KillResponse) ProtoMessage() {}
 
 func (x *KillResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[19]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1172,7 +1590,7 @@ func (x *KillResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KillResponse.ProtoReflect.Descriptor instead.
 func (*KillResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{19}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{24}
 }
 
 type VersionRequest struct {
@@ -1184,7 +1602,7 @@ type VersionRequest struct {
 func (x *VersionRequest) Reset() {
 	*x = VersionRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[20]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1197,7 +1615,7 @@ func (x *VersionRequest) String() string {
 func (*VersionRequest) ProtoMessage() {}
 
 func (x *VersionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[20]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1210,7 +1628,7 @@ func (x *VersionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
 func (*VersionRequest) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{20}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{25}
 }
 
 type VersionResponse struct {
@@ -1224,7 +1642,7 @@ type VersionResponse struct {
 func (x *VersionResponse) Reset() {
 	*x = VersionResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[21]
+		mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1237,7 +1655,7 @@ func (x *VersionResponse) String() string {
 func (*VersionResponse) ProtoMessage() {}
 
 func (x *VersionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[21]
+	mi := &file_timecraft_server_v1_timecraft_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1250,7 +1668,7 @@ func (x *VersionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
 func (*VersionResponse) Descriptor() ([]byte, []int) {
-	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{21}
+	return file_timecraft_server_v1_timecraft_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *VersionResponse) GetVersion() string {
@@ -1266,7 +1684,7 @@ var file_timecraft_server_v1_timecraft_proto_rawDesc = []byte{
 	0x0a, 0x23, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2f, 0x73, 0x65, 0x72, 0x76,
 	0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x13, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74,
-	0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x96, 0x01, 0x0a, 0x0b, 0x54,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0xe9, 0x03, 0x0a, 0x0b, 0x54,
 	0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x6d, 0x6f,
 	0x64, 0x75, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d,
 	0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31,
@@ -1275,181 +1693,248 @@ var file_timecraft_server_v1_timecraft_proto_rawDesc = []byte{
 	0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65,
 	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
 	0x48, 0x54, 0x54, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x68,
-	0x74, 0x74, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x69, 0x6e,
-	0x70, 0x75, 0x74, 0x22, 0xf5, 0x01, 0x0a, 0x0c, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x34, 0x0a,
-	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x74,
-	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
-	0x76, 0x31, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74,
-	0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69,
-	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
-	0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x48, 0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x5f,
-	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21,
+	0x74, 0x74, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x48, 0x0a, 0x0d, 0x66, 0x75,
+	0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x61, 0x6c, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x43, 0x61, 0x6c, 0x6c, 0x48, 0x00, 0x52, 0x0c, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x43, 0x61, 0x6c, 0x6c, 0x12, 0x30, 0x0a, 0x14, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x12, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x4e, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x71, 0x75, 0x65, 0x75, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0e, 0x71, 0x75, 0x65, 0x75, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x73,
+	0x12, 0x43, 0x0a, 0x0c, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
+	0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x74,
+	0x72, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0b, 0x72, 0x65, 0x74, 0x72, 0x79, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x3d, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74,
+	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
+	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61,
+	0x73, 0x6b, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f,
+	0x72, 0x69, 0x74, 0x79, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65,
+	0x6e, 0x63, 0x79, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x69,
+	0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4b, 0x65, 0x79, 0x42, 0x07, 0x0a,
+	0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x22, 0x74, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x72, 0x79, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x74, 0x74,
+	0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78,
+	0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x6d, 0x69, 0x6e, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4e, 0x73, 0x12, 0x20, 0x0a, 0x0c, 0x6d, 0x61,
+	0x78, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x6d, 0x61, 0x78, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4e, 0x73, 0x22, 0xc5, 0x02, 0x0a,
+	0x0c, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a,
+	0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66,
+	0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x73, 0x6b,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x48, 0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
+	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x54,
+	0x54, 0x50, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x68, 0x74,
+	0x74, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0f, 0x66, 0x75,
+	0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x00, 0x52, 0x0e, 0x66, 0x75, 0x6e, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x42, 0x08, 0x0a, 0x06, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x22, 0xaa, 0x01, 0x0a, 0x0a, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x53,
+	0x70, 0x65, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x46, 0x0a, 0x0e, 0x6f, 0x75, 0x74,
+	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x53, 0x70,
+	0x65, 0x63, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x78,
+	0x79, 0x22, 0x98, 0x01, 0x0a, 0x0b, 0x48, 0x54, 0x54, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x35, 0x0a,
+	0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b,
 	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65,
-	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x54, 0x54, 0x50, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x48, 0x00, 0x52, 0x0c, 0x68, 0x74, 0x74, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x42, 0x08, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22, 0xaa, 0x01, 0x0a, 0x0a,
-	0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
-	0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1a,
-	0x0a, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72,
-	0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10,
-	0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76,
-	0x12, 0x46, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x70, 0x72, 0x6f,
-	0x78, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63,
-	0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d,
-	0x6f, 0x64, 0x75, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x62, 0x6f,
-	0x75, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x22, 0x98, 0x01, 0x0a, 0x0b, 0x48, 0x54, 0x54,
-	0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68,
-	0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64,
-	0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x70, 0x61, 0x74, 0x68, 0x12, 0x35, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18,
-	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66,
-	0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64,
-	0x65, 0x72, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x62,
-	0x6f, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12,
-	0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70,
-	0x6f, 0x72, 0x74, 0x22, 0x7a, 0x0a, 0x0c, 0x48, 0x54, 0x54, 0x50, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f,
-	0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x43, 0x6f, 0x64, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18,
-	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66,
-	0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64,
-	0x65, 0x72, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x62,
-	0x6f, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22,
-	0x32, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x22, 0x52, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73,
-	0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x08, 0x72, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x69,
-	0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
-	0x31, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0x2e, 0x0a, 0x13, 0x53, 0x75, 0x62, 0x6d, 0x69,
-	0x74, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x07, 0x68, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x22, 0x7a, 0x0a, 0x0c,
+	0x48, 0x54, 0x54, 0x50, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x35, 0x0a,
+	0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x07, 0x68, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0x54, 0x0a, 0x0c, 0x46, 0x75, 0x6e, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x43, 0x61, 0x6c, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6e, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6e, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x64, 0x69,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x22, 0x5d,
+	0x0a, 0x0e, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73,
+	0x74, 0x64, 0x6f, 0x75, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x22, 0x32, 0x0a,
+	0x06, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x22, 0x52, 0x0a, 0x12, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65,
+	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0x2e, 0x0a, 0x13, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54,
+	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07,
+	0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74,
+	0x61, 0x73, 0x6b, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x12, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54,
+	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x74,
+	0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61,
+	0x73, 0x6b, 0x49, 0x64, 0x22, 0x56, 0x0a, 0x13, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61,
+	0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x09, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x52, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x22, 0x50, 0x0a, 0x10,
+	0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x73, 0x22, 0x54,
+	0x0a, 0x11, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
+	0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x73,
+	0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x73, 0x22, 0x2c, 0x0a, 0x11, 0x57, 0x61, 0x74, 0x63, 0x68, 0x54, 0x61, 0x73,
+	0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73,
+	0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b,
+	0x49, 0x64, 0x22, 0x53, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x54, 0x61, 0x73, 0x6b, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d,
+	0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2e, 0x0a, 0x13, 0x44, 0x69, 0x73, 0x63, 0x61,
+	0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17,
 	0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
-	0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x22, 0x2d, 0x0a, 0x12, 0x4c, 0x6f, 0x6f, 0x6b, 0x75,
-	0x70, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a,
-	0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
-	0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x22, 0x56, 0x0a, 0x13, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70,
-	0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a,
-	0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x52, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x22, 0x50,
-	0x0a, 0x10, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a,
-	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x73, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x73,
-	0x22, 0x54, 0x0a, 0x11, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63,
-	0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54,
-	0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x09, 0x72, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x22, 0x2e, 0x0a, 0x13, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72,
-	0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a,
-	0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
-	0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72,
-	0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x12,
-	0x0a, 0x10, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x22, 0x32, 0x0a, 0x11, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65,
-	0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f,
-	0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x22, 0x47, 0x0a, 0x0c, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
-	0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x6f, 0x64,
-	0x75, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x22,
-	0x4d, 0x0a, 0x0d, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x12,
-	0x1d, 0x0a, 0x0a, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x2c,
-	0x0a, 0x0b, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a,
-	0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x22, 0x0e, 0x0a, 0x0c,
-	0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x10, 0x0a, 0x0e,
-	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2b,
-	0x0a, 0x0f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x2a, 0xa3, 0x01, 0x0a, 0x09,
-	0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x54, 0x41, 0x53,
-	0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
-	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53, 0x54,
-	0x41, 0x54, 0x45, 0x5f, 0x51, 0x55, 0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17,
-	0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x49, 0x4e, 0x49, 0x54, 0x49,
-	0x41, 0x4c, 0x49, 0x5a, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x54, 0x41, 0x53,
-	0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x45, 0x58, 0x45, 0x43, 0x55, 0x54, 0x49, 0x4e,
-	0x47, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54,
-	0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x12, 0x16, 0x0a, 0x12, 0x54, 0x41, 0x53,
-	0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53, 0x10,
-	0x05, 0x32, 0xf6, 0x05, 0x0a, 0x10, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x53,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x62, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74,
-	0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x27, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66,
-	0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d,
-	0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28,
+	0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x44, 0x69, 0x73, 0x63, 0x61,
+	0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x12, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x32, 0x0a, 0x11, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63,
+	0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72,
+	0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x22, 0x47, 0x0a, 0x0c, 0x53, 0x70, 0x61, 0x77, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
+	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x6f,
+	0x64, 0x75, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x63, 0x52, 0x06, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65,
+	0x22, 0x4d, 0x0a, 0x0d, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64,
+	0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22,
+	0x2c, 0x0a, 0x0b, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d,
+	0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x22, 0x0e, 0x0a,
+	0x0c, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x10, 0x0a,
+	0x0e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x2b, 0x0a, 0x0f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x2a, 0xa3, 0x01, 0x0a,
+	0x09, 0x54, 0x61, 0x73, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x54, 0x41,
+	0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53,
+	0x54, 0x41, 0x54, 0x45, 0x5f, 0x51, 0x55, 0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1b, 0x0a,
+	0x17, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x49, 0x4e, 0x49, 0x54,
+	0x49, 0x41, 0x4c, 0x49, 0x5a, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x54, 0x41,
+	0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x45, 0x58, 0x45, 0x43, 0x55, 0x54, 0x49,
+	0x4e, 0x47, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41,
+	0x54, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x04, 0x12, 0x16, 0x0a, 0x12, 0x54, 0x41,
+	0x53, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53,
+	0x10, 0x05, 0x2a, 0x76, 0x0a, 0x0c, 0x54, 0x61, 0x73, 0x6b, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69,
+	0x74, 0x79, 0x12, 0x1d, 0x0a, 0x19, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52,
+	0x49, 0x54, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x15, 0x0a, 0x11, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49,
+	0x54, 0x59, 0x5f, 0x4c, 0x4f, 0x57, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x54, 0x41, 0x53, 0x4b,
+	0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c,
+	0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x54, 0x41, 0x53, 0x4b, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52,
+	0x49, 0x54, 0x59, 0x5f, 0x48, 0x49, 0x47, 0x48, 0x10, 0x03, 0x32, 0xd9, 0x06, 0x0a, 0x10, 0x54,
+	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x62, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x27,
 	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65,
 	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x62, 0x0a, 0x0b, 0x4c, 0x6f,
-	0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x27, 0x2e, 0x74, 0x69, 0x6d, 0x65,
-	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
-	0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x28, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
+	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75,
+	0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x12, 0x62, 0x0a, 0x0b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x73,
+	0x6b, 0x73, 0x12, 0x27, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73,
 	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54,
-	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5c,
-	0x0a, 0x09, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x25, 0x2e, 0x74, 0x69,
+	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x28, 0x2e, 0x74, 0x69,
 	0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
-	0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x26, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73,
-	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73,
-	0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x65, 0x0a, 0x0c,
-	0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x28, 0x2e, 0x74,
-	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
-	0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
-	0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73,
-	0x63, 0x61, 0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44,
-	0x12, 0x25, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
-	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72,
-	0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
-	0x00, 0x12, 0x50, 0x0a, 0x05, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x12, 0x21, 0x2e, 0x74, 0x69, 0x6d,
-	0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31,
-	0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e,
-	0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
-	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x04, 0x4b, 0x69, 0x6c, 0x6c, 0x12, 0x20, 0x2e, 0x74, 0x69,
+	0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x09, 0x50, 0x6f, 0x6c, 0x6c, 0x54,
+	0x61, 0x73, 0x6b, 0x73, 0x12, 0x25, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x6c, 0x54,
+	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x74, 0x69,
 	0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
-	0x31, 0x2e, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e,
-	0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
-	0x2e, 0x76, 0x31, 0x2e, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x00, 0x12, 0x56, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x2e,
-	0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
-	0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x24, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73,
-	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0xe5, 0x01, 0x0a, 0x17, 0x63,
-	0x6f, 0x6d, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x42, 0x0e, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66,
-	0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x4c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x74, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x72, 0x6f, 0x63, 0x6b,
-	0x65, 0x74, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2f, 0x67, 0x65, 0x6e,
-	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x6f, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
-	0x61, 0x66, 0x74, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x73, 0x65,
-	0x72, 0x76, 0x65, 0x72, 0x76, 0x31, 0xa2, 0x02, 0x03, 0x54, 0x53, 0x58, 0xaa, 0x02, 0x13, 0x54,
-	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
-	0x56, 0x31, 0xca, 0x02, 0x13, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x5c, 0x53,
-	0x65, 0x72, 0x76, 0x65, 0x72, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x1f, 0x54, 0x69, 0x6d, 0x65, 0x63,
-	0x72, 0x61, 0x66, 0x74, 0x5c, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5c, 0x56, 0x31, 0x5c, 0x47,
-	0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x15, 0x54, 0x69, 0x6d,
-	0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x3a, 0x3a, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x3a, 0x3a,
-	0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x6c, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x61, 0x0a, 0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x54, 0x61,
+	0x73, 0x6b, 0x73, 0x12, 0x26, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x54,
+	0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x74, 0x69,
+	0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x65, 0x0a, 0x0c, 0x44, 0x69, 0x73, 0x63,
+	0x61, 0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x12, 0x28, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63,
+	0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44,
+	0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x29, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64,
+	0x54, 0x61, 0x73, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x5c, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x12, 0x25, 0x2e, 0x74,
+	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73,
+	0x73, 0x49, 0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x50, 0x0a,
+	0x05, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x12, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
+	0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x61,
+	0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x74, 0x69, 0x6d, 0x65,
+	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x4d, 0x0a, 0x04, 0x4b, 0x69, 0x6c, 0x6c, 0x12, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72,
+	0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4b, 0x69,
+	0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x74, 0x69, 0x6d, 0x65,
+	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x56,
+	0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x2e, 0x74, 0x69, 0x6d, 0x65,
+	0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0xe5, 0x01, 0x0a, 0x17, 0x63, 0x6f, 0x6d, 0x2e, 0x74,
+	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x42, 0x0e, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x50, 0x72, 0x6f,
+	0x74, 0x6f, 0x50, 0x01, 0x5a, 0x4c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x73, 0x74, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x72, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2f, 0x74,
+	0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2f, 0x67, 0x6f, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x2f,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x76, 0x31, 0xa2, 0x02, 0x03, 0x54, 0x53, 0x58, 0xaa, 0x02, 0x13, 0x54, 0x69, 0x6d, 0x65, 0x63,
+	0x72, 0x61, 0x66, 0x74, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2e, 0x56, 0x31, 0xca, 0x02,
+	0x13, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74, 0x5c, 0x53, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x1f, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61, 0x66, 0x74,
+	0x5c, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x15, 0x54, 0x69, 0x6d, 0x65, 0x63, 0x72, 0x61,
+	0x66, 0x74, 0x3a, 0x3a, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x3a, 0x3a, 0x56, 0x31, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1464,66 +1949,79 @@ func file_timecraft_server_v1_timecraft_proto_rawDescGZIP() []byte {
 	return file_timecraft_server_v1_timecraft_proto_rawDescData
 }
 
-var file_timecraft_server_v1_timecraft_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_timecraft_server_v1_timecraft_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_timecraft_server_v1_timecraft_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_timecraft_server_v1_timecraft_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_timecraft_server_v1_timecraft_proto_goTypes = []interface{}{
 	(TaskState)(0),               // 0: timecraft.server.v1.TaskState
-	(*TaskRequest)(nil),          // 1: timecraft.server.v1.TaskRequest
-	(*TaskResponse)(nil),         // 2: timecraft.server.v1.TaskResponse
-	(*ModuleSpec)(nil),           // 3: timecraft.server.v1.ModuleSpec
-	(*HTTPRequest)(nil),          // 4: timecraft.server.v1.HTTPRequest
-	(*HTTPResponse)(nil),         // 5: timecraft.server.v1.HTTPResponse
-	(*Header)(nil),               // 6: timecraft.server.v1.Header
-	(*SubmitTasksRequest)(nil),   // 7: timecraft.server.v1.SubmitTasksRequest
-	(*SubmitTasksResponse)(nil),  // 8: timecraft.server.v1.SubmitTasksResponse
-	(*LookupTasksRequest)(nil),   // 9: timecraft.server.v1.LookupTasksRequest
-	(*LookupTasksResponse)(nil),  // 10: timecraft.server.v1.LookupTasksResponse
-	(*PollTasksRequest)(nil),     // 11: timecraft.server.v1.PollTasksRequest
-	(*PollTasksResponse)(nil),    // 12: timecraft.server.v1.PollTasksResponse
-	(*DiscardTasksRequest)(nil),  // 13: timecraft.server.v1.DiscardTasksRequest
-	(*DiscardTasksResponse)(nil), // 14: timecraft.server.v1.DiscardTasksResponse
-	(*ProcessIDRequest)(nil),     // 15: timecraft.server.v1.ProcessIDRequest
-	(*ProcessIDResponse)(nil),    // 16: timecraft.server.v1.ProcessIDResponse
-	(*SpawnRequest)(nil),         // 17: timecraft.server.v1.SpawnRequest
-	(*SpawnResponse)(nil),        // 18: timecraft.server.v1.SpawnResponse
-	(*KillRequest)(nil),          // 19: timecraft.server.v1.KillRequest
-	(*KillResponse)(nil),         // 20: timecraft.server.v1.KillResponse
-	(*VersionRequest)(nil),       // 21: timecraft.server.v1.VersionRequest
-	(*VersionResponse)(nil),      // 22: timecraft.server.v1.VersionResponse
+	(TaskPriority)(0),            // 1: timecraft.server.v1.TaskPriority
+	(*TaskRequest)(nil),          // 2: timecraft.server.v1.TaskRequest
+	(*RetryPolicy)(nil),          // 3: timecraft.server.v1.RetryPolicy
+	(*TaskResponse)(nil),         // 4: timecraft.server.v1.TaskResponse
+	(*ModuleSpec)(nil),           // 5: timecraft.server.v1.ModuleSpec
+	(*HTTPRequest)(nil),          // 6: timecraft.server.v1.HTTPRequest
+	(*HTTPResponse)(nil),         // 7: timecraft.server.v1.HTTPResponse
+	(*FunctionCall)(nil),         // 8: timecraft.server.v1.FunctionCall
+	(*FunctionResult)(nil),       // 9: timecraft.server.v1.FunctionResult
+	(*Header)(nil),               // 10: timecraft.server.v1.Header
+	(*SubmitTasksRequest)(nil),   // 11: timecraft.server.v1.SubmitTasksRequest
+	(*SubmitTasksResponse)(nil),  // 12: timecraft.server.v1.SubmitTasksResponse
+	(*LookupTasksRequest)(nil),   // 13: timecraft.server.v1.LookupTasksRequest
+	(*LookupTasksResponse)(nil),  // 14: timecraft.server.v1.LookupTasksResponse
+	(*PollTasksRequest)(nil),     // 15: timecraft.server.v1.PollTasksRequest
+	(*PollTasksResponse)(nil),    // 16: timecraft.server.v1.PollTasksResponse
+	(*WatchTasksRequest)(nil),    // 17: timecraft.server.v1.WatchTasksRequest
+	(*WatchTasksResponse)(nil),   // 18: timecraft.server.v1.WatchTasksResponse
+	(*DiscardTasksRequest)(nil),  // 19: timecraft.server.v1.DiscardTasksRequest
+	(*DiscardTasksResponse)(nil), // 20: timecraft.server.v1.DiscardTasksResponse
+	(*ProcessIDRequest)(nil),     // 21: timecraft.server.v1.ProcessIDRequest
+	(*ProcessIDResponse)(nil),    // 22: timecraft.server.v1.ProcessIDResponse
+	(*SpawnRequest)(nil),         // 23: timecraft.server.v1.SpawnRequest
+	(*SpawnResponse)(nil),        // 24: timecraft.server.v1.SpawnResponse
+	(*KillRequest)(nil),          // 25: timecraft.server.v1.KillRequest
+	(*KillResponse)(nil),         // 26: timecraft.server.v1.KillResponse
+	(*VersionRequest)(nil),       // 27: timecraft.server.v1.VersionRequest
+	(*VersionResponse)(nil),      // 28: timecraft.server.v1.VersionResponse
 }
 var file_timecraft_server_v1_timecraft_proto_depIdxs = []int32{
-	3,  // 0: timecraft.server.v1.TaskRequest.module:type_name -> timecraft.server.v1.ModuleSpec
-	4,  // 1: timecraft.server.v1.TaskRequest.http_request:type_name -> timecraft.server.v1.HTTPRequest
-	0,  // 2: timecraft.server.v1.TaskResponse.state:type_name -> timecraft.server.v1.TaskState
-	5,  // 3: timecraft.server.v1.TaskResponse.http_response:type_name -> timecraft.server.v1.HTTPResponse
-	3,  // 4: timecraft.server.v1.ModuleSpec.outbound_proxy:type_name -> timecraft.server.v1.ModuleSpec
-	6,  // 5: timecraft.server.v1.HTTPRequest.headers:type_name -> timecraft.server.v1.Header
-	6,  // 6: timecraft.server.v1.HTTPResponse.headers:type_name -> timecraft.server.v1.Header
-	1,  // 7: timecraft.server.v1.SubmitTasksRequest.requests:type_name -> timecraft.server.v1.TaskRequest
-	2,  // 8: timecraft.server.v1.LookupTasksResponse.responses:type_name -> timecraft.server.v1.TaskResponse
-	2,  // 9: timecraft.server.v1.PollTasksResponse.responses:type_name -> timecraft.server.v1.TaskResponse
-	3,  // 10: timecraft.server.v1.SpawnRequest.module:type_name -> timecraft.server.v1.ModuleSpec
-	7,  // 11: timecraft.server.v1.TimecraftService.SubmitTasks:input_type -> timecraft.server.v1.SubmitTasksRequest
-	9,  // 12: timecraft.server.v1.TimecraftService.LookupTasks:input_type -> timecraft.server.v1.LookupTasksRequest
-	11, // 13: timecraft.server.v1.TimecraftService.PollTasks:input_type -> timecraft.server.v1.PollTasksRequest
-	13, // 14: timecraft.server.v1.TimecraftService.DiscardTasks:input_type -> timecraft.server.v1.DiscardTasksRequest
-	15, // 15: timecraft.server.v1.TimecraftService.ProcessID:input_type -> timecraft.server.v1.ProcessIDRequest
-	17, // 16: timecraft.server.v1.TimecraftService.Spawn:input_type -> timecraft.server.v1.SpawnRequest
-	19, // 17: timecraft.server.v1.TimecraftService.Kill:input_type -> timecraft.server.v1.KillRequest
-	21, // 18: timecraft.server.v1.TimecraftService.Version:input_type -> timecraft.server.v1.VersionRequest
-	8,  // 19: timecraft.server.v1.TimecraftService.SubmitTasks:output_type -> timecraft.server.v1.SubmitTasksResponse
-	10, // 20: timecraft.server.v1.TimecraftService.LookupTasks:output_type -> timecraft.server.v1.LookupTasksResponse
-	12, // 21: timecraft.server.v1.TimecraftService.PollTasks:output_type -> timecraft.server.v1.PollTasksResponse
-	14, // 22: timecraft.server.v1.TimecraftService.DiscardTasks:output_type -> timecraft.server.v1.DiscardTasksResponse
-	16, // 23: timecraft.server.v1.TimecraftService.ProcessID:output_type -> timecraft.server.v1.ProcessIDResponse
-	18, // 24: timecraft.server.v1.TimecraftService.Spawn:output_type -> timecraft.server.v1.SpawnResponse
-	20, // 25: timecraft.server.v1.TimecraftService.Kill:output_type -> timecraft.server.v1.KillResponse
-	22, // 26: timecraft.server.v1.TimecraftService.Version:output_type -> timecraft.server.v1.VersionResponse
-	19, // [19:27] is the sub-list for method output_type
-	11, // [11:19] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	5,  // 0: timecraft.server.v1.TaskRequest.module:type_name -> timecraft.server.v1.ModuleSpec
+	6,  // 1: timecraft.server.v1.TaskRequest.http_request:type_name -> timecraft.server.v1.HTTPRequest
+	8,  // 2: timecraft.server.v1.TaskRequest.function_call:type_name -> timecraft.server.v1.FunctionCall
+	3,  // 3: timecraft.server.v1.TaskRequest.retry_policy:type_name -> timecraft.server.v1.RetryPolicy
+	1,  // 4: timecraft.server.v1.TaskRequest.priority:type_name -> timecraft.server.v1.TaskPriority
+	0,  // 5: timecraft.server.v1.TaskResponse.state:type_name -> timecraft.server.v1.TaskState
+	7,  // 6: timecraft.server.v1.TaskResponse.http_response:type_name -> timecraft.server.v1.HTTPResponse
+	9,  // 7: timecraft.server.v1.TaskResponse.function_result:type_name -> timecraft.server.v1.FunctionResult
+	5,  // 8: timecraft.server.v1.ModuleSpec.outbound_proxy:type_name -> timecraft.server.v1.ModuleSpec
+	10, // 9: timecraft.server.v1.HTTPRequest.headers:type_name -> timecraft.server.v1.Header
+	10, // 10: timecraft.server.v1.HTTPResponse.headers:type_name -> timecraft.server.v1.Header
+	2,  // 11: timecraft.server.v1.SubmitTasksRequest.requests:type_name -> timecraft.server.v1.TaskRequest
+	4,  // 12: timecraft.server.v1.LookupTasksResponse.responses:type_name -> timecraft.server.v1.TaskResponse
+	4,  // 13: timecraft.server.v1.PollTasksResponse.responses:type_name -> timecraft.server.v1.TaskResponse
+	4,  // 14: timecraft.server.v1.WatchTasksResponse.response:type_name -> timecraft.server.v1.TaskResponse
+	5,  // 15: timecraft.server.v1.SpawnRequest.module:type_name -> timecraft.server.v1.ModuleSpec
+	11, // 16: timecraft.server.v1.TimecraftService.SubmitTasks:input_type -> timecraft.server.v1.SubmitTasksRequest
+	13, // 17: timecraft.server.v1.TimecraftService.LookupTasks:input_type -> timecraft.server.v1.LookupTasksRequest
+	15, // 18: timecraft.server.v1.TimecraftService.PollTasks:input_type -> timecraft.server.v1.PollTasksRequest
+	17, // 19: timecraft.server.v1.TimecraftService.WatchTasks:input_type -> timecraft.server.v1.WatchTasksRequest
+	19, // 20: timecraft.server.v1.TimecraftService.DiscardTasks:input_type -> timecraft.server.v1.DiscardTasksRequest
+	21, // 21: timecraft.server.v1.TimecraftService.ProcessID:input_type -> timecraft.server.v1.ProcessIDRequest
+	23, // 22: timecraft.server.v1.TimecraftService.Spawn:input_type -> timecraft.server.v1.SpawnRequest
+	25, // 23: timecraft.server.v1.TimecraftService.Kill:input_type -> timecraft.server.v1.KillRequest
+	27, // 24: timecraft.server.v1.TimecraftService.Version:input_type -> timecraft.server.v1.VersionRequest
+	12, // 25: timecraft.server.v1.TimecraftService.SubmitTasks:output_type -> timecraft.server.v1.SubmitTasksResponse
+	14, // 26: timecraft.server.v1.TimecraftService.LookupTasks:output_type -> timecraft.server.v1.LookupTasksResponse
+	16, // 27: timecraft.server.v1.TimecraftService.PollTasks:output_type -> timecraft.server.v1.PollTasksResponse
+	18, // 28: timecraft.server.v1.TimecraftService.WatchTasks:output_type -> timecraft.server.v1.WatchTasksResponse
+	20, // 29: timecraft.server.v1.TimecraftService.DiscardTasks:output_type -> timecraft.server.v1.DiscardTasksResponse
+	22, // 30: timecraft.server.v1.TimecraftService.ProcessID:output_type -> timecraft.server.v1.ProcessIDResponse
+	24, // 31: timecraft.server.v1.TimecraftService.Spawn:output_type -> timecraft.server.v1.SpawnResponse
+	26, // 32: timecraft.server.v1.TimecraftService.Kill:output_type -> timecraft.server.v1.KillResponse
+	28, // 33: timecraft.server.v1.TimecraftService.Version:output_type -> timecraft.server.v1.VersionResponse
+	25, // [25:34] is the sub-list for method output_type
+	16, // [16:25] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_timecraft_server_v1_timecraft_proto_init() }
@@ -1545,7 +2043,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TaskResponse); i {
+			switch v := v.(*RetryPolicy); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1557,7 +2055,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ModuleSpec); i {
+			switch v := v.(*TaskResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1569,7 +2067,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HTTPRequest); i {
+			switch v := v.(*ModuleSpec); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1581,7 +2079,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HTTPResponse); i {
+			switch v := v.(*HTTPRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1593,7 +2091,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Header); i {
+			switch v := v.(*HTTPResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1605,7 +2103,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubmitTasksRequest); i {
+			switch v := v.(*FunctionCall); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1617,7 +2115,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SubmitTasksResponse); i {
+			switch v := v.(*FunctionResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1629,7 +2127,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LookupTasksRequest); i {
+			switch v := v.(*Header); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1641,7 +2139,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LookupTasksResponse); i {
+			switch v := v.(*SubmitTasksRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1653,7 +2151,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PollTasksRequest); i {
+			switch v := v.(*SubmitTasksResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1665,7 +2163,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PollTasksResponse); i {
+			switch v := v.(*LookupTasksRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1677,7 +2175,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DiscardTasksRequest); i {
+			switch v := v.(*LookupTasksResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1689,7 +2187,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DiscardTasksResponse); i {
+			switch v := v.(*PollTasksRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1701,7 +2199,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ProcessIDRequest); i {
+			switch v := v.(*PollTasksResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1713,7 +2211,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ProcessIDResponse); i {
+			switch v := v.(*WatchTasksRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1725,7 +2223,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest); i {
+			switch v := v.(*WatchTasksResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1737,7 +2235,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnResponse); i {
+			switch v := v.(*DiscardTasksRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1749,7 +2247,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KillRequest); i {
+			switch v := v.(*DiscardTasksResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1761,7 +2259,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KillResponse); i {
+			switch v := v.(*ProcessIDRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1773,7 +2271,7 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VersionRequest); i {
+			switch v := v.(*ProcessIDResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1785,6 +2283,66 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 			}
 		}
 		file_timecraft_server_v1_timecraft_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_timecraft_server_v1_timecraft_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_timecraft_server_v1_timecraft_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KillRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_timecraft_server_v1_timecraft_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KillResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_timecraft_server_v1_timecraft_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VersionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_timecraft_server_v1_timecraft_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VersionResponse); i {
 			case 0:
 				return &v.state
@@ -1799,17 +2357,19 @@ func file_timecraft_server_v1_timecraft_proto_init() {
 	}
 	file_timecraft_server_v1_timecraft_proto_msgTypes[0].OneofWrappers = []interface{}{
 		(*TaskRequest_HttpRequest)(nil),
+		(*TaskRequest_FunctionCall)(nil),
 	}
-	file_timecraft_server_v1_timecraft_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_timecraft_server_v1_timecraft_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*TaskResponse_HttpResponse)(nil),
+		(*TaskResponse_FunctionResult)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_timecraft_server_v1_timecraft_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   22,
+			NumEnums:      2,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -60,6 +60,7 @@ type resource struct {
 	get       func(context.Context, io.Writer, *timemachine.Registry, bool) stream.WriteCloser[*format.Descriptor]
 	describe  func(context.Context, *timemachine.Registry, string, *timecraft.Config) (any, error)
 	lookup    func(context.Context, *timemachine.Registry, string, *timecraft.Config) (any, error)
+	delete    func(context.Context, *timemachine.Registry, string) (string, error)
 }
 
 var resources = [...]resource{
@@ -87,6 +88,7 @@ var resources = [...]resource{
 		mediaType: format.TypeTimecraftProcess,
 		describe:  describeProcess,
 		lookup:    lookupProcess,
+		delete:    deleteProcess,
 	},
 
 	{
@@ -96,6 +98,7 @@ var resources = [...]resource{
 		get:       getProfiles,
 		describe:  describeProfile,
 		lookup:    lookupProfile,
+		delete:    deleteProfile,
 	},
 
 	{
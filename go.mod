@@ -27,3 +27,5 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 	oras.land/oras-go/v2 v2.2.1
 )
+
+require golang.org/x/text v0.10.0 // indirect
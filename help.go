@@ -10,8 +10,10 @@ const helpUsage = `
 Usage:	timecraft <command> [options]
 
 Registry Commands:
+   delete    Delete resources from the time machine registry
    describe  Show detailed information about specific resources
    export    Export resources to local files
+   gc        Delete resources of the registry which are not used anymore
    get       Display resources from the time machine registry
 
 Runtime Commands:
@@ -19,6 +21,7 @@ Runtime Commands:
    replay    Replay a recorded trace of execution
 
 Debugging Commands:
+   diff      Compare the system calls recorded by two executions
    logs      Print the logs for a module execution
    profile   Generate performance profile from execution records
    trace     Generate traces from execution records
@@ -55,10 +58,16 @@ func help(ctx context.Context, args []string) error {
 		switch cmd {
 		case "config":
 			msg = configUsage
+		case "delete":
+			msg = deleteUsage
 		case "describe":
 			msg = describeUsage
+		case "diff":
+			msg = diffUsage
 		case "export":
 			msg = exportUsage
+		case "gc":
+			msg = gcUsage
 		case "get":
 			msg = getUsage
 		case "help":
@@ -47,6 +47,13 @@ var help = tests{
 		assert.Equal(t, stderr, "")
 	},
 
+	"timecraft help delete": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "help", "delete")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft delete ")
+		assert.Equal(t, stderr, "")
+	},
+
 	"timecraft help describe": func(t *testing.T) {
 		stdout, stderr, exitCode := timecraft(t, "help", "describe")
 		assert.Equal(t, exitCode, 0)
@@ -54,6 +61,13 @@ var help = tests{
 		assert.Equal(t, stderr, "")
 	},
 
+	"timecraft help diff": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "help", "diff")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft diff ")
+		assert.Equal(t, stderr, "")
+	},
+
 	"timecraft help export": func(t *testing.T) {
 		stdout, stderr, exitCode := timecraft(t, "help", "export")
 		assert.Equal(t, exitCode, 0)
@@ -61,6 +75,13 @@ var help = tests{
 		assert.Equal(t, stderr, "")
 	},
 
+	"timecraft help gc": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "help", "gc")
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stdout, "Usage:\ttimecraft gc ")
+		assert.Equal(t, stderr, "")
+	},
+
 	"timecraft help get": func(t *testing.T) {
 		stdout, stderr, exitCode := timecraft(t, "help", "get")
 		assert.Equal(t, exitCode, 0)
@@ -0,0 +1,217 @@
+// Package debugger implements an interactive debugger for the replay of
+// WebAssembly modules.
+//
+// The debugger pauses the guest at the boundary of the host function call
+// which consumes a selected record of the log, and exposes a REPL to inspect
+// the state of the module (linear memory, globals, call stack) at this point
+// of the execution.
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/print/human"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// ErrQuit is returned by the record reader of a Debugger when the user exited
+// the debugger before the end of the replay.
+var ErrQuit = errors.New("debugger: quit")
+
+// Breakpoint describes the record at which the replay pauses.
+type Breakpoint struct {
+	// Offset of the record that the guest pauses at. The offset is only used
+	// when Time is zero.
+	Offset int64
+	// When non-zero, the guest pauses at the first record which occurred at
+	// or after this time.
+	Time time.Time
+}
+
+// ParseBreakpoint parses the representation of a breakpoint, which is either a
+// record offset, a duration relative to the start time of the process, or an
+// absolute point in time.
+func ParseBreakpoint(s string, startTime time.Time) (Breakpoint, error) {
+	if s == "" {
+		return Breakpoint{}, errors.New("malformed breakpoint: empty string")
+	}
+	if offset, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if offset < 0 {
+			return Breakpoint{}, fmt.Errorf("malformed breakpoint: %q (negative record offset)", s)
+		}
+		return Breakpoint{Offset: offset}, nil
+	}
+	if d, err := human.ParseDuration(s); err == nil {
+		return Breakpoint{Time: startTime.Add(time.Duration(d))}, nil
+	}
+	if t, err := human.ParseTime(s); err == nil {
+		return Breakpoint{Time: time.Time(t)}, nil
+	}
+	return Breakpoint{}, fmt.Errorf("malformed breakpoint: %q (not a record offset, duration, or time)", s)
+}
+
+func (b Breakpoint) reached(record *timemachine.Record) bool {
+	if !b.Time.IsZero() {
+		return !record.Time.Before(b.Time)
+	}
+	return record.Offset >= b.Offset
+}
+
+func (b Breakpoint) String() string {
+	if !b.Time.IsZero() {
+		return b.Time.Format(time.RFC3339Nano)
+	}
+	return "record " + strconv.FormatInt(b.Offset, 10)
+}
+
+// Debugger pauses the replay of a module when the record iterator reaches a
+// breakpoint.
+//
+// The Debugger is both the reader of records passed to the replay, and a
+// function listener factory which must be installed when compiling the guest
+// module and the host module. Function listeners are created for host
+// functions so the debugger can pause the guest before it calls them.
+type Debugger struct {
+	records    stream.Reader[timemachine.Record]
+	breakpoint *Breakpoint
+	step       bool
+	reached    bool
+	quit       bool
+
+	input  *bufio.Scanner
+	output io.Writer
+
+	// The debugger needs to look at the next record in order to determine
+	// whether a breakpoint was reached before the host function is called.
+	// Records only remain valid until the next read, so the function call
+	// is copied to one of two buffers that are alternated when the record
+	// is handed over to the replay.
+	next    timemachine.Record
+	nextErr error
+	hasNext bool
+	buffers [2][]byte
+	buffer  int
+}
+
+// New constructs a debugger which pauses the replay when reading the record at
+// the given breakpoint from records. The REPL reads commands from input and
+// writes its output to output.
+func New(records stream.Reader[timemachine.Record], breakpoint Breakpoint, input io.Reader, output io.Writer) *Debugger {
+	return &Debugger{
+		records:    records,
+		breakpoint: &breakpoint,
+		input:      bufio.NewScanner(input),
+		output:     output,
+	}
+}
+
+// Reached returns true if the replay was paused at the breakpoint.
+func (d *Debugger) Reached() bool {
+	return d.reached
+}
+
+// Read satisfies the stream.Reader interface. Records are read one at a time
+// so the debugger always knows which record the next host function call will
+// consume.
+func (d *Debugger) Read(records []timemachine.Record) (int, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	if d.quit {
+		return 0, ErrQuit
+	}
+	if d.hasNext {
+		records[0] = d.next
+		d.hasNext = false
+		d.buffer ^= 1
+		return 1, nil
+	}
+	if d.nextErr != nil {
+		return 0, d.nextErr
+	}
+	return d.records.Read(records[:1])
+}
+
+func (d *Debugger) peek() (*timemachine.Record, bool) {
+	if !d.hasNext && d.nextErr == nil {
+		var records [1]timemachine.Record
+		n, err := d.records.Read(records[:])
+		if n == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			d.nextErr = err
+			return nil, false
+		}
+		d.next = records[0]
+		d.buffers[d.buffer] = append(d.buffers[d.buffer][:0], d.next.FunctionCall...)
+		d.next.FunctionCall = d.buffers[d.buffer]
+		d.hasNext = true
+	}
+	return &d.next, d.hasNext
+}
+
+// NewFunctionListener satisfies the experimental.FunctionListenerFactory
+// interface.
+func (d *Debugger) NewFunctionListener(def api.FunctionDefinition) experimental.FunctionListener {
+	if def.GoFunction() == nil {
+		return nil
+	}
+	return experimental.FunctionListenerFunc(d.before)
+}
+
+func (d *Debugger) before(ctx context.Context, mod api.Module, def api.FunctionDefinition, params []uint64, stack experimental.StackIterator) {
+	if d.quit || (d.breakpoint == nil && !d.step) {
+		return
+	}
+	record, ok := d.peek()
+	if d.step {
+		d.step = false
+	} else if !ok || !d.breakpoint.reached(record) {
+		return
+	} else {
+		d.breakpoint = nil
+	}
+	d.reached = true
+
+	var frames []frame
+	for stack.Next() {
+		fn := stack.Function()
+		f := frame{function: fn.Definition().DebugName()}
+		if pc := stack.ProgramCounter(); pc != 0 {
+			f.offset = fn.SourceOffsetForPC(pc)
+			f.hasOffset = f.offset != 0
+		}
+		frames = append(frames, f)
+	}
+
+	s := &session{
+		debugger: d,
+		module:   mod,
+		function: def,
+		params:   params,
+		frames:   frames,
+		record:   record,
+	}
+	s.run()
+}
+
+type frame struct {
+	function  string
+	offset    uint64
+	hasOffset bool
+}
+
+var (
+	_ stream.Reader[timemachine.Record]    = (*Debugger)(nil)
+	_ experimental.FunctionListenerFactory = (*Debugger)(nil)
+)
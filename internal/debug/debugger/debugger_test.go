@@ -0,0 +1,39 @@
+package debugger_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/debug/debugger"
+)
+
+func TestParseBreakpoint(t *testing.T) {
+	startTime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in  string
+		out debugger.Breakpoint
+	}{
+		{in: "0", out: debugger.Breakpoint{Offset: 0}},
+		{in: "42", out: debugger.Breakpoint{Offset: 42}},
+		{in: "1.5s", out: debugger.Breakpoint{Time: startTime.Add(1500 * time.Millisecond)}},
+		{in: "2023-06-01T12:30:00Z", out: debugger.Breakpoint{Time: startTime.Add(30 * time.Minute)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			b, err := debugger.ParseBreakpoint(test.in, startTime)
+			assert.OK(t, err)
+			assert.Equal(t, b.Offset, test.out.Offset)
+			assert.True(t, b.Time.Equal(test.out.Time))
+		})
+	}
+
+	for _, in := range []string{"-1", "", "whatever"} {
+		t.Run(in, func(t *testing.T) {
+			_, err := debugger.ParseBreakpoint(in, startTime)
+			assert.True(t, err != nil)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package debugger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+const prompt = "(timecraft) "
+
+const replUsage = `Commands:
+  record, r                  Show the record consumed by the next host function call
+  stack, bt                  Show the call stack of the guest
+  memory, x <addr> [length]  Show the content of the linear memory (64 bytes by default)
+  globals, g                 Show the values of the module globals
+  step, s                    Resume the replay and pause at the next host function call
+  continue, c [breakpoint]   Resume the replay, optionally until another breakpoint
+  quit, q                    Stop the replay
+  help, h                    Show this list of commands
+`
+
+// session is the state of the REPL while the guest is paused.
+type session struct {
+	debugger *Debugger
+	module   api.Module
+	function api.FunctionDefinition
+	params   []uint64
+	frames   []frame
+	record   *timemachine.Record
+}
+
+func (s *session) run() {
+	d := s.debugger
+	fmt.Fprintf(d.output, "replay paused before calling %s\n", s.function.DebugName())
+	s.printRecord()
+
+	for {
+		fmt.Fprint(d.output, prompt)
+		if !d.input.Scan() {
+			// The input was closed, there is no way to receive commands so
+			// we let the replay run to completion.
+			fmt.Fprintln(d.output)
+			return
+		}
+		args := strings.Fields(d.input.Text())
+		if len(args) == 0 {
+			continue
+		}
+		cmd, args := args[0], args[1:]
+
+		switch cmd {
+		case "record", "r":
+			s.printRecord()
+		case "stack", "bt":
+			s.printStack()
+		case "memory", "x":
+			s.printMemory(args)
+		case "globals", "g":
+			s.printGlobals()
+		case "step", "s":
+			d.step = true
+			return
+		case "continue", "c":
+			if len(args) == 0 {
+				return
+			}
+			if s.record == nil {
+				fmt.Fprintln(d.output, "error: there are no more records to replay")
+				continue
+			}
+			breakpoint, err := ParseBreakpoint(args[0], s.record.Time)
+			if err != nil {
+				fmt.Fprintf(d.output, "error: %s\n", err)
+				continue
+			}
+			d.breakpoint = &breakpoint
+			return
+		case "quit", "q":
+			d.quit = true
+			return
+		case "help", "h":
+			fmt.Fprint(d.output, replUsage)
+		default:
+			fmt.Fprintf(d.output, "error: unknown command: %q (type 'help' for the list of commands)\n", cmd)
+		}
+	}
+}
+
+func (s *session) printRecord() {
+	w := s.debugger.output
+	if s.record == nil {
+		fmt.Fprintln(w, "no more records to replay")
+		return
+	}
+	fmt.Fprintf(w, "record %d: %s (%d bytes) at %s\n",
+		s.record.Offset,
+		wasicall.SyscallID(s.record.FunctionID),
+		len(s.record.FunctionCall),
+		s.record.Time.Format(time.RFC3339Nano),
+	)
+}
+
+func (s *session) printStack() {
+	w := tabwriter.NewWriter(s.debugger.output, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	for i, f := range s.frames {
+		if f.hasOffset {
+			fmt.Fprintf(w, "#%d\t%s\t+0x%x\n", i, f.function, f.offset)
+		} else {
+			fmt.Fprintf(w, "#%d\t%s\t\n", i, f.function)
+		}
+	}
+}
+
+func (s *session) printMemory(args []string) {
+	w := s.debugger.output
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(w, "usage: memory <address> [length]")
+		return
+	}
+	address, err := strconv.ParseUint(args[0], 0, 32)
+	if err != nil {
+		fmt.Fprintf(w, "error: malformed address: %q\n", args[0])
+		return
+	}
+	length := uint64(64)
+	if len(args) == 2 {
+		if length, err = strconv.ParseUint(args[1], 0, 32); err != nil {
+			fmt.Fprintf(w, "error: malformed length: %q\n", args[1])
+			return
+		}
+	}
+
+	memory := s.module.Memory()
+	if memory == nil {
+		fmt.Fprintln(w, "error: the module has no memory")
+		return
+	}
+	data, ok := memory.Read(uint32(address), uint32(length))
+	if !ok {
+		fmt.Fprintf(w, "error: out of bounds memory access: [0x%x:0x%x] (memory size is 0x%x)\n", address, address+length, memory.Size())
+		return
+	}
+
+	line := new(strings.Builder)
+	for i := 0; i < len(data); i += 16 {
+		chunk := data[i:min(i+16, len(data))]
+		line.Reset()
+		fmt.Fprintf(line, "%08x ", address+uint64(i))
+		for j := 0; j < 16; j++ {
+			if j%8 == 0 {
+				line.WriteByte(' ')
+			}
+			if j < len(chunk) {
+				fmt.Fprintf(line, "%02x ", chunk[j])
+			} else {
+				line.WriteString("   ")
+			}
+		}
+		line.WriteString(" |")
+		for _, c := range chunk {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			line.WriteByte(c)
+		}
+		line.WriteString("|\n")
+		fmt.Fprint(w, line.String())
+	}
+}
+
+func (s *session) printGlobals() {
+	m, ok := s.module.(experimental.InternalModule)
+	if !ok {
+		fmt.Fprintln(s.debugger.output, "error: the module globals cannot be inspected")
+		return
+	}
+	w := tabwriter.NewWriter(s.debugger.output, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	for i := 0; i < m.NumGlobal(); i++ {
+		g := m.Global(i)
+		fmt.Fprintf(w, "global[%d]\t%s\t%s\n", i, api.ValueTypeName(g.Type()), formatValue(g.Type(), g.Get()))
+	}
+}
+
+func formatValue(t api.ValueType, v uint64) string {
+	switch t {
+	case api.ValueTypeI32:
+		return strconv.FormatInt(int64(int32(v)), 10)
+	case api.ValueTypeI64:
+		return strconv.FormatInt(int64(v), 10)
+	case api.ValueTypeF32:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(v))), 'g', -1, 32)
+	case api.ValueTypeF64:
+		return strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64)
+	default:
+		return fmt.Sprintf("0x%x", v)
+	}
+}
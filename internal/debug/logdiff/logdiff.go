@@ -0,0 +1,347 @@
+// Package logdiff compares the system calls recorded in two logs to find where
+// the executions they were recorded from diverged.
+package logdiff
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+)
+
+// DefaultWindow is the default number of system calls that Compare looks ahead
+// in each log to realign the executions after they diverged.
+const DefaultWindow = 256
+
+// Reader is the interface used to read system calls from logs, implemented by
+// *wasicall.Reader.
+type Reader interface {
+	ReadSyscall() (time.Time, wasicall.Syscall, error)
+}
+
+// Call is a system call read from a log.
+//
+// Calls from two logs are aligned when they are calls to the same system call
+// with the same parameters. Buffers which are filled by the host (e.g. the
+// iovecs of fd_read or the buffer of random_get) are considered results of
+// the system calls, only their length is part of the parameters.
+type Call struct {
+	Offset  int64     `json:"offset"            yaml:"offset"`
+	Time    time.Time `json:"time"              yaml:"time"`
+	Syscall string    `json:"syscall"           yaml:"syscall"`
+	Params  string    `json:"params"            yaml:"params"`
+	Results string    `json:"results,omitempty" yaml:"results,omitempty"`
+
+	id wasicall.SyscallID
+}
+
+// NewCall constructs a Call from a system call read at the given offset in a
+// log.
+func NewCall(offset int64, t time.Time, s wasicall.Syscall) Call {
+	params, results := s.Params(), s.Results()
+
+	switch s := s.(type) {
+	case *wasicall.FDReadSyscall:
+		params = []any{s.FD, iovecsLen(s.IOVecs)}
+		results = append([]any{s.IOVecs}, results...)
+	case *wasicall.FDPreadSyscall:
+		params = []any{s.FD, iovecsLen(s.IOVecs), s.Offset}
+		results = append([]any{s.IOVecs}, results...)
+	case *wasicall.SockRecvSyscall:
+		params = []any{s.FD, iovecsLen(s.IOVecs), s.IFlags}
+		results = append([]any{s.IOVecs}, results...)
+	case *wasicall.SockRecvFromSyscall:
+		params = []any{s.FD, iovecsLen(s.IOVecs), s.IFlags}
+		results = append([]any{s.IOVecs}, results...)
+	case *wasicall.RandomGetSyscall:
+		params = []any{len(s.B)}
+		results = append([]any{s.B}, results...)
+	case *wasicall.FDReadDirSyscall:
+		params = []any{s.FD, s.Cookie, s.BufferSizeBytes}
+		results = append([]any{s.Entries}, results...)
+	case *wasicall.PollOneOffSyscall:
+		params = []any{s.Subscriptions}
+		results = append([]any{s.Events}, results...)
+	}
+
+	return Call{
+		Offset:  offset,
+		Time:    t,
+		Syscall: s.ID().String(),
+		Params:  formatValues(params),
+		Results: formatValues(results),
+		id:      s.ID(),
+	}
+}
+
+func (c *Call) sameCall(other *Call) bool {
+	return c.id == other.id && c.Params == other.Params
+}
+
+func (c *Call) sameResults(other *Call) bool {
+	return c.Results == other.Results
+}
+
+// String returns a representation of the call similar to the output of
+// strace-like tracing. The results are omitted if the call has none, which is
+// the case of calls that have not completed.
+func (c Call) String() string {
+	if c.Results == "" {
+		return fmt.Sprintf("%s(%s)", c.Syscall, c.Params)
+	}
+	return fmt.Sprintf("%s(%s) => %s", c.Syscall, c.Params, c.Results)
+}
+
+// Divergence is the first pair of calls where the executions recorded in two
+// logs diverged. A or B are nil if the corresponding log ended before the
+// other.
+type Divergence struct {
+	A *Call `json:"a,omitempty" yaml:"a,omitempty"`
+	B *Call `json:"b,omitempty" yaml:"b,omitempty"`
+}
+
+// Stat is a summary of the differences found for a system call.
+type Stat struct {
+	Syscall string `json:"syscall"     yaml:"syscall"     text:"SYSCALL"`
+	CallsA  int    `json:"callsA"      yaml:"callsA"      text:"CALLS (A)"`
+	CallsB  int    `json:"callsB"      yaml:"callsB"      text:"CALLS (B)"`
+	OnlyA   int    `json:"onlyA"       yaml:"onlyA"       text:"ONLY IN A"`
+	OnlyB   int    `json:"onlyB"       yaml:"onlyB"       text:"ONLY IN B"`
+	Results int    `json:"diffResults" yaml:"diffResults" text:"DIFFERENT RESULTS"`
+}
+
+// Diff is the result of comparing two logs.
+type Diff struct {
+	// Number of system calls read from each log.
+	CallsA int `json:"callsA" yaml:"callsA"`
+	CallsB int `json:"callsB" yaml:"callsB"`
+	// Number of aligned calls, and number of aligned calls which returned
+	// different results.
+	Aligned        int `json:"aligned"        yaml:"aligned"`
+	AlignedResults int `json:"alignedResults" yaml:"alignedResults"`
+	// The first divergence found between the logs, nil if the same system
+	// calls were made in the same order.
+	Divergence *Divergence `json:"divergence,omitempty" yaml:"divergence,omitempty"`
+	// The summary of differences for the system calls which differed between
+	// the two logs, sorted by name.
+	Syscalls []Stat `json:"syscalls" yaml:"syscalls"`
+}
+
+// Compare reads the system calls from a and b, and returns the differences
+// between the two sequences.
+//
+// The calls are aligned in order. When they diverge, Compare searches the next
+// window calls of each log for the closest pair of calls to realign the logs;
+// the calls skipped on each side are reported as only present in this log.
+func Compare(a, b Reader, window int) (*Diff, error) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	d := &Diff{}
+	qa := &queue{reader: a}
+	qb := &queue{reader: b}
+	stats := make(map[string]*Stat)
+	stat := func(syscall string) *Stat {
+		s := stats[syscall]
+		if s == nil {
+			s = &Stat{Syscall: syscall}
+			stats[syscall] = s
+		}
+		return s
+	}
+	onlyA := func(c *Call) { stat(c.Syscall).OnlyA++ }
+	onlyB := func(c *Call) { stat(c.Syscall).OnlyB++ }
+
+	for {
+		if err := qa.fill(1); err != nil {
+			return nil, err
+		}
+		if err := qb.fill(1); err != nil {
+			return nil, err
+		}
+		ca, cb := qa.peek(0), qb.peek(0)
+		if ca == nil && cb == nil {
+			break
+		}
+
+		if ca != nil && cb != nil && ca.sameCall(cb) {
+			d.Aligned++
+			if !ca.sameResults(cb) {
+				d.AlignedResults++
+				stat(ca.Syscall).Results++
+			}
+			qa.pop(1)
+			qb.pop(1)
+			continue
+		}
+
+		if d.Divergence == nil {
+			d.Divergence = &Divergence{}
+			if ca != nil {
+				c := *ca
+				d.Divergence.A = &c
+			}
+			if cb != nil {
+				c := *cb
+				d.Divergence.B = &c
+			}
+		}
+
+		if ca == nil {
+			qb.pop(1, onlyB)
+			continue
+		}
+		if cb == nil {
+			qa.pop(1, onlyA)
+			continue
+		}
+
+		if err := qa.fill(window); err != nil {
+			return nil, err
+		}
+		if err := qb.fill(window); err != nil {
+			return nil, err
+		}
+		if i, j, ok := realign(qa.calls, qb.calls); ok {
+			qa.pop(i, onlyA)
+			qb.pop(j, onlyB)
+		} else {
+			qa.pop(1, onlyA)
+			qb.pop(1, onlyB)
+		}
+	}
+
+	d.CallsA, d.CallsB = qa.count, qb.count
+	for name, count := range qa.counts {
+		stat(name).CallsA = count
+	}
+	for name, count := range qb.counts {
+		stat(name).CallsB = count
+	}
+	for _, s := range stats {
+		if s.OnlyA != 0 || s.OnlyB != 0 || s.Results != 0 {
+			d.Syscalls = append(d.Syscalls, *s)
+		}
+	}
+	slices.SortFunc(d.Syscalls, func(s1, s2 Stat) int {
+		return strings.Compare(s1.Syscall, s2.Syscall)
+	})
+	return d, nil
+}
+
+// realign finds the pair of aligned calls in a and b that minimizes the number
+// of calls skipped in both sequences.
+func realign(a, b []Call) (i, j int, ok bool) {
+	for n := 1; n < len(a)+len(b)-1; n++ {
+		for i = max(0, n-len(b)+1); i <= n && i < len(a); i++ {
+			j = n - i
+			if a[i].sameCall(&b[j]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// queue buffers calls read from a log. Calls are formatted when read since
+// the syscalls returned by readers are only valid until the next read.
+type queue struct {
+	reader Reader
+	calls  []Call
+	offset int64
+	count  int
+	counts map[string]int
+	eof    bool
+}
+
+func (q *queue) fill(n int) error {
+	for len(q.calls) < n && !q.eof {
+		t, s, err := q.reader.ReadSyscall()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				q.eof = true
+				return nil
+			}
+			return err
+		}
+		c := NewCall(q.offset, t, s)
+		q.calls = append(q.calls, c)
+		q.offset++
+		q.count++
+		if q.counts == nil {
+			q.counts = make(map[string]int)
+		}
+		q.counts[c.Syscall]++
+	}
+	return nil
+}
+
+func (q *queue) peek(i int) *Call {
+	if i < len(q.calls) {
+		return &q.calls[i]
+	}
+	return nil
+}
+
+func (q *queue) pop(n int, skip ...func(*Call)) {
+	for i := range q.calls[:n] {
+		for _, f := range skip {
+			f(&q.calls[i])
+		}
+	}
+	q.calls = slices.Delete(q.calls, 0, n)
+}
+
+func iovecsLen(iovecs []wasi.IOVec) int {
+	n := 0
+	for _, iov := range iovecs {
+		n += len(iov)
+	}
+	return n
+}
+
+const maxBytes = 32
+
+func formatValues(values []any) string {
+	var b strings.Builder
+	for i, v := range values {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		switch v := v.(type) {
+		case []wasi.IOVec:
+			var data []byte
+			for _, iov := range v {
+				data = append(data, iov...)
+			}
+			formatBytes(&b, data)
+		case []byte:
+			formatBytes(&b, v)
+		case wasi.Errno:
+			b.WriteString(v.Name())
+		default:
+			fmt.Fprintf(&b, "%+v", v)
+		}
+	}
+	return b.String()
+}
+
+func formatBytes(b *strings.Builder, data []byte) {
+	if len(data) <= maxBytes {
+		b.WriteString(strconv.Quote(string(data)))
+		return
+	}
+	// The representation includes a hash of the full content so buffers that
+	// differ beyond the truncated prefix do not compare equal.
+	h := fnv.New32a()
+	h.Write(data)
+	fmt.Fprintf(b, "%q...(%d bytes, fnv32a=%08x)", data[:maxBytes], len(data), h.Sum32())
+}
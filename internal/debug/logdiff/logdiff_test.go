@@ -0,0 +1,115 @@
+package logdiff_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/debug/logdiff"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+)
+
+type syscalls []wasicall.Syscall
+
+func (s *syscalls) ReadSyscall() (time.Time, wasicall.Syscall, error) {
+	if len(*s) == 0 {
+		return time.Time{}, nil, io.EOF
+	}
+	syscall := (*s)[0]
+	*s = (*s)[1:]
+	return time.Time{}, syscall, nil
+}
+
+func write(fd wasi.FD, data string) wasicall.Syscall {
+	return &wasicall.FDWriteSyscall{FD: fd, IOVecs: []wasi.IOVec{[]byte(data)}, Size: wasi.Size(len(data))}
+}
+
+func read(fd wasi.FD, data string) wasicall.Syscall {
+	return &wasicall.FDReadSyscall{FD: fd, IOVecs: []wasi.IOVec{[]byte(data)}, Size: wasi.Size(len(data))}
+}
+
+func random(data string) wasicall.Syscall {
+	return &wasicall.RandomGetSyscall{B: []byte(data)}
+}
+
+func exit(code wasi.ExitCode) wasicall.Syscall {
+	return &wasicall.ProcExitSyscall{ExitCode: code}
+}
+
+func compare(t *testing.T, a, b syscalls) *logdiff.Diff {
+	t.Helper()
+	d, err := logdiff.Compare(&a, &b, 0)
+	assert.OK(t, err)
+	return d
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("identical logs do not diverge", func(t *testing.T) {
+		d := compare(t,
+			syscalls{write(1, "hello"), exit(0)},
+			syscalls{write(1, "hello"), exit(0)},
+		)
+		assert.Equal(t, d.CallsA, 2)
+		assert.Equal(t, d.CallsB, 2)
+		assert.Equal(t, d.Aligned, 2)
+		assert.True(t, d.Divergence == nil)
+		assert.Equal(t, len(d.Syscalls), 0)
+	})
+
+	t.Run("buffers filled by the host are results", func(t *testing.T) {
+		d := compare(t,
+			syscalls{random("abcd"), read(0, "hello"), exit(0)},
+			syscalls{random("efgh"), read(0, "world"), exit(0)},
+		)
+		assert.Equal(t, d.Aligned, 3)
+		assert.Equal(t, d.AlignedResults, 2)
+		assert.True(t, d.Divergence == nil)
+		assert.DeepEqual(t, d.Syscalls, []logdiff.Stat{
+			{Syscall: "FDRead", CallsA: 1, CallsB: 1, Results: 1},
+			{Syscall: "RandomGet", CallsA: 1, CallsB: 1, Results: 1},
+		})
+	})
+
+	t.Run("the first divergence is reported", func(t *testing.T) {
+		d := compare(t,
+			syscalls{read(0, "1"), write(1, "one"), write(1, "two"), exit(0)},
+			syscalls{read(0, "2"), write(1, "two"), exit(1)},
+		)
+		assert.True(t, d.Divergence != nil)
+		assert.Equal(t, d.Divergence.A.Offset, 1)
+		assert.Equal(t, d.Divergence.A.String(), `FDWrite(1, "one") => 3, ESUCCESS`)
+		assert.Equal(t, d.Divergence.B.Offset, 1)
+		assert.Equal(t, d.Divergence.B.String(), `FDWrite(1, "two") => 3, ESUCCESS`)
+		// The second write of A is realigned with the first write of B.
+		assert.Equal(t, d.Aligned, 2)
+		assert.DeepEqual(t, d.Syscalls, []logdiff.Stat{
+			{Syscall: "FDRead", CallsA: 1, CallsB: 1, Results: 1},
+			{Syscall: "FDWrite", CallsA: 2, CallsB: 1, OnlyA: 1},
+			{Syscall: "ProcExit", CallsA: 1, CallsB: 1, OnlyA: 1, OnlyB: 1},
+		})
+	})
+
+	t.Run("logs ending early diverge", func(t *testing.T) {
+		d := compare(t,
+			syscalls{write(1, "hello")},
+			syscalls{write(1, "hello"), exit(0)},
+		)
+		assert.True(t, d.Divergence != nil)
+		assert.True(t, d.Divergence.A == nil)
+		assert.Equal(t, d.Divergence.B.Syscall, "ProcExit")
+		assert.DeepEqual(t, d.Syscalls, []logdiff.Stat{
+			{Syscall: "ProcExit", CallsB: 1, OnlyB: 1},
+		})
+	})
+
+	t.Run("large buffers are compared entirely", func(t *testing.T) {
+		prefix := string(make([]byte, 100))
+		d := compare(t,
+			syscalls{write(1, prefix+"a")},
+			syscalls{write(1, prefix+"b")},
+		)
+		assert.True(t, d.Divergence != nil)
+	})
+}
@@ -99,6 +99,12 @@ func (r *Reader) Read(b []byte) (n int, err error) {
 				if record.Time.Before(r.StartTime) {
 					continue
 				}
+				// The data of elided records is not in the log, only its size.
+				if _, elided, err := r.codec.DecodeElision(record.FunctionCall); err != nil {
+					return n, err
+				} else if elided {
+					continue
+				}
 				fd, iovecs, size, _, err := r.codec.DecodeFDRead(record.FunctionCall, r.iovecs[:0])
 				if err != nil {
 					return n, err
@@ -112,6 +118,12 @@ func (r *Reader) Read(b []byte) (n int, err error) {
 				if record.Time.Before(r.StartTime) {
 					continue
 				}
+				// The data of elided records is not in the log, only its size.
+				if _, elided, err := r.codec.DecodeElision(record.FunctionCall); err != nil {
+					return n, err
+				} else if elided {
+					continue
+				}
 				fd, iovecs, size, _, err := r.codec.DecodeFDWrite(record.FunctionCall, r.iovecs[:0])
 				if err != nil {
 					return n, err
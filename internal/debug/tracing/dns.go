@@ -0,0 +1,445 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/wasi-go"
+	"golang.org/x/net/dns/dnsmessage"
+	"gopkg.in/yaml.v3"
+)
+
+// AddressLookup carries the arguments and results of a name resolution made
+// by the guest with the sock_getaddrinfo system call.
+type AddressLookup struct {
+	Name    string
+	Service string
+	Hints   wasi.AddressInfo
+	Results []wasi.AddressInfo
+	// Upper bound of the time spent resolving the name.
+	Span time.Duration
+}
+
+func (l *AddressLookup) String() string {
+	return fmt.Sprintf("%s (%s) => %s",
+		addressLookupName(l.Name, l.Service),
+		addressInfoHints(l.Hints),
+		strings.Join(addressInfoResults(l.Results), ", "))
+}
+
+func (l *AddressLookup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.marshal())
+}
+
+func (l *AddressLookup) MarshalYAML() (any, error) {
+	return l.marshal(), nil
+}
+
+func (l *AddressLookup) marshal() *addressLookup {
+	return &addressLookup{
+		Name:    l.Name,
+		Service: l.Service,
+		Hints:   addressInfoHints(l.Hints),
+		Results: addressInfoResults(l.Results),
+		Span:    l.Span,
+	}
+}
+
+type addressLookup struct {
+	Name    string        `json:"name"              yaml:"name"`
+	Service string        `json:"service,omitempty" yaml:"service,omitempty"`
+	Hints   string        `json:"hints"             yaml:"hints"`
+	Results []string      `json:"results"           yaml:"results"`
+	Span    time.Duration `json:"span"              yaml:"span"`
+}
+
+func addressLookupName(name, service string) string {
+	if service == "" {
+		return name
+	}
+	return name + ":" + service
+}
+
+func addressInfoHints(hints wasi.AddressInfo) string {
+	s := []string{
+		hints.Family.String(),
+		hints.SocketType.String(),
+		hints.Protocol.String(),
+	}
+	if hints.Flags != 0 {
+		s = append(s, hints.Flags.String())
+	}
+	return strings.Join(s, " ")
+}
+
+func addressInfoResults(results []wasi.AddressInfo) []string {
+	s := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Address != nil && !slices.Contains(s, r.Address.String()) {
+			s = append(s, r.Address.String())
+		}
+	}
+	return s
+}
+
+const (
+	// DNSLookupAddressInfo is the source of lookups made with the
+	// sock_getaddrinfo system call.
+	DNSLookupAddressInfo = "getaddrinfo"
+	// DNSLookupPacket is the source of lookups made by sending DNS queries
+	// over UDP.
+	DNSLookupPacket = "dns"
+)
+
+// DNSLookup values represent name resolutions made by the guest, either by
+// calling sock_getaddrinfo or by exchanging raw DNS packets with a server.
+type DNSLookup struct {
+	// Time at which the lookup started, and the time it took to complete.
+	Time time.Time
+	Span time.Duration
+	// Either DNSLookupAddressInfo or DNSLookupPacket.
+	Source string
+	// Address of the DNS server, only set for lookups made with DNS packets.
+	Server net.Addr
+	// The name being resolved. The service is only set for lookups made
+	// with sock_getaddrinfo, and the type only for DNS packets.
+	Name    string
+	Service string
+	Type    string
+	// The hints passed to sock_getaddrinfo, and the results of the lookup.
+	Hints   string
+	Results []string
+	// The error number returned by sock_getaddrinfo, or the DNS response code
+	// when it was not successful.
+	Errno wasi.Errno
+	Err   error
+}
+
+func (l DNSLookup) Format(w fmt.State, v rune) {
+	fmt.Fprintf(w, "%s %s", formatTime(l.Time), l.Source)
+	if l.Server != nil {
+		fmt.Fprintf(w, " %s", socketAddressString(l.Server))
+	}
+
+	if w.Flag('+') {
+		fmt.Fprintf(w, " (%s)\n", l.Span)
+		fmt.Fprintf(textprint.NewPrefixWriter(w, http1RequestFormatPrefix), "%s\n", l.query())
+		res := textprint.NewPrefixWriter(w, http1ResponseFormatPrefix)
+		if l.Err != nil {
+			fmt.Fprintf(res, "%s\n", l.errorName())
+		}
+		for _, r := range l.Results {
+			fmt.Fprintf(res, "%s\n", r)
+		}
+	} else {
+		fmt.Fprintf(w, ": %s => %s (%s)", l.query(), l.outcome(), l.Span)
+	}
+}
+
+func (l *DNSLookup) query() string {
+	name := addressLookupName(l.Name, l.Service)
+	if l.Type != "" {
+		name += " " + l.Type
+	}
+	if l.Hints != "" {
+		name += " (" + l.Hints + ")"
+	}
+	return name
+}
+
+func (l *DNSLookup) outcome() string {
+	if l.Err != nil {
+		return l.errorName()
+	}
+	if len(l.Results) == 0 {
+		return "(empty)"
+	}
+	return strings.Join(l.Results, ", ")
+}
+
+func (l *DNSLookup) errorName() string {
+	if l.Errno != wasi.ESUCCESS {
+		return l.Errno.Name()
+	}
+	return l.Err.Error()
+}
+
+func (l DNSLookup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.marshal())
+}
+
+func (l DNSLookup) MarshalYAML() (any, error) {
+	return l.marshal(), nil
+}
+
+func (l *DNSLookup) marshal() *dnsLookup {
+	m := &dnsLookup{
+		Time:    l.Time,
+		Span:    l.Span,
+		Source:  l.Source,
+		Server:  l.Server,
+		Name:    l.Name,
+		Service: l.Service,
+		Type:    l.Type,
+		Hints:   l.Hints,
+		Results: l.Results,
+		Err:     errorString(l.Err),
+	}
+	if l.Source == DNSLookupAddressInfo {
+		m.Errno = errnoName(l.Errno)
+	}
+	return m
+}
+
+type dnsLookup struct {
+	Time    time.Time     `json:"time"              yaml:"time"`
+	Span    time.Duration `json:"span"              yaml:"span"`
+	Source  string        `json:"source"            yaml:"source"`
+	Server  net.Addr      `json:"server,omitempty"  yaml:"server,omitempty"`
+	Name    string        `json:"name"              yaml:"name"`
+	Service string        `json:"service,omitempty" yaml:"service,omitempty"`
+	Type    string        `json:"type,omitempty"    yaml:"type,omitempty"`
+	Hints   string        `json:"hints,omitempty"   yaml:"hints,omitempty"`
+	Results []string      `json:"results"           yaml:"results"`
+	Errno   string        `json:"errno,omitempty"   yaml:"errno,omitempty"`
+	Err     string        `json:"error,omitempty"   yaml:"error,omitempty"`
+}
+
+var (
+	_ fmt.Formatter  = DNSLookup{}
+	_ json.Marshaler = DNSLookup{}
+	_ yaml.Marshaler = DNSLookup{}
+)
+
+// dnsPort is the port that DNS servers listen on.
+const dnsPort = "53"
+
+// dnsError is the error reported for DNS responses carrying a response code
+// other than success.
+type dnsError dnsmessage.RCode
+
+func (e dnsError) Error() string {
+	switch dnsmessage.RCode(e) {
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	default:
+		return fmt.Sprintf("RCODE%d", e)
+	}
+}
+
+type dnsQueryKey struct {
+	fd wasi.FD
+	id uint16
+}
+
+// DNSLookupReader is a reader of DNSLookup values. Instances of
+// DNSLookupReader consume events from a reader of Event values and produce
+// a lookup for each call to sock_getaddrinfo, and for each DNS query sent
+// over UDP to port 53. Queries which never received a response are reported
+// with io.ErrUnexpectedEOF when the end of the event stream is reached.
+type DNSLookupReader struct {
+	Events stream.Reader[Event]
+
+	events  []Event
+	lookups []DNSLookup
+	offset  int
+	pending map[dnsQueryKey]*DNSLookup
+	parser  dnsmessage.Parser
+	done    bool
+}
+
+func (r *DNSLookupReader) Read(lookups []DNSLookup) (n int, err error) {
+	if r.pending == nil {
+		r.pending = make(map[dnsQueryKey]*DNSLookup)
+	}
+	if len(r.events) == 0 {
+		r.events = make([]Event, 1000)
+	}
+
+	for {
+		if r.offset < len(r.lookups) {
+			n = copy(lookups, r.lookups[r.offset:])
+			if r.offset += n; r.offset == len(r.lookups) {
+				r.offset, r.lookups = 0, r.lookups[:0]
+			}
+			return n, nil
+		}
+
+		if r.done {
+			return 0, io.EOF
+		}
+
+		numEvents, err := stream.ReadFull(r.Events, r.events)
+		if numEvents == 0 {
+			switch err {
+			case nil:
+				return 0, io.ErrNoProgress
+			case io.EOF, io.ErrUnexpectedEOF:
+				r.done = true
+				for _, l := range r.pending {
+					l.Err = io.ErrUnexpectedEOF
+					r.lookups = append(r.lookups, *l)
+				}
+				clear(r.pending)
+				r.sort()
+				continue
+			default:
+				return 0, err
+			}
+		}
+
+		for i := range r.events[:numEvents] {
+			e := &r.events[i]
+
+			switch e.Type.Type() {
+			case Lookup:
+				if e.Lookup != nil {
+					r.lookups = append(r.lookups, r.addressInfo(e))
+				}
+			case Send:
+				if e.Proto == UDP && isDNSServer(e.Peer) {
+					r.query(e)
+				}
+			case Receive:
+				if e.Proto == UDP && isDNSServer(e.Peer) {
+					r.response(e)
+				}
+			}
+		}
+
+		r.sort()
+	}
+}
+
+func (r *DNSLookupReader) sort() {
+	slices.SortStableFunc(r.lookups, func(a, b DNSLookup) int {
+		return a.Time.Compare(b.Time)
+	})
+}
+
+func (r *DNSLookupReader) addressInfo(e *Event) DNSLookup {
+	l := DNSLookup{
+		Time:    e.Time.Add(-e.Lookup.Span),
+		Span:    e.Lookup.Span,
+		Source:  DNSLookupAddressInfo,
+		Name:    e.Lookup.Name,
+		Service: e.Lookup.Service,
+		Hints:   addressInfoHints(e.Lookup.Hints),
+		Results: addressInfoResults(e.Lookup.Results),
+		Errno:   e.Error,
+	}
+	if e.Error != wasi.ESUCCESS {
+		l.Err = e.Error
+	}
+	return l
+}
+
+func (r *DNSLookupReader) query(e *Event) {
+	h, err := r.parser.Start(dnsEventData(e))
+	if err != nil || h.Response {
+		return
+	}
+	q, err := r.parser.Question()
+	if err != nil {
+		return
+	}
+	r.pending[dnsQueryKey{e.FD, h.ID}] = &DNSLookup{
+		Time:   e.Time,
+		Source: DNSLookupPacket,
+		Server: e.Peer,
+		Name:   q.Name.String(),
+		Type:   dnsTypeString(q.Type),
+	}
+}
+
+func (r *DNSLookupReader) response(e *Event) {
+	h, err := r.parser.Start(dnsEventData(e))
+	if err != nil || !h.Response {
+		return
+	}
+	k := dnsQueryKey{e.FD, h.ID}
+	l := r.pending[k]
+	if l == nil {
+		return
+	}
+	delete(r.pending, k)
+	l.Span = e.Time.Sub(l.Time)
+
+	if h.RCode != dnsmessage.RCodeSuccess {
+		l.Err = dnsError(h.RCode)
+	} else if err := r.parser.SkipAllQuestions(); err != nil {
+		l.Err = err
+	} else {
+		answers, err := r.parser.AllAnswers()
+		if err != nil {
+			l.Err = err
+		}
+		for _, a := range answers {
+			l.Results = append(l.Results, dnsResourceString(a))
+		}
+	}
+
+	r.lookups = append(r.lookups, *l)
+}
+
+func dnsEventData(e *Event) []byte {
+	if len(e.Data) == 1 {
+		return e.Data[0]
+	}
+	data := make([]byte, 0, iovecSize(e.Data))
+	for _, iov := range e.Data {
+		data = append(data, iov...)
+	}
+	return data
+}
+
+func isDNSServer(addr net.Addr) bool {
+	if addr == nil {
+		return false
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	return err == nil && port == dnsPort
+}
+
+func dnsTypeString(t dnsmessage.Type) string {
+	return strings.TrimPrefix(t.String(), "Type")
+}
+
+func dnsResourceString(r dnsmessage.Resource) string {
+	typ := dnsTypeString(r.Header.Type)
+	switch b := r.Body.(type) {
+	case *dnsmessage.AResource:
+		return typ + " " + net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return typ + " " + net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return typ + " " + b.CNAME.String()
+	case *dnsmessage.NSResource:
+		return typ + " " + b.NS.String()
+	case *dnsmessage.PTRResource:
+		return typ + " " + b.PTR.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%s %d %s", typ, b.Pref, b.MX)
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%s %d %d %d %s", typ, b.Priority, b.Weight, b.Port, b.Target)
+	case *dnsmessage.TXTResource:
+		return fmt.Sprintf("%s %q", typ, b.TXT)
+	default:
+		return typ
+	}
+}
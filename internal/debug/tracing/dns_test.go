@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func dnsQueryBytes(t *testing.T, id uint16, name string) []byte {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	assert.OK(t, b.StartQuestions())
+	assert.OK(t, b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  dnsmessage.TypeA,
+		Class: dnsmessage.ClassINET,
+	}))
+	m, err := b.Finish()
+	assert.OK(t, err)
+	return m
+}
+
+func dnsResponseBytes(t *testing.T, id uint16, name string, rcode dnsmessage.RCode, addrs ...[4]byte) []byte {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true, RCode: rcode})
+	assert.OK(t, b.StartQuestions())
+	assert.OK(t, b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  dnsmessage.TypeA,
+		Class: dnsmessage.ClassINET,
+	}))
+	assert.OK(t, b.StartAnswers())
+	for _, addr := range addrs {
+		assert.OK(t, b.AResource(dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(name),
+			Class: dnsmessage.ClassINET,
+			TTL:   60,
+		}, dnsmessage.AResource{A: addr}))
+	}
+	m, err := b.Finish()
+	assert.OK(t, err)
+	return m
+}
+
+func TestDNSLookups(t *testing.T) {
+	var codec wasicall.Codec
+	var records []timemachine.Record
+	now := time.Now()
+
+	record := func(id wasicall.SyscallID, call []byte) {
+		now = now.Add(time.Millisecond)
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			Time:         now,
+			FunctionID:   int(id),
+			FunctionCall: call,
+		})
+	}
+
+	server := &wasi.Inet4Address{Addr: [4]byte{10, 0, 0, 1}, Port: 53}
+	hints := wasi.AddressInfo{
+		Family:     wasi.InetFamily,
+		SocketType: wasi.StreamSocket,
+	}
+
+	// Lookups made with sock_getaddrinfo, the first one succeeds and the
+	// second one fails.
+	record(wasicall.SockAddressInfo, codec.EncodeSockAddressInfo(nil, "example.com", "http", hints,
+		[]wasi.AddressInfo{{
+			Family:     wasi.InetFamily,
+			SocketType: wasi.StreamSocket,
+			Protocol:   wasi.TCPProtocol,
+			Address:    &wasi.Inet4Address{Addr: [4]byte{93, 184, 216, 34}, Port: 80},
+		}},
+		wasi.ESUCCESS))
+	record(wasicall.SockAddressInfo, codec.EncodeSockAddressInfo(nil, "missing.example.com", "", hints, nil, wasi.ENOENT))
+
+	// Lookups made by sending DNS packets over UDP.
+	query1 := dnsQueryBytes(t, 1, "example.com.")
+	query2 := dnsQueryBytes(t, 2, "nxdomain.example.com.")
+	query3 := dnsQueryBytes(t, 3, "timeout.example.com.")
+	response1 := dnsResponseBytes(t, 1, "example.com.", dnsmessage.RCodeSuccess, [4]byte{93, 184, 216, 34})
+	response2 := dnsResponseBytes(t, 2, "nxdomain.example.com.", dnsmessage.RCodeNameError)
+
+	record(wasicall.SockOpen, codec.EncodeSockOpen(nil, wasi.InetFamily, wasi.DatagramSocket, wasi.UDPProtocol, 0, 0, 4, wasi.ESUCCESS))
+	record(wasicall.SockSendTo, codec.EncodeSockSendTo(nil, 4, []wasi.IOVec{query1}, 0, server, wasi.Size(len(query1)), wasi.ESUCCESS))
+	record(wasicall.SockSendTo, codec.EncodeSockSendTo(nil, 4, []wasi.IOVec{query2}, 0, server, wasi.Size(len(query2)), wasi.ESUCCESS))
+	record(wasicall.SockRecvFrom, codec.EncodeSockRecvFrom(nil, 4, []wasi.IOVec{response2}, 0, wasi.Size(len(response2)), 0, server, wasi.ESUCCESS))
+	record(wasicall.SockRecvFrom, codec.EncodeSockRecvFrom(nil, 4, []wasi.IOVec{response1}, 0, wasi.Size(len(response1)), 0, server, wasi.ESUCCESS))
+	record(wasicall.SockSendTo, codec.EncodeSockSendTo(nil, 4, []wasi.IOVec{query3}, 0, server, wasi.Size(len(query3)), wasi.ESUCCESS))
+
+	lookups, err := stream.ReadAll[DNSLookup](&DNSLookupReader{
+		Events: &EventReader{
+			Records: stream.NewReader(records...),
+		},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(lookups), 5)
+
+	format := func(l DNSLookup) string {
+		// Strip the date and time at the beginning of the line.
+		return strings.SplitN(fmt.Sprint(l), " ", 3)[2]
+	}
+	assert.Equal(t, format(lookups[0]),
+		"getaddrinfo: example.com:http (InetFamily StreamSocket IPProtocol) => 93.184.216.34:80 (0s)")
+	assert.Equal(t, format(lookups[1]),
+		"getaddrinfo: missing.example.com (InetFamily StreamSocket IPProtocol) => ENOENT (1ms)")
+	assert.Equal(t, format(lookups[2]),
+		"dns 10.0.0.1:53: example.com. A => A 93.184.216.34 (3ms)")
+	assert.Equal(t, format(lookups[3]),
+		"dns 10.0.0.1:53: nxdomain.example.com. A => NXDOMAIN (1ms)")
+
+	assert.Equal(t, lookups[1].Errno, wasi.ENOENT)
+	assert.Error(t, lookups[1].Err, wasi.ENOENT)
+	assert.Equal(t, lookups[2].Time, records[3].Time)
+	assert.Equal(t, lookups[2].Span, 3*time.Millisecond)
+
+	assert.Equal(t, lookups[4].Name, "timeout.example.com.")
+	assert.Error(t, lookups[4].Err, io.ErrUnexpectedEOF)
+}
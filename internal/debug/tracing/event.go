@@ -76,6 +76,7 @@ const (
 	Receive
 	Send
 	Shutdown
+	Lookup
 	// extra flags associated with the Shut event type
 	ShutRD = 1 << 6
 	ShutWR = 1 << 7
@@ -111,6 +112,8 @@ func (t EventType) String() string {
 		default:
 			return "SHUT"
 		}
+	case Lookup:
+		return "LOOKUP"
 	default:
 		return "NOP"
 	}
@@ -136,6 +139,8 @@ func (t *EventType) UnmarshalText(b []byte) error {
 		*t = Shutdown | ShutRD
 	case "SHUT (w)":
 		*t = Shutdown | ShutWR
+	case "LOOKUP":
+		*t = Lookup
 	case "NOP", "":
 		*t = 0
 	default:
@@ -160,6 +165,9 @@ type Event struct {
 	Addr   net.Addr   `json:"addr,omitempty"  yaml:"addr,omitempty"`
 	Peer   net.Addr   `json:"peer,omitempty"  yaml:"peer,omitempty"`
 	Data   []Bytes    `json:"data,omitempty"  yaml:"data,omitempty"`
+	// Set on events of the Lookup type, which are not associated with a
+	// socket.
+	Lookup *AddressLookup `json:"lookup,omitempty" yaml:"lookup,omitempty"`
 }
 
 func (e Event) clone() Event {
@@ -188,6 +196,16 @@ func (e Event) Format(w fmt.State, _ rune) {
 		fmt.Fprintf(w, "[%d] ", e.Record)
 	}
 
+	if e.Type == Lookup && e.Lookup != nil {
+		fmt.Fprintf(w, "%s %s %s %s %s\n",
+			formatTime(e.Time),
+			e.Proto,
+			e.Type,
+			errnoName(e.Error),
+			e.Lookup)
+		return
+	}
+
 	fmt.Fprintf(w, "%s %s %s > %s: %s %s",
 		formatTime(e.Time),
 		e.Proto,
@@ -275,10 +293,11 @@ var (
 type EventReader struct {
 	Records stream.Reader[timemachine.Record]
 
-	sockets map[wasi.FD]*socket
-	records []timemachine.Record
-	iovecs  []wasi.IOVec
-	codec   wasicall.Codec
+	sockets  map[wasi.FD]*socket
+	records  []timemachine.Record
+	iovecs   []wasi.IOVec
+	codec    wasicall.Codec
+	lastTime time.Time
 }
 
 type socket struct {
@@ -300,9 +319,12 @@ func (r *EventReader) Read(events []Event) (n int, err error) {
 	}
 
 	for {
-		rn, err := stream.ReadFull(r.Records, r.records)
+		rn, err := r.Records.Read(r.records)
 
 		for _, record := range r.records[:rn] {
+			lastTime := r.lastTime
+			r.lastTime = record.Time
+
 			switch wasicall.SyscallID(record.FunctionID) {
 			case wasicall.FDClose:
 				fd, errno, err := r.codec.DecodeFDClose(record.FunctionCall)
@@ -340,6 +362,13 @@ func (r *EventReader) Read(events []Event) (n int, err error) {
 				r.sockets[to] = socket
 
 			case wasicall.FDRead:
+				// The data of elided records is not in the log, it would be
+				// decoded as zeros if it was not skipped.
+				if _, elided, err := r.codec.DecodeElision(record.FunctionCall); err != nil {
+					return n, err
+				} else if elided {
+					continue
+				}
 				fd, iovecs, size, errno, err := r.codec.DecodeFDRead(record.FunctionCall, r.iovecs[:0])
 				if err != nil {
 					return n, err
@@ -360,6 +389,13 @@ func (r *EventReader) Read(events []Event) (n int, err error) {
 				n++
 
 			case wasicall.FDWrite:
+				// The data of elided records is not in the log, it would be
+				// decoded as zeros if it was not skipped.
+				if _, elided, err := r.codec.DecodeElision(record.FunctionCall); err != nil {
+					return n, err
+				} else if elided {
+					continue
+				}
 				fd, iovecs, size, errno, err := r.codec.DecodeFDWrite(record.FunctionCall, r.iovecs[:0])
 				if err != nil {
 					return n, err
@@ -568,6 +604,41 @@ func (r *EventReader) Read(events []Event) (n int, err error) {
 				events[n].write(iovecs, size)
 				events[n].Peer = addr
 				n++
+
+			case wasicall.SockAddressInfo:
+				name, service, hints, results, errno, err := r.codec.DecodeSockAddressInfo(record.FunctionCall, nil)
+				if err != nil {
+					return n, err
+				}
+				lookup := &AddressLookup{
+					Name:    name,
+					Service: service,
+					Hints:   hints,
+					Results: results,
+				}
+				// The records are written after the system calls return, the
+				// time elapsed since the previous system call is an upper
+				// bound of the time spent resolving the name.
+				if !lastTime.IsZero() {
+					lookup.Span = record.Time.Sub(lastTime)
+				}
+				proto := IP
+				switch {
+				case hints.Protocol == wasi.TCPProtocol || hints.SocketType == wasi.StreamSocket:
+					proto = TCP
+				case hints.Protocol == wasi.UDPProtocol || hints.SocketType == wasi.DatagramSocket:
+					proto = UDP
+				}
+				events[n] = Event{
+					Record: record.Offset,
+					Time:   record.Time,
+					Type:   Lookup,
+					Proto:  proto,
+					Error:  errno,
+					Data:   events[n].Data[:0],
+					Lookup: lookup,
+				}
+				n++
 			}
 		}
 
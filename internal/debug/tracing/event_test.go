@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+)
+
+func TestEventReaderElided(t *testing.T) {
+	var codec wasicall.Codec
+	var records []timemachine.Record
+	now := time.Now()
+
+	record := func(id wasicall.SyscallID, call []byte) {
+		now = now.Add(time.Millisecond)
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			Time:         now,
+			FunctionID:   int(id),
+			FunctionCall: call,
+		})
+	}
+
+	addr := &wasi.Inet4Address{Addr: [4]byte{10, 0, 0, 2}, Port: 49152}
+	peer := &wasi.Inet4Address{Addr: [4]byte{10, 0, 0, 1}, Port: 80}
+	hello := []byte("hello")
+	secret := []byte("secret")
+
+	record(wasicall.SockOpen, codec.EncodeSockOpen(nil, wasi.InetFamily, wasi.StreamSocket, wasi.TCPProtocol, 0, 0, 4, wasi.ESUCCESS))
+	record(wasicall.SockConnect, codec.EncodeSockConnect(nil, 4, peer, addr, wasi.ESUCCESS))
+	record(wasicall.FDWrite, codec.EncodeFDWriteElided(nil, 4, []wasi.IOVec{secret}, wasi.Size(len(secret)), wasi.ESUCCESS, "", 0))
+	record(wasicall.FDRead, codec.EncodeFDReadElided(nil, 4, []wasi.IOVec{secret}, wasi.Size(len(secret)), wasi.ESUCCESS, "", 0))
+	record(wasicall.FDWrite, codec.EncodeFDWrite(nil, 4, []wasi.IOVec{hello}, wasi.Size(len(hello)), wasi.ESUCCESS))
+
+	// The data of elided records is not in the log, the records are skipped
+	// instead of producing events with zeroed data.
+	events, err := stream.ReadAll[Event](&EventReader{
+		Records: stream.NewReader(records...),
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Type, Connect)
+	assert.Equal(t, events[1].Type, Send)
+	assert.Equal(t, events[1].Record, records[4].Offset)
+	assert.Equal(t, string(events[1].Data[0]), "hello")
+}
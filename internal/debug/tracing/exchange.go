@@ -71,6 +71,10 @@ func (e Exchange) Format(w fmt.State, v rune) {
 
 		fmt.Fprintf(w, " => ")
 		e.Res.Format(w, v)
+
+		if e.Res.Err == nil {
+			fmt.Fprintf(w, " (%s)", e.Res.Time+e.Res.Span)
+		}
 	}
 }
 
@@ -155,7 +159,7 @@ func (r *ExchangeReader) Read(exchanges []Exchange) (n int, err error) {
 			return n, nil
 		}
 
-		numMessages, err := stream.ReadFull(r.Messages, r.messages)
+		numMessages, err := r.Messages.Read(r.messages)
 		if numMessages == 0 {
 			if len(r.inflight) > 0 {
 				// The stream was interrupted before receiving a response for
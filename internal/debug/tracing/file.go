@@ -0,0 +1,365 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+	"gopkg.in/yaml.v3"
+)
+
+// FileOperation is an enumeration of the operations reported by the
+// filesystem tracing layer.
+type FileOperation uint8
+
+const (
+	// The file was opened, FileAccess values of this type represent the
+	// lifetime of the file descriptor until it was closed.
+	FileOpen FileOperation = iota
+	FileRename
+	FileUnlink
+)
+
+func (op FileOperation) String() string {
+	switch op {
+	case FileOpen:
+		return "OPEN"
+	case FileRename:
+		return "RENAME"
+	case FileUnlink:
+		return "UNLINK"
+	default:
+		return "NOP"
+	}
+}
+
+func (op FileOperation) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// FileAccess values represent the activity of a guest on its file system.
+type FileAccess struct {
+	// Time at which the operation started. For files being opened, the span
+	// is the time the file descriptor remained open.
+	Time time.Time
+	Span time.Duration
+	Op   FileOperation
+	// The path of the file, and the path that it was renamed to for FileRename
+	// operations. Paths are resolved relative to the directories opened by the
+	// guest; paths relative to preopened directories are reported unchanged
+	// since the names of preopens are not part of the recordings.
+	Path    string
+	NewPath string
+	FD      wasi.FD
+	// Number of read and write operations, and the amount of data transferred
+	// by those operations.
+	Reads        int64
+	Writes       int64
+	BytesRead    int64
+	BytesWritten int64
+	// Set to true if the file descriptor was still open at the end of the
+	// trace.
+	Open  bool
+	Errno wasi.Errno
+}
+
+func (f FileAccess) Format(w fmt.State, v rune) {
+	fmt.Fprintf(w, "%s %s %s", formatTime(f.Time), f.Op, errnoName(f.Errno))
+
+	switch f.Op {
+	case FileOpen:
+		fmt.Fprintf(w, " %s", f.Path)
+		if f.Errno != wasi.ESUCCESS {
+			break
+		}
+		fmt.Fprintf(w, " (fd=%d, read=%d, written=%d", f.FD, f.BytesRead, f.BytesWritten)
+		if w.Flag('+') {
+			fmt.Fprintf(w, ", reads=%d, writes=%d", f.Reads, f.Writes)
+		}
+		fmt.Fprintf(w, ")")
+		if f.Open {
+			fmt.Fprintf(w, " (still open after %s)", f.Span)
+		} else {
+			fmt.Fprintf(w, " (%s)", f.Span)
+		}
+	case FileRename:
+		fmt.Fprintf(w, " %s => %s", f.Path, f.NewPath)
+	default:
+		fmt.Fprintf(w, " %s", f.Path)
+	}
+}
+
+func (f FileAccess) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.marshal())
+}
+
+func (f FileAccess) MarshalYAML() (any, error) {
+	return f.marshal(), nil
+}
+
+func (f *FileAccess) marshal() *fileAccess {
+	a := &fileAccess{
+		Time:    f.Time,
+		Op:      f.Op,
+		Path:    f.Path,
+		NewPath: f.NewPath,
+		Errno:   errnoName(f.Errno),
+	}
+	if f.Op == FileOpen && f.Errno == wasi.ESUCCESS {
+		a.Span = f.Span
+		a.FD = &f.FD
+		a.Reads = f.Reads
+		a.Writes = f.Writes
+		a.BytesRead = f.BytesRead
+		a.BytesWritten = f.BytesWritten
+		a.Open = f.Open
+	}
+	return a
+}
+
+type fileAccess struct {
+	Time         time.Time     `json:"time"                   yaml:"time"`
+	Span         time.Duration `json:"span,omitempty"         yaml:"span,omitempty"`
+	Op           FileOperation `json:"op"                     yaml:"op"`
+	Path         string        `json:"path"                   yaml:"path"`
+	NewPath      string        `json:"newPath,omitempty"      yaml:"newPath,omitempty"`
+	FD           *wasi.FD      `json:"fd,omitempty"           yaml:"fd,omitempty"`
+	Reads        int64         `json:"reads,omitempty"        yaml:"reads,omitempty"`
+	Writes       int64         `json:"writes,omitempty"       yaml:"writes,omitempty"`
+	BytesRead    int64         `json:"bytesRead,omitempty"    yaml:"bytesRead,omitempty"`
+	BytesWritten int64         `json:"bytesWritten,omitempty" yaml:"bytesWritten,omitempty"`
+	Open         bool          `json:"open,omitempty"         yaml:"open,omitempty"`
+	Errno        string        `json:"errno"                  yaml:"errno"`
+}
+
+var (
+	_ fmt.Formatter  = FileAccess{}
+	_ json.Marshaler = FileAccess{}
+	_ yaml.Marshaler = FileAccess{}
+)
+
+// FileAccessReader is a reader of FileAccess values. Instances of
+// FileAccessReader consume records of a time machine log and reconstruct the
+// file-level activity of the guest from the PathOpen, FDRead, FDWrite,
+// FDClose, PathRename and PathUnlinkFile system calls (as well as their
+// FDPread, FDPwrite and FDRenumber variants).
+//
+// Files are reported when their file descriptor is closed, or at the end of
+// the trace for files that were still open. Failed operations are reported
+// immediately.
+type FileAccessReader struct {
+	Records stream.Reader[timemachine.Record]
+
+	files    map[wasi.FD]*FileAccess
+	records  []timemachine.Record
+	iovecs   []wasi.IOVec
+	accesses []FileAccess
+	offset   int
+	lastTime time.Time
+	codec    wasicall.Codec
+	done     bool
+}
+
+func (r *FileAccessReader) Read(accesses []FileAccess) (n int, err error) {
+	if r.files == nil {
+		r.files = make(map[wasi.FD]*FileAccess)
+	}
+	if len(r.records) == 0 {
+		r.records = make([]timemachine.Record, 1000)
+	}
+
+	for {
+		if r.offset < len(r.accesses) {
+			n = copy(accesses, r.accesses[r.offset:])
+			if r.offset += n; r.offset == len(r.accesses) {
+				r.offset, r.accesses = 0, r.accesses[:0]
+			}
+			return n, nil
+		}
+
+		if r.done {
+			return 0, io.EOF
+		}
+
+		// Records are only valid until the next call to Read, which may
+		// reuse their buffers, so they are decoded after each call.
+		numRecords, err := r.Records.Read(r.records)
+
+		for _, record := range r.records[:numRecords] {
+			if err := r.readRecord(&record); err != nil {
+				return 0, err
+			}
+			r.lastTime = record.Time
+		}
+		if numRecords > 0 {
+			// The accesses of the records that were read are returned
+			// first, the error is reported again by the next call to Read.
+			r.sort()
+			continue
+		}
+
+		switch err {
+		case nil:
+			return 0, io.ErrNoProgress
+		case io.EOF, io.ErrUnexpectedEOF:
+			r.done = true
+			for _, f := range r.files {
+				f.Span = r.lastTime.Sub(f.Time)
+				f.Open = true
+				r.accesses = append(r.accesses, *f)
+			}
+			clear(r.files)
+		default:
+			return 0, err
+		}
+
+		r.sort()
+	}
+}
+
+func (r *FileAccessReader) sort() {
+	slices.SortStableFunc(r.accesses, func(a, b FileAccess) int {
+		return a.Time.Compare(b.Time)
+	})
+}
+
+func (r *FileAccessReader) readRecord(record *timemachine.Record) error {
+	switch wasicall.SyscallID(record.FunctionID) {
+	case wasicall.PathOpen:
+		fd, _, path, _, _, _, _, newfd, errno, err := r.codec.DecodePathOpen(record.FunctionCall)
+		if err != nil {
+			return err
+		}
+		f := &FileAccess{
+			Time:  record.Time,
+			Op:    FileOpen,
+			Path:  r.resolve(fd, path),
+			Errno: errno,
+		}
+		if errno != wasi.ESUCCESS {
+			r.accesses = append(r.accesses, *f)
+		} else {
+			f.FD = newfd
+			r.files[newfd] = f
+		}
+
+	case wasicall.FDClose:
+		fd, _, err := r.codec.DecodeFDClose(record.FunctionCall)
+		if err != nil {
+			return err
+		}
+		if f, ok := r.files[fd]; ok {
+			delete(r.files, fd)
+			f.Span = record.Time.Sub(f.Time)
+			r.accesses = append(r.accesses, *f)
+		}
+
+	case wasicall.FDRenumber:
+		from, to, errno, err := r.codec.DecodeFDRenumber(record.FunctionCall)
+		if err != nil {
+			return err
+		}
+		if f, ok := r.files[from]; ok && errno == wasi.ESUCCESS {
+			if g, ok := r.files[to]; ok {
+				g.Span = record.Time.Sub(g.Time)
+				r.accesses = append(r.accesses, *g)
+			}
+			delete(r.files, from)
+			f.FD = to
+			r.files[to] = f
+		}
+
+	case wasicall.FDRead:
+		fd, iovecs, size, errno, err := r.codec.DecodeFDRead(record.FunctionCall, r.iovecs[:0])
+		if err != nil {
+			return err
+		}
+		r.iovecs = iovecs
+		r.read(fd, size, errno)
+
+	case wasicall.FDPread:
+		fd, iovecs, _, size, errno, err := r.codec.DecodeFDPread(record.FunctionCall, r.iovecs[:0])
+		if err != nil {
+			return err
+		}
+		r.iovecs = iovecs
+		r.read(fd, size, errno)
+
+	case wasicall.FDWrite:
+		fd, iovecs, size, errno, err := r.codec.DecodeFDWrite(record.FunctionCall, r.iovecs[:0])
+		if err != nil {
+			return err
+		}
+		r.iovecs = iovecs
+		r.write(fd, size, errno)
+
+	case wasicall.FDPwrite:
+		fd, iovecs, _, size, errno, err := r.codec.DecodeFDPwrite(record.FunctionCall, r.iovecs[:0])
+		if err != nil {
+			return err
+		}
+		r.iovecs = iovecs
+		r.write(fd, size, errno)
+
+	case wasicall.PathRename:
+		fd, oldPath, newFD, newPath, errno, err := r.codec.DecodePathRename(record.FunctionCall)
+		if err != nil {
+			return err
+		}
+		r.accesses = append(r.accesses, FileAccess{
+			Time:    record.Time,
+			Op:      FileRename,
+			Path:    r.resolve(fd, oldPath),
+			NewPath: r.resolve(newFD, newPath),
+			Errno:   errno,
+		})
+
+	case wasicall.PathUnlinkFile:
+		fd, path, errno, err := r.codec.DecodePathUnlinkFile(record.FunctionCall)
+		if err != nil {
+			return err
+		}
+		r.accesses = append(r.accesses, FileAccess{
+			Time:  record.Time,
+			Op:    FileUnlink,
+			Path:  r.resolve(fd, path),
+			Errno: errno,
+		})
+	}
+	return nil
+}
+
+func (r *FileAccessReader) read(fd wasi.FD, size wasi.Size, errno wasi.Errno) {
+	if f, ok := r.files[fd]; ok && errno == wasi.ESUCCESS {
+		f.Reads++
+		f.BytesRead += int64(size)
+	}
+}
+
+func (r *FileAccessReader) write(fd wasi.FD, size wasi.Size, errno wasi.Errno) {
+	if f, ok := r.files[fd]; ok && errno == wasi.ESUCCESS {
+		f.Writes++
+		f.BytesWritten += int64(size)
+	}
+}
+
+// resolve returns the path of name relative to the directory opened as dirfd.
+// The name is decoded from a record and may reference its memory, so the path
+// returned is always a copy.
+func (r *FileAccessReader) resolve(dirfd wasi.FD, name string) string {
+	if path.IsAbs(name) {
+		return strings.Clone(path.Clean(name))
+	}
+	if dir, ok := r.files[dirfd]; ok {
+		return path.Join(dir.Path, name)
+	}
+	return strings.Clone(path.Clean(name))
+}
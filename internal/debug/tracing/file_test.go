@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
+)
+
+func fileAccessRecords() []timemachine.Record {
+	var codec wasicall.Codec
+	var records []timemachine.Record
+	now := time.Now()
+
+	record := func(id wasicall.SyscallID, call []byte) {
+		now = now.Add(time.Millisecond)
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			Time:         now,
+			FunctionID:   int(id),
+			FunctionCall: call,
+		})
+	}
+
+	const preopen = 3
+	data := []wasi.IOVec{[]byte("hello world")}
+
+	// A directory is opened relative to a preopen, then a file relative to
+	// that directory.
+	record(wasicall.PathOpen, codec.EncodePathOpen(nil, preopen, 0, "tmp", wasi.OpenDirectory, 0, 0, 0, 4, wasi.ESUCCESS))
+	record(wasicall.PathOpen, codec.EncodePathOpen(nil, 4, 0, "data.txt", wasi.OpenCreate, 0, 0, 0, 5, wasi.ESUCCESS))
+	record(wasicall.FDWrite, codec.EncodeFDWrite(nil, 5, data, 11, wasi.ESUCCESS))
+	record(wasicall.FDWrite, codec.EncodeFDWrite(nil, 5, data, 5, wasi.ESUCCESS))
+	record(wasicall.FDRead, codec.EncodeFDRead(nil, 5, data, 0, wasi.EBADF))
+	record(wasicall.FDClose, codec.EncodeFDClose(nil, 5, wasi.ESUCCESS))
+
+	// Failed lookup, rename, and unlink.
+	record(wasicall.PathOpen, codec.EncodePathOpen(nil, 4, 0, "missing.txt", 0, 0, 0, 0, 0, wasi.ENOENT))
+	record(wasicall.PathRename, codec.EncodePathRename(nil, 4, "data.txt", preopen, "data.old", wasi.ESUCCESS))
+	record(wasicall.PathUnlinkFile, codec.EncodePathUnlinkFile(nil, preopen, "data.old", wasi.ESUCCESS))
+
+	// A file that is never closed.
+	record(wasicall.PathOpen, codec.EncodePathOpen(nil, preopen, 0, "/etc/hosts", 0, 0, 0, 0, 6, wasi.ESUCCESS))
+	record(wasicall.FDRead, codec.EncodeFDRead(nil, 6, data, 11, wasi.ESUCCESS))
+	return records
+}
+
+func TestFileAccesses(t *testing.T) {
+	accesses, err := stream.ReadAll[FileAccess](&FileAccessReader{
+		Records: stream.NewReader(fileAccessRecords()...),
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(accesses), 6)
+
+	format := func(f FileAccess) string {
+		// Strip the date and time at the beginning of the line.
+		return strings.SplitN(fmt.Sprint(f), " ", 3)[2]
+	}
+	// Files which are still open are reported at the end of the trace.
+	assert.Equal(t, format(accesses[0]), "OPEN OK tmp/data.txt (fd=5, read=0, written=16) (4ms)")
+	assert.Equal(t, format(accesses[1]), "OPEN ENOENT tmp/missing.txt")
+	assert.Equal(t, format(accesses[2]), "RENAME OK tmp/data.txt => data.old")
+	assert.Equal(t, format(accesses[3]), "UNLINK OK data.old")
+	assert.Equal(t, format(accesses[4]), "OPEN OK tmp (fd=4, read=0, written=0) (still open after 10ms)")
+	assert.Equal(t, format(accesses[5]), "OPEN OK /etc/hosts (fd=6, read=11, written=0) (still open after 1ms)")
+
+	assert.Equal(t, accesses[0].Writes, int64(2))
+	assert.Equal(t, accesses[0].Reads, int64(0))
+	assert.True(t, accesses[5].Open)
+	assert.Equal(t, fmt.Sprintf("%+v", accesses[5])[27:],
+		"OPEN OK /etc/hosts (fd=6, read=11, written=0, reads=1, writes=0) (still open after 1ms)")
+}
+
+// reusingRecordReader returns one record per call to Read, and overwrites the
+// memory of the records it returned previously, like readers of log segments
+// which reuse their buffers.
+type reusingRecordReader struct {
+	records []timemachine.Record
+	buffer  []byte
+}
+
+func (r *reusingRecordReader) Read(records []timemachine.Record) (int, error) {
+	if len(r.records) == 0 {
+		return 0, io.EOF
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	for i := range r.buffer {
+		r.buffer[i] = 'X'
+	}
+	r.buffer = append(r.buffer[:0], r.records[0].FunctionCall...)
+	records[0] = r.records[0]
+	records[0].FunctionCall = r.buffer
+	r.records = r.records[1:]
+	return 1, nil
+}
+
+func TestFileAccessesReusedRecords(t *testing.T) {
+	records := fileAccessRecords()
+
+	want, err := stream.ReadAll[FileAccess](&FileAccessReader{
+		Records: stream.NewReader(records...),
+	})
+	assert.OK(t, err)
+
+	got, err := stream.ReadAll[FileAccess](&FileAccessReader{
+		Records: &reusingRecordReader{records: records},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(got), len(want))
+
+	for i := range want {
+		assert.Equal(t, fmt.Sprintf("%+v", got[i]), fmt.Sprintf("%+v", want[i]))
+	}
+}
@@ -0,0 +1,536 @@
+package tracing
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	objquery "github.com/stealthrocket/timecraft/internal/object/query"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+// Fields is a flat view of the properties of a trace value that filters are
+// evaluated against. Field names are dot-separated paths such as "http.status"
+// or "net.peer"; all field values are represented as strings.
+//
+// The fields available on each type of value are:
+//
+//	time                    all values
+//	net.proto               Event
+//	net.type                Event
+//	net.fd                  Event
+//	net.size                Event
+//	net.errno               Event
+//	net.addr, net.peer      Event, Message, Exchange
+//	net.src, net.dst        Message, Exchange
+//	dns.name                Event (lookups)
+//	proto                   Message, Exchange
+//	span                    Message, Exchange
+//	error                   Message, Exchange
+//	http.method             Message, Exchange
+//	http.path               Message, Exchange
+//	http.host               Message, Exchange
+//	http.version            Message, Exchange
+//	http.header.<name>      Message, Exchange (request headers)
+//	http.status             Message, Exchange
+//	grpc.status             Message, Exchange
+//	sql.query               Message, Exchange
+//	redis.command           Message, Exchange
+type Fields struct {
+	time   time.Time
+	values map[string]string
+}
+
+func (f *Fields) After(t time.Time) bool { return f.time.After(t) }
+
+func (f *Fields) Before(t time.Time) bool { return f.time.Before(t) }
+
+func (f *Fields) Match(name, value string) bool {
+	v, ok := f.values[name]
+	return ok && v == value
+}
+
+// Lookup returns the value of the field with the given name.
+func (f *Fields) Lookup(name string) (string, bool) {
+	if name == "time" {
+		return f.time.Format(time.RFC3339Nano), true
+	}
+	v, ok := f.values[name]
+	return v, ok
+}
+
+func (f *Fields) set(name, value string) {
+	if value != "" {
+		f.values[name] = value
+	}
+}
+
+func makeFields(t time.Time) *Fields {
+	return &Fields{time: t, values: make(map[string]string)}
+}
+
+// EventFields returns the fields of a network event.
+func EventFields(e *Event) *Fields {
+	f := makeFields(e.Time)
+	f.set("net.proto", e.Proto.String())
+	f.set("net.type", e.Type.String())
+	f.set("net.errno", errnoName(e.Error))
+	if e.Type != Lookup {
+		f.set("net.fd", strconv.Itoa(int(e.FD)))
+		f.set("net.size", strconv.Itoa(int(iovecSize(e.Data))))
+	}
+	if e.Addr != nil {
+		f.set("net.addr", e.Addr.String())
+	}
+	if e.Peer != nil {
+		f.set("net.peer", e.Peer.String())
+	}
+	if e.Lookup != nil {
+		f.set("dns.name", e.Lookup.Name)
+	}
+	return f
+}
+
+// MessageFields returns the fields of a protocol message.
+func MessageFields(m *Message) *Fields {
+	f := makeFields(m.Time)
+	f.set("span", m.Span.String())
+	f.set("error", errorString(m.Err))
+	linkFields(f, m.Link, m.msg)
+	messageFields(f, m.msg)
+	return f
+}
+
+// ExchangeFields returns the fields of an exchange. The time of the exchange
+// is the time of the request, and its span is the time until the response was
+// completely received.
+func ExchangeFields(e *Exchange) *Fields {
+	f := makeFields(e.Req.Time)
+	f.set("span", (e.Res.Time + e.Res.Span).String())
+	if e.Req.Err != nil {
+		f.set("error", e.Req.Err.Error())
+	} else {
+		f.set("error", errorString(e.Res.Err))
+	}
+	linkFields(f, e.Link, e.Req.msg)
+	messageFields(f, e.Req.msg)
+	messageFields(f, e.Res.msg)
+	return f
+}
+
+func linkFields(f *Fields, link Link, msg ConnMessage) {
+	// The source of a link is the peer which initiated the connection, which
+	// is the remote peer when the guest is serving requests.
+	addr, peer := link.Src, link.Dst
+	if msg != nil {
+		if c, ok := msg.Conn().(interface{ server() bool }); ok && c.server() {
+			addr, peer = peer, addr
+		}
+	}
+	for _, a := range [...]struct {
+		name string
+		addr net.Addr
+	}{
+		{"net.src", link.Src},
+		{"net.dst", link.Dst},
+		{"net.addr", addr},
+		{"net.peer", peer},
+	} {
+		if a.addr != nil {
+			f.set(a.name, a.addr.String())
+		}
+	}
+}
+
+func messageFields(f *Fields, msg ConnMessage) {
+	if msg == nil {
+		return
+	}
+	f.set("proto", msg.Conn().Protocol().Name())
+
+	switch m := msg.Marshal().(type) {
+	case *grpcRequest:
+		httpRequestFields(f, &m.httpRequest)
+	case *grpcResponse:
+		httpResponseFields(f, &m.httpResponse)
+		if m.Status != nil {
+			f.set("grpc.status", m.Status.String())
+		}
+	case *httpRequest:
+		httpRequestFields(f, m)
+	case *httpResponse:
+		httpResponseFields(f, m)
+	case *postgresRequestMarshal:
+		f.set("sql.query", strings.Join(m.Statements, "; "))
+	case *redisRequestMarshal:
+		f.set("redis.command", m.Command)
+	}
+
+	// The host of HTTP/2 requests is carried by the :authority pseudo-header,
+	// which is not part of the header of requests.
+	if r, ok := msg.(*http2Request); ok && f.values["http.host"] == "" {
+		f.set("http.host", http2Field(r.half.header, ":authority"))
+	}
+}
+
+func httpRequestFields(f *Fields, req *httpRequest) {
+	f.set("http.method", req.Method)
+	f.set("http.path", req.Path)
+	f.set("http.version", req.Proto)
+	for name, values := range req.Header {
+		if len(values) > 0 {
+			f.set("http.header."+strings.ToLower(name), values[0])
+		}
+	}
+	f.set("http.host", f.values["http.header.host"])
+}
+
+func httpResponseFields(f *Fields, res *httpResponse) {
+	if res.StatusCode != 0 {
+		f.set("http.status", strconv.Itoa(res.StatusCode))
+	}
+	if f.values["http.version"] == "" {
+		f.set("http.version", res.Proto)
+	}
+}
+
+// Filter is the type of filters evaluated against the fields of trace values.
+type Filter = objquery.Filter[*Fields]
+
+// FilterReader is a reader which only produces the values of its underlying
+// reader matching a filter.
+type FilterReader[T any] struct {
+	Reader stream.Reader[T]
+	Filter Filter
+	// Fields is the function used to extract the fields of values, which is
+	// one of EventFields, MessageFields, or ExchangeFields.
+	Fields func(*T) *Fields
+}
+
+func (r *FilterReader[T]) Read(values []T) (int, error) {
+	for {
+		n, err := r.Reader.Read(values)
+		i := 0
+		for j := range values[:n] {
+			if r.Filter.Match(r.Fields(&values[j])) {
+				values[i] = values[j]
+				i++
+			}
+		}
+		if i > 0 || err != nil {
+			return i, err
+		}
+	}
+}
+
+// ParseFilter parses a filter expression.
+//
+// Expressions are made of comparisons between a field and a value, combined
+// with the "and", "or", and "not" operators and grouped with parentheses:
+//
+//	http.status>=500 and http.path~"^/api"
+//	net.peer=10.0.0.5:5432
+//	not (http.method=GET or http.method=HEAD)
+//
+// The comparison operators are =, !=, <, <=, >, >=, ~ (regular expression
+// match), and !~. Values which are numbers or durations are compared as such,
+// other values are compared as strings. Values containing spaces or operators
+// must be quoted. Comparisons with the "time" field accept RFC 3339 values.
+//
+// Comparisons of fields that a value does not have are false, except for the
+// negated operators != and !~.
+func ParseFilter(expr string) (Filter, error) {
+	p := &filterParser{input: expr}
+	p.next()
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.typ != filterEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return f, nil
+}
+
+type filterTokenType int
+
+const (
+	filterEOF filterTokenType = iota
+	filterIdent
+	filterString
+	filterOp
+	filterLParen
+	filterRParen
+	filterInvalid
+)
+
+type filterToken struct {
+	typ filterTokenType
+	val string
+	pos int
+}
+
+func (t filterToken) String() string {
+	switch t.typ {
+	case filterEOF:
+		return "end of expression"
+	case filterString:
+		return strconv.Quote(t.val)
+	default:
+		return fmt.Sprintf("'%s'", t.val)
+	}
+}
+
+type filterParser struct {
+	input string
+	pos   int
+	tok   filterToken
+}
+
+func (p *filterParser) errorf(msg string, args ...any) error {
+	return fmt.Errorf("malformed filter at offset %d: %s", p.tok.pos, fmt.Sprintf(msg, args...))
+}
+
+func (p *filterParser) next() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.input) {
+		p.tok = filterToken{typ: filterEOF, pos: start}
+		return
+	}
+
+	switch c := p.input[p.pos]; c {
+	case '(':
+		p.pos++
+		p.tok = filterToken{typ: filterLParen, val: "(", pos: start}
+	case ')':
+		p.pos++
+		p.tok = filterToken{typ: filterRParen, val: ")", pos: start}
+	case '=', '~':
+		p.pos++
+		p.tok = filterToken{typ: filterOp, val: string(c), pos: start}
+	case '!', '<', '>':
+		p.pos++
+		if p.pos < len(p.input) && (p.input[p.pos] == '=' || (c == '!' && p.input[p.pos] == '~')) {
+			p.pos++
+		}
+		typ := filterOp
+		if p.input[start:p.pos] == "!" {
+			typ = filterInvalid
+		}
+		p.tok = filterToken{typ: typ, val: p.input[start:p.pos], pos: start}
+	case '"':
+		end := p.pos + 1
+		for end < len(p.input) && p.input[end] != '"' {
+			if p.input[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.input) {
+			p.pos = len(p.input)
+			p.tok = filterToken{typ: filterInvalid, val: p.input[start:], pos: start}
+			return
+		}
+		p.pos = end + 1
+		s, err := strconv.Unquote(p.input[start:p.pos])
+		if err != nil {
+			p.tok = filterToken{typ: filterInvalid, val: p.input[start:p.pos], pos: start}
+			return
+		}
+		p.tok = filterToken{typ: filterString, val: s, pos: start}
+	default:
+		for p.pos < len(p.input) && !strings.ContainsRune(" \t()=~!<>\"", rune(p.input[p.pos])) {
+			p.pos++
+		}
+		p.tok = filterToken{typ: filterIdent, val: p.input[start:p.pos], pos: start}
+	}
+}
+
+func (p *filterParser) keyword(name string) bool {
+	return p.tok.typ == filterIdent && strings.EqualFold(p.tok.val, name)
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.next()
+		g, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		f = objquery.Or[*Fields]{f, g}
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	f, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.next()
+		g, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		f = objquery.And[*Fields]{f, g}
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseNot() (Filter, error) {
+	if p.keyword("not") {
+		p.next()
+		f, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return objquery.Not[*Fields]{f}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (Filter, error) {
+	switch p.tok.typ {
+	case filterLParen:
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.typ != filterRParen {
+			return nil, p.errorf("expected ')' but found %s", p.tok)
+		}
+		p.next()
+		return f, nil
+	case filterIdent:
+	default:
+		return nil, p.errorf("expected field name but found %s", p.tok)
+	}
+
+	field := p.tok.val
+	p.next()
+	if p.tok.typ != filterOp {
+		return nil, p.errorf("expected comparison operator after '%s' but found %s", field, p.tok)
+	}
+	op := p.tok.val
+	p.next()
+	if p.tok.typ != filterIdent && p.tok.typ != filterString {
+		return nil, p.errorf("expected value after '%s%s' but found %s", field, op, p.tok)
+	}
+	value := p.tok.val
+	pos := p.tok.pos
+	p.next()
+
+	f, err := makeFilter(field, op, value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed filter at offset %d: %w", pos, err)
+	}
+	return f, nil
+}
+
+func makeFilter(field, op, value string) (Filter, error) {
+	if field == "time" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time value: %q", value)
+		}
+		switch op {
+		case "<":
+			return objquery.Before[*Fields](t), nil
+		case ">":
+			return objquery.After[*Fields](t), nil
+		case "<=":
+			return objquery.Not[*Fields]{objquery.After[*Fields](t)}, nil
+		case ">=":
+			return objquery.Not[*Fields]{objquery.Before[*Fields](t)}, nil
+		default:
+			return nil, fmt.Errorf("operator '%s' cannot be used with the time field", op)
+		}
+	}
+
+	switch op {
+	case "=":
+		return objquery.Match[*Fields]{field, value}, nil
+	case "!=":
+		return objquery.Not[*Fields]{objquery.Match[*Fields]{field, value}}, nil
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		var f Filter = matchRegexp{field, re}
+		if op == "!~" {
+			f = objquery.Not[*Fields]{f}
+		}
+		return f, nil
+	default:
+		return compareField{field, op, value}, nil
+	}
+}
+
+type matchRegexp struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (m matchRegexp) Match(f *Fields) bool {
+	v, ok := f.Lookup(m.field)
+	return ok && m.re.MatchString(v)
+}
+
+type compareField struct {
+	field string
+	op    string
+	value string
+}
+
+func (c compareField) Match(f *Fields) bool {
+	v, ok := f.Lookup(c.field)
+	if !ok {
+		return false
+	}
+	n := compareValues(v, c.value)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	default: // ">="
+		return n >= 0
+	}
+}
+
+func compareValues(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, err := time.ParseDuration(a); err == nil {
+		if y, err := time.ParseDuration(b); err == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+var (
+	_ objquery.Value           = (*Fields)(nil)
+	_ stream.Reader[Event]     = (*FilterReader[Event])(nil)
+	_ objquery.Filter[*Fields] = matchRegexp{}
+	_ objquery.Filter[*Fields] = compareField{}
+)
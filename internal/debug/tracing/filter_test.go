@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+func TestParseFilter(t *testing.T) {
+	f := &Fields{
+		time: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		values: map[string]string{
+			"http.method": "GET",
+			"http.path":   "/api/users?id=1",
+			"http.status": "503",
+			"net.peer":    "10.0.0.5:5432",
+			"span":        "1.5ms",
+		},
+	}
+
+	for _, test := range []struct {
+		expr  string
+		match bool
+	}{
+		{`http.status>=500`, true},
+		{`http.status<500`, false},
+		{`http.status>=500 and http.path~"^/api"`, true},
+		{`http.status>=500 and http.path~"^/web"`, false},
+		{`http.path~"^/web" or http.method=GET`, true},
+		{`not (http.method=GET or http.method=HEAD)`, false},
+		{`NOT http.method=POST`, true},
+		{`net.peer=10.0.0.5:5432`, true},
+		{`net.peer!=10.0.0.5:5432`, false},
+		{`http.path!~users`, false},
+		{`span>1ms and span<=1500us`, true},
+		{`time>2023-06-01T11:00:00Z and time<2023-06-01T13:00:00Z`, true},
+		{`time>=2023-06-01T12:00:00Z`, true},
+		{`grpc.status=OK`, false},
+		{`grpc.status!=OK`, true},
+		{`grpc.status>0`, false},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+			filter, err := ParseFilter(test.expr)
+			assert.OK(t, err)
+			assert.Equal(t, filter.Match(f), test.match)
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, test := range []struct {
+		expr string
+		err  string
+	}{
+		{``, `malformed filter at offset 0: expected field name but found end of expression`},
+		{`http.status`, `malformed filter at offset 11: expected comparison operator after 'http.status' but found end of expression`},
+		{`http.status>=`, `malformed filter at offset 13: expected value after 'http.status>=' but found end of expression`},
+		{`(http.method=GET`, `malformed filter at offset 16: expected ')' but found end of expression`},
+		{`http.method=GET http.method=HEAD`, `malformed filter at offset 16: unexpected 'http.method'`},
+		{`http.path~"("`, "malformed filter at offset 10: error parsing regexp: missing closing ): `(`"},
+		{`time~2023`, `malformed filter at offset 5: invalid time value: "2023"`},
+		{`http.path="unterminated`, `malformed filter at offset 10: expected value after 'http.path=' but found '"unterminated'`},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := ParseFilter(test.expr)
+			assert.NotEqual(t, err, nil)
+			assert.Equal(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestFilterExchanges(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 5), Port: 8080}
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []Event{{Time: now, Type: Connect, FD: 3, Addr: addr, Peer: peer}}
+	exchange := func(req, res string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(req)}})
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(res)}})
+	}
+	exchange("GET /api/users HTTP/1.1\r\nHost: svc\r\n\r\n", "HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")
+	exchange("GET /api/orders HTTP/1.1\r\nHost: svc\r\n\r\n", "HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\n\r\n")
+	exchange("GET /health HTTP/1.1\r\nHost: svc\r\n\r\n", "HTTP/1.1 500 Internal Server Error\r\nContent-Length: 0\r\n\r\n")
+
+	filter, err := ParseFilter(`http.status>=500 and http.path~"^/api" and net.peer=10.0.0.5:8080 and http.host=svc`)
+	assert.OK(t, err)
+
+	exchanges, err := stream.ReadAll[Exchange](&FilterReader[Exchange]{
+		Reader: &ExchangeReader{
+			Messages: &MessageReader{
+				Events: stream.NewReader(events...),
+				Protos: []ConnProtocol{HTTP1()},
+			},
+		},
+		Filter: filter,
+		Fields: ExchangeFields,
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(exchanges), 1)
+
+	fields := ExchangeFields(&exchanges[0])
+	path, _ := fields.Lookup("http.path")
+	assert.Equal(t, path, "/api/orders")
+	src, _ := fields.Lookup("net.src")
+	assert.Equal(t, src, "10.0.0.1:49152")
+
+	events, err = stream.ReadAll[Event](&FilterReader[Event]{
+		Reader: stream.NewReader(events...),
+		Filter: must(ParseFilter(`net.type=RECV and net.size>50`)),
+		Fields: EventFields,
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(events), 2)
+}
+
+func must(f Filter, err error) Filter {
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
@@ -0,0 +1,354 @@
+package tracing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+// NewHARWriter returns a writer of Exchange values which produces an HTTP
+// Archive (HAR 1.2) document on w, see http://www.softwareishard.com/blog/har-12-spec/
+//
+// HAR documents are a single JSON object, so the entries are buffered in
+// memory and the document is only written to w when the writer is closed.
+// Exchanges of protocols other than HTTP are ignored.
+func NewHARWriter(w io.Writer, version string) stream.WriteCloser[Exchange] {
+	return &harWriter{
+		output:  w,
+		version: version,
+	}
+}
+
+type harWriter struct {
+	output  io.Writer
+	version string
+	entries []harEntry
+}
+
+func (w *harWriter) Write(exchanges []Exchange) (int, error) {
+	for i := range exchanges {
+		if entry, ok := makeHAREntry(&exchanges[i]); ok {
+			w.entries = append(w.entries, entry)
+		}
+	}
+	return len(exchanges), nil
+}
+
+func (w *harWriter) Close() error {
+	entries := w.entries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	// Exchanges are ordered by completion time, HAR entries are expected to
+	// be ordered by the time requests were sent.
+	slices.SortStableFunc(entries, func(a, b harEntry) int {
+		return a.StartedDateTime.Compare(b.StartedDateTime)
+	})
+	e := json.NewEncoder(w.output)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(&harDocument{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{
+				Name:    "timecraft",
+				Version: w.version,
+			},
+			Entries: entries,
+		},
+	})
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	ServerPort      int         `json:"_serverPort,omitempty"`
+	Connection      string      `json:"connection,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// The HAR specification does not define how to represent binary request
+	// bodies, custom fields must be prefixed with an underscore.
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type harContent struct {
+	Size        int    `json:"size"`
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func makeHAREntry(e *Exchange) (entry harEntry, ok bool) {
+	var req *httpRequest
+	switch r := e.Req.msg.Marshal().(type) {
+	case *httpRequest:
+		req = r
+	case *grpcRequest:
+		req = &r.httpRequest
+	default:
+		return entry, false
+	}
+
+	var res *httpResponse
+	if e.Res.msg != nil {
+		switch r := e.Res.msg.Marshal().(type) {
+		case *httpResponse:
+			res = r
+		case *grpcResponse:
+			res = &r.httpResponse
+		}
+	}
+
+	entry.StartedDateTime = e.Req.Time
+	entry.Request = harRequest{
+		Method:      req.Method,
+		URL:         harURL(e, req),
+		HTTPVersion: req.Proto,
+		Cookies:     harCookies(req.Header["Cookie"], false),
+		Headers:     harHeaders(req.Header),
+		QueryString: harQueryString(req.Path),
+		HeadersSize: -1,
+		BodySize:    len(req.Body),
+	}
+	if len(req.Body) > 0 {
+		text, encoding := harText(req.Body)
+		entry.Request.PostData = &harPostData{
+			MimeType: harHeader(req.Header, "Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		}
+	}
+
+	if res != nil {
+		body := []byte(res.Body)
+		if harHeader(res.Header, "Content-Encoding") == "gzip" {
+			if b, err := gunzip(body); err == nil {
+				body = b
+			}
+		}
+		text, encoding := harText(body)
+		entry.Response = harResponse{
+			Status:      res.StatusCode,
+			StatusText:  res.StatusText,
+			HTTPVersion: res.Proto,
+			Cookies:     harCookies(res.Header["Set-Cookie"], true),
+			Headers:     harHeaders(res.Header),
+			Content: harContent{
+				Size:        len(body),
+				Compression: len(body) - len(res.Body),
+				MimeType:    harHeader(res.Header, "Content-Type"),
+				Text:        text,
+				Encoding:    encoding,
+			},
+			RedirectURL: harHeader(res.Header, "Location"),
+			HeadersSize: -1,
+			BodySize:    len(res.Body),
+		}
+	} else {
+		entry.Response = harResponse{
+			HTTPVersion: req.Proto,
+			Cookies:     []harCookie{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+	}
+
+	if err := e.Res.Err; err != nil {
+		entry.Error = err.Error()
+	} else if err := e.Req.Err; err != nil {
+		entry.Error = err.Error()
+	}
+
+	// The time that the response started being received is relative to the
+	// time at which the request started being sent.
+	send := e.Req.Span
+	wait := max(e.Res.Time-e.Req.Span, 0)
+	receive := e.Res.Span
+	if res == nil {
+		wait, receive = 0, 0
+	}
+	entry.Timings = harTimings{
+		Blocked: -1,
+		DNS:     -1,
+		Connect: -1,
+		Send:    harMillis(send),
+		Wait:    harMillis(wait),
+		Receive: harMillis(receive),
+	}
+	entry.Time = harMillis(send + wait + receive)
+
+	if host, port, err := net.SplitHostPort(socketAddressString(e.Link.Dst)); err == nil {
+		entry.ServerIPAddress = host
+		entry.ServerPort, _ = strconv.Atoi(port)
+	}
+	// Connections are identified by the address of the client, which is
+	// unique for all exchanges made on the same connection.
+	if e.Link.Src != nil {
+		entry.Connection = e.Link.Src.String()
+	}
+	return entry, true
+}
+
+func harURL(e *Exchange, req *httpRequest) string {
+	scheme, host := "http", harHeader(req.Header, "Host")
+	if r, ok := e.Req.msg.(*http2Request); ok {
+		if s := http2Field(r.half.header, ":scheme"); s != "" {
+			scheme = s
+		}
+		if a := http2Field(r.half.header, ":authority"); a != "" {
+			host = a
+		}
+	}
+	if host == "" {
+		host = socketAddressString(e.Link.Dst)
+	}
+	return scheme + "://" + host + req.Path
+}
+
+func harMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func harHeader(header map[string][]string, name string) string {
+	if values := header[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func harHeaders(header map[string][]string) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	harSortNameValues(headers)
+	return headers
+}
+
+func harQueryString(path string) []harNameValue {
+	params := []harNameValue{}
+	_, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return params
+	}
+	values, _ := url.ParseQuery(query)
+	for name, list := range values {
+		for _, value := range list {
+			params = append(params, harNameValue{Name: name, Value: value})
+		}
+	}
+	harSortNameValues(params)
+	return params
+}
+
+func harSortNameValues(values []harNameValue) {
+	slices.SortStableFunc(values, func(a, b harNameValue) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+func harCookies(header []string, setCookie bool) []harCookie {
+	cookies := []harCookie{}
+	for _, h := range header {
+		// Cookie headers carry a list of cookies separated by semicolons,
+		// Set-Cookie headers carry a single cookie followed by attributes.
+		list := strings.Split(h, ";")
+		if setCookie {
+			list = list[:1]
+		}
+		for _, c := range list {
+			name, value, ok := strings.Cut(strings.TrimSpace(c), "=")
+			if ok {
+				cookies = append(cookies, harCookie{Name: name, Value: value})
+			}
+		}
+	}
+	return cookies
+}
+
+func harText(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
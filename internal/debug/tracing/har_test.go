@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+func TestHARWriter(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []Event{{
+		Time: now,
+		Type: Connect,
+		FD:   3,
+		Addr: addr,
+		Peer: peer,
+	}}
+	send := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+	recv := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+
+	gzipped := new(bytes.Buffer)
+	z := gzip.NewWriter(gzipped)
+	_, _ = z.Write([]byte("Hello World!"))
+	assert.OK(t, z.Close())
+
+	send("GET /hello?name=world&lang=en HTTP/1.1\r\nHost: localhost\r\nCookie: a=1; b=2\r\n\r\n")
+	recv("HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Encoding: gzip\r\n" +
+		"Set-Cookie: session=42; Path=/; HttpOnly\r\n" +
+		"Content-Length: " + strconv.Itoa(gzipped.Len()) + "\r\n\r\n" + gzipped.String())
+
+	send("POST /upload HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/octet-stream\r\nContent-Length: 2\r\n\r\n\xff\xfe")
+	events = append(events, Event{Time: now, Type: Shutdown | ShutRD | ShutWR, FD: 3, Addr: addr, Peer: peer})
+
+	b := new(bytes.Buffer)
+	w := NewHARWriter(b, "devel")
+	_, err := stream.Copy[Exchange](w, &ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1()},
+		},
+	})
+	assert.OK(t, err)
+	assert.OK(t, w.Close())
+
+	var har harDocument
+	assert.OK(t, json.Unmarshal(b.Bytes(), &har))
+	assert.Equal(t, har.Log.Version, "1.2")
+	assert.Equal(t, har.Log.Creator.Version, "devel")
+	assert.Equal(t, len(har.Log.Entries), 2)
+
+	get := har.Log.Entries[0]
+	assert.Equal(t, get.StartedDateTime, events[1].Time)
+	assert.Equal(t, get.Request.Method, "GET")
+	assert.Equal(t, get.Request.URL, "http://localhost/hello?name=world&lang=en")
+	assert.Equal(t, get.Request.HTTPVersion, "HTTP/1.1")
+	assert.DeepEqual(t, get.Request.QueryString, []harNameValue{
+		{Name: "lang", Value: "en"},
+		{Name: "name", Value: "world"},
+	})
+	assert.DeepEqual(t, get.Request.Cookies, []harCookie{
+		{Name: "a", Value: "1"},
+		{Name: "b", Value: "2"},
+	})
+	assert.Equal(t, get.Response.Status, 200)
+	assert.Equal(t, get.Response.StatusText, "OK")
+	assert.Equal(t, get.Response.Content.Text, "Hello World!")
+	assert.Equal(t, get.Response.Content.MimeType, "text/plain")
+	assert.Equal(t, get.Response.Content.Size, 12)
+	assert.Equal(t, get.Response.BodySize, gzipped.Len())
+	assert.DeepEqual(t, get.Response.Cookies, []harCookie{
+		{Name: "session", Value: "42"},
+	})
+	assert.Equal(t, get.Timings.Wait, 1.0)
+	assert.Equal(t, get.Time, 1.0)
+	assert.Equal(t, get.ServerIPAddress, "127.0.0.1")
+	assert.Equal(t, get.ServerPort, 8080)
+	assert.Equal(t, get.Connection, "127.0.0.1:49152")
+	assert.Equal(t, get.Error, "")
+
+	post := har.Log.Entries[1]
+	assert.Equal(t, post.Request.Method, "POST")
+	assert.Equal(t, post.Request.PostData.Encoding, "base64")
+	assert.Equal(t, post.Request.PostData.Text, "//4=")
+	assert.Equal(t, post.Response.Status, 0)
+	assert.Equal(t, post.Error, "unexpected EOF")
+}
+
+func TestHARWriterEmpty(t *testing.T) {
+	b := new(bytes.Buffer)
+	w := NewHARWriter(b, "devel")
+	assert.OK(t, w.Close())
+
+	var har harDocument
+	assert.OK(t, json.Unmarshal(b.Bytes(), &har))
+	assert.Equal(t, har.Log.Version, "1.2")
+	assert.DeepEqual(t, har.Log.Entries, []harEntry{})
+}
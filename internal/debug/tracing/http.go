@@ -1,5 +1,13 @@
 package tracing
 
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+)
+
 type httpRequest struct {
 	Proto  string              `json:"protocol,omitempty" yaml:"protocol,omitempty"`
 	Method string              `json:"method,omitempty"   yaml:"method,omitempty"`
@@ -14,4 +22,43 @@ type httpResponse struct {
 	StatusText string              `json:"statusText,omitempty" yaml:"statusText,omitempty"`
 	Header     map[string][]string `json:"header,omitempty"     yaml:"header,omitempty"`
 	Body       Bytes               `json:"body,omitempty"       yaml:"body,omitempty"`
+	Trailer    map[string][]string `json:"trailer,omitempty"    yaml:"trailer,omitempty"`
+}
+
+func httpFormatBody(state fmt.State, verb rune, body, contentEncoding []byte) {
+	switch verb {
+	case 'x':
+		hexdump := hex.Dumper(state)
+		_, _ = hexdump.Write(body)
+		hexdump.Close()
+
+	default:
+		switch string(contentEncoding) {
+		case "gzip":
+			b, err := gunzip(body)
+			if err == nil {
+				body = b
+			}
+		}
+		if utf8.Valid(body) {
+			state.Write(body)
+			if len(body) > 0 && body[len(body)-1] != '\n' {
+				state.Write(newLine)
+			}
+		} else {
+			fmt.Fprintf(state, "(binary content)")
+		}
+	}
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b := new(bytes.Buffer)
+	b.Grow(5 * len(data))
+	_, err = b.ReadFrom(r)
+	return b.Bytes(), err
 }
@@ -2,15 +2,12 @@ package tracing
 
 import (
 	"bytes"
-	"compress/gzip"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
 	"net/textproto"
 	"strconv"
 	"time"
-	"unicode/utf8"
 
 	"github.com/stealthrocket/timecraft/internal/print/textprint"
 	"github.com/stealthrocket/wasi-go"
@@ -77,6 +74,8 @@ type http1Conn struct {
 	resID int64
 }
 
+func (c *http1Conn) server() bool { return c.req == &c.recv }
+
 func (c *http1Conn) Protocol() ConnProtocol {
 	return http1Protocol{}
 }
@@ -195,29 +194,7 @@ func (msg *http1Message) format(state fmt.State, verb rune, prefix []byte) {
 		write(unparsed)
 		write(newLine)
 
-		switch verb {
-		case 'x':
-			hexdump := hex.Dumper(state)
-			_, _ = hexdump.Write(body)
-			hexdump.Close()
-
-		default:
-			switch string(contentEncoding) {
-			case "gzip":
-				b, err := gunzip(body)
-				if err == nil {
-					body = b
-				}
-			}
-			if utf8.Valid(body) {
-				state.Write(body)
-				if len(body) > 0 && body[len(body)-1] != '\n' {
-					state.Write(newLine)
-				}
-			} else {
-				fmt.Fprintf(state, "(binary content)")
-			}
-		}
+		httpFormatBody(state, verb, body, contentEncoding)
 	} else {
 		startLine = bytes.TrimPrefix(startLine, []byte("HTTP/1.0 "))
 		startLine = bytes.TrimPrefix(startLine, []byte("HTTP/1.1 "))
@@ -227,18 +204,6 @@ func (msg *http1Message) format(state fmt.State, verb rune, prefix []byte) {
 	}
 }
 
-func gunzip(data []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	b := new(bytes.Buffer)
-	b.Grow(5 * len(data))
-	_, err = b.ReadFrom(r)
-	return b.Bytes(), err
-}
-
 type http1Request struct {
 	http1Message
 }
@@ -0,0 +1,705 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/wasi-go"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// HTTP2 is the implementation of the HTTP/2 protocol.
+//
+// Streams carrying gRPC calls (with a content type of application/grpc) are
+// detected, the length-prefixed messages of their bodies are decoded, and the
+// status reported by the grpc-status and grpc-message trailers is exposed in
+// the responses.
+func HTTP2() ConnProtocol { return http2Protocol{} }
+
+type http2Protocol struct{}
+
+func (http2Protocol) Name() string { return "HTTP/2" }
+
+func (http2Protocol) CanHandle(data []byte) bool {
+	return bytes.HasPrefix(data, http2ClientPreface)
+}
+
+func (http2Protocol) NewClient(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newHTTP2Conn(fd, addr, peer)
+	conn.req = &conn.send
+	conn.res = &conn.recv
+	conn.req.preface = true
+	return conn
+}
+
+func (http2Protocol) NewServer(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newHTTP2Conn(fd, peer, addr)
+	conn.req = &conn.recv
+	conn.res = &conn.send
+	conn.req.preface = true
+	return conn
+}
+
+var (
+	http2ClientPreface = []byte(http2.ClientPreface)
+)
+
+const (
+	http2FrameHeaderLength = 9
+	// Default size of the HPACK dynamic table (RFC 7540, section 6.5.2).
+	http2HeaderTableSize = 4096
+)
+
+func newHTTP2Conn(fd wasi.FD, addr1, addr2 net.Addr) *http2Conn {
+	return &http2Conn{
+		addr1:   addr1,
+		addr2:   addr2,
+		recv:    http2Parser{decoder: hpack.NewDecoder(http2HeaderTableSize, nil)},
+		send:    http2Parser{decoder: hpack.NewDecoder(http2HeaderTableSize, nil)},
+		connID:  int64(fd) << 32,
+		streams: make(map[uint32]*http2Stream),
+	}
+}
+
+type http2Conn struct {
+	addr1   net.Addr
+	addr2   net.Addr
+	flag    uint
+	recv    http2Parser
+	send    http2Parser
+	req     *http2Parser
+	res     *http2Parser
+	connID  int64
+	streams map[uint32]*http2Stream
+	msgs    []Message
+}
+
+func (c *http2Conn) server() bool { return c.req == &c.recv }
+
+// http2Stream is the state of a stream, made of the request sent by the client
+// and the response sent back by the server.
+type http2Stream struct {
+	id   uint32
+	grpc bool
+	sent bool
+	req  http2Half
+	res  http2Half
+}
+
+// http2Half is one half of a stream, representing the frames sent by one of the
+// peers.
+type http2Half struct {
+	start   time.Time
+	end     time.Time
+	header  []hpack.HeaderField
+	trailer []hpack.HeaderField
+	body    []byte
+	err     error
+	done    bool
+}
+
+func (c *http2Conn) Protocol() ConnProtocol {
+	return http2Protocol{}
+}
+
+func (c *http2Conn) Done() bool {
+	const shutdown = ShutRD | ShutWR
+	return (c.flag & shutdown) == shutdown
+}
+
+func (c *http2Conn) Observe(e *Event) {
+	switch e.Type.Type() {
+	case Receive:
+		c.recv.buffer.write(e.Time, e.Data)
+		c.parse(&c.recv)
+	case Send:
+		c.send.buffer.write(e.Time, e.Data)
+		c.parse(&c.send)
+	case Shutdown:
+		c.flag |= e.Type.Flag()
+		if c.Done() {
+			// The streams which were still open when the connection was
+			// closed will never complete, generate messages for them so
+			// they are not masked from the application.
+			c.abort(e.Time, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func (c *http2Conn) Next(msg *Message) bool {
+	if len(c.msgs) == 0 {
+		return false
+	}
+	*msg = c.msgs[0]
+	c.msgs = c.msgs[:copy(c.msgs, c.msgs[1:])]
+	return true
+}
+
+func (c *http2Conn) parse(p *http2Parser) {
+	for {
+		start, end, frame, err, ok := p.read()
+		if !ok {
+			return
+		}
+		if err == nil {
+			err = c.handle(p, start, end, frame)
+		}
+		if err != nil {
+			// Errors at the connection level leave the parser in a state
+			// where the following frames cannot be decoded (e.g. the HPACK
+			// tables got out of sync), all open streams are failed.
+			p.err = err
+			c.abort(end, err)
+			return
+		}
+	}
+}
+
+func (c *http2Conn) handle(p *http2Parser, start, end time.Time, frame []byte) error {
+	frameType := http2.FrameType(frame[3])
+	flags := http2.Flags(frame[4])
+	streamID := binary.BigEndian.Uint32(frame[5:9]) & (1<<31 - 1)
+	payload := frame[http2FrameHeaderLength:]
+
+	if p.headerStream != 0 && frameType != http2.FrameContinuation {
+		return fmt.Errorf("malformed http2 header block: %v frame received before the end of headers", frameType)
+	}
+
+	switch frameType {
+	case http2.FrameData:
+		payload, err := http2Unpad(flags, http2.FlagDataPadded, payload)
+		if err != nil {
+			return err
+		}
+		if s, h := c.half(p, streamID, start, end); h != nil {
+			h.body = append(h.body, payload...)
+			if flags.Has(http2.FlagDataEndStream) {
+				c.end(s, h)
+			}
+		}
+
+	case http2.FrameHeaders:
+		payload, err := http2Unpad(flags, http2.FlagHeadersPadded, payload)
+		if err != nil {
+			return err
+		}
+		if flags.Has(http2.FlagHeadersPriority) {
+			if len(payload) < 5 {
+				return fmt.Errorf("malformed http2 headers frame: priority fields are truncated")
+			}
+			payload = payload[5:]
+		}
+		return c.headers(p, http2.FrameHeaders, streamID, flags, start, end, payload)
+
+	case http2.FramePushPromise:
+		payload, err := http2Unpad(flags, http2.FlagPushPromisePadded, payload)
+		if err != nil {
+			return err
+		}
+		if len(payload) < 4 {
+			return fmt.Errorf("malformed http2 push promise frame: promised stream id is truncated")
+		}
+		return c.headers(p, http2.FramePushPromise, streamID, flags, start, end, payload[4:])
+
+	case http2.FrameContinuation:
+		if p.headerStream != streamID {
+			return fmt.Errorf("malformed http2 header block: unexpected continuation frame on stream %d", streamID)
+		}
+		return c.headers(p, http2.FrameContinuation, streamID, flags, start, end, payload)
+
+	case http2.FrameRSTStream:
+		if len(payload) < 4 {
+			return fmt.Errorf("malformed http2 rst stream frame: error code is truncated")
+		}
+		code := http2.ErrCode(binary.BigEndian.Uint32(payload))
+		c.reset(streamID, end, http2.StreamError{StreamID: streamID, Code: code})
+
+	case http2.FrameSettings:
+		if flags.Has(http2.FlagSettingsAck) {
+			break
+		}
+		for len(payload) >= 6 {
+			id := http2.SettingID(binary.BigEndian.Uint16(payload))
+			val := binary.BigEndian.Uint32(payload[2:])
+			payload = payload[6:]
+			// The peer sending the settings announces the size of the table
+			// that it uses to decode headers, which are the headers encoded
+			// in the other direction.
+			if id == http2.SettingHeaderTableSize {
+				c.peer(p).decoder.SetAllowedMaxDynamicTableSize(val)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *http2Conn) headers(p *http2Parser, frameType http2.FrameType, streamID uint32, flags http2.Flags, start, end time.Time, fragment []byte) error {
+	if frameType != http2.FrameContinuation {
+		p.headerType = frameType
+		p.headerFlags = flags
+		p.headerBlock = p.headerBlock[:0]
+	}
+	p.headerBlock = append(p.headerBlock, fragment...)
+
+	// HEADERS, PUSH_PROMISE and CONTINUATION frames all use the same value for
+	// the END_HEADERS flag.
+	if !flags.Has(http2.FlagHeadersEndHeaders) {
+		p.headerStream = streamID
+		return nil
+	}
+	p.headerStream = 0
+
+	// The header block must be decoded even if the stream is ignored to keep
+	// the HPACK dynamic table in sync with the peer.
+	fields, err := p.decoder.DecodeFull(p.headerBlock)
+	if err != nil {
+		return fmt.Errorf("malformed http2 header block: %w", err)
+	}
+	if p.headerType == http2.FramePushPromise {
+		return nil
+	}
+
+	s, h := c.half(p, streamID, start, end)
+	if h == nil {
+		return nil
+	}
+	if h.header == nil || http2IsInformational(h.header) {
+		h.header = fields
+	} else {
+		h.trailer = fields
+	}
+	if p.headerFlags.Has(http2.FlagHeadersEndStream) {
+		c.end(s, h)
+	}
+	return nil
+}
+
+// half returns the half of the stream that the frames read by p belong to,
+// creating the stream if it is opened by the client. The method returns nil if
+// the frames must be ignored (e.g. they are on the connection control stream or
+// on a stream pushed by the server).
+func (c *http2Conn) half(p *http2Parser, streamID uint32, start, end time.Time) (*http2Stream, *http2Half) {
+	if streamID == 0 || (streamID%2) == 0 {
+		return nil, nil
+	}
+	s := c.streams[streamID]
+	if s == nil {
+		if p != c.req {
+			return nil, nil
+		}
+		s = &http2Stream{id: streamID}
+		c.streams[streamID] = s
+	}
+	h := &s.res
+	if p == c.req {
+		h = &s.req
+	}
+	if h.done {
+		return nil, nil
+	}
+	if h.start.IsZero() {
+		h.start = start
+	}
+	h.end = end
+	return s, h
+}
+
+func (c *http2Conn) peer(p *http2Parser) *http2Parser {
+	if p == &c.recv {
+		return &c.send
+	}
+	return &c.recv
+}
+
+func (c *http2Conn) end(s *http2Stream, h *http2Half) {
+	h.done = true
+	c.emit(s)
+}
+
+func (c *http2Conn) reset(streamID uint32, now time.Time, err error) {
+	if s := c.streams[streamID]; s != nil {
+		c.fail(s, now, err)
+	}
+}
+
+func (c *http2Conn) abort(now time.Time, err error) {
+	streamIDs := make([]uint32, 0, len(c.streams))
+	for streamID := range c.streams {
+		streamIDs = append(streamIDs, streamID)
+	}
+	slices.Sort(streamIDs)
+	for _, streamID := range streamIDs {
+		c.fail(c.streams[streamID], now, err)
+	}
+}
+
+func (c *http2Conn) fail(s *http2Stream, now time.Time, err error) {
+	for _, h := range []*http2Half{&s.req, &s.res} {
+		if !h.done {
+			if h.start.IsZero() {
+				h.start, h.end = now, now
+			}
+			h.err = err
+			h.done = true
+		}
+	}
+	c.emit(s)
+}
+
+// emit generates the messages for the halves of the stream which are done.
+// The response is always emitted after the request, even if the server
+// completed its half first, so the messages can be paired into exchanges.
+func (c *http2Conn) emit(s *http2Stream) {
+	if !s.sent && s.req.done {
+		s.sent = true
+		s.grpc = http2IsGRPC(s.req.header)
+		c.msgs = append(c.msgs, Message{
+			Link: Link{Src: c.addr1, Dst: c.addr2},
+			Time: s.req.start,
+			Span: s.req.end.Sub(s.req.start),
+			Err:  s.req.err,
+			id:   c.connID | int64(s.id),
+			msg: &http2Request{
+				http2Message: http2Message{conn: c, stream: s, half: &s.req},
+			},
+		})
+	}
+	if s.sent && s.res.done {
+		delete(c.streams, s.id)
+		c.msgs = append(c.msgs, Message{
+			Link: Link{Src: c.addr2, Dst: c.addr1},
+			Time: s.res.start,
+			Span: s.res.end.Sub(s.res.start),
+			Err:  s.res.err,
+			id:   c.connID | int64(s.id),
+			msg: &http2Response{
+				http2Message: http2Message{conn: c, stream: s, half: &s.res},
+			},
+		})
+	}
+}
+
+type http2Parser struct {
+	buffer  buffer
+	preface bool
+	decoder *hpack.Decoder
+	err     error
+	// State of the header block being read, which may span multiple frames.
+	headerStream uint32
+	headerType   http2.FrameType
+	headerFlags  http2.Flags
+	headerBlock  []byte
+}
+
+func (p *http2Parser) read() (start, end time.Time, frame []byte, err error, ok bool) {
+	if p.err != nil {
+		return
+	}
+	if p.preface {
+		if len(p.buffer.bytes) < len(http2ClientPreface) {
+			return
+		}
+		start, end, frame = p.buffer.slice(len(http2ClientPreface))
+		if !bytes.Equal(frame, http2ClientPreface) {
+			return start, end, nil, errors.New("malformed http2 connection preface"), true
+		}
+		p.preface = false
+	}
+	if len(p.buffer.bytes) < http2FrameHeaderLength {
+		return
+	}
+	b := p.buffer.bytes
+	frameLength := http2FrameHeaderLength + (int(b[0])<<16 | int(b[1])<<8 | int(b[2]))
+	if len(b) < frameLength {
+		return
+	}
+	start, end, frame = p.buffer.slice(frameLength)
+	return start, end, frame, nil, true
+}
+
+func http2Unpad(flags, padded http2.Flags, payload []byte) ([]byte, error) {
+	if !flags.Has(padded) {
+		return payload, nil
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("malformed http2 frame: missing pad length")
+	}
+	padLength := int(payload[0])
+	payload = payload[1:]
+	if padLength > len(payload) {
+		return nil, fmt.Errorf("malformed http2 frame: pad length too large: %d > %d", padLength, len(payload))
+	}
+	return payload[:len(payload)-padLength], nil
+}
+
+func http2Field(fields []hpack.HeaderField, name string) string {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func http2IsInformational(fields []hpack.HeaderField) bool {
+	return strings.HasPrefix(http2Field(fields, ":status"), "1")
+}
+
+func http2IsGRPC(fields []hpack.HeaderField) bool {
+	return strings.HasPrefix(http2Field(fields, "content-type"), "application/grpc")
+}
+
+func http2MakeHeader(fields []hpack.HeaderField) map[string][]string {
+	var header map[string][]string
+	for _, f := range fields {
+		if f.IsPseudo() {
+			continue
+		}
+		if header == nil {
+			header = make(map[string][]string)
+		}
+		k := textproto.CanonicalMIMEHeaderKey(f.Name)
+		header[k] = append(header[k], f.Value)
+	}
+	return header
+}
+
+type http2Message struct {
+	conn   *http2Conn
+	stream *http2Stream
+	half   *http2Half
+}
+
+func (msg *http2Message) Conn() Conn { return msg.conn }
+
+func (msg *http2Message) format(state fmt.State, verb rune, prefix []byte, startLine string) {
+	w := textprint.NewPrefixWriter(state, prefix)
+
+	writeFields := func(fields []hpack.HeaderField) {
+		for _, f := range fields {
+			if !f.IsPseudo() {
+				fmt.Fprintf(w, "%s: %s\n", f.Name, f.Value)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%s\n", startLine)
+	writeFields(msg.half.header)
+	fmt.Fprintf(w, "\n")
+
+	if msg.stream.grpc {
+		messages, err := grpcSplitMessages(msg.half.body)
+		for i, m := range messages {
+			compressed := ""
+			if m.Compressed {
+				compressed = ", compressed"
+			}
+			fmt.Fprintf(state, "(grpc message %d: %d bytes%s)\n", i, len(m.Data), compressed)
+			if verb == 'x' {
+				hexdump := hex.Dumper(state)
+				_, _ = hexdump.Write(m.Data)
+				hexdump.Close()
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(state, "(%s)\n", err)
+		}
+	} else {
+		contentEncoding := http2Field(msg.half.header, "content-encoding")
+		httpFormatBody(state, verb, msg.half.body, []byte(contentEncoding))
+	}
+
+	writeFields(msg.half.trailer)
+}
+
+type http2Request struct {
+	http2Message
+}
+
+func (req *http2Request) Format(w fmt.State, v rune) {
+	method := http2Field(req.half.header, ":method")
+	path := http2Field(req.half.header, ":path")
+	if w.Flag('+') {
+		req.format(w, v, http1RequestFormatPrefix, method+" "+path+" HTTP/2.0")
+	} else {
+		fmt.Fprintf(w, "%s %s", method, path)
+	}
+}
+
+func (req *http2Request) Marshal() any {
+	r := httpRequest{
+		Proto:  "HTTP/2.0",
+		Method: http2Field(req.half.header, ":method"),
+		Path:   http2Field(req.half.header, ":path"),
+		Header: http2MakeHeader(req.half.header),
+		Body:   Bytes(req.half.body),
+	}
+	if !req.stream.grpc {
+		return &r
+	}
+	messages, _ := grpcSplitMessages(r.Body)
+	r.Body = nil
+	return &grpcRequest{
+		httpRequest: r,
+		Messages:    messages,
+	}
+}
+
+type http2Response struct {
+	http2Message
+}
+
+func (res *http2Response) status() (statusCode int, statusText string) {
+	statusCode, _ = strconv.Atoi(http2Field(res.half.header, ":status"))
+	return statusCode, http.StatusText(statusCode)
+}
+
+func (res *http2Response) Format(w fmt.State, v rune) {
+	statusCode, statusText := res.status()
+	if w.Flag('+') {
+		res.format(w, v, http1ResponseFormatPrefix, fmt.Sprintf("HTTP/2.0 %d %s", statusCode, statusText))
+		return
+	}
+	fmt.Fprintf(w, "%d %s", statusCode, statusText)
+	if res.stream.grpc {
+		if code, message, ok := res.grpcStatus(); ok {
+			if message != "" {
+				fmt.Fprintf(w, " (%s: %s)", code, message)
+			} else {
+				fmt.Fprintf(w, " (%s)", code)
+			}
+		}
+	}
+}
+
+func (res *http2Response) Marshal() any {
+	statusCode, statusText := res.status()
+	r := httpResponse{
+		Proto:      "HTTP/2.0",
+		StatusCode: statusCode,
+		StatusText: statusText,
+		Header:     http2MakeHeader(res.half.header),
+		Body:       Bytes(res.half.body),
+		Trailer:    http2MakeHeader(res.half.trailer),
+	}
+	if !res.stream.grpc {
+		return &r
+	}
+	messages, _ := grpcSplitMessages(r.Body)
+	r.Body = nil
+	g := &grpcResponse{
+		httpResponse: r,
+		Messages:     messages,
+	}
+	if code, message, ok := res.grpcStatus(); ok {
+		g.Status = &code
+		g.Message = message
+	}
+	return g
+}
+
+// grpcStatus returns the status of the gRPC call, which is found in the
+// trailers, or in the headers of trailers-only responses.
+func (res *http2Response) grpcStatus() (code grpcCode, message string, ok bool) {
+	for _, fields := range [][]hpack.HeaderField{res.half.trailer, res.half.header} {
+		for _, f := range fields {
+			switch f.Name {
+			case "grpc-status":
+				c, err := strconv.Atoi(f.Value)
+				if err != nil {
+					continue
+				}
+				code, ok = grpcCode(c), true
+			case "grpc-message":
+				message, _ = url.PathUnescape(f.Value)
+			}
+		}
+		if ok {
+			return
+		}
+	}
+	return
+}
+
+type grpcRequest struct {
+	httpRequest `yaml:",inline"`
+	Messages    []grpcMessage `json:"messages,omitempty" yaml:"messages,omitempty"`
+}
+
+type grpcResponse struct {
+	httpResponse `yaml:",inline"`
+	Messages     []grpcMessage `json:"messages,omitempty"    yaml:"messages,omitempty"`
+	Status       *grpcCode     `json:"grpcStatus,omitempty"  yaml:"grpcStatus,omitempty"`
+	Message      string        `json:"grpcMessage,omitempty" yaml:"grpcMessage,omitempty"`
+}
+
+type grpcMessage struct {
+	Compressed bool  `json:"compressed,omitempty" yaml:"compressed,omitempty"`
+	Data       Bytes `json:"data"                 yaml:"data"`
+}
+
+// grpcSplitMessages splits the body of a gRPC request or response into the
+// length-prefixed messages that it is made of.
+func grpcSplitMessages(body []byte) (messages []grpcMessage, err error) {
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return messages, fmt.Errorf("malformed grpc message: truncated message prefix of %d bytes", len(body))
+		}
+		compressed := body[0] != 0
+		length := binary.BigEndian.Uint32(body[1:5])
+		body = body[5:]
+		if uint64(length) > uint64(len(body)) {
+			return messages, fmt.Errorf("malformed grpc message: message of %d bytes truncated to %d bytes", length, len(body))
+		}
+		messages = append(messages, grpcMessage{
+			Compressed: compressed,
+			Data:       Bytes(body[:length:length]),
+		})
+		body = body[length:]
+	}
+	return messages, nil
+}
+
+// grpcCode is the representation of gRPC status codes.
+type grpcCode int
+
+var grpcCodes = [...]string{
+	0:  "OK",
+	1:  "CANCELLED",
+	2:  "UNKNOWN",
+	3:  "INVALID_ARGUMENT",
+	4:  "DEADLINE_EXCEEDED",
+	5:  "NOT_FOUND",
+	6:  "ALREADY_EXISTS",
+	7:  "PERMISSION_DENIED",
+	8:  "RESOURCE_EXHAUSTED",
+	9:  "FAILED_PRECONDITION",
+	10: "ABORTED",
+	11: "OUT_OF_RANGE",
+	12: "UNIMPLEMENTED",
+	13: "INTERNAL",
+	14: "UNAVAILABLE",
+	15: "DATA_LOSS",
+	16: "UNAUTHENTICATED",
+}
+
+func (c grpcCode) String() string {
+	if c >= 0 && int(c) < len(grpcCodes) {
+		return grpcCodes[c]
+	}
+	return strconv.Itoa(int(c))
+}
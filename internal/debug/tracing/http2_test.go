@@ -0,0 +1,235 @@
+package tracing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+type http2Peer struct {
+	buf bytes.Buffer
+	hdr bytes.Buffer
+	enc *hpack.Encoder
+	fr  *http2.Framer
+}
+
+func newHTTP2Peer() *http2Peer {
+	p := new(http2Peer)
+	p.enc = hpack.NewEncoder(&p.hdr)
+	p.fr = http2.NewFramer(&p.buf, nil)
+	return p
+}
+
+func (p *http2Peer) headers(t *testing.T, streamID uint32, endStream bool, fields ...string) {
+	p.hdr.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		assert.OK(t, p.enc.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	// Split the header block in two to exercise the reassembly of blocks
+	// spread over CONTINUATION frames.
+	block := p.hdr.Bytes()
+	half := len(block) / 2
+	assert.OK(t, p.fr.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block[:half],
+		EndStream:     endStream,
+	}))
+	assert.OK(t, p.fr.WriteContinuation(streamID, true, block[half:]))
+}
+
+func (p *http2Peer) data(t *testing.T, streamID uint32, endStream bool, data []byte) {
+	assert.OK(t, p.fr.WriteData(streamID, endStream, data))
+}
+
+func (p *http2Peer) flush() Bytes {
+	b := bytes.Clone(p.buf.Bytes())
+	p.buf.Reset()
+	return b
+}
+
+func grpcFrame(data string) []byte {
+	return append([]byte{0, 0, 0, 0, byte(len(data))}, data...)
+}
+
+func TestHttp2CanHandle(t *testing.T) {
+	proto := HTTP2()
+	assert.True(t, proto.CanHandle([]byte(http2.ClientPreface)))
+	assert.True(t, proto.CanHandle([]byte(http2.ClientPreface+"\x00\x00\x00\x04")))
+	assert.False(t, proto.CanHandle([]byte(http2.ClientPreface[:10])))
+	assert.False(t, proto.CanHandle([]byte("GET / HTTP/1.1\r\n")))
+	assert.False(t, HTTP1().CanHandle([]byte(http2.ClientPreface)))
+}
+
+func TestHttp2Exchanges(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+	now := time.Now()
+
+	client, server := newHTTP2Peer(), newHTTP2Peer()
+	events := []Event{{
+		Time: now,
+		Type: Connect,
+		FD:   3,
+		Addr: addr,
+		Peer: peer,
+	}}
+	send := func(data Bytes) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{data}})
+	}
+	recv := func(data Bytes) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{data}})
+	}
+
+	// Plain HTTP/2 request and response.
+	assert.OK(t, client.fr.WriteSettings())
+	client.headers(t, 1, true,
+		":method", "GET",
+		":path", "/hello",
+		":scheme", "http",
+		":authority", "localhost",
+	)
+	// The preface and frames are split across events to make sure the
+	// frames are reassembled.
+	frames := client.flush()
+	send(append([]byte(http2.ClientPreface), frames[:5]...))
+	send(frames[5:])
+
+	assert.OK(t, server.fr.WriteSettings())
+	server.headers(t, 1, false, ":status", "200", "content-type", "text/plain")
+	server.data(t, 1, true, []byte("Hello World!"))
+	recv(server.flush())
+
+	// gRPC call with messages and trailers.
+	client.headers(t, 3, false,
+		":method", "POST",
+		":path", "/test.Service/Call",
+		":scheme", "http",
+		":authority", "localhost",
+		"content-type", "application/grpc",
+	)
+	client.data(t, 3, true, append(grpcFrame("req-1"), grpcFrame("req-2")...))
+	send(client.flush())
+
+	server.headers(t, 3, false, ":status", "200", "content-type", "application/grpc")
+	server.data(t, 3, false, grpcFrame("res-1"))
+	server.headers(t, 3, true, "grpc-status", "5", "grpc-message", "no%20such%20thing")
+	recv(server.flush())
+
+	// gRPC call reset by the client after receiving a trailers-only response.
+	client.headers(t, 5, false,
+		":method", "POST",
+		":path", "/test.Service/Stream",
+		":scheme", "http",
+		":authority", "localhost",
+		"content-type", "application/grpc+proto",
+	)
+	send(client.flush())
+
+	server.headers(t, 5, true, ":status", "200", "content-type", "application/grpc", "grpc-status", "14")
+	recv(server.flush())
+
+	assert.OK(t, client.fr.WriteRSTStream(5, http2.ErrCodeCancel))
+	send(client.flush())
+
+	// Request left without a response when the connection is closed.
+	client.headers(t, 7, true,
+		":method", "GET",
+		":path", "/pending",
+		":scheme", "http",
+		":authority", "localhost",
+	)
+	send(client.flush())
+	events = append(events, Event{Time: now, Type: Shutdown | ShutRD | ShutWR, FD: 3, Addr: addr, Peer: peer})
+
+	exchanges, err := stream.ReadAll[Exchange](&ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1(), HTTP2()},
+		},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(exchanges), 4)
+
+	e := exchanges[0]
+	assert.Equal(t, e.Link.Src.String(), addr.String())
+	assert.Equal(t, e.Link.Dst.String(), peer.String())
+	assert.DeepEqual(t, e.Req.msg.Marshal(), &httpRequest{
+		Proto:  "HTTP/2.0",
+		Method: "GET",
+		Path:   "/hello",
+	})
+	assert.DeepEqual(t, e.Res.msg.Marshal(), &httpResponse{
+		Proto:      "HTTP/2.0",
+		StatusCode: 200,
+		StatusText: "OK",
+		Header:     map[string][]string{"Content-Type": {"text/plain"}},
+		Body:       Bytes("Hello World!"),
+	})
+	assert.Equal(t, fmt.Sprint(e.Req), "GET /hello")
+	assert.Equal(t, fmt.Sprint(e.Res), "200 OK")
+	host, _ := ExchangeFields(&e).Lookup("http.host")
+	assert.Equal(t, host, "localhost")
+
+	e = exchanges[1]
+	notFound := grpcCode(5)
+	assert.DeepEqual(t, e.Req.msg.Marshal(), &grpcRequest{
+		httpRequest: httpRequest{
+			Proto:  "HTTP/2.0",
+			Method: "POST",
+			Path:   "/test.Service/Call",
+			Header: map[string][]string{"Content-Type": {"application/grpc"}},
+		},
+		Messages: []grpcMessage{{Data: Bytes("req-1")}, {Data: Bytes("req-2")}},
+	})
+	assert.DeepEqual(t, e.Res.msg.Marshal(), &grpcResponse{
+		httpResponse: httpResponse{
+			Proto:      "HTTP/2.0",
+			StatusCode: 200,
+			StatusText: "OK",
+			Header:     map[string][]string{"Content-Type": {"application/grpc"}},
+			Trailer:    map[string][]string{"Grpc-Status": {"5"}, "Grpc-Message": {"no%20such%20thing"}},
+		},
+		Messages: []grpcMessage{{Data: Bytes("res-1")}},
+		Status:   &notFound,
+		Message:  "no such thing",
+	})
+	assert.Equal(t, fmt.Sprint(e.Res), "200 OK (NOT_FOUND: no such thing)")
+	host, _ = ExchangeFields(&e).Lookup("http.host")
+	assert.Equal(t, host, "localhost")
+
+	e = exchanges[2]
+	var streamError http2.StreamError
+	assert.True(t, errors.As(e.Req.Err, &streamError))
+	assert.Equal(t, streamError.Code, http2.ErrCodeCancel)
+	assert.OK(t, e.Res.Err)
+	assert.Equal(t, fmt.Sprint(e.Res), "200 OK (UNAVAILABLE)")
+
+	e = exchanges[3]
+	assert.OK(t, e.Req.Err)
+	assert.Equal(t, fmt.Sprint(e.Req), "GET /pending")
+	assert.Error(t, e.Res.Err, io.ErrUnexpectedEOF)
+}
+
+func TestGrpcSplitMessages(t *testing.T) {
+	messages, err := grpcSplitMessages(append(grpcFrame("hello"), 1, 0, 0, 0, 0))
+	assert.OK(t, err)
+	assert.DeepEqual(t, messages, []grpcMessage{
+		{Data: Bytes("hello")},
+		{Compressed: true, Data: Bytes{}},
+	})
+
+	messages, err = grpcSplitMessages(append(grpcFrame("hello"), 0, 0, 0, 0, 10, 'a'))
+	assert.DeepEqual(t, messages, []grpcMessage{{Data: Bytes("hello")}})
+	assert.True(t, err != nil)
+}
@@ -184,7 +184,7 @@ func (r *MessageReader) Read(msgs []Message) (n int, err error) {
 			return n, nil
 		}
 
-		numEvents, err := stream.ReadFull(r.Events, r.events)
+		numEvents, err := r.Events.Read(r.events)
 		if numEvents == 0 {
 			switch err {
 			case nil:
@@ -209,6 +209,8 @@ func (r *MessageReader) Read(msgs []Message) (n int, err error) {
 					newConn: ConnProtocol.NewClient,
 				}
 				continue
+			case Lookup:
+				continue
 			}
 
 			c := r.conns[e.FD]
@@ -305,12 +307,16 @@ func (b *buffer) slice(size int) (start, end time.Time, data []byte) {
 
 	offset := 0
 	for i, ts := range b.times {
+		end = ts.time
 		if offset += ts.size; offset >= size {
-			b.times[i].size -= offset - size
+			// Keep the time slice only if some of its bytes remain in the
+			// buffer after the data is removed.
+			if b.times[i].size = offset - size; b.times[i].size == 0 {
+				i++
+			}
 			b.times = b.times[:copy(b.times, b.times[i:])]
 			break
 		}
-		end = ts.time
 	}
 
 	data = slices.Clone(b.bytes[:size])
@@ -325,10 +331,12 @@ func (b *buffer) write(now time.Time, iovs []Bytes) {
 		b.bytes = append(b.bytes, iov...)
 	}
 
-	b.times = append(b.times, timeslice{
-		time: now,
-		size: len(b.bytes) - length,
-	})
+	if size := len(b.bytes) - length; size > 0 {
+		b.times = append(b.times, timeslice{
+			time: now,
+			size: size,
+		})
+	}
 }
 
 type pendingConn struct {
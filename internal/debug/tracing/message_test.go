@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+func TestBufferSlice(t *testing.T) {
+	t0 := time.Unix(1, 0)
+	t1 := t0.Add(time.Second)
+	t2 := t1.Add(time.Second)
+
+	b := new(buffer)
+	b.write(t0, []Bytes{Bytes("abc")})
+	b.write(t1, []Bytes{Bytes("de"), Bytes("fghi")})
+	b.write(t2, nil) // empty writes do not create time slices
+	b.write(t2, []Bytes{Bytes("hijkl")})
+	assert.Equal(t, len(b.times), 3)
+
+	// The data spans the first two time slices, the second one is only
+	// partially consumed and must retain the remaining bytes.
+	start, end, data := b.slice(5)
+	assert.Equal(t, string(data), "abcde")
+	assert.True(t, start.Equal(t0))
+	assert.True(t, end.Equal(t1))
+	assert.Equal(t, len(b.times), 2)
+	assert.Equal(t, b.times[0].size, 4)
+	assert.Equal(t, b.times[1].size, 5)
+
+	// The data ends exactly at the boundary of a time slice, which must be
+	// removed from the buffer.
+	start, end, data = b.slice(4)
+	assert.Equal(t, string(data), "fghi")
+	assert.True(t, start.Equal(t1))
+	assert.True(t, end.Equal(t1))
+	assert.Equal(t, len(b.times), 1)
+
+	start, end, data = b.slice(5)
+	assert.Equal(t, string(data), "hijkl")
+	assert.True(t, start.Equal(t2))
+	assert.True(t, end.Equal(t2))
+	assert.Equal(t, len(b.times), 0)
+	assert.Equal(t, len(b.bytes), 0)
+}
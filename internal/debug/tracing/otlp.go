@@ -0,0 +1,403 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLPProcess carries the properties of the process that exchanges written to
+// an OTLPWriter were recorded from.
+type OTLPProcess struct {
+	ID        [16]byte
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	// The exit code is only set if the process called proc_exit.
+	ExitCode *int
+}
+
+// OTLPWriter is a writer of Exchange values which produces OpenTelemetry spans
+// encoded with the OTLP/JSON format (as an ExportTraceServiceRequest).
+//
+// The process is represented by a root span, and HTTP exchanges are converted
+// to server and client spans. When the recorded traffic contains traceparent
+// headers, spans are attached to the traces that they carry so the recording
+// can be correlated with the spans exported by other services.
+//
+// Spans are buffered in memory since their parent/child relationships are only
+// known once all the exchanges have been seen; the document is written to the
+// output when the writer is closed. The Process field may be updated until the
+// writer is closed.
+type OTLPWriter struct {
+	Output  io.Writer
+	Process OTLPProcess
+
+	spans []otlpSpan
+	seq   uint64
+}
+
+type otlpSpan struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []otlpKeyValue
+	status   otlpStatus
+	// Set when the parent of the span was given by a traceparent header.
+	propagated bool
+}
+
+// Values of the SpanKind enumeration of the OpenTelemetry protocol.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+)
+
+// Values of the StatusCode enumeration of the OpenTelemetry protocol.
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+func (w *OTLPWriter) Write(exchanges []Exchange) (int, error) {
+	for i := range exchanges {
+		if span, ok := w.makeSpan(&exchanges[i]); ok {
+			w.spans = append(w.spans, span)
+		}
+	}
+	return len(exchanges), nil
+}
+
+func (w *OTLPWriter) Close() error {
+	process := otlpSpan{
+		traceID: w.Process.ID,
+		spanID:  w.spanID(0),
+		name:    w.processName(),
+		kind:    otlpSpanKindInternal,
+		start:   w.Process.StartTime,
+		end:     w.Process.EndTime,
+		attrs: []otlpKeyValue{
+			otlpString("timecraft.process.id", w.processID()),
+		},
+		status: otlpStatus{Code: otlpStatusOK},
+	}
+	if exitCode := w.Process.ExitCode; exitCode != nil {
+		process.attrs = append(process.attrs, otlpInt("process.exit_code", int64(*exitCode)))
+		if *exitCode != 0 {
+			process.status = otlpStatus{
+				Code:    otlpStatusError,
+				Message: "exit code " + strconv.Itoa(*exitCode),
+			}
+		}
+	}
+
+	spans := w.spans
+	slices.SortStableFunc(spans, func(a, b otlpSpan) int {
+		return a.start.Compare(b.start)
+	})
+
+	for i := range spans {
+		s := &spans[i]
+		if s.propagated {
+			// Guests which forward the trace context of the request that they
+			// are serving without creating spans send the same traceparent;
+			// the client span is then attached to the server span.
+			if s.kind == otlpSpanKindClient {
+				if parent := otlpEnclosingServerSpan(spans, s); parent != nil {
+					if parent.traceID == s.traceID && parent.parentID == s.parentID {
+						s.parentID = parent.spanID
+					}
+				}
+			}
+			continue
+		}
+		if s.kind == otlpSpanKindClient {
+			if parent := otlpEnclosingServerSpan(spans, s); parent != nil {
+				if s.traceID == ([16]byte{}) {
+					s.traceID = parent.traceID
+				}
+				if s.traceID == parent.traceID {
+					s.parentID = parent.spanID
+					continue
+				}
+			}
+		}
+		if s.traceID == ([16]byte{}) {
+			s.traceID = process.traceID
+		}
+		if s.traceID == process.traceID {
+			s.parentID = process.spanID
+		}
+	}
+
+	doc := &otlpExportTraceServiceRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{
+					otlpString("service.name", process.name),
+					otlpString("timecraft.process.id", w.processID()),
+				},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "timecraft"},
+				Spans: make([]otlpSpanJSON, 0, len(spans)+1),
+			}},
+		}},
+	}
+	scope := &doc.ResourceSpans[0].ScopeSpans[0]
+	scope.Spans = append(scope.Spans, process.marshal())
+	for i := range spans {
+		scope.Spans = append(scope.Spans, spans[i].marshal())
+	}
+
+	e := json.NewEncoder(w.Output)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(doc)
+}
+
+func (w *OTLPWriter) processID() string {
+	return hex.EncodeToString(w.Process.ID[:])
+}
+
+func (w *OTLPWriter) processName() string {
+	if w.Process.Name != "" {
+		return w.Process.Name
+	}
+	return "timecraft"
+}
+
+// spanID generates a span id which is deterministic for a given process and
+// sequence number, so exporting the same recording twice produces the same
+// spans.
+func (w *OTLPWriter) spanID(seq uint64) (id [8]byte) {
+	h := fnv.New64a()
+	h.Write(w.Process.ID[:])
+	h.Write(binary.LittleEndian.AppendUint64(nil, seq))
+	binary.BigEndian.PutUint64(id[:], h.Sum64())
+	return id
+}
+
+func (w *OTLPWriter) makeSpan(e *Exchange) (span otlpSpan, ok bool) {
+	var req *httpRequest
+	var grpc bool
+	switch r := e.Req.msg.Marshal().(type) {
+	case *httpRequest:
+		req = r
+	case *grpcRequest:
+		req, grpc = &r.httpRequest, true
+	default:
+		return span, false
+	}
+
+	var res *httpResponse
+	if e.Res.msg != nil {
+		switch r := e.Res.msg.Marshal().(type) {
+		case *httpResponse:
+			res = r
+		case *grpcResponse:
+			res = &r.httpResponse
+		}
+	}
+
+	server := false
+	if c, ok := e.Req.msg.Conn().(interface{ server() bool }); ok {
+		server = c.server()
+	}
+
+	w.seq++
+	span.spanID = w.spanID(w.seq)
+	span.start = e.Req.Time
+	span.end = e.Req.Time.Add(e.Req.Span)
+	if res != nil {
+		span.end = e.Req.Time.Add(e.Res.Time + e.Res.Span)
+	}
+
+	path, query, _ := strings.Cut(req.Path, "?")
+	if grpc {
+		span.name = strings.TrimPrefix(path, "/")
+		span.attrs = append(span.attrs, otlpString("rpc.system", "grpc"))
+	} else {
+		span.name = req.Method + " " + path
+	}
+	span.attrs = append(span.attrs,
+		otlpString("http.request.method", req.Method),
+		otlpString("url.path", path),
+	)
+	if query != "" {
+		span.attrs = append(span.attrs, otlpString("url.query", query))
+	}
+	if version := strings.TrimPrefix(req.Proto, "HTTP/"); version != "" {
+		span.attrs = append(span.attrs, otlpString("network.protocol.version", version))
+	}
+	if host, port, err := net.SplitHostPort(socketAddressString(e.Link.Dst)); err == nil {
+		span.attrs = append(span.attrs, otlpString("server.address", host))
+		if p, err := strconv.Atoi(port); err == nil {
+			span.attrs = append(span.attrs, otlpInt("server.port", int64(p)))
+		}
+	}
+	if host, _, err := net.SplitHostPort(socketAddressString(e.Link.Src)); err == nil {
+		span.attrs = append(span.attrs, otlpString("client.address", host))
+	}
+
+	traceID, parentID, hasParent := otlpParseTraceParent(harHeader(req.Header, "Traceparent"))
+	if server {
+		span.kind = otlpSpanKindServer
+		if hasParent {
+			span.traceID, span.parentID, span.propagated = traceID, parentID, true
+		}
+	} else {
+		span.kind = otlpSpanKindClient
+		if hasParent {
+			// The traceparent header sent by a client carries the id of the
+			// span which made the request in the guest, the span of the
+			// exchange is its child.
+			span.traceID, span.parentID, span.propagated = traceID, parentID, true
+		}
+		// The host of HTTP/2 requests is carried by the :authority
+		// pseudo-header, which harURL falls back to.
+		span.attrs = append(span.attrs, otlpString("url.full", harURL(e, req)))
+	}
+
+	span.status = otlpStatus{Code: otlpStatusOK}
+	switch {
+	case e.Res.Err != nil:
+		span.status = otlpStatus{Code: otlpStatusError, Message: e.Res.Err.Error()}
+	case e.Req.Err != nil:
+		span.status = otlpStatus{Code: otlpStatusError, Message: e.Req.Err.Error()}
+	case res != nil:
+		span.attrs = append(span.attrs, otlpInt("http.response.status_code", int64(res.StatusCode)))
+		// Client errors are only reported as span errors on the client side,
+		// as recommended by the semantic conventions.
+		if res.StatusCode >= 500 || (!server && res.StatusCode >= 400) {
+			span.status = otlpStatus{Code: otlpStatusError, Message: res.StatusText}
+		}
+	}
+	return span, true
+}
+
+// otlpEnclosingServerSpan returns the server span which started last before the
+// client span passed as argument and was still in progress when it started.
+func otlpEnclosingServerSpan(spans []otlpSpan, client *otlpSpan) *otlpSpan {
+	var parent *otlpSpan
+	for i := range spans {
+		s := &spans[i]
+		if s.kind != otlpSpanKindServer || s.start.After(client.start) || s.end.Before(client.start) {
+			continue
+		}
+		if client.traceID != ([16]byte{}) && client.traceID != s.traceID && s.traceID != ([16]byte{}) {
+			continue
+		}
+		if parent == nil || s.start.After(parent.start) {
+			parent = s
+		}
+	}
+	return parent
+}
+
+// otlpParseTraceParent parses the value of a W3C traceparent header, see
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func otlpParseTraceParent(s string) (traceID [16]byte, parentID [8]byte, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil {
+		return traceID, parentID, false
+	}
+	if traceID == ([16]byte{}) || parentID == ([8]byte{}) {
+		return traceID, parentID, false
+	}
+	return traceID, parentID, true
+}
+
+func (s *otlpSpan) marshal() otlpSpanJSON {
+	span := otlpSpanJSON{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		Attributes:        s.attrs,
+		Status:            s.status,
+	}
+	if s.parentID != ([8]byte{}) {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	return span
+}
+
+type otlpExportTraceServiceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope      `json:"scope"`
+	Spans []otlpSpanJSON `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpanJSON struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	// 64 bits integers are encoded as strings in OTLP/JSON.
+	IntValue *string `json:"intValue,omitempty"`
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) otlpKeyValue {
+	s := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpValue{IntValue: &s}}
+}
@@ -0,0 +1,211 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/wasi-go"
+	"golang.org/x/net/http2"
+)
+
+func TestOTLPWriter(t *testing.T) {
+	local := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 8080}
+	client := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 50000}
+	upstream := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 3), Port: 80}
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	var events []Event
+	event := func(typ EventType, fd int32, addr, peer net.Addr, data string) {
+		now = now.Add(time.Millisecond)
+		e := Event{Time: now, Type: typ, Proto: TCP, FD: wasi.FD(fd), Addr: addr, Peer: peer}
+		if data != "" {
+			e.Data = []Bytes{Bytes(data)}
+		}
+		events = append(events, e)
+	}
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+		clientID = "b7ad6b7169203331"
+	)
+
+	// Request served by the guest, carrying a trace context.
+	event(Accept, 3, local, client, "")
+	event(Receive, 3, local, client, "GET /api HTTP/1.1\r\nHost: svc\r\nTraceparent: 00-"+traceID+"-"+parentID+"-01\r\n\r\n")
+	// Request made by the guest while serving the request, without trace
+	// context.
+	event(Connect, 4, nil, upstream, "")
+	event(Send, 4, nil, upstream, "GET /db?x=1 HTTP/1.1\r\nHost: db\r\n\r\n")
+	event(Receive, 4, nil, upstream, "HTTP/1.1 500 Internal Server Error\r\nContent-Length: 0\r\n\r\n")
+	// Request made by the guest while serving the request, forwarding the
+	// trace context that it received.
+	event(Connect, 7, nil, upstream, "")
+	event(Send, 7, nil, upstream, "GET /cache HTTP/1.1\r\nHost: db\r\nTraceparent: 00-"+traceID+"-"+parentID+"-01\r\n\r\n")
+	event(Receive, 7, nil, upstream, "HTTP/1.1 204 No Content\r\n\r\n")
+	event(Send, 3, local, client, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	// Request made by the guest outside of any request, propagating its own
+	// trace context.
+	event(Connect, 5, nil, upstream, "")
+	event(Send, 5, nil, upstream, "GET /ping HTTP/1.1\r\nHost: db\r\nTraceparent: 00-"+traceID+"-"+clientID+"-01\r\n\r\n")
+	event(Receive, 5, nil, upstream, "HTTP/1.1 204 No Content\r\n\r\n")
+	// Request made by the guest outside of any request.
+	event(Connect, 6, nil, upstream, "")
+	event(Send, 6, nil, upstream, "GET /ping HTTP/1.1\r\nHost: db\r\n\r\n")
+	event(Receive, 6, nil, upstream, "HTTP/1.1 204 No Content\r\n\r\n")
+
+	b := new(bytes.Buffer)
+	exitCode := 1
+	w := &OTLPWriter{
+		Output: b,
+		Process: OTLPProcess{
+			ID:        [16]byte{0: 1, 15: 2},
+			Name:      "app.wasm",
+			StartTime: start,
+		},
+	}
+	_, err := stream.Copy[Exchange](w, &ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1()},
+		},
+	})
+	assert.OK(t, err)
+	w.Process.EndTime = now
+	w.Process.ExitCode = &exitCode
+	assert.OK(t, w.Close())
+
+	var doc otlpExportTraceServiceRequest
+	assert.OK(t, json.Unmarshal(b.Bytes(), &doc))
+	assert.Equal(t, len(doc.ResourceSpans), 1)
+	assert.Equal(t, len(doc.ResourceSpans[0].ScopeSpans), 1)
+	spans := doc.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 6)
+
+	attr := func(span otlpSpanJSON, key string) string {
+		for _, kv := range span.Attributes {
+			if kv.Key == key {
+				if kv.Value.StringValue != nil {
+					return *kv.Value.StringValue
+				}
+				return *kv.Value.IntValue
+			}
+		}
+		return ""
+	}
+
+	process := spans[0]
+	assert.Equal(t, process.Name, "app.wasm")
+	assert.Equal(t, process.TraceID, "01000000000000000000000000000002")
+	assert.Equal(t, process.ParentSpanID, "")
+	assert.Equal(t, process.Status.Code, otlpStatusError)
+	assert.Equal(t, attr(process, "process.exit_code"), "1")
+
+	server := spans[1]
+	assert.Equal(t, server.Name, "GET /api")
+	assert.Equal(t, server.Kind, otlpSpanKindServer)
+	assert.Equal(t, server.TraceID, traceID)
+	assert.Equal(t, server.ParentSpanID, parentID)
+	assert.Equal(t, server.Status.Code, otlpStatusOK)
+	assert.Equal(t, attr(server, "http.response.status_code"), "200")
+	assert.Equal(t, attr(server, "client.address"), "10.0.0.2")
+	assert.Equal(t, server.StartTimeUnixNano, "1685620800002000000")
+	assert.Equal(t, server.EndTimeUnixNano, "1685620800009000000")
+
+	nested := spans[2]
+	assert.Equal(t, nested.Name, "GET /db")
+	assert.Equal(t, nested.Kind, otlpSpanKindClient)
+	assert.Equal(t, nested.TraceID, traceID)
+	assert.Equal(t, nested.ParentSpanID, server.SpanID)
+	assert.Equal(t, nested.Status.Code, otlpStatusError)
+	assert.Equal(t, attr(nested, "url.full"), "http://db/db?x=1")
+	assert.Equal(t, attr(nested, "server.port"), "80")
+
+	forwarded := spans[3]
+	assert.Equal(t, forwarded.Name, "GET /cache")
+	assert.Equal(t, forwarded.TraceID, traceID)
+	assert.Equal(t, forwarded.ParentSpanID, server.SpanID)
+
+	propagated := spans[4]
+	assert.Equal(t, propagated.TraceID, traceID)
+	assert.Equal(t, propagated.ParentSpanID, clientID)
+
+	orphan := spans[5]
+	assert.Equal(t, orphan.TraceID, process.TraceID)
+	assert.Equal(t, orphan.ParentSpanID, process.SpanID)
+
+	// Each span has its own id, which is never the id of its parent.
+	ids := map[string]bool{}
+	for _, span := range spans {
+		assert.False(t, ids[span.SpanID])
+		assert.NotEqual(t, span.SpanID, span.ParentSpanID)
+		assert.NotEqual(t, span.SpanID, parentID)
+		assert.NotEqual(t, span.SpanID, clientID)
+		ids[span.SpanID] = true
+	}
+}
+
+func TestOTLPWriterHTTP2(t *testing.T) {
+	upstream := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 3), Port: 8443}
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	client, server := newHTTP2Peer(), newHTTP2Peer()
+	assert.OK(t, client.fr.WriteSettings())
+	client.headers(t, 1, true,
+		":method", "GET",
+		":path", "/hello?x=1",
+		":scheme", "https",
+		":authority", "api.example",
+	)
+	assert.OK(t, server.fr.WriteSettings())
+	server.headers(t, 1, true, ":status", "204")
+
+	events := []Event{
+		{Time: now, Type: Connect, Proto: TCP, FD: 3, Peer: upstream},
+		{Time: now.Add(1 * time.Millisecond), Type: Send, Proto: TCP, FD: 3, Peer: upstream,
+			Data: []Bytes{append(Bytes(http2.ClientPreface), client.flush()...)}},
+		{Time: now.Add(2 * time.Millisecond), Type: Receive, Proto: TCP, FD: 3, Peer: upstream,
+			Data: []Bytes{server.flush()}},
+	}
+
+	b := new(bytes.Buffer)
+	w := &OTLPWriter{Output: b, Process: OTLPProcess{Name: "app.wasm", StartTime: now}}
+	_, err := stream.Copy[Exchange](w, &ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1(), HTTP2()},
+		},
+	})
+	assert.OK(t, err)
+	assert.OK(t, w.Close())
+
+	var doc otlpExportTraceServiceRequest
+	assert.OK(t, json.Unmarshal(b.Bytes(), &doc))
+	spans := doc.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 2)
+
+	for _, kv := range spans[1].Attributes {
+		if kv.Key == "url.full" {
+			assert.Equal(t, *kv.Value.StringValue, "https://api.example/hello?x=1")
+			return
+		}
+	}
+	t.Fatal("url.full attribute not found")
+}
+
+func TestOTLPParseTraceParent(t *testing.T) {
+	_, _, ok := otlpParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	_, _, ok = otlpParseTraceParent("00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+	_, _, ok = otlpParseTraceParent("ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.False(t, ok)
+	_, _, ok = otlpParseTraceParent("garbage")
+	assert.False(t, ok)
+}
@@ -0,0 +1,410 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/wasi-go"
+)
+
+// PCAP-NG block types and constants, see:
+// https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html
+const (
+	pcapSectionHeaderBlock        = 0x0A0D0D0A
+	pcapInterfaceDescriptionBlock = 0x00000001
+	pcapEnhancedPacketBlock       = 0x00000006
+
+	pcapByteOrderMagic = 0x1A2B3C4D
+
+	pcapOptionEndOfOpt     = 0
+	pcapOptionShbUserAppl  = 4
+	pcapOptionIfName       = 2
+	pcapOptionIfTsResol    = 9
+	pcapLinkTypeRaw        = 101
+	pcapTimestampNanosUnit = 9
+
+	// Payloads larger than this size are split across multiple packets so
+	// the synthesized frames never exceed the maximum size of IP packets.
+	pcapMaxSegmentSize = 32768
+)
+
+const (
+	tcpFlagFIN = 1 << 0
+	tcpFlagSYN = 1 << 1
+	tcpFlagPSH = 1 << 3
+	tcpFlagACK = 1 << 4
+
+	ipProtocolTCP = 6
+	ipProtocolUDP = 17
+)
+
+// NewPcapWriter returns a writer of Event values which synthesizes network
+// packets from the events and writes them to w in the PCAP-NG format.
+//
+// The recorded events only carry the payloads exchanged by the guest on its
+// sockets, so the writer generates the TCP handshakes, sequence numbers and
+// connection teardowns to produce a capture that tools like Wireshark can
+// dissect. Events of protocols other than TCP and UDP are ignored.
+func NewPcapWriter(w io.Writer) stream.WriteCloser[Event] {
+	return &pcapWriter{
+		output: w,
+		conns:  make(map[wasi.FD]*pcapConn),
+	}
+}
+
+type pcapWriter struct {
+	output io.Writer
+	buffer []byte
+	packet []byte
+	conns  map[wasi.FD]*pcapConn
+	ipID   uint16
+	header bool
+}
+
+type pcapConn struct {
+	proto  Protocol
+	local  netip.AddrPort
+	remote netip.AddrPort
+	// Sequence numbers of the next bytes sent by the local and remote ends
+	// of TCP connections.
+	seq  uint32
+	ack  uint32
+	shut EventType
+}
+
+func (w *pcapWriter) Write(events []Event) (int, error) {
+	w.writeHeader()
+
+	for i := range events {
+		w.writeEvent(&events[i])
+	}
+
+	if err := w.flush(); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (w *pcapWriter) Close() error {
+	w.writeHeader()
+	return w.flush()
+}
+
+func (w *pcapWriter) flush() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	_, err := w.output.Write(w.buffer)
+	w.buffer = w.buffer[:0]
+	return err
+}
+
+func (w *pcapWriter) writeHeader() {
+	if w.header {
+		return
+	}
+	w.header = true
+
+	w.buffer = pcapAppendBlock(w.buffer, pcapSectionHeaderBlock, func(b []byte) []byte {
+		b = binary.LittleEndian.AppendUint32(b, pcapByteOrderMagic)
+		b = binary.LittleEndian.AppendUint16(b, 1) // major version
+		b = binary.LittleEndian.AppendUint16(b, 0) // minor version
+		b = binary.LittleEndian.AppendUint64(b, ^uint64(0))
+		b = pcapAppendOption(b, pcapOptionShbUserAppl, []byte("timecraft"))
+		return pcapAppendOption(b, pcapOptionEndOfOpt, nil)
+	})
+
+	w.buffer = pcapAppendBlock(w.buffer, pcapInterfaceDescriptionBlock, func(b []byte) []byte {
+		b = binary.LittleEndian.AppendUint16(b, pcapLinkTypeRaw)
+		b = binary.LittleEndian.AppendUint16(b, 0) // reserved
+		b = binary.LittleEndian.AppendUint32(b, 0) // snap length
+		b = pcapAppendOption(b, pcapOptionIfName, []byte("timecraft"))
+		b = pcapAppendOption(b, pcapOptionIfTsResol, []byte{pcapTimestampNanosUnit})
+		return pcapAppendOption(b, pcapOptionEndOfOpt, nil)
+	})
+}
+
+func (w *pcapWriter) writeEvent(e *Event) {
+	if e.Proto != TCP && e.Proto != UDP {
+		return
+	}
+
+	switch e.Type.Type() {
+	case Accept, Connect:
+		if e.Error != wasi.ESUCCESS && e.Error != wasi.EINPROGRESS {
+			return
+		}
+		c := w.newConn(e)
+		if c == nil {
+			return
+		}
+		w.conns[e.FD] = c
+		if c.proto == TCP {
+			if e.Type.Type() == Accept {
+				w.writeTCP(e, c, false, tcpFlagSYN, nil)
+				w.writeTCP(e, c, true, tcpFlagSYN|tcpFlagACK, nil)
+			} else {
+				w.writeTCP(e, c, true, tcpFlagSYN, nil)
+				w.writeTCP(e, c, false, tcpFlagSYN|tcpFlagACK, nil)
+			}
+			w.writeTCP(e, c, e.Type.Type() == Connect, tcpFlagACK, nil)
+		}
+
+	case Send, Receive:
+		if e.Error != wasi.ESUCCESS {
+			return
+		}
+		c := w.conns[e.FD]
+		if c == nil {
+			// Sockets may have been opened before the start of the trace,
+			// or not be connected in the case of UDP.
+			if c = w.newConn(e); c == nil {
+				return
+			}
+			if c.proto == TCP {
+				w.conns[e.FD] = c
+			}
+		}
+		outbound := e.Type.Type() == Send
+		for _, data := range e.Data {
+			for len(data) > 0 {
+				n := min(len(data), pcapMaxSegmentSize)
+				if c.proto == TCP {
+					w.writeTCP(e, c, outbound, tcpFlagPSH|tcpFlagACK, data[:n])
+				} else {
+					w.writeUDP(e, c, outbound, data[:n], e.Peer)
+				}
+				data = data[n:]
+			}
+		}
+
+	case Shutdown:
+		c := w.conns[e.FD]
+		if c == nil {
+			return
+		}
+		if c.proto == TCP {
+			if (e.Type&ShutWR) != 0 && (c.shut&ShutWR) == 0 {
+				w.writeTCP(e, c, true, tcpFlagFIN|tcpFlagACK, nil)
+			}
+			if (e.Type&ShutRD) != 0 && (c.shut&ShutRD) == 0 {
+				w.writeTCP(e, c, false, tcpFlagFIN|tcpFlagACK, nil)
+			}
+		}
+		c.shut |= e.Type & (ShutRD | ShutWR)
+		if c.shut == (ShutRD | ShutWR) {
+			if c.proto == TCP {
+				w.writeTCP(e, c, true, tcpFlagACK, nil)
+			}
+			delete(w.conns, e.FD)
+		}
+	}
+}
+
+func (w *pcapWriter) newConn(e *Event) *pcapConn {
+	remote := pcapAddrPort(e.Peer)
+	if !remote.IsValid() {
+		return nil
+	}
+	local := pcapAddrPort(e.Addr)
+	if !local.IsValid() {
+		if remote.Addr().Is4() {
+			local = netip.AddrPortFrom(netip.IPv4Unspecified(), 0)
+		} else {
+			local = netip.AddrPortFrom(netip.IPv6Unspecified(), 0)
+		}
+	}
+	local, remote = pcapSameFamily(local, remote)
+	// The initial sequence numbers are derived from the time of the event
+	// so the synthesized captures are deterministic.
+	isn := uint32(e.Time.UnixNano())
+	return &pcapConn{
+		proto:  e.Proto,
+		local:  local,
+		remote: remote,
+		seq:    isn,
+		ack:    ^isn,
+	}
+}
+
+func (w *pcapWriter) writeTCP(e *Event, c *pcapConn, outbound bool, flags byte, data []byte) {
+	src, dst := c.remote, c.local
+	seq, ack := c.ack, c.seq
+	if outbound {
+		src, dst = dst, src
+		seq, ack = ack, seq
+	}
+	if (flags & tcpFlagSYN) != 0 {
+		// The SYN packet initiating the connection does not acknowledge any
+		// sequence number.
+		if (flags & tcpFlagACK) == 0 {
+			ack = 0
+		}
+	}
+
+	b := w.packet[:0]
+	b = binary.BigEndian.AppendUint16(b, src.Port())
+	b = binary.BigEndian.AppendUint16(b, dst.Port())
+	b = binary.BigEndian.AppendUint32(b, seq)
+	b = binary.BigEndian.AppendUint32(b, ack)
+	b = append(b, 5<<4, flags)
+	b = binary.BigEndian.AppendUint16(b, 65535) // window
+	b = binary.BigEndian.AppendUint16(b, 0)     // checksum
+	b = binary.BigEndian.AppendUint16(b, 0)     // urgent pointer
+	b = append(b, data...)
+	binary.BigEndian.PutUint16(b[16:], pcapChecksum(src.Addr(), dst.Addr(), ipProtocolTCP, b))
+	w.packet = b
+
+	next := uint32(len(data))
+	if (flags & (tcpFlagSYN | tcpFlagFIN)) != 0 {
+		next++
+	}
+	if outbound {
+		c.seq += next
+	} else {
+		c.ack += next
+	}
+
+	w.writePacket(e, src.Addr(), dst.Addr(), ipProtocolTCP, b)
+}
+
+func (w *pcapWriter) writeUDP(e *Event, c *pcapConn, outbound bool, data []byte, peer net.Addr) {
+	src, dst := c.remote, c.local
+	if remote := pcapAddrPort(peer); remote.IsValid() {
+		dst, src = pcapSameFamily(c.local, remote)
+	}
+	if outbound {
+		src, dst = dst, src
+	}
+
+	b := w.packet[:0]
+	b = binary.BigEndian.AppendUint16(b, src.Port())
+	b = binary.BigEndian.AppendUint16(b, dst.Port())
+	b = binary.BigEndian.AppendUint16(b, uint16(8+len(data)))
+	b = binary.BigEndian.AppendUint16(b, 0) // checksum
+	b = append(b, data...)
+	binary.BigEndian.PutUint16(b[6:], pcapChecksum(src.Addr(), dst.Addr(), ipProtocolUDP, b))
+	w.packet = b
+
+	w.writePacket(e, src.Addr(), dst.Addr(), ipProtocolUDP, b)
+}
+
+func (w *pcapWriter) writePacket(e *Event, src, dst netip.Addr, proto byte, payload []byte) {
+	w.buffer = pcapAppendBlock(w.buffer, pcapEnhancedPacketBlock, func(b []byte) []byte {
+		timestamp := uint64(e.Time.UnixNano())
+		b = binary.LittleEndian.AppendUint32(b, 0) // interface id
+		b = binary.LittleEndian.AppendUint32(b, uint32(timestamp>>32))
+		b = binary.LittleEndian.AppendUint32(b, uint32(timestamp))
+		i := len(b)
+		b = binary.LittleEndian.AppendUint32(b, 0) // captured length
+		b = binary.LittleEndian.AppendUint32(b, 0) // original length
+		j := len(b)
+
+		if src.Is4() {
+			w.ipID++
+			h := len(b)
+			b = append(b, 0x45, 0)
+			b = binary.BigEndian.AppendUint16(b, uint16(20+len(payload)))
+			b = binary.BigEndian.AppendUint16(b, w.ipID)
+			b = binary.BigEndian.AppendUint16(b, 0x4000) // don't fragment
+			b = append(b, 64, proto)
+			b = binary.BigEndian.AppendUint16(b, 0) // checksum
+			b = append(b, src.AsSlice()...)
+			b = append(b, dst.AsSlice()...)
+			binary.BigEndian.PutUint16(b[h+10:], ^pcapSum(0, b[h:]))
+		} else {
+			b = binary.BigEndian.AppendUint32(b, 6<<28)
+			b = binary.BigEndian.AppendUint16(b, uint16(len(payload)))
+			b = append(b, proto, 64)
+			b = append(b, src.AsSlice()...)
+			b = append(b, dst.AsSlice()...)
+		}
+		b = append(b, payload...)
+
+		size := uint32(len(b) - j)
+		binary.LittleEndian.PutUint32(b[i+0:], size)
+		binary.LittleEndian.PutUint32(b[i+4:], size)
+		return pcapAppendPadding(b, len(b)-j)
+	})
+}
+
+func pcapAppendBlock(b []byte, typ uint32, body func([]byte) []byte) []byte {
+	i := len(b)
+	b = binary.LittleEndian.AppendUint32(b, typ)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = body(b)
+	size := uint32(len(b)-i) + 4
+	binary.LittleEndian.PutUint32(b[i+4:], size)
+	return binary.LittleEndian.AppendUint32(b, size)
+}
+
+func pcapAppendOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return pcapAppendPadding(b, len(value))
+}
+
+func pcapAppendPadding(b []byte, size int) []byte {
+	for size%4 != 0 {
+		b = append(b, 0)
+		size++
+	}
+	return b
+}
+
+func pcapAddrPort(addr net.Addr) netip.AddrPort {
+	if addr == nil {
+		return netip.AddrPort{}
+	}
+	addrPort, _ := netip.ParseAddrPort(addr.String())
+	return addrPort
+}
+
+// pcapSameFamily converts IPv4 addresses to IPv4-mapped IPv6 addresses when
+// the two addresses are not of the same family.
+func pcapSameFamily(a, b netip.AddrPort) (netip.AddrPort, netip.AddrPort) {
+	if a.Addr().Is4() != b.Addr().Is4() {
+		a = netip.AddrPortFrom(netip.AddrFrom16(a.Addr().As16()), a.Port())
+		b = netip.AddrPortFrom(netip.AddrFrom16(b.Addr().As16()), b.Port())
+	}
+	return a, b
+}
+
+func pcapChecksum(src, dst netip.Addr, proto byte, segment []byte) uint16 {
+	var pseudo []byte
+	pseudo = append(pseudo, src.AsSlice()...)
+	pseudo = append(pseudo, dst.AsSlice()...)
+	if src.Is4() {
+		pseudo = append(pseudo, 0, proto)
+		pseudo = binary.BigEndian.AppendUint16(pseudo, uint16(len(segment)))
+	} else {
+		pseudo = binary.BigEndian.AppendUint32(pseudo, uint32(len(segment)))
+		pseudo = append(pseudo, 0, 0, 0, proto)
+	}
+	sum := ^pcapSum(pcapSum(0, pseudo), segment)
+	if sum == 0 && proto == ipProtocolUDP {
+		sum = 0xFFFF
+	}
+	return sum
+}
+
+// pcapSum computes the one's complement sum used in the checksums of IP, TCP
+// and UDP headers, starting from the intermediate sum passed as argument.
+func pcapSum(sum uint16, b []byte) uint16 {
+	s := uint32(sum)
+	for len(b) >= 2 {
+		s += uint32(binary.BigEndian.Uint16(b))
+		b = b[2:]
+	}
+	if len(b) > 0 {
+		s += uint32(b[0]) << 8
+	}
+	for s > 0xFFFF {
+		s = (s >> 16) + (s & 0xFFFF)
+	}
+	return uint16(s)
+}
@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+type pcapPacket struct {
+	time    time.Time
+	src     netip.AddrPort
+	dst     netip.AddrPort
+	proto   byte
+	seq     uint32
+	ack     uint32
+	flags   byte
+	payload string
+}
+
+func pcapReadPackets(t *testing.T, b []byte) (packets []pcapPacket) {
+	blockTypes := []uint32{}
+
+	for len(b) > 0 {
+		typ := binary.LittleEndian.Uint32(b[0:])
+		size := binary.LittleEndian.Uint32(b[4:])
+		assert.Equal(t, size%4, 0)
+		assert.Equal(t, binary.LittleEndian.Uint32(b[size-4:]), size)
+		blockTypes = append(blockTypes, typ)
+
+		if typ == pcapEnhancedPacketBlock {
+			body := b[8 : size-4]
+			ts := uint64(binary.LittleEndian.Uint32(body[4:]))<<32 | uint64(binary.LittleEndian.Uint32(body[8:]))
+			length := binary.LittleEndian.Uint32(body[12:])
+			ip := body[20 : 20+length]
+
+			assert.Equal(t, ip[0]>>4, 4)
+			assert.Equal(t, pcapSum(0, ip[:20]), 0xFFFF)
+			src, _ := netip.AddrFromSlice(ip[12:16])
+			dst, _ := netip.AddrFromSlice(ip[16:20])
+			segment := ip[20:]
+			checksumOffset := 6
+			if ip[9] == ipProtocolTCP {
+				checksumOffset = 16
+			}
+			checksum := binary.BigEndian.Uint16(segment[checksumOffset:])
+			binary.BigEndian.PutUint16(segment[checksumOffset:], 0)
+			assert.Equal(t, pcapChecksum(src, dst, ip[9], segment), checksum)
+
+			p := pcapPacket{
+				time:  time.Unix(0, int64(ts)),
+				src:   netip.AddrPortFrom(src, binary.BigEndian.Uint16(segment[0:])),
+				dst:   netip.AddrPortFrom(dst, binary.BigEndian.Uint16(segment[2:])),
+				proto: ip[9],
+			}
+			if p.proto == ipProtocolTCP {
+				p.seq = binary.BigEndian.Uint32(segment[4:])
+				p.ack = binary.BigEndian.Uint32(segment[8:])
+				p.flags = segment[13]
+				p.payload = string(segment[20:])
+			} else {
+				p.payload = string(segment[8:])
+			}
+			packets = append(packets, p)
+		}
+
+		b = b[size:]
+	}
+
+	assert.True(t, len(blockTypes) >= 2)
+	assert.Equal(t, blockTypes[0], pcapSectionHeaderBlock)
+	assert.Equal(t, blockTypes[1], pcapInterfaceDescriptionBlock)
+	return packets
+}
+
+func TestPcapWriterEmpty(t *testing.T) {
+	b := new(bytes.Buffer)
+	w := NewPcapWriter(b)
+	assert.OK(t, w.Close())
+	assert.Equal(t, len(pcapReadPackets(t, b.Bytes())), 0)
+}
+
+func TestPcapWriter(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+	dns := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 53}
+	now := time.Unix(1700000000, 0)
+
+	events := []Event{
+		{Time: now, Type: Connect, Proto: TCP, FD: 3, Addr: addr, Peer: peer},
+		{Time: now.Add(1 * time.Millisecond), Type: Send, Proto: TCP, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes("hello "), Bytes("world")}},
+		{Time: now.Add(2 * time.Millisecond), Type: Receive, Proto: TCP, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes("bye")}},
+		{Time: now.Add(3 * time.Millisecond), Type: Shutdown | ShutRD | ShutWR, Proto: TCP, FD: 3, Addr: addr, Peer: peer},
+		{Time: now.Add(4 * time.Millisecond), Type: Send, Proto: UDP, FD: 4, Peer: dns, Data: []Bytes{Bytes("query")}},
+		{Time: now.Add(5 * time.Millisecond), Type: Lookup, Proto: TCP, Lookup: &AddressLookup{Name: "localhost"}},
+	}
+
+	b := new(bytes.Buffer)
+	w := NewPcapWriter(b)
+	n, err := stream.Copy[Event](w, stream.NewReader(events...))
+	assert.OK(t, err)
+	assert.Equal(t, n, int64(len(events)))
+	assert.OK(t, w.Close())
+
+	packets := pcapReadPackets(t, b.Bytes())
+	assert.Equal(t, len(packets), 10)
+
+	local := netip.MustParseAddrPort("127.0.0.1:49152")
+	remote := netip.MustParseAddrPort("127.0.0.1:8080")
+
+	// Three-way handshake.
+	syn, synAck, ack := packets[0], packets[1], packets[2]
+	assert.Equal(t, syn.src, local)
+	assert.Equal(t, syn.dst, remote)
+	assert.Equal(t, syn.flags, tcpFlagSYN)
+	assert.Equal(t, synAck.src, remote)
+	assert.Equal(t, synAck.flags, tcpFlagSYN|tcpFlagACK)
+	assert.Equal(t, synAck.ack, syn.seq+1)
+	assert.Equal(t, ack.flags, tcpFlagACK)
+	assert.Equal(t, ack.seq, syn.seq+1)
+	assert.Equal(t, ack.ack, synAck.seq+1)
+
+	// Payloads, one packet per iovec.
+	assert.Equal(t, packets[3].payload, "hello ")
+	assert.Equal(t, packets[4].payload, "world")
+	assert.Equal(t, packets[4].seq, packets[3].seq+6)
+	assert.Equal(t, packets[5].payload, "bye")
+	assert.Equal(t, packets[5].src, remote)
+	assert.Equal(t, packets[5].ack, packets[4].seq+5)
+	assert.Equal(t, packets[5].time, now.Add(2*time.Millisecond))
+
+	// Teardown.
+	assert.Equal(t, packets[6].flags, tcpFlagFIN|tcpFlagACK)
+	assert.Equal(t, packets[6].src, local)
+	assert.Equal(t, packets[7].flags, tcpFlagFIN|tcpFlagACK)
+	assert.Equal(t, packets[7].src, remote)
+	assert.Equal(t, packets[8].flags, tcpFlagACK)
+	assert.Equal(t, packets[8].ack, packets[7].seq+1)
+
+	// UDP datagram sent from an unbound socket.
+	assert.Equal(t, packets[9].proto, ipProtocolUDP)
+	assert.Equal(t, packets[9].dst, netip.MustParseAddrPort("10.0.0.1:53"))
+	assert.Equal(t, packets[9].payload, "query")
+}
@@ -0,0 +1,878 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/wasi-go"
+)
+
+// Postgres is the implementation of the PostgreSQL frontend/backend protocol
+// (version 3.0).
+//
+// Requests are made of the frontend messages sent up to a synchronization
+// point (a simple query, or a Sync message terminating an extended query), and
+// responses of the backend messages sent up to the next ReadyForQuery message.
+func Postgres() ConnProtocol { return postgresProtocol{} }
+
+type postgresProtocol struct{}
+
+func (postgresProtocol) Name() string { return "Postgres" }
+
+func (postgresProtocol) CanHandle(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(data[0:])
+	code := binary.BigEndian.Uint32(data[4:])
+	switch code {
+	case postgresSSLRequestCode, postgresGSSENCRequestCode:
+		return length == 8
+	case postgresCancelRequestCode:
+		return length == 16
+	case postgresProtocolVersion:
+		return length >= 8 && length <= postgresMaxStartupLength
+	}
+	return false
+}
+
+func (postgresProtocol) NewClient(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newPostgresConn(fd, addr, peer)
+	conn.req = &conn.send
+	conn.res = &conn.recv
+	conn.req.startup = true
+	return conn
+}
+
+func (postgresProtocol) NewServer(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newPostgresConn(fd, peer, addr)
+	conn.req = &conn.recv
+	conn.res = &conn.send
+	conn.req.startup = true
+	return conn
+}
+
+const (
+	postgresProtocolVersion   = 3 << 16
+	postgresCancelRequestCode = 80877102
+	postgresSSLRequestCode    = 80877103
+	postgresGSSENCRequestCode = 80877104
+	// Startup messages are made of a handful of parameters, this limit is
+	// only intended to prevent false positives when detecting the protocol.
+	postgresMaxStartupLength = 10000
+)
+
+func newPostgresConn(fd wasi.FD, addr1, addr2 net.Addr) *postgresConn {
+	return &postgresConn{
+		addr1:      addr1,
+		addr2:      addr2,
+		reqID:      int64(fd) << 32,
+		resID:      int64(fd) << 32,
+		statements: make(map[string]string),
+		portals:    make(map[string]string),
+	}
+}
+
+type postgresConn struct {
+	addr1 net.Addr
+	addr2 net.Addr
+	flag  uint
+	recv  postgresParser
+	send  postgresParser
+	req   *postgresParser
+	res   *postgresParser
+	reqID int64
+	resID int64
+	// Set when the client and server negotiated an encrypted session, the
+	// rest of the connection cannot be decoded.
+	encrypted bool
+	// Prepared statements and portals created by the client, used to
+	// resolve the SQL statements executed during extended queries.
+	statements map[string]string
+	portals    map[string]string
+	frontend   []postgresMessage
+	backend    []postgresMessage
+	msgs       []Message
+}
+
+func (c *postgresConn) Protocol() ConnProtocol {
+	return postgresProtocol{}
+}
+
+func (c *postgresConn) Done() bool {
+	const shutdown = ShutRD | ShutWR
+	return (c.flag & shutdown) == shutdown
+}
+
+func (c *postgresConn) Observe(e *Event) {
+	switch e.Type.Type() {
+	case Receive:
+		c.write(&c.recv, e.Time, e.Data)
+	case Send:
+		c.write(&c.send, e.Time, e.Data)
+	case Shutdown:
+		c.flag |= e.Type.Flag()
+		if c.Done() {
+			c.flush(e.Time)
+		}
+	}
+}
+
+func (c *postgresConn) Next(msg *Message) bool {
+	if len(c.msgs) == 0 {
+		return false
+	}
+	*msg = c.msgs[0]
+	c.msgs = c.msgs[:copy(c.msgs, c.msgs[1:])]
+	return true
+}
+
+func (c *postgresConn) write(p *postgresParser, now time.Time, data []Bytes) {
+	if c.encrypted {
+		return
+	}
+	p.buffer.write(now, data)
+
+	for !c.encrypted {
+		m, ok := p.read()
+		if !ok {
+			return
+		}
+		if p == c.req {
+			c.observeFrontend(m)
+		} else {
+			c.observeBackend(m)
+		}
+	}
+}
+
+func (c *postgresConn) observeFrontend(m postgresMessage) {
+	switch m.typ {
+	case postgresStartup:
+		switch binary.BigEndian.Uint32(m.data) {
+		case postgresSSLRequestCode, postgresGSSENCRequestCode:
+			// The server responds with a single byte indicating whether
+			// it accepts to encrypt the session, and the client sends a
+			// new startup message if it does not.
+			c.res.negotiation = true
+			c.req.startup = true
+		case postgresProtocolVersion:
+			c.frontend = append(c.frontend, m)
+			c.nextRequest()
+		}
+	case 'Q', 'S', 'F':
+		c.frontend = append(c.frontend, m)
+		c.nextRequest()
+	case 'P', 'B', 'E', 'D', 'C', 'H':
+		c.frontend = append(c.frontend, m)
+	default:
+		// Passwords, copy data and terminate messages are not part of the
+		// requests. Dropping the password messages also ensures that the
+		// credentials do not end up in the traces.
+	}
+}
+
+func (c *postgresConn) observeBackend(m postgresMessage) {
+	if m.typ == postgresNegotiation {
+		switch m.data[0] {
+		case 'S', 'G':
+			c.encrypted = true
+		}
+		return
+	}
+	c.backend = append(c.backend, m)
+	if m.typ == 'Z' {
+		c.nextResponse(m.end, nil)
+	}
+}
+
+func (c *postgresConn) nextRequest() {
+	msgs := c.frontend
+	c.frontend = nil
+
+	first, last := &msgs[0], &msgs[len(msgs)-1]
+	c.reqID++
+	c.msgs = append(c.msgs, Message{
+		Link: Link{Src: c.addr1, Dst: c.addr2},
+		Time: first.start,
+		Span: last.end.Sub(first.start),
+		id:   c.reqID,
+		msg: &postgresRequest{
+			conn:       c,
+			msgs:       msgs,
+			statements: c.resolveStatements(msgs),
+		},
+	})
+}
+
+func (c *postgresConn) nextResponse(now time.Time, err error) {
+	msgs := c.backend
+	c.backend = nil
+
+	start, end := now, now
+	if len(msgs) > 0 {
+		start, end = msgs[0].start, msgs[len(msgs)-1].end
+	}
+	c.resID++
+	c.msgs = append(c.msgs, Message{
+		Link: Link{Src: c.addr2, Dst: c.addr1},
+		Time: start,
+		Span: end.Sub(start),
+		Err:  err,
+		id:   c.resID,
+		msg: &postgresResponse{
+			conn: c,
+			msgs: msgs,
+		},
+	})
+}
+
+func (c *postgresConn) flush(now time.Time) {
+	if len(c.frontend) > 0 {
+		c.nextRequest()
+	}
+	for c.resID < c.reqID {
+		err := c.res.err
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		c.nextResponse(now, err)
+	}
+}
+
+// resolveStatements returns the SQL statements executed by the list of
+// frontend messages, updating the state of prepared statements and portals
+// of the connection.
+func (c *postgresConn) resolveStatements(msgs []postgresMessage) []postgresStatement {
+	var statements []postgresStatement
+
+	for _, m := range msgs {
+		switch m.typ {
+		case 'Q':
+			query, _ := postgresCString(m.data)
+			statements = append(statements, postgresStatement{
+				sql:    query,
+				time:   m.start,
+				simple: true,
+			})
+		case 'P':
+			name, b := postgresCString(m.data)
+			query, _ := postgresCString(b)
+			c.statements[name] = query
+		case 'B':
+			portal, b := postgresCString(m.data)
+			name, _ := postgresCString(b)
+			c.portals[portal] = c.statements[name]
+		case 'E':
+			portal, _ := postgresCString(m.data)
+			statements = append(statements, postgresStatement{
+				sql:  c.portals[portal],
+				time: m.start,
+			})
+		case 'C':
+			if len(m.data) > 0 {
+				name, _ := postgresCString(m.data[1:])
+				switch m.data[0] {
+				case 'S':
+					delete(c.statements, name)
+				case 'P':
+					delete(c.portals, name)
+				}
+			}
+		}
+	}
+
+	return statements
+}
+
+const (
+	// Pseudo message types used for messages which do not start with a type
+	// byte in the protocol.
+	postgresStartup     = 0
+	postgresNegotiation = 1
+)
+
+type postgresMessage struct {
+	typ   byte
+	start time.Time
+	end   time.Time
+	data  []byte
+}
+
+type postgresParser struct {
+	buffer      buffer
+	startup     bool
+	negotiation bool
+	err         error
+}
+
+func (p *postgresParser) read() (m postgresMessage, ok bool) {
+	if p.err != nil {
+		return
+	}
+	b := p.buffer.bytes
+
+	switch {
+	case p.negotiation:
+		if len(b) < 1 {
+			return
+		}
+		m.typ = postgresNegotiation
+		m.start, m.end, m.data = p.buffer.slice(1)
+		p.negotiation = false
+		return m, true
+
+	case p.startup:
+		if len(b) < 4 {
+			return
+		}
+		length := int(binary.BigEndian.Uint32(b))
+		if length < 8 || length > postgresMaxStartupLength {
+			p.err = fmt.Errorf("malformed postgres startup message: invalid length: %d", length)
+			return
+		}
+		if len(b) < length {
+			return
+		}
+		m.typ = postgresStartup
+		m.start, m.end, m.data = p.buffer.slice(length)
+		m.data = m.data[4:]
+		p.startup = false
+		return m, true
+
+	default:
+		if len(b) < 5 {
+			return
+		}
+		length := int(binary.BigEndian.Uint32(b[1:]))
+		if length < 4 {
+			p.err = fmt.Errorf("malformed postgres message: invalid length: %d", length)
+			return
+		}
+		if len(b) < 1+length {
+			return
+		}
+		m.start, m.end, m.data = p.buffer.slice(1 + length)
+		m.typ, m.data = m.data[0], m.data[5:]
+		return m, true
+	}
+}
+
+type postgresStatement struct {
+	sql    string
+	time   time.Time
+	simple bool
+}
+
+// postgresResult is the result of executing a statement, reconstructed from
+// the backend messages of a response.
+type postgresResult struct {
+	Columns []string       `json:"columns,omitempty" yaml:"columns,omitempty"`
+	Rows    [][]*string    `json:"rows,omitempty"    yaml:"rows,omitempty"`
+	Command string         `json:"command,omitempty" yaml:"command,omitempty"`
+	Error   *postgresError `json:"error,omitempty"   yaml:"error,omitempty"`
+
+	time  time.Time
+	count int64
+}
+
+func postgresResults(msgs []postgresMessage) []postgresResult {
+	var results []postgresResult
+	var result postgresResult
+
+	complete := func(m *postgresMessage) {
+		result.time = m.end
+		results = append(results, result)
+		result = postgresResult{}
+	}
+
+	for i := range msgs {
+		m := &msgs[i]
+		switch m.typ {
+		case 'T':
+			result.Columns = postgresRowDescription(m.data)
+		case 'D':
+			result.Rows = append(result.Rows, postgresDataRow(m.data))
+		case 'C':
+			result.Command, _ = postgresCString(m.data)
+			result.count = postgresRowCount(result.Command, len(result.Rows))
+			complete(m)
+		case 's', 'I':
+			result.count = int64(len(result.Rows))
+			complete(m)
+		case 'E':
+			result.Error = postgresParseError(m.data)
+			complete(m)
+		}
+	}
+
+	return results
+}
+
+func postgresRowCount(tag string, rows int) int64 {
+	// Command tags like "SELECT 3" or "INSERT 0 1" end with the number of rows
+	// that were affected by the statement.
+	if i := strings.LastIndexByte(tag, ' '); i >= 0 {
+		if n, err := strconv.ParseInt(tag[i+1:], 10, 64); err == nil {
+			return n
+		}
+	}
+	return int64(rows)
+}
+
+func postgresRowDescription(b []byte) []string {
+	if len(b) < 2 {
+		return nil
+	}
+	numFields := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	columns := make([]string, 0, numFields)
+	for i := 0; i < numFields && len(b) > 0; i++ {
+		var name string
+		name, b = postgresCString(b)
+		columns = append(columns, name)
+		// table oid, column attribute number, type oid, type size, type
+		// modifier, format code
+		if len(b) < 18 {
+			break
+		}
+		b = b[18:]
+	}
+	return columns
+}
+
+func postgresDataRow(b []byte) []*string {
+	if len(b) < 2 {
+		return nil
+	}
+	numValues := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	values := make([]*string, 0, numValues)
+	for i := 0; i < numValues && len(b) >= 4; i++ {
+		length := int32(binary.BigEndian.Uint32(b))
+		b = b[4:]
+		if length < 0 {
+			values = append(values, nil)
+			continue
+		}
+		if int(length) > len(b) {
+			break
+		}
+		value := postgresValue(b[:length])
+		values = append(values, &value)
+		b = b[length:]
+	}
+	return values
+}
+
+func postgresValue(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	// Values in binary format are represented the same way that postgres
+	// prints bytea values.
+	return `\x` + hex.EncodeToString(b)
+}
+
+type postgresError struct {
+	Severity string `json:"severity"         yaml:"severity"`
+	Code     string `json:"code"             yaml:"code"`
+	Message  string `json:"message"          yaml:"message"`
+	Detail   string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Hint     string `json:"hint,omitempty"   yaml:"hint,omitempty"`
+}
+
+func (e *postgresError) Error() string {
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.Severity, e.Message, e.Code)
+}
+
+func postgresParseError(b []byte) *postgresError {
+	e := new(postgresError)
+	for len(b) > 0 && b[0] != 0 {
+		field := b[0]
+		value, next := postgresCString(b[1:])
+		b = next
+		switch field {
+		case 'S':
+			if e.Severity == "" {
+				e.Severity = value
+			}
+		case 'V':
+			e.Severity = value
+		case 'C':
+			e.Code = value
+		case 'M':
+			e.Message = value
+		case 'D':
+			e.Detail = value
+		case 'H':
+			e.Hint = value
+		}
+	}
+	return e
+}
+
+func postgresCString(b []byte) (s string, next []byte) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return string(b), nil
+	}
+	return string(b[:i]), b[i+1:]
+}
+
+func postgresStartupParameters(b []byte) map[string]string {
+	params := make(map[string]string)
+	if len(b) >= 4 {
+		b = b[4:] // protocol version
+	}
+	for len(b) > 0 && b[0] != 0 {
+		var name, value string
+		name, b = postgresCString(b)
+		value, b = postgresCString(b)
+		params[name] = value
+	}
+	return params
+}
+
+var (
+	postgresFrontendMessages = map[byte]string{
+		postgresStartup: "StartupMessage",
+		'Q':             "Query",
+		'P':             "Parse",
+		'B':             "Bind",
+		'E':             "Execute",
+		'D':             "Describe",
+		'C':             "Close",
+		'S':             "Sync",
+		'H':             "Flush",
+		'F':             "FunctionCall",
+	}
+
+	postgresBackendMessages = map[byte]string{
+		'R': "Authentication",
+		'S': "ParameterStatus",
+		'K': "BackendKeyData",
+		'Z': "ReadyForQuery",
+		'T': "RowDescription",
+		'D': "DataRow",
+		'C': "CommandComplete",
+		'E': "ErrorResponse",
+		'N': "NoticeResponse",
+		'1': "ParseComplete",
+		'2': "BindComplete",
+		'3': "CloseComplete",
+		'n': "NoData",
+		'I': "EmptyQueryResponse",
+		's': "PortalSuspended",
+		't': "ParameterDescription",
+		'G': "CopyInResponse",
+		'H': "CopyOutResponse",
+		'W': "CopyBothResponse",
+		'd': "CopyData",
+		'c': "CopyDone",
+		'A': "NotificationResponse",
+		'v': "NegotiateProtocolVersion",
+		'V': "FunctionCallResponse",
+	}
+)
+
+func postgresMessageName(names map[byte]string, typ byte) string {
+	if name, ok := names[typ]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%q)", typ)
+}
+
+func postgresFrontendDetail(m *postgresMessage) string {
+	switch m.typ {
+	case postgresStartup:
+		params := postgresStartupParameters(m.data)
+		return fmt.Sprintf("user=%s database=%s", params["user"], params["database"])
+	case 'Q':
+		query, _ := postgresCString(m.data)
+		return query
+	case 'P':
+		name, b := postgresCString(m.data)
+		query, _ := postgresCString(b)
+		if name != "" {
+			return fmt.Sprintf("[%s] %s", name, query)
+		}
+		return query
+	case 'B':
+		portal, b := postgresCString(m.data)
+		name, _ := postgresCString(b)
+		return fmt.Sprintf("portal=%q statement=%q", portal, name)
+	case 'E':
+		portal, _ := postgresCString(m.data)
+		return fmt.Sprintf("portal=%q", portal)
+	case 'D', 'C':
+		if len(m.data) > 0 {
+			name, _ := postgresCString(m.data[1:])
+			return fmt.Sprintf("%c %q", m.data[0], name)
+		}
+	}
+	return ""
+}
+
+func postgresBackendDetail(m *postgresMessage) string {
+	switch m.typ {
+	case 'R':
+		if len(m.data) >= 4 {
+			switch binary.BigEndian.Uint32(m.data) {
+			case 0:
+				return "ok"
+			case 3:
+				return "cleartext password"
+			case 5:
+				return "md5 password"
+			case 10:
+				return "sasl"
+			}
+		}
+	case 'S':
+		name, b := postgresCString(m.data)
+		value, _ := postgresCString(b)
+		return name + "=" + value
+	case 'Z':
+		if len(m.data) > 0 {
+			switch m.data[0] {
+			case 'I':
+				return "idle"
+			case 'T':
+				return "in transaction"
+			case 'E':
+				return "failed transaction"
+			}
+		}
+	case 'T':
+		return strings.Join(postgresRowDescription(m.data), ", ")
+	case 'D':
+		values := postgresDataRow(m.data)
+		strs := make([]string, len(values))
+		for i, v := range values {
+			if v == nil {
+				strs[i] = "NULL"
+			} else {
+				strs[i] = *v
+			}
+		}
+		return strings.Join(strs, ", ")
+	case 'C':
+		tag, _ := postgresCString(m.data)
+		return tag
+	case 'E', 'N':
+		return postgresParseError(m.data).Error()
+	}
+	return ""
+}
+
+func postgresFormatMessages(state fmt.State, prefix []byte, msgs []postgresMessage, name func(byte) string, detail func(*postgresMessage) string) {
+	w := textprint.NewPrefixWriter(state, prefix)
+	for i := range msgs {
+		m := &msgs[i]
+		if d := detail(m); d != "" {
+			fmt.Fprintf(w, "%s: %s\n", name(m.typ), d)
+		} else {
+			fmt.Fprintf(w, "%s\n", name(m.typ))
+		}
+	}
+}
+
+type postgresMessageSummary struct {
+	Type   string `json:"type"             yaml:"type"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+type postgresRequest struct {
+	conn       *postgresConn
+	msgs       []postgresMessage
+	statements []postgresStatement
+}
+
+func (req *postgresRequest) Conn() Conn { return req.conn }
+
+func (req *postgresRequest) name(typ byte) string {
+	return postgresMessageName(postgresFrontendMessages, typ)
+}
+
+func (req *postgresRequest) Format(w fmt.State, v rune) {
+	if w.Flag('+') {
+		postgresFormatMessages(w, http1RequestFormatPrefix, req.msgs, req.name, postgresFrontendDetail)
+		return
+	}
+	switch {
+	case len(req.statements) > 0:
+		for i, stmt := range req.statements {
+			if i != 0 {
+				fmt.Fprint(w, "; ")
+			}
+			fmt.Fprint(w, postgresCompactSQL(stmt.sql))
+		}
+	case req.msgs[0].typ == postgresStartup:
+		fmt.Fprintf(w, "%s %s", req.name(postgresStartup), postgresFrontendDetail(&req.msgs[0]))
+	default:
+		for i := range req.msgs {
+			if i != 0 {
+				fmt.Fprint(w, ", ")
+			}
+			fmt.Fprint(w, req.name(req.msgs[i].typ))
+		}
+	}
+}
+
+func (req *postgresRequest) Marshal() any {
+	r := &postgresRequestMarshal{
+		Messages: make([]postgresMessageSummary, len(req.msgs)),
+	}
+	for i := range req.msgs {
+		m := &req.msgs[i]
+		if m.typ == postgresStartup {
+			r.Startup = postgresStartupParameters(m.data)
+		}
+		r.Messages[i] = postgresMessageSummary{
+			Type:   req.name(m.typ),
+			Detail: postgresFrontendDetail(m),
+		}
+	}
+	for _, stmt := range req.statements {
+		r.Statements = append(r.Statements, stmt.sql)
+	}
+	return r
+}
+
+type postgresRequestMarshal struct {
+	Startup    map[string]string        `json:"startup,omitempty"    yaml:"startup,omitempty"`
+	Statements []string                 `json:"statements,omitempty" yaml:"statements,omitempty"`
+	Messages   []postgresMessageSummary `json:"messages"             yaml:"messages"`
+}
+
+type postgresResponse struct {
+	conn *postgresConn
+	msgs []postgresMessage
+}
+
+func (res *postgresResponse) Conn() Conn { return res.conn }
+
+func (res *postgresResponse) name(typ byte) string {
+	return postgresMessageName(postgresBackendMessages, typ)
+}
+
+func (res *postgresResponse) Format(w fmt.State, v rune) {
+	if w.Flag('+') {
+		postgresFormatMessages(w, http1ResponseFormatPrefix, res.msgs, res.name, postgresBackendDetail)
+		return
+	}
+	results := postgresResults(res.msgs)
+	if len(results) == 0 {
+		if len(res.msgs) == 0 {
+			fmt.Fprint(w, "(no response)")
+		} else {
+			fmt.Fprint(w, res.name(res.msgs[len(res.msgs)-1].typ))
+		}
+		return
+	}
+	for i, r := range results {
+		if i != 0 {
+			fmt.Fprint(w, ", ")
+		}
+		if r.Error != nil {
+			fmt.Fprint(w, r.Error.Error())
+		} else {
+			fmt.Fprint(w, r.Command)
+		}
+	}
+}
+
+func (res *postgresResponse) Marshal() any {
+	r := &postgresResponseMarshal{
+		Results:  postgresResults(res.msgs),
+		Messages: make([]postgresMessageSummary, len(res.msgs)),
+	}
+	for i := range res.msgs {
+		m := &res.msgs[i]
+		r.Messages[i] = postgresMessageSummary{
+			Type:   res.name(m.typ),
+			Detail: postgresBackendDetail(m),
+		}
+	}
+	return r
+}
+
+type postgresResponseMarshal struct {
+	Results  []postgresResult         `json:"results,omitempty" yaml:"results,omitempty"`
+	Messages []postgresMessageSummary `json:"messages"          yaml:"messages"`
+}
+
+// postgresQueries converts an exchange of postgres messages into the list of
+// queries that it executed.
+func postgresQueries(queries []Query, e *Exchange, req *postgresRequest) []Query {
+	var results []postgresResult
+	if res, ok := e.Res.msg.(*postgresResponse); ok {
+		results = postgresResults(res.msgs)
+	}
+
+	last := e.Req.Time
+	for i, stmt := range req.statements {
+		q := Query{
+			Link:      e.Link,
+			Time:      stmt.time,
+			Statement: stmt.sql,
+			Err:       e.Req.Err,
+			proto:     req.conn.Protocol(),
+		}
+		if q.Time.Before(last) {
+			// The server executes statements sequentially, execution of a
+			// statement starts after the previous one completed.
+			q.Time = last
+		}
+
+		n := 0
+		switch {
+		case i >= len(results):
+		case stmt.simple && i == len(req.statements)-1:
+			// Simple queries may contain multiple statements separated by
+			// semicolons, the results are all attributed to the query.
+			n = len(results) - i
+		default:
+			n = 1
+		}
+
+		if n == 0 {
+			if e.Res.Err == nil {
+				// When a statement fails during an extended query, the
+				// server skips the remaining messages until Sync and the
+				// next statements are not executed.
+				continue
+			}
+			q.Err = e.Res.Err
+			q.Span = e.Req.Time.Add(e.Res.Time + e.Res.Span).Sub(q.Time)
+		} else {
+			for _, r := range results[i : i+n] {
+				q.Command = r.Command
+				q.Rows += r.count
+				if r.Error != nil && q.Err == nil {
+					q.Err = r.Error
+				}
+				last = r.time
+			}
+			q.Span = last.Sub(q.Time)
+		}
+
+		queries = append(queries, q)
+	}
+
+	return queries
+}
+
+func postgresCompactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,206 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+func postgresAppendMessage(b []byte, typ byte, fields ...any) []byte {
+	if typ != postgresStartup {
+		b = append(b, typ)
+	}
+	i := len(b)
+	b = append(b, 0, 0, 0, 0)
+	for _, f := range fields {
+		switch v := f.(type) {
+		case string:
+			b = append(b, v...)
+			b = append(b, 0)
+		case byte:
+			b = append(b, v)
+		case int16:
+			b = binary.BigEndian.AppendUint16(b, uint16(v))
+		case int32:
+			b = binary.BigEndian.AppendUint32(b, uint32(v))
+		case []byte:
+			b = append(b, v...)
+		}
+	}
+	binary.BigEndian.PutUint32(b[i:], uint32(len(b)-i))
+	return b
+}
+
+func postgresColumn(name string) []any {
+	return []any{name, int32(0), int16(0), int32(23), int16(4), int32(-1), int16(0)}
+}
+
+func postgresRow(values ...any) []any {
+	fields := []any{int16(len(values))}
+	for _, v := range values {
+		if v == nil {
+			fields = append(fields, int32(-1))
+		} else {
+			s := v.(string)
+			fields = append(fields, int32(len(s)), []byte(s))
+		}
+	}
+	return fields
+}
+
+func TestPostgresCanHandle(t *testing.T) {
+	proto := Postgres()
+	startup := postgresAppendMessage(nil, postgresStartup, int32(postgresProtocolVersion), "user", "postgres", "")
+	assert.True(t, proto.CanHandle(startup))
+	assert.True(t, proto.CanHandle(postgresAppendMessage(nil, postgresStartup, int32(postgresSSLRequestCode))))
+	assert.False(t, proto.CanHandle(startup[:4]))
+	assert.False(t, proto.CanHandle([]byte("GET / HTTP/1.1\r\n")))
+}
+
+func TestPostgresQueries(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5432}
+	now := time.Now()
+
+	events := []Event{{
+		Time: now,
+		Type: Connect,
+		FD:   3,
+		Addr: addr,
+		Peer: peer,
+	}}
+	send := func(data []byte) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{data}})
+	}
+	recv := func(data []byte) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{data}})
+	}
+
+	// The session starts with a refused SSL request.
+	send(postgresAppendMessage(nil, postgresStartup, int32(postgresSSLRequestCode)))
+	recv([]byte("N"))
+
+	send(postgresAppendMessage(nil, postgresStartup, int32(postgresProtocolVersion),
+		"user", "postgres", "database", "test", ""))
+	recv(postgresAppendMessage(nil, 'R', int32(5), []byte("salt")))
+	send(postgresAppendMessage(nil, 'p', "md5secret"))
+
+	b := postgresAppendMessage(nil, 'R', int32(0))
+	b = postgresAppendMessage(b, 'S', "server_version", "16.0")
+	b = postgresAppendMessage(b, 'K', int32(42), int32(1234))
+	b = postgresAppendMessage(b, 'Z', byte('I'))
+	recv(b)
+
+	// Simple query, the response is split across events.
+	send(postgresAppendMessage(nil, 'Q', "SELECT id, name\n  FROM users"))
+	b = postgresAppendMessage(nil, 'T', append(append([]any{int16(2)}, postgresColumn("id")...), postgresColumn("name")...)...)
+	b = postgresAppendMessage(b, 'D', postgresRow("1", "alice")...)
+	b = postgresAppendMessage(b, 'D', postgresRow("2", nil)...)
+	b = postgresAppendMessage(b, 'C', "SELECT 2")
+	b = postgresAppendMessage(b, 'Z', byte('I'))
+	recv(b[:10])
+	recv(b[10:])
+
+	// Extended query where the second statement fails, and the third one is
+	// skipped by the server.
+	b = postgresAppendMessage(nil, 'P', "s1", "INSERT INTO t VALUES ($1)", int16(0))
+	b = postgresAppendMessage(b, 'B', "", "s1", int16(0), int16(1), int32(1), []byte("1"), int16(0))
+	b = postgresAppendMessage(b, 'E', "", int32(0))
+	b = postgresAppendMessage(b, 'P', "", "SELECT 1/0", int16(0))
+	b = postgresAppendMessage(b, 'B', "", "", int16(0), int16(0), int16(0))
+	b = postgresAppendMessage(b, 'E', "", int32(0))
+	b = postgresAppendMessage(b, 'B', "", "s1", int16(0), int16(1), int32(1), []byte("2"), int16(0))
+	b = postgresAppendMessage(b, 'E', "", int32(0))
+	b = postgresAppendMessage(b, 'S')
+	send(b)
+
+	b = postgresAppendMessage(nil, '1')
+	b = postgresAppendMessage(b, '2')
+	b = postgresAppendMessage(b, 'C', "INSERT 0 1")
+	b = postgresAppendMessage(b, '1')
+	b = postgresAppendMessage(b, '2')
+	recv(b)
+	b = postgresAppendMessage(nil, 'E', byte('S'), "ERROR", byte('C'), "22012", byte('M'), "division by zero", byte(0))
+	b = postgresAppendMessage(b, 'Z', byte('I'))
+	recv(b)
+
+	// Query left without a response when the connection is closed.
+	send(postgresAppendMessage(nil, 'Q', "SELECT pg_sleep(10)"))
+	events = append(events, Event{Time: now, Type: Shutdown | ShutRD | ShutWR, FD: 3, Addr: addr, Peer: peer})
+
+	exchanges, err := stream.ReadAll[Exchange](&ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1(), HTTP2(), Postgres()},
+		},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(exchanges), 4)
+
+	assert.Equal(t, fmt.Sprint(exchanges[0].Req), "StartupMessage user=postgres database=test")
+	assert.Equal(t, fmt.Sprint(exchanges[0].Res), "ReadyForQuery")
+	assert.Equal(t, fmt.Sprint(exchanges[1].Req), "SELECT id, name FROM users")
+	assert.Equal(t, fmt.Sprint(exchanges[1].Res), "SELECT 2")
+	assert.Equal(t, fmt.Sprintf("%+v", exchanges[1].Res), `< RowDescription: id, name
+< DataRow: 1, alice
+< DataRow: 2, NULL
+< CommandComplete: SELECT 2
+< ReadyForQuery: idle
+`)
+	assert.Equal(t, fmt.Sprint(exchanges[2].Req),
+		"INSERT INTO t VALUES ($1); SELECT 1/0; INSERT INTO t VALUES ($1)")
+	assert.Equal(t, fmt.Sprint(exchanges[2].Res),
+		"INSERT 0 1, ERROR: division by zero (SQLSTATE 22012)")
+	assert.Error(t, exchanges[3].Res.Err, io.ErrUnexpectedEOF)
+
+	assert.DeepEqual(t, exchanges[1].Res.msg.Marshal().(*postgresResponseMarshal).Results, []postgresResult{{
+		Columns: []string{"id", "name"},
+		Rows:    [][]*string{{ptr("1"), ptr("alice")}, {ptr("2"), nil}},
+		Command: "SELECT 2",
+		time:    exchanges[1].Req.Time.Add(2 * time.Millisecond),
+		count:   2,
+	}})
+
+	// The password sent by the client must not appear in the traces.
+	for _, e := range exchanges {
+		assert.False(t, strings.Contains(fmt.Sprintf("%+v", e), "md5secret"))
+	}
+
+	queries, err := stream.ReadAll[Query](&QueryReader{
+		Exchanges: stream.NewReader(exchanges...),
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(queries), 4)
+
+	assert.Equal(t, queries[0].Statement, "SELECT id, name\n  FROM users")
+	assert.Equal(t, queries[0].Command, "SELECT 2")
+	assert.Equal(t, queries[0].Rows, int64(2))
+	assert.Equal(t, queries[0].Span, 2*time.Millisecond)
+	assert.OK(t, queries[0].Err)
+	assert.Equal(t, fmt.Sprint(queries[0])[27:],
+		"Postgres 127.0.0.1:49152 > 127.0.0.1:5432: SELECT id, name FROM users => SELECT 2 (2 rows) (2ms)")
+
+	assert.Equal(t, queries[1].Statement, "INSERT INTO t VALUES ($1)")
+	assert.Equal(t, queries[1].Command, "INSERT 0 1")
+	assert.Equal(t, queries[1].Rows, int64(1))
+	assert.Equal(t, queries[1].Span, time.Millisecond)
+
+	assert.Equal(t, queries[2].Statement, "SELECT 1/0")
+	assert.Equal(t, queries[2].Err.Error(), "ERROR: division by zero (SQLSTATE 22012)")
+	assert.Equal(t, queries[2].Time, queries[1].Time.Add(time.Millisecond))
+	assert.Equal(t, queries[2].Span, time.Millisecond)
+
+	assert.Equal(t, queries[3].Statement, "SELECT pg_sleep(10)")
+	assert.Error(t, queries[3].Err, io.ErrUnexpectedEOF)
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"gopkg.in/yaml.v3"
+)
+
+// Query values represent the execution of a statement by a database server.
+type Query struct {
+	Link Link
+	// Time at which the server started executing the statement, and the time
+	// it took to complete.
+	Time time.Time
+	Span time.Duration
+	// The statement executed, the command tag reported by the server, and
+	// the number of rows returned or affected by the statement.
+	Statement string
+	Command   string
+	Rows      int64
+	Err       error
+
+	proto ConnProtocol
+}
+
+func (q Query) Format(w fmt.State, v rune) {
+	fmt.Fprintf(w, "%s %s %s > %s",
+		formatTime(q.Time),
+		q.proto.Name(),
+		socketAddressString(q.Link.Src),
+		socketAddressString(q.Link.Dst))
+
+	if w.Flag('+') {
+		fmt.Fprintf(w, " (%s)\n", q.Span)
+		fmt.Fprintf(textprint.NewPrefixWriter(w, http1RequestFormatPrefix), "%s\n", q.Statement)
+		fmt.Fprintf(textprint.NewPrefixWriter(w, http1ResponseFormatPrefix), "%s\n", q.outcome())
+	} else {
+		fmt.Fprintf(w, ": %s => %s (%s)", postgresCompactSQL(q.Statement), q.outcome(), q.Span)
+	}
+}
+
+func (q *Query) outcome() string {
+	if q.Err != nil {
+		return q.Err.Error()
+	}
+	rows := "rows"
+	if q.Rows == 1 {
+		rows = "row"
+	}
+	return fmt.Sprintf("%s (%d %s)", q.Command, q.Rows, rows)
+}
+
+func (q Query) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.marshal())
+}
+
+func (q Query) MarshalYAML() (any, error) {
+	return q.marshal(), nil
+}
+
+func (q *Query) marshal() *query {
+	return &query{
+		Link:      q.Link,
+		Time:      q.Time,
+		Span:      q.Span,
+		Statement: q.Statement,
+		Command:   q.Command,
+		Rows:      q.Rows,
+		Err:       errorString(q.Err),
+	}
+}
+
+type query struct {
+	Link      Link          `json:"link"              yaml:"link"`
+	Time      time.Time     `json:"time"              yaml:"time"`
+	Span      time.Duration `json:"span"              yaml:"span"`
+	Statement string        `json:"statement"         yaml:"statement"`
+	Command   string        `json:"command,omitempty" yaml:"command,omitempty"`
+	Rows      int64         `json:"rows"              yaml:"rows"`
+	Err       string        `json:"error,omitempty"   yaml:"error,omitempty"`
+}
+
+var (
+	_ fmt.Formatter  = Query{}
+	_ json.Marshaler = Query{}
+	_ yaml.Marshaler = Query{}
+)
+
+// QueryReader is a reader of Query values. Instances of QueryReader consume
+// exchanges from a reader of Exchange values and extract the statements
+// executed by database servers. Exchanges of protocols other than database
+// protocols are ignored.
+type QueryReader struct {
+	Exchanges stream.Reader[Exchange]
+
+	exchanges []Exchange
+	queries   []Query
+	offset    int
+}
+
+func (r *QueryReader) Read(queries []Query) (n int, err error) {
+	if len(r.exchanges) == 0 {
+		r.exchanges = make([]Exchange, 1000)
+	}
+
+	for {
+		if r.offset < len(r.queries) {
+			n = copy(queries, r.queries[r.offset:])
+			if r.offset += n; r.offset == len(r.queries) {
+				r.offset, r.queries = 0, r.queries[:0]
+			}
+			return n, nil
+		}
+
+		numExchanges, err := stream.ReadFull(r.Exchanges, r.exchanges)
+		if numExchanges == 0 {
+			switch err {
+			case nil:
+				err = io.ErrNoProgress
+			case io.ErrUnexpectedEOF:
+				err = io.EOF
+			}
+			return 0, err
+		}
+
+		for i := range r.exchanges[:numExchanges] {
+			e := &r.exchanges[i]
+
+			switch req := e.Req.msg.(type) {
+			case *postgresRequest:
+				r.queries = postgresQueries(r.queries, e, req)
+			}
+		}
+	}
+}
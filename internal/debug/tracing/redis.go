@@ -0,0 +1,801 @@
+package tracing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/wasi-go"
+)
+
+// Redis is the implementation of the Redis serialization protocol (RESP2 and
+// RESP3).
+//
+// Commands are paired with the replies sent by the server in the order they
+// were sent, which supports pipelining. Messages pushed by the server to
+// clients subscribed to pub/sub channels are paired with the command which
+// created the subscription.
+func Redis() ConnProtocol { return redisProtocol{} }
+
+type redisProtocol struct{}
+
+func (redisProtocol) Name() string { return "Redis" }
+
+func (redisProtocol) CanHandle(data []byte) bool {
+	// Clients send commands as arrays of bulk strings, for example:
+	//
+	//	*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n
+	//
+	line, next, ok := redisSplitLine(data)
+	if !ok || len(line) < 2 || line[0] != '*' {
+		return false
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	return err == nil && n > 0 && len(next) > 0 && next[0] == '$'
+}
+
+func (redisProtocol) NewClient(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newRedisConn(fd, addr, peer)
+	conn.req = &conn.send
+	conn.res = &conn.recv
+	return conn
+}
+
+func (redisProtocol) NewServer(fd wasi.FD, addr, peer net.Addr) Conn {
+	conn := newRedisConn(fd, peer, addr)
+	conn.req = &conn.recv
+	conn.res = &conn.send
+	return conn
+}
+
+func newRedisConn(fd wasi.FD, addr1, addr2 net.Addr) *redisConn {
+	return &redisConn{
+		addr1:  addr1,
+		addr2:  addr2,
+		connID: int64(fd) << 32,
+	}
+}
+
+type redisConn struct {
+	addr1  net.Addr
+	addr2  net.Addr
+	flag   uint
+	recv   redisParser
+	send   redisParser
+	req    *redisParser
+	res    *redisParser
+	connID int64
+	lastID int64
+	// Commands waiting for a reply from the server, in the order they were
+	// sent.
+	pending []*redisCommand
+	// Channels and patterns that the client is subscribed to, used to pair
+	// messages pushed by the server with the subscription they were sent for.
+	subscriptions []redisSubscription
+	msgs          []Message
+}
+
+type redisSubscription struct {
+	// The command which created the subscription, SUBSCRIBE, PSUBSCRIBE, or
+	// SSUBSCRIBE.
+	name    string
+	channel string
+	cmd     *redisCommand
+}
+
+type redisCommand struct {
+	id    int64
+	msg   Message
+	reply []redisValue
+	start time.Time
+	// For commands of the subscribe family, the number of confirmations that
+	// the server is expected to send (one per channel).
+	confirmations int
+}
+
+func (c *redisConn) Protocol() ConnProtocol {
+	return redisProtocol{}
+}
+
+func (c *redisConn) Done() bool {
+	const shutdown = ShutRD | ShutWR
+	return (c.flag & shutdown) == shutdown
+}
+
+func (c *redisConn) Observe(e *Event) {
+	switch e.Type.Type() {
+	case Receive:
+		c.write(&c.recv, e.Time, e.Data)
+	case Send:
+		c.write(&c.send, e.Time, e.Data)
+	case Shutdown:
+		c.flag |= e.Type.Flag()
+		if c.Done() {
+			err := c.res.err
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			for len(c.pending) > 0 {
+				c.reply(e.Time, e.Time, err)
+			}
+		}
+	}
+}
+
+func (c *redisConn) Next(msg *Message) bool {
+	if len(c.msgs) == 0 {
+		return false
+	}
+	*msg = c.msgs[0]
+	c.msgs = c.msgs[:copy(c.msgs, c.msgs[1:])]
+	return true
+}
+
+func (c *redisConn) write(p *redisParser, now time.Time, data []Bytes) {
+	p.buffer.write(now, data)
+
+	for {
+		start, end, value, ok := p.read(p == c.req)
+		if !ok {
+			return
+		}
+		if p == c.req {
+			c.observeCommand(start, end, value)
+		} else {
+			c.observeReply(start, end, value)
+		}
+	}
+}
+
+func (c *redisConn) observeCommand(start, end time.Time, value redisValue) {
+	c.lastID++
+	cmd := &redisCommand{
+		id:    c.connID | c.lastID,
+		start: start,
+	}
+	cmd.msg = Message{
+		Link: Link{Src: c.addr1, Dst: c.addr2},
+		Time: start,
+		Span: end.Sub(start),
+		id:   cmd.id,
+		msg:  &redisRequest{conn: c, value: value},
+	}
+
+	switch name := strings.ToUpper(value.command()); name {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE":
+		cmd.confirmations = len(value.elems) - 1
+		if strings.Contains(name, "UNSUBSCRIBE") {
+			if cmd.confirmations == 0 {
+				// Unsubscribing from all channels, the server sends one
+				// confirmation per channel that the client is subscribed
+				// to, or a single one if there were none.
+				cmd.confirmations = max(c.countSubscriptions(strings.Replace(name, "UN", "", 1)), 1)
+			}
+			break
+		}
+		for _, arg := range value.elems[1:] {
+			c.removeSubscription(name, arg.str)
+			c.subscriptions = append(c.subscriptions, redisSubscription{
+				name:    name,
+				channel: arg.str,
+				cmd:     cmd,
+			})
+		}
+	}
+
+	c.pending = append(c.pending, cmd)
+	c.msgs = append(c.msgs, cmd.msg)
+}
+
+func (c *redisConn) observeReply(start, end time.Time, value redisValue) {
+	if c.isPush(value) {
+		c.push(start, end, value)
+		return
+	}
+	if len(c.pending) == 0 {
+		// Reply received without a command, there is not much we can do
+		// with it so it is dropped.
+		return
+	}
+
+	cmd := c.pending[0]
+	if cmd.reply == nil {
+		cmd.start = start
+	}
+	cmd.reply = append(cmd.reply, value)
+
+	switch kind := value.kind(); kind {
+	case "unsubscribe", "punsubscribe", "sunsubscribe":
+		c.unsubscribe(strings.ToUpper(strings.Replace(kind, "un", "", 1)), value)
+	}
+
+	if len(cmd.reply) < cmd.confirmations {
+		return
+	}
+
+	c.reply(cmd.start, end, nil)
+}
+
+// reply pairs the reply accumulated for the command at the head of the queue
+// of pending commands, and removes it from the queue.
+func (c *redisConn) reply(start, end time.Time, err error) {
+	cmd := c.pending[0]
+	c.pending = c.pending[:copy(c.pending, c.pending[1:])]
+
+	if cmd.reply == nil {
+		start = end
+	}
+	c.msgs = append(c.msgs, Message{
+		Link: Link{Src: c.addr2, Dst: c.addr1},
+		Time: start,
+		Span: end.Sub(start),
+		Err:  err,
+		id:   cmd.id,
+		msg:  &redisResponse{conn: c, values: cmd.reply},
+	})
+}
+
+// unsubscribe removes the subscription that the server confirmed was removed
+// by a command of the unsubscribe family. The name is the one of the command
+// which created the subscription.
+func (c *redisConn) unsubscribe(name string, value redisValue) {
+	if n, ok := value.subscriptionCount(); ok && n == 0 {
+		c.subscriptions = nil
+		return
+	}
+	if len(value.elems) < 2 {
+		return
+	}
+	c.removeSubscription(name, value.elems[1].str)
+}
+
+func (c *redisConn) removeSubscription(name, channel string) {
+	c.subscriptions = slices.DeleteFunc(c.subscriptions, func(sub redisSubscription) bool {
+		return sub.name == name && sub.channel == channel
+	})
+}
+
+func (c *redisConn) countSubscriptions(name string) (n int) {
+	for _, sub := range c.subscriptions {
+		if sub.name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *redisConn) isPush(value redisValue) bool {
+	switch value.typ {
+	case '>':
+		// In RESP3, the subscription confirmations are also sent as push
+		// values, but they are replies to the subscribe commands.
+		switch value.kind() {
+		case "subscribe", "psubscribe", "ssubscribe", "unsubscribe", "punsubscribe", "sunsubscribe":
+			return false
+		}
+		return true
+	case '*':
+		// In RESP2, pushed messages are arrays which can only be told apart
+		// from replies when the client has subscribed to channels.
+		if len(c.subscriptions) > 0 {
+			switch value.kind() {
+			case "message", "pmessage", "smessage":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *redisConn) push(start, end time.Time, value redisValue) {
+	// Pushed messages are paired with the command which subscribed to the
+	// channel or pattern, so they appear as responses to it.
+	var sub *redisCommand
+	if len(value.elems) > 1 {
+		channel := value.elems[1].str
+		for i := len(c.subscriptions) - 1; i >= 0; i-- {
+			if c.subscriptions[i].channel == channel {
+				sub = c.subscriptions[i].cmd
+				break
+			}
+		}
+	}
+	if sub == nil && len(c.subscriptions) > 0 {
+		sub = c.subscriptions[len(c.subscriptions)-1].cmd
+	}
+
+	c.lastID++
+	id := c.connID | c.lastID
+
+	req := Message{
+		Link: Link{Src: c.addr1, Dst: c.addr2},
+		Time: start,
+		id:   id,
+		msg:  &redisRequest{conn: c},
+	}
+	if sub != nil {
+		req = sub.msg
+		req.id = id
+	}
+
+	c.msgs = append(c.msgs, req, Message{
+		Link: Link{Src: c.addr2, Dst: c.addr1},
+		Time: start,
+		Span: end.Sub(start),
+		id:   id,
+		msg:  &redisResponse{conn: c, values: []redisValue{value}, push: true},
+	})
+}
+
+type redisParser struct {
+	buffer  buffer
+	scanner redisScanner
+	err     error
+}
+
+func (p *redisParser) read(commands bool) (start, end time.Time, value redisValue, ok bool) {
+	for p.err == nil && len(p.buffer.bytes) > 0 {
+		var n int
+		var err error
+
+		if commands && p.buffer.bytes[0] != '*' {
+			value, n, err = redisParseInline(p.buffer.bytes)
+		} else if n, err = p.scanner.scan(p.buffer.bytes); n > 0 {
+			// The value is only parsed once it is complete, which avoids
+			// decoding it again each time more data is received.
+			value, n, err = redisParse(p.buffer.bytes[:n])
+		}
+
+		switch {
+		case err != nil:
+			p.err = err
+		case n == 0:
+			return
+		default:
+			start, end, _ = p.buffer.slice(n)
+			switch value.typ {
+			case 0:
+				// Empty inline command.
+				continue
+			case '|':
+				// Attributes carry metadata about the value that follows,
+				// they are not part of the exchanges.
+				continue
+			}
+			return start, end, value, true
+		}
+	}
+	return
+}
+
+// redisValue is the representation of values of the RESP protocol.
+type redisValue struct {
+	typ   byte
+	str   string
+	null  bool
+	elems []redisValue
+}
+
+var errRedisMalformed = errors.New("malformed redis protocol message")
+
+// redisMaxLength is the maximum length of bulk strings and aggregates. It is
+// larger than what Redis servers accept by default, and guarantees that sizes
+// and counts can be used in arithmetic without overflowing.
+const redisMaxLength = math.MaxInt32
+
+// redisMinValueSize is the size of the smallest RESP values (e.g. "_\r\n").
+const redisMinValueSize = 3
+
+func redisParseLength(b []byte) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil || n < -1 || n > redisMaxLength {
+		return 0, errRedisMalformed
+	}
+	return n, nil
+}
+
+// redisScanner finds the end of the RESP value at the beginning of a buffer
+// which is filled incrementally. It retains its progress across calls to scan
+// so that the bytes of incomplete values are not scanned again each time more
+// data is received.
+type redisScanner struct {
+	// Offset of the next element to scan.
+	off int
+	// Offset where the search for the end of the next line resumes.
+	from int
+	// Number of elements remaining in each of the enclosing aggregates.
+	depth []int
+}
+
+// scan returns the size of the value at the beginning of b, or zero if b does
+// not contain a complete value yet. The buffer must retain the bytes passed to
+// previous calls until a value is returned.
+func (s *redisScanner) scan(b []byte) (int, error) {
+	for {
+		from := max(s.off, s.from)
+		i := bytes.Index(b[from:], []byte("\r\n"))
+		if i < 0 {
+			// The last byte may be the CR of the line ending.
+			s.from = max(s.off, len(b)-1)
+			return 0, nil
+		}
+		line := b[s.off : from+i]
+		next := from + i + 2
+		if len(line) == 0 {
+			return 0, errRedisMalformed
+		}
+
+		switch line[0] {
+		case '+', '-', ':', ',', '#', '(', '_':
+		case '$', '!', '=':
+			size, err := redisParseLength(line[1:])
+			if err != nil {
+				return 0, err
+			}
+			if size >= 0 {
+				if len(b)-next < size+2 {
+					s.from = next - 2
+					return 0, nil
+				}
+				next += size + 2
+			}
+		case '*', '~', '>', '%', '|':
+			count, err := redisParseLength(line[1:])
+			if err != nil {
+				return 0, err
+			}
+			if line[0] == '%' || line[0] == '|' {
+				count *= 2
+			}
+			if count > 0 {
+				s.off, s.from = next, next
+				s.depth = append(s.depth, count)
+				continue
+			}
+		default:
+			return 0, errRedisMalformed
+		}
+
+		s.off, s.from = next, next
+		// The element is complete, which may complete the aggregates that
+		// it is part of.
+		for {
+			top := len(s.depth) - 1
+			if top < 0 {
+				n := s.off
+				s.off, s.from, s.depth = 0, 0, s.depth[:0]
+				return n, nil
+			}
+			if s.depth[top]--; s.depth[top] > 0 {
+				break
+			}
+			s.depth = s.depth[:top]
+		}
+	}
+}
+
+// redisParse parses the value at the beginning of b, returning the number of
+// bytes that it spanned, or zero if b does not contain a complete value.
+func redisParse(b []byte) (v redisValue, n int, err error) {
+	line, next, ok := redisSplitLine(b)
+	if !ok {
+		return v, 0, nil
+	}
+	if len(line) == 0 {
+		return v, 0, errRedisMalformed
+	}
+	n = len(b) - len(next)
+	v.typ, v.str = line[0], string(line[1:])
+
+	switch v.typ {
+	case '+', '-', ':', ',', '#', '(':
+		return v, n, nil
+
+	case '_':
+		v.null = true
+		return v, n, nil
+
+	case '$', '!', '=':
+		size, err := redisParseLength(line[1:])
+		if err != nil {
+			return v, 0, err
+		}
+		if size < 0 {
+			v.null, v.str = true, ""
+			return v, n, nil
+		}
+		if len(next) < size+2 {
+			return v, 0, nil
+		}
+		v.str = string(next[:size])
+		if v.typ == '=' && len(v.str) >= 4 {
+			v.str = v.str[4:] // encoding prefix, e.g. "txt:"
+		}
+		return v, n + size + 2, nil
+
+	case '*', '~', '>', '%', '|':
+		count, err := redisParseLength(line[1:])
+		if err != nil {
+			return v, 0, err
+		}
+		v.str = ""
+		if count < 0 {
+			v.null = true
+			return v, n, nil
+		}
+		if v.typ == '%' || v.typ == '|' {
+			count *= 2
+		}
+		// The count is not trusted to preallocate the elements, the buffer
+		// must at least contain the smallest value for each of them.
+		v.elems = make([]redisValue, 0, min(count, (len(b)-n)/redisMinValueSize))
+		for i := 0; i < count; i++ {
+			elem, size, err := redisParse(b[n:])
+			if err != nil || size == 0 {
+				return v, 0, err
+			}
+			v.elems = append(v.elems, elem)
+			n += size
+		}
+		return v, n, nil
+	}
+
+	return v, 0, errRedisMalformed
+}
+
+// redisParseInline parses inline commands, which are sent as a single line of
+// space separated arguments (e.g. by telnet sessions).
+func redisParseInline(b []byte) (v redisValue, n int, err error) {
+	line, next, ok := redisSplitLine(b)
+	if !ok {
+		return v, 0, nil
+	}
+	for _, arg := range strings.Fields(string(line)) {
+		v.typ = '*'
+		v.elems = append(v.elems, redisValue{typ: '$', str: arg})
+	}
+	return v, len(b) - len(next), nil
+}
+
+func redisSplitLine(b []byte) (line, next []byte, ok bool) {
+	i := bytes.Index(b, []byte("\r\n"))
+	if i < 0 {
+		return nil, b, false
+	}
+	return b[:i], b[i+2:], true
+}
+
+// command returns the name of the command that v represents.
+func (v *redisValue) command() string {
+	if len(v.elems) == 0 {
+		return ""
+	}
+	return v.elems[0].str
+}
+
+// kind returns the kind of pub/sub messages, which is the first element of the
+// arrays sent by the server.
+func (v *redisValue) kind() string {
+	if len(v.elems) == 0 {
+		return ""
+	}
+	return strings.ToLower(v.elems[0].str)
+}
+
+func (v *redisValue) subscriptionCount() (int, bool) {
+	if len(v.elems) != 3 || v.elems[2].typ != ':' {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v.elems[2].str)
+	return n, err == nil
+}
+
+// redisMaxArgLength is the length after which arguments of commands are
+// truncated in the compact format.
+const redisMaxArgLength = 64
+
+func (v *redisValue) formatCommand(w io.Writer, truncate bool) {
+	for i, arg := range v.elems {
+		if i != 0 {
+			io.WriteString(w, " ")
+		}
+		s := arg.str
+		if truncate && len(s) > redisMaxArgLength {
+			s = s[:redisMaxArgLength] + "..."
+		}
+		if redisNeedsQuote(s) {
+			io.WriteString(w, strconv.Quote(s))
+		} else {
+			io.WriteString(w, s)
+		}
+	}
+}
+
+func redisNeedsQuote(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '\\' || !unicode.IsPrint(r)
+	}) >= 0
+}
+
+func (v *redisValue) format(w io.Writer) {
+	if v.null {
+		io.WriteString(w, "(nil)")
+		return
+	}
+	switch v.typ {
+	case '+':
+		io.WriteString(w, v.str)
+	case '-', '!':
+		fmt.Fprintf(w, "(error) %s", v.str)
+	case ':':
+		fmt.Fprintf(w, "(integer) %s", v.str)
+	case ',':
+		fmt.Fprintf(w, "(double) %s", v.str)
+	case '(':
+		fmt.Fprintf(w, "(big number) %s", v.str)
+	case '#':
+		if v.str == "t" {
+			io.WriteString(w, "(true)")
+		} else {
+			io.WriteString(w, "(false)")
+		}
+	case '$', '=':
+		io.WriteString(w, strconv.Quote(v.str))
+	case '%':
+		io.WriteString(w, "{")
+		for i := 0; i+1 < len(v.elems); i += 2 {
+			if i != 0 {
+				io.WriteString(w, ", ")
+			}
+			v.elems[i].format(w)
+			io.WriteString(w, ": ")
+			v.elems[i+1].format(w)
+		}
+		io.WriteString(w, "}")
+	default:
+		io.WriteString(w, "[")
+		for i := range v.elems {
+			if i != 0 {
+				io.WriteString(w, ", ")
+			}
+			v.elems[i].format(w)
+		}
+		io.WriteString(w, "]")
+	}
+}
+
+func (v *redisValue) marshal() any {
+	if v.null {
+		return nil
+	}
+	switch v.typ {
+	case '-', '!':
+		return map[string]string{"error": v.str}
+	case ':':
+		if n, err := strconv.ParseInt(v.str, 10, 64); err == nil {
+			return n
+		}
+	case ',':
+		if f, err := strconv.ParseFloat(v.str, 64); err == nil {
+			return f
+		}
+	case '#':
+		return v.str == "t"
+	case '%':
+		m := make(map[string]any, len(v.elems)/2)
+		for i := 0; i+1 < len(v.elems); i += 2 {
+			k := new(strings.Builder)
+			if key := &v.elems[i]; key.typ == '$' || key.typ == '+' {
+				k.WriteString(key.str)
+			} else {
+				key.format(k)
+			}
+			m[k.String()] = v.elems[i+1].marshal()
+		}
+		return m
+	case '*', '~', '>':
+		a := make([]any, len(v.elems))
+		for i := range v.elems {
+			a[i] = v.elems[i].marshal()
+		}
+		return a
+	}
+	return v.str
+}
+
+type redisRequest struct {
+	conn  *redisConn
+	value redisValue
+}
+
+func (req *redisRequest) Conn() Conn { return req.conn }
+
+func (req *redisRequest) Format(w fmt.State, v rune) {
+	if w.Flag('+') {
+		p := textprint.NewPrefixWriter(w, http1RequestFormatPrefix)
+		req.value.formatCommand(p, false)
+		io.WriteString(p, "\n")
+	} else {
+		req.value.formatCommand(w, true)
+	}
+}
+
+func (req *redisRequest) Marshal() any {
+	r := &redisRequestMarshal{
+		Command: req.value.command(),
+	}
+	if len(req.value.elems) > 1 {
+		r.Args = make([]string, len(req.value.elems)-1)
+		for i, arg := range req.value.elems[1:] {
+			r.Args[i] = arg.str
+		}
+	}
+	return r
+}
+
+type redisRequestMarshal struct {
+	Command string   `json:"command"        yaml:"command"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+type redisResponse struct {
+	conn   *redisConn
+	values []redisValue
+	push   bool
+}
+
+func (res *redisResponse) Conn() Conn { return res.conn }
+
+func (res *redisResponse) Format(w fmt.State, v rune) {
+	var out io.Writer = w
+	if w.Flag('+') {
+		out = textprint.NewPrefixWriter(w, http1ResponseFormatPrefix)
+	}
+	if res.push {
+		io.WriteString(out, "(push) ")
+	}
+	for i := range res.values {
+		if i != 0 {
+			io.WriteString(out, ", ")
+		}
+		res.values[i].format(out)
+	}
+	if w.Flag('+') {
+		io.WriteString(out, "\n")
+	}
+}
+
+func (res *redisResponse) Marshal() any {
+	r := &redisResponseMarshal{Push: res.push}
+	switch len(res.values) {
+	case 0:
+	case 1:
+		r.Value = res.values[0].marshal()
+	default:
+		// Commands of the subscribe family receive one reply per channel.
+		values := make([]any, len(res.values))
+		for i := range res.values {
+			values[i] = res.values[i].marshal()
+		}
+		r.Value = values
+	}
+	return r
+}
+
+type redisResponseMarshal struct {
+	Value any  `json:"value"          yaml:"value"`
+	Push  bool `json:"push,omitempty" yaml:"push,omitempty"`
+}
@@ -0,0 +1,296 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/stream"
+)
+
+func redisCommandBytes(args ...string) []byte {
+	b := fmt.Appendf(nil, "*%d\r\n", len(args))
+	for _, arg := range args {
+		b = fmt.Appendf(b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return b
+}
+
+func TestRedisCanHandle(t *testing.T) {
+	proto := Redis()
+	assert.True(t, proto.CanHandle(redisCommandBytes("GET", "key")))
+	assert.False(t, proto.CanHandle([]byte("*2\r\n")))
+	assert.False(t, proto.CanHandle([]byte("GET / HTTP/1.1\r\n")))
+}
+
+func TestRedisParse(t *testing.T) {
+	tests := []struct {
+		scenario string
+		input    string
+		output   string
+		size     int
+	}{
+		{
+			scenario: "simple string",
+			input:    "+OK\r\n",
+			output:   "OK",
+		},
+
+		{
+			scenario: "error",
+			input:    "-ERR unknown command\r\n",
+			output:   "(error) ERR unknown command",
+		},
+
+		{
+			scenario: "integer",
+			input:    ":42\r\n",
+			output:   "(integer) 42",
+		},
+
+		{
+			scenario: "null bulk string",
+			input:    "$-1\r\n",
+			output:   "(nil)",
+		},
+
+		{
+			scenario: "bulk string containing CRLF",
+			input:    "$7\r\nhey\r\nyo\r\n",
+			output:   `"hey\r\nyo"`,
+		},
+
+		{
+			scenario: "incomplete bulk string",
+			input:    "$7\r\nhey\r\n",
+			size:     -1,
+		},
+
+		{
+			scenario: "nested arrays",
+			input:    "*2\r\n:1\r\n*2\r\n+a\r\n$1\r\nb\r\n",
+			output:   `[(integer) 1, [a, "b"]]`,
+		},
+
+		{
+			scenario: "incomplete array",
+			input:    "*2\r\n:1\r\n",
+			size:     -1,
+		},
+
+		{
+			scenario: "resp3 map",
+			input:    "%2\r\n+proto\r\n:3\r\n+mode\r\n$10\r\nstandalone\r\n",
+			output:   `{proto: (integer) 3, mode: "standalone"}`,
+		},
+
+		{
+			scenario: "resp3 scalars",
+			input:    "*4\r\n_\r\n#t\r\n,1.5\r\n=7\r\ntxt:abc\r\n",
+			output:   `[(nil), (true), (double) 1.5, "abc"]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			v, n, err := redisParse([]byte(test.input))
+			assert.OK(t, err)
+			if test.size < 0 {
+				assert.Equal(t, n, 0)
+				return
+			}
+			assert.Equal(t, n, len(test.input))
+			out := new(strings.Builder)
+			v.format(out)
+			assert.Equal(t, out.String(), test.output)
+		})
+	}
+}
+
+func TestRedisParseLimits(t *testing.T) {
+	// Counts larger than the data available do not cause large allocations.
+	v, n, err := redisParse([]byte("*1000000000\r\n:1\r\n"))
+	assert.OK(t, err)
+	assert.Equal(t, n, 0)
+	assert.True(t, cap(v.elems) <= 1)
+
+	for _, input := range []string{
+		"*9999999999\r\n",
+		"%1073741824000\r\n",
+		"*-2\r\n",
+		"$9223372036854775807\r\nabc\r\n",
+		"$99999999999999999999\r\n",
+	} {
+		_, _, err := redisParse([]byte(input))
+		assert.Equal(t, err, errRedisMalformed)
+
+		var s redisScanner
+		_, err = s.scan([]byte(input))
+		assert.Equal(t, err, errRedisMalformed)
+	}
+}
+
+func TestRedisScanner(t *testing.T) {
+	values := "*2\r\n:1\r\n*2\r\n+a\r\n$7\r\nhey\r\nyo\r\n" +
+		"%1\r\n+k\r\n*0\r\n" +
+		"$-1\r\n" +
+		"*3\r\n_\r\n*-1\r\n=7\r\ntxt:abc\r\n"
+
+	// Feed the values one byte at a time, each value must be found exactly
+	// when its last byte is written.
+	var s redisScanner
+	var b []byte
+	var sizes []int
+	for i := 0; i < len(values); i++ {
+		b = append(b, values[i])
+		n, err := s.scan(b)
+		assert.OK(t, err)
+		if n > 0 {
+			_, size, err := redisParse(b)
+			assert.OK(t, err)
+			assert.Equal(t, n, size)
+			assert.Equal(t, n, len(b))
+			sizes = append(sizes, n)
+			b = b[:0]
+		}
+	}
+	assert.Equal(t, len(b), 0)
+	assert.Equal(t, len(sizes), 4)
+}
+
+func TestRedisExchanges(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6379}
+	now := time.Now()
+
+	events := []Event{{
+		Time: now,
+		Type: Connect,
+		FD:   3,
+		Addr: addr,
+		Peer: peer,
+	}}
+	send := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+	recv := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+
+	// Pipelined commands, with replies split across events.
+	send(string(redisCommandBytes("SET", "key", "hello world")) +
+		string(redisCommandBytes("GET", "key")) +
+		string(redisCommandBytes("GET", "missing")))
+	recv("+OK\r\n$11\r\nhello")
+	recv(" world\r\n$-1\r\n")
+
+	// Pub/sub subscriptions and pushed messages.
+	send(string(redisCommandBytes("SUBSCRIBE", "news", "sports")))
+	recv("*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+	recv("*3\r\n$9\r\nsubscribe\r\n$6\r\nsports\r\n:2\r\n")
+	recv("*3\r\n$7\r\nmessage\r\n$6\r\nsports\r\n$5\r\ngoal!\r\n")
+
+	// Command left without a reply when the connection is closed.
+	send(string(redisCommandBytes("PING")))
+	events = append(events, Event{Time: now, Type: Shutdown | ShutRD | ShutWR, FD: 3, Addr: addr, Peer: peer})
+
+	exchanges, err := stream.ReadAll[Exchange](&ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{HTTP1(), HTTP2(), Postgres(), Redis()},
+		},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(exchanges), 6)
+
+	format := func(e Exchange) string {
+		s := fmt.Sprint(e)
+		return s[strings.Index(s, ": ")+2:]
+	}
+	assert.Equal(t, format(exchanges[0]), `SET key "hello world" => OK (1ms)`)
+	assert.Equal(t, format(exchanges[1]), `GET key => "hello world" (2ms)`)
+	assert.Equal(t, format(exchanges[2]), `GET missing => (nil) (2ms)`)
+	assert.Equal(t, format(exchanges[3]),
+		`SUBSCRIBE news sports => ["subscribe", "news", (integer) 1], ["subscribe", "sports", (integer) 2] (2ms)`)
+	assert.Equal(t, format(exchanges[4]),
+		`SUBSCRIBE news sports => (push) ["message", "sports", "goal!"] (3ms)`)
+
+	assert.DeepEqual(t, exchanges[1].Req.msg.Marshal(), &redisRequestMarshal{
+		Command: "GET",
+		Args:    []string{"key"},
+	})
+	assert.DeepEqual(t, exchanges[4].Res.msg.Marshal(), &redisResponseMarshal{
+		Value: []any{"message", "sports", "goal!"},
+		Push:  true,
+	})
+
+	assert.Equal(t, fmt.Sprint(exchanges[5].Req), "PING")
+	assert.Error(t, exchanges[5].Res.Err, io.ErrUnexpectedEOF)
+}
+
+func TestRedisUnsubscribe(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	peer := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6379}
+	now := time.Now()
+
+	events := []Event{{
+		Time: now,
+		Type: Connect,
+		FD:   3,
+		Addr: addr,
+		Peer: peer,
+	}}
+	send := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Send, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+	recv := func(data string) {
+		now = now.Add(time.Millisecond)
+		events = append(events, Event{Time: now, Type: Receive, FD: 3, Addr: addr, Peer: peer, Data: []Bytes{Bytes(data)}})
+	}
+
+	send(string(redisCommandBytes("SUBSCRIBE", "news", "sports")))
+	recv("*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+	recv("*3\r\n$9\r\nsubscribe\r\n$6\r\nsports\r\n:2\r\n")
+	send(string(redisCommandBytes("PSUBSCRIBE", "n*")))
+	recv("*3\r\n$10\r\npsubscribe\r\n$2\r\nn*\r\n:3\r\n")
+
+	// Messages matching the pattern are paired with the command which
+	// subscribed to it, even after other channels were unsubscribed.
+	send(string(redisCommandBytes("UNSUBSCRIBE", "news")))
+	recv("*3\r\n$11\r\nunsubscribe\r\n$4\r\nnews\r\n:2\r\n")
+	recv("*4\r\n$8\r\npmessage\r\n$2\r\nn*\r\n$4\r\nnews\r\n$5\r\nrain!\r\n")
+
+	// Once all subscriptions are removed, replies which look like pushed
+	// messages are paired with the commands they were sent for.
+	send(string(redisCommandBytes("UNSUBSCRIBE")))
+	recv("*3\r\n$11\r\nunsubscribe\r\n$6\r\nsports\r\n:1\r\n")
+	send(string(redisCommandBytes("PUNSUBSCRIBE")))
+	recv("*3\r\n$12\r\npunsubscribe\r\n$2\r\nn*\r\n:0\r\n")
+	send(string(redisCommandBytes("LRANGE", "list", "0", "-1")))
+	recv("*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\nb\r\n")
+
+	exchanges, err := stream.ReadAll[Exchange](&ExchangeReader{
+		Messages: &MessageReader{
+			Events: stream.NewReader(events...),
+			Protos: []ConnProtocol{Redis()},
+		},
+	})
+	assert.OK(t, err)
+	assert.Equal(t, len(exchanges), 7)
+
+	format := func(e Exchange) string {
+		s := fmt.Sprint(e)
+		return s[strings.Index(s, ": ")+2:]
+	}
+	assert.Equal(t, format(exchanges[3]),
+		`PSUBSCRIBE n* => (push) ["pmessage", "n*", "news", "rain!"] (4ms)`)
+	assert.Equal(t, format(exchanges[6]),
+		`LRANGE list 0 -1 => ["message", "a", "b"] (1ms)`)
+}
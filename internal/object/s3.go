@@ -0,0 +1,660 @@
+package object
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/object/query"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"golang.org/x/sync/errgroup"
+)
+
+// S3Config is the configuration of object stores backed by an S3-compatible
+// storage service.
+type S3Config struct {
+	// Name of the bucket that objects are stored in.
+	Bucket string
+	// Prefix prepended to the keys of objects in the bucket, which allows
+	// multiple stores to share the same bucket.
+	Prefix string
+	// Region of the bucket, us-east-1 if empty.
+	Region string
+	// Base URL of the S3 API. When empty, requests are sent to the AWS
+	// endpoint of the region using virtual-hosted style URLs. Custom endpoints
+	// (e.g. a MinIO server) are accessed using path-style URLs.
+	Endpoint string
+	// Credentials used to sign requests. When AccessKeyID is empty, requests
+	// are sent anonymously.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// HTTP client used to send requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// S3Store constructs an object store backed by a bucket of an S3-compatible
+// storage service.
+//
+// Object tags are stored in the metadata of S3 objects. Since listing a bucket
+// does not return the object metadata, the store has to retrieve it for each
+// object when listing, which makes it best suited for use cases where few
+// objects are listed at a time (e.g. by selecting them with key prefixes).
+func S3Store(config S3Config) (Store, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("s3 object store: missing bucket name")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Prefix = strings.Trim(config.Prefix, "/"); config.Prefix != "" {
+		config.Prefix += "/"
+	}
+
+	store := &s3Store{config: config}
+	if config.Endpoint == "" {
+		store.endpoint = &url.URL{
+			Scheme: "https",
+			Host:   config.Bucket + ".s3." + config.Region + ".amazonaws.com",
+		}
+	} else {
+		endpoint, err := url.Parse(config.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("s3 object store: malformed endpoint: %w", err)
+		}
+		if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+			return nil, fmt.Errorf("s3 object store: unsupported endpoint scheme: %q", config.Endpoint)
+		}
+		store.endpoint = endpoint
+		store.pathStyle = true
+	}
+	return store, nil
+}
+
+type s3Store struct {
+	config    S3Config
+	endpoint  *url.URL
+	pathStyle bool
+}
+
+// s3TagsMetadata is the name of the metadata header where object tags are
+// stored. Metadata names are case insensitive and values are limited to ASCII
+// characters, so the tags are encoded as a URL query string.
+const s3TagsMetadata = "X-Amz-Meta-Timecraft-Tags"
+
+// s3ListConcurrency is the maximum number of concurrent requests sent to read
+// the metadata of objects when listing a bucket.
+const s3ListConcurrency = 16
+
+func (store *s3Store) CreateObject(ctx context.Context, name string, data io.Reader, tags ...Tag) error {
+	key, err := store.objectKey(name)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(path.Base(name), ".") {
+		return fmt.Errorf("object names cannot start with a dot: %s", name)
+	}
+
+	header := make(http.Header)
+	if len(tags) > 0 {
+		values := make(url.Values, len(tags))
+		for _, tag := range tags {
+			if !validTag(tag) {
+				return fmt.Errorf("invalid tag: %q=%q", tag.Name, tag.Value)
+			}
+			values.Add(tag.Name, tag.Value)
+		}
+		header.Set(s3TagsMetadata, values.Encode())
+	}
+
+	// S3 requires the content length to be known when uploading objects, if
+	// we cannot determine it from the reader the data is first written to a
+	// temporary file.
+	var size int64
+	switch r := data.(type) {
+	case interface{ Len() int }:
+		size = int64(r.Len())
+	default:
+		f, err := os.CreateTemp("", "timecraft-s3-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		if size, err = io.Copy(f, data); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		data = f
+	}
+
+	// The Put operation of S3 is atomic, the object is not visible until the
+	// upload completed.
+	res, err := store.do(ctx, http.MethodPut, key, nil, header, io.NopCloser(data), size)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (store *s3Store) ReadObject(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	key, err := store.objectKey(name)
+	if err != nil {
+		return nil, err
+	}
+	res, err := store.do(ctx, http.MethodGet, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	r := &s3Reader{
+		ctx:   ctx,
+		store: store,
+		key:   key,
+		body:  res.Body,
+		size:  res.ContentLength,
+	}
+	return r, nil
+}
+
+func (store *s3Store) StatObject(ctx context.Context, name string) (Info, error) {
+	key, err := store.objectKey(name)
+	if err != nil {
+		return Info{}, err
+	}
+	info, err := store.headObject(ctx, key)
+	info.Name = name
+	return info, err
+}
+
+func (store *s3Store) ListObjects(ctx context.Context, prefix string, filters ...Filter) stream.ReadCloser[Info] {
+	var dirPath, namePrefix string
+	if prefix == "." || strings.HasSuffix(prefix, "/") {
+		dirPath = prefix
+	} else {
+		dirPath, namePrefix = path.Split(prefix)
+	}
+	if dirPath = path.Clean(dirPath); !fs.ValidPath(dirPath) {
+		return &errorInfoReader{err: fmt.Errorf("invalid object name: %q", prefix)}
+	}
+	listPrefix := store.config.Prefix
+	if dirPath != "." {
+		listPrefix += dirPath + "/"
+	}
+	return &s3Lister{
+		ctx:     ctx,
+		store:   store,
+		prefix:  listPrefix + namePrefix,
+		filters: slices.Clone(filters),
+	}
+}
+
+func (store *s3Store) DeleteObject(ctx context.Context, name string) error {
+	key, err := store.objectKey(name)
+	if err != nil {
+		return err
+	}
+	res, err := store.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			err = nil
+		}
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (store *s3Store) objectKey(name string) (string, error) {
+	if name = path.Clean(name); !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid object name: %q", name)
+	}
+	return store.config.Prefix + name, nil
+}
+
+func (store *s3Store) headObject(ctx context.Context, key string) (Info, error) {
+	res, err := store.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return Info{}, err
+	}
+	res.Body.Close()
+
+	info := Info{
+		Name: strings.TrimPrefix(key, store.config.Prefix),
+		Size: res.ContentLength,
+	}
+	if lastModified := res.Header.Get("Last-Modified"); lastModified != "" {
+		t, err := http.ParseTime(lastModified)
+		if err != nil {
+			return Info{}, fmt.Errorf("s3 object store: %s: malformed last modified time: %w", key, err)
+		}
+		info.CreatedAt = t.UTC()
+	}
+	if metadata := res.Header.Get(s3TagsMetadata); metadata != "" {
+		values, err := url.ParseQuery(metadata)
+		if err != nil {
+			return Info{}, fmt.Errorf("s3 object store: %s: malformed tags: %w", key, err)
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, value := range values[name] {
+				info.Tags = append(info.Tags, Tag{Name: name, Value: value})
+			}
+		}
+	}
+	return info, nil
+}
+
+func (store *s3Store) objectURL(key string, query url.Values) string {
+	u := *store.endpoint
+	escapedPath := strings.TrimSuffix(u.EscapedPath(), "/")
+	if store.pathStyle {
+		escapedPath += "/" + s3Escape(store.config.Bucket, false)
+	}
+	escapedPath += "/" + s3Escape(key, true)
+	s := u.Scheme + "://" + u.Host + escapedPath
+	if len(query) > 0 {
+		s += "?" + s3CanonicalQuery(query)
+	}
+	return s
+}
+
+func (store *s3Store) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.ReadCloser, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, store.objectURL(key, query), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		// The HTTP client uses chunked encoding when the content length is
+		// zero, which S3 does not support; empty bodies must be explicit.
+		if req.ContentLength = size; size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	if store.config.AccessKeyID != "" {
+		s3Sign(req, store.config, "UNSIGNED-PAYLOAD", time.Now())
+	}
+
+	res, err := store.config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return res, nil
+	case res.StatusCode == http.StatusNotFound && key != "":
+		res.Body.Close()
+		return nil, fmt.Errorf("%s: %w", key, ErrNotExist)
+	default:
+		defer res.Body.Close()
+		e := &S3Error{StatusCode: res.StatusCode}
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+		_ = xml.Unmarshal(b, e)
+		if e.Code == "" {
+			e.Code = http.StatusText(res.StatusCode)
+		}
+		return nil, e
+	}
+}
+
+// S3Error is returned by S3 object stores when the service responds with an
+// error status.
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	Resource   string `xml:"Resource"`
+}
+
+func (e *S3Error) Error() string {
+	s := "s3 object store: " + e.Code
+	if e.Resource != "" {
+		s += ": " + e.Resource
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s + " (" + strconv.Itoa(e.StatusCode) + ")"
+}
+
+// s3Reader reads the content of an object, seeking is implemented by sending
+// a ranged request starting at the new read offset on the next call to Read.
+//
+// The size is -1 when the response did not have a content length (e.g. when
+// it used a chunked transfer encoding), in which case the object is read until
+// the body ends.
+type s3Reader struct {
+	ctx    context.Context
+	store  *s3Store
+	key    string
+	body   io.ReadCloser
+	offset int64
+	size   int64
+}
+
+func (r *s3Reader) Read(b []byte) (int, error) {
+	if r.size >= 0 && r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		header := http.Header{"Range": {"bytes=" + strconv.FormatInt(r.offset, 10) + "-"}}
+		res, err := r.store.do(r.ctx, http.MethodGet, r.key, nil, header, nil, 0)
+		if err != nil {
+			return 0, err
+		}
+		r.body = res.Body
+	}
+	n, err := r.body.Read(b)
+	r.offset += int64(n)
+	if err == io.EOF {
+		if r.size < 0 {
+			r.size = r.offset
+		} else if r.offset < r.size {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	return n, err
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		if r.size < 0 {
+			info, err := r.store.headObject(r.ctx, r.key)
+			if err != nil {
+				return -1, err
+			}
+			r.size = info.Size
+		}
+		offset += r.size
+	default:
+		return -1, fmt.Errorf("seek: invalid whence value: %d", whence)
+	}
+	if offset < 0 {
+		return -1, fmt.Errorf("seek: negative offset: %d", offset)
+	}
+	if offset != r.offset {
+		r.closeBody()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *s3Reader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+func (r *s3Reader) closeBody() {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+}
+
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// s3Lister lists objects of a bucket one page at a time, the metadata of each
+// object on a page is retrieved concurrently to evaluate the filters.
+type s3Lister struct {
+	ctx     context.Context
+	store   *s3Store
+	prefix  string
+	filters []Filter
+	token   string
+	done    bool
+	items   []Info
+}
+
+func (l *s3Lister) Close() error {
+	l.done, l.items = true, nil
+	return nil
+}
+
+func (l *s3Lister) Read(items []Info) (n int, err error) {
+	for n < len(items) {
+		if len(l.items) == 0 {
+			if l.done {
+				if n == 0 {
+					err = io.EOF
+				}
+				return n, err
+			}
+			if l.items, err = l.readPage(); err != nil {
+				return n, err
+			}
+			continue
+		}
+		c := copy(items[n:], l.items)
+		l.items = l.items[c:]
+		n += c
+	}
+	return n, nil
+}
+
+func (l *s3Lister) readPage() ([]Info, error) {
+	params := url.Values{
+		"list-type": {"2"},
+		"delimiter": {"/"},
+		"prefix":    {l.prefix},
+	}
+	if l.token != "" {
+		params.Set("continuation-token", l.token)
+	}
+	res, err := l.store.do(l.ctx, http.MethodGet, "", params, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var page s3ListResult
+	if err := xml.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("s3 object store: decoding list of objects: %w", err)
+	}
+	l.token = page.NextContinuationToken
+	l.done = !page.IsTruncated || l.token == ""
+
+	infos := make([]Info, 0, len(page.CommonPrefixes)+len(page.Contents))
+	for _, p := range page.CommonPrefixes {
+		infos = append(infos, Info{
+			Name: strings.TrimPrefix(p.Prefix, l.store.config.Prefix),
+		})
+	}
+
+	objects := make([]Info, len(page.Contents))
+	group, ctx := errgroup.WithContext(l.ctx)
+	group.SetLimit(s3ListConcurrency)
+	for i, c := range page.Contents {
+		if strings.HasPrefix(path.Base(c.Key), ".") {
+			continue
+		}
+		i, key := i, c.Key
+		group.Go(func() error {
+			info, err := l.store.headObject(ctx, key)
+			if err != nil {
+				// The object may have been deleted since the bucket was
+				// listed, it is simply omitted from the results.
+				if errors.Is(err, ErrNotExist) {
+					err = nil
+				}
+				return err
+			}
+			objects[i] = info
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	for _, info := range objects {
+		if info.Name != "" {
+			infos = append(infos, info)
+		}
+	}
+
+	matches := infos[:0]
+	for i := range infos {
+		if query.MatchAll(&infos[i], l.filters...) {
+			matches = append(matches, infos[i])
+		}
+	}
+	return matches, nil
+}
+
+// s3Sign signs req with the AWS Signature Version 4 algorithm.
+//
+// The host, range, and x-amz-* headers of the request are signed.
+func s3Sign(req *http.Request, config S3Config, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", config.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalRequest := new(strings.Builder)
+	canonicalRequest.WriteString(req.Method)
+	canonicalRequest.WriteByte('\n')
+	canonicalRequest.WriteString(req.URL.EscapedPath())
+	canonicalRequest.WriteByte('\n')
+	canonicalRequest.WriteString(s3CanonicalQuery(req.URL.Query()))
+	canonicalRequest.WriteByte('\n')
+	for _, name := range names {
+		canonicalRequest.WriteString(name)
+		canonicalRequest.WriteByte(':')
+		canonicalRequest.WriteString(headers[name])
+		canonicalRequest.WriteByte('\n')
+	}
+	canonicalRequest.WriteByte('\n')
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest.WriteString(signedHeaders)
+	canonicalRequest.WriteByte('\n')
+	canonicalRequest.WriteString(payloadHash)
+
+	scope := date + "/" + config.Region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := s3HMAC([]byte("AWS4"+config.SecretAccessKey), date)
+	key = s3HMAC(key, config.Region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 "+
+		"Credential="+config.AccessKeyID+"/"+scope+", "+
+		"SignedHeaders="+signedHeaders+", "+
+		"Signature="+signature)
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := new(strings.Builder)
+	for _, name := range names {
+		values := slices.Clone(query[name])
+		sort.Strings(values)
+		for _, value := range values {
+			if s.Len() > 0 {
+				s.WriteByte('&')
+			}
+			s.WriteString(s3Escape(name, false))
+			s.WriteByte('=')
+			s.WriteString(s3Escape(value, false))
+		}
+	}
+	return s.String()
+}
+
+// s3Escape percent-encodes all the bytes of s except the unreserved characters
+// of RFC 3986, as required to compute signatures. Slashes are preserved when
+// keepSlash is true.
+func s3Escape(s string, keepSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b = append(b, c)
+		default:
+			b = append(b, '%', hexDigits[c>>4], hexDigits[c&15])
+		}
+	}
+	return string(b)
+}
+
+var (
+	_ Store             = (*s3Store)(nil)
+	_ io.ReadSeekCloser = (*s3Reader)(nil)
+)
@@ -322,7 +322,24 @@ func (store dirStore) DeleteObject(ctx context.Context, name string) error {
 		return err
 	}
 	dir, file := filepath.Split(path)
-	return remove(filepath.Join(dir, ".tags", file))
+	if err := remove(filepath.Join(dir, ".tags", file)); err != nil {
+		return err
+	}
+	store.removeEmptyDirs(filepath.Join(dir, ".tags"))
+	store.removeEmptyDirs(filepath.Clean(dir))
+	return nil
+}
+
+// removeEmptyDirs removes the directory at path and its parents until one of
+// them is not empty, so deleting objects does not leave empty prefixes behind
+// when listing the store. The root of the store is never removed.
+func (store dirStore) removeEmptyDirs(path string) {
+	for path != string(store) && strings.HasPrefix(path, string(store)) {
+		if os.Remove(path) != nil {
+			return
+		}
+		path = filepath.Dir(path)
+	}
 }
 
 func remove(path string) error {
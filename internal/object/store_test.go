@@ -24,6 +24,14 @@ func TestObjectStore(t *testing.T) {
 			return store, func() {}
 		})
 	})
+
+	t.Run("s3", func(t *testing.T) {
+		testObjectStore(t, newS3Store)
+	})
+
+	t.Run("s3-chunked", func(t *testing.T) {
+		testObjectStore(t, newChunkedS3Store)
+	})
 }
 
 func testObjectStore(t *testing.T, newStore func(*testing.T) (object.Store, func())) {
@@ -61,11 +69,21 @@ func testObjectStore(t *testing.T, newStore func(*testing.T) (object.Store, func
 			function: testObjectStoreCreateAndRead,
 		},
 
+		{
+			scenario: "objects can be read after seeking",
+			function: testObjectStoreSeekAndRead,
+		},
+
 		{
 			scenario: "deleted objects cannot be listed anymore",
 			function: testObjectStoreDeleteAndList,
 		},
 
+		{
+			scenario: "deleting the last object of a prefix removes the prefix",
+			function: testObjectStoreDeleteAndListPrefix,
+		},
+
 		{
 			scenario: "objects being created are not visible when listing",
 			function: testObjectStoreListWhileCreate,
@@ -152,6 +170,28 @@ func testObjectStoreCreateAndRead(t *testing.T, ctx context.Context, store objec
 	assert.Equal(t, string(readBytes(t, test3)), "BC")
 }
 
+func testObjectStoreSeekAndRead(t *testing.T, ctx context.Context, store object.Store) {
+	assert.OK(t, store.CreateObject(ctx, "test", strings.NewReader("Hello World!")))
+
+	r, err := store.ReadObject(ctx, "test")
+	assert.OK(t, err)
+	defer r.Close()
+
+	offset, err := r.Seek(6, io.SeekStart)
+	assert.OK(t, err)
+	assert.Equal(t, offset, 6)
+	b, err := io.ReadAll(r)
+	assert.OK(t, err)
+	assert.Equal(t, string(b), "World!")
+
+	offset, err = r.Seek(-6, io.SeekEnd)
+	assert.OK(t, err)
+	assert.Equal(t, offset, 6)
+	b, err = io.ReadAll(r)
+	assert.OK(t, err)
+	assert.Equal(t, string(b), "World!")
+}
+
 func testObjectStoreDeleteAndList(t *testing.T, ctx context.Context, store object.Store) {
 	assert.OK(t, store.CreateObject(ctx, "test-1", strings.NewReader("")))
 	assert.OK(t, store.CreateObject(ctx, "test-2", strings.NewReader("A")))
@@ -165,6 +205,23 @@ func testObjectStoreDeleteAndList(t *testing.T, ctx context.Context, store objec
 	})
 }
 
+func testObjectStoreDeleteAndListPrefix(t *testing.T, ctx context.Context, store object.Store) {
+	assert.OK(t, store.CreateObject(ctx, "test-1", strings.NewReader("")))
+	assert.OK(t, store.CreateObject(ctx, "sub/key-1", strings.NewReader("A"), object.Tag{"name", "value"}))
+	assert.OK(t, store.CreateObject(ctx, "sub/key-2", strings.NewReader("BC")))
+
+	assert.OK(t, store.DeleteObject(ctx, "sub/key-1"))
+	assert.DeepEqual(t, listObjects(t, ctx, store, "."), []object.Info{
+		{Name: "sub/", Size: 0},
+		{Name: "test-1", Size: 0},
+	})
+
+	assert.OK(t, store.DeleteObject(ctx, "sub/key-2"))
+	assert.DeepEqual(t, listObjects(t, ctx, store, "."), []object.Info{
+		{Name: "test-1", Size: 0},
+	})
+}
+
 func testObjectStoreListWhileCreate(t *testing.T, ctx context.Context, store object.Store) {
 	assert.OK(t, store.CreateObject(ctx, "test-1", strings.NewReader("")))
 	assert.OK(t, store.CreateObject(ctx, "test-2", strings.NewReader("A")))
@@ -54,6 +54,11 @@ func (w *writer[T]) Write(values []T) (int, error) {
 			return n, err
 		}
 	}
+	// Flush after each batch of values so the output is visible immediately
+	// when the writer is fed from a live stream.
+	if err := w.output.Flush(); err != nil {
+		return 0, err
+	}
 	return len(values), nil
 }
 
@@ -177,7 +177,7 @@ func (d *openDir) Open(name string, flags sandbox.OpenFlags, mode fs.FileMode) (
 		sandbox.O_RDWR |
 		sandbox.O_WRONLY
 
-	if ((flags & unsupportedFlags) != 0) || mode != 0 || name == "" {
+	if ((flags & unsupportedFlags) != 0) || name == "" {
 		return nil, sandbox.EINVAL
 	}
 
@@ -19,11 +19,27 @@ const (
 // Config is timecraft configuration.
 type Config struct {
 	Registry struct {
+		// Location is either the path to a local directory, or the location
+		// of an S3 bucket of the form s3://bucket/prefix.
 		Location Option[human.Path] `json:"location"`
 	} `json:"registry"`
 	Cache struct {
 		Location Option[human.Path] `json:"location"`
 	} `json:"cache"`
+	Record struct {
+		// Elide configures the system calls for which the data read or
+		// written is elided from the logs. Only the size and a digest of
+		// the data are recorded.
+		Elide struct {
+			// FDs is the list of file descriptors for which data written is
+			// elided (e.g. 1 for stdout).
+			FDs []int `json:"fds"`
+			// Paths is a list of path prefixes for which data read from and
+			// written to files is elided. Replays read the data back from a
+			// copy of the files.
+			Paths []string `json:"paths"`
+		} `json:"elide"`
+	} `json:"record"`
 }
 
 // ConfigPath is the path to the timecraft configuration.
@@ -0,0 +1,83 @@
+package timecraft
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/debug/logdiff"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+)
+
+// DivergenceError is returned by replays when the module made a system call
+// which did not match the record found in the log.
+type DivergenceError struct {
+	// Offset and time of the record where the replay diverged.
+	Offset int64     `json:"offset" yaml:"offset"`
+	Time   time.Time `json:"time"   yaml:"time"`
+	// Description of the mismatch between the recorded and actual calls.
+	Reason string `json:"reason" yaml:"reason"`
+	// The system call recorded in the log, and the system call made by the
+	// module in its place. The actual call has no results since it was not
+	// completed.
+	Expect *logdiff.Call `json:"expect"           yaml:"expect"`
+	Actual *logdiff.Call `json:"actual,omitempty" yaml:"actual,omitempty"`
+	// The last system calls replayed before the divergence.
+	History []logdiff.Call `json:"history" yaml:"history"`
+
+	err error
+}
+
+func (e *DivergenceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *DivergenceError) Unwrap() error {
+	return e.err
+}
+
+// newDivergenceError builds a DivergenceError from the error that the replay
+// panicked with, and the last system call observed before the divergence. The
+// original error is returned if the records could not be decoded.
+func newDivergenceError(d *wasicall.DivergenceError, actual wasicall.Syscall) error {
+	var decoder wasicall.Decoder
+
+	decode := func(record timemachine.Record) (*logdiff.Call, error) {
+		t, syscall, err := decoder.Decode(record)
+		if err != nil {
+			return nil, err
+		}
+		call := logdiff.NewCall(record.Offset, t, syscall)
+		return &call, nil
+	}
+
+	expect, err := decode(d.Record)
+	if err != nil {
+		return errors.Join(d, err)
+	}
+
+	e := &DivergenceError{
+		Offset:  d.Record.Offset,
+		Time:    d.Record.Time,
+		Reason:  d.Err.Error(),
+		Expect:  expect,
+		History: make([]logdiff.Call, 0, len(d.History)),
+		err:     d,
+	}
+
+	if actual != nil {
+		call := logdiff.NewCall(d.Record.Offset, d.Record.Time, actual)
+		call.Results = ""
+		e.Actual = &call
+	}
+
+	for _, record := range d.History {
+		call, err := decode(record)
+		if err != nil {
+			return errors.Join(d, fmt.Errorf("decoding record %d: %w", record.Offset, err))
+		}
+		e.History = append(e.History, *call)
+	}
+	return e
+}
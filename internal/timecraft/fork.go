@@ -0,0 +1,78 @@
+package timecraft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+)
+
+// fork is the state of a recorded process that a new process is forked from.
+type fork struct {
+	parentID ProcessID
+	code     []byte
+	function string
+	args     []string
+	env      []string
+	image    *format.Descriptor
+	records  stream.ReadCloser[timemachine.Record]
+}
+
+// openFork loads the module, configuration, and log of a recorded process so
+// its execution can be replayed, then continued live in a new process.
+func openFork(ctx context.Context, registry *timemachine.Registry, parentID ProcessID) (*fork, error) {
+	replay := NewReplay(registry, nil, parentID)
+
+	code, function, err := replay.ModuleCode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the module of process %s: %w", parentID, err)
+	}
+	manifest, err := registry.LookupLogManifest(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	process, err := registry.LookupProcess(ctx, manifest.Process.Digest)
+	if err != nil {
+		return nil, err
+	}
+	config, err := registry.LookupConfig(ctx, process.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Args) == 0 {
+		return nil, fmt.Errorf("could not fork process %s: the process configuration has no arguments", parentID)
+	}
+	records, _, err := replay.RecordReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the log of process %s: %w", parentID, err)
+	}
+	return &fork{
+		parentID: parentID,
+		code:     code,
+		function: function,
+		args:     config.Args,
+		env:      config.Env,
+		image:    config.Image,
+		records:  records,
+	}, nil
+}
+
+// checkImage verifies that the image opened for the fork is the one that the
+// recorded process was started from, since the replay of the log would
+// otherwise diverge on the first access to the root file system.
+func (f *fork) checkImage(image *Image) error {
+	switch {
+	case f.image == nil && image == nil:
+		return nil
+	case f.image == nil:
+		return fmt.Errorf("could not fork process %s: the process was not started from an image", f.parentID)
+	case image == nil:
+		return fmt.Errorf("could not fork process %s: the process was started from image %s, which must be given to the fork", f.parentID, f.image.Digest)
+	case image.Digest() != f.image.Digest:
+		return fmt.Errorf("could not fork process %s: the image digest %s does not match the digest %s of the image that the process was started from", f.parentID, image.Digest(), f.image.Digest)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package timecraft
+
+import (
+	"bytes"
+	"sync"
+)
+
+// FunctionCall is a call to a function exported by a WebAssembly module.
+//
+// Each call starts a new process which runs the function to completion. When
+// not empty, Function and Args override those of the module spec of the task.
+type FunctionCall struct {
+	Function string
+	Args     []string
+	Stdin    []byte
+}
+
+func (*FunctionCall) taskInput() {}
+
+// FunctionResult is the result of a function call.
+type FunctionResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+func (*FunctionResult) taskOutput() {}
+
+// maxFunctionOutputSize is the maximum number of bytes captured from each of
+// the stdout and stderr of a function call. The output past the limit is
+// discarded, so that functions writing large outputs do not exhaust the
+// memory of the host.
+const maxFunctionOutputSize = 1 << 20
+
+// lockedBuffer is a bytes.Buffer that is safe to use concurrently. It is used
+// to capture the output of processes, which is shared with the subprocesses
+// that they spawn.
+//
+// Writes past the limit are discarded but reported as successful, since the
+// processes should not fail because their output is truncated.
+type lockedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := len(p)
+	if free := b.limit - b.buf.Len(); n > free {
+		p = p[:max(free, 0)]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
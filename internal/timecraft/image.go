@@ -0,0 +1,285 @@
+package timecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stealthrocket/timecraft/format"
+	"github.com/stealthrocket/timecraft/internal/container"
+	"github.com/stealthrocket/timecraft/internal/sandbox"
+	"github.com/stealthrocket/timecraft/internal/sandbox/ocifs"
+	"github.com/stealthrocket/timecraft/internal/sandbox/tarfs"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+const defaultImageTag = "latest"
+
+// DefaultImagePlatform is the platform selected when resolving images that
+// provide multiple variants.
+var DefaultImagePlatform = ocispec.Platform{
+	OS:           "wasip1",
+	Architecture: "wasm",
+}
+
+// ImageSpec describes an OCI image used as the root file system of a module.
+type ImageSpec struct {
+	// Layout is the path to a directory holding images in the OCI image
+	// layout format.
+	Layout string
+
+	// Reference is the tag or digest of the image in the layout.
+	Reference string
+
+	// Platform selects the variant of the image to run. If nil, the
+	// DefaultImagePlatform is used.
+	Platform *ocispec.Platform
+
+	// LayersPath is the directory where the image layers are extracted.
+	// If empty, the layers are extracted in a temporary directory which is
+	// removed when the image is closed.
+	LayersPath string
+}
+
+// ParseImageSpec parses an image specification of the form <layout>:<tag>.
+//
+// When the tag is omitted, "latest" is used.
+func ParseImageSpec(s string) (ImageSpec, error) {
+	layout, reference := s, defaultImageTag
+	if i := strings.LastIndexByte(s, ':'); i >= 0 && !strings.ContainsRune(s[i:], filepath.Separator) {
+		layout, reference = s[:i], s[i+1:]
+	}
+	if layout == "" {
+		return ImageSpec{}, fmt.Errorf("malformed image %q: missing OCI layout directory", s)
+	}
+	if reference == "" {
+		return ImageSpec{}, fmt.Errorf("malformed image %q: missing image tag", s)
+	}
+	return ImageSpec{Layout: layout, Reference: reference}, nil
+}
+
+func (s *ImageSpec) String() string {
+	return s.Layout + ":" + s.Reference
+}
+
+// ParseImagePlatform parses a platform of the form os/arch[/variant].
+func ParseImagePlatform(s string) (ocispec.Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return ocispec.Platform{}, fmt.Errorf("malformed image platform: %q (not of the form os/arch[/variant])", s)
+	}
+	p := ocispec.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// FormatImagePlatform formats a platform in the form os/arch[/variant].
+func FormatImagePlatform(p ocispec.Platform) string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Annotations of the image descriptor recorded in the configuration of the
+// processes started from an image, which locate the image so it can be opened
+// again to replay or fork the processes.
+const (
+	imageLayoutAnnotation   = "timecraft.image.layout"
+	imagePlatformAnnotation = "timecraft.image.platform"
+)
+
+// imageDescriptor returns the descriptor of an image opened from spec, which
+// is recorded in the configuration of processes.
+func imageDescriptor(image *Image, spec *ImageSpec) (*format.Descriptor, error) {
+	layout, err := filepath.Abs(spec.Layout)
+	if err != nil {
+		return nil, err
+	}
+	platform := spec.Platform
+	if platform == nil {
+		platform = &DefaultImagePlatform
+	}
+	return &format.Descriptor{
+		MediaType: format.MediaType(image.Descriptor.MediaType),
+		Digest:    image.Digest(),
+		Size:      image.Descriptor.Size,
+		Annotations: map[string]string{
+			ocispec.AnnotationRefName: spec.Reference,
+			imageLayoutAnnotation:     layout,
+			imagePlatformAnnotation:   FormatImagePlatform(*platform),
+		},
+	}, nil
+}
+
+// recordedImageSpec returns the spec of the image described by a descriptor
+// recorded in the configuration of a process. The image is referenced by the
+// digest of its manifest, so the same image is opened even if its tag was
+// moved since.
+func recordedImageSpec(desc *format.Descriptor) (*ImageSpec, error) {
+	layout := desc.Annotations[imageLayoutAnnotation]
+	if layout == "" {
+		return nil, fmt.Errorf("the location of image %s was not recorded", desc.Digest)
+	}
+	spec := &ImageSpec{
+		Layout:    layout,
+		Reference: desc.Digest.String(),
+	}
+	if p, ok := desc.Annotations[imagePlatformAnnotation]; ok {
+		platform, err := ParseImagePlatform(p)
+		if err != nil {
+			return nil, err
+		}
+		spec.Platform = &platform
+	}
+	return spec, nil
+}
+
+// Image is an OCI image opened to run a module.
+type Image struct {
+	// Descriptor is the descriptor of the image manifest that was resolved
+	// for the image reference and platform.
+	Descriptor ocispec.Descriptor
+
+	// Config is the runtime configuration of the image (entrypoint, env,
+	// working directory, ...).
+	Config ocispec.ImageConfig
+
+	// FS is the root file system of the image, made of the image layers
+	// merged with whiteout semantics.
+	FS sandbox.FileSystem
+
+	files   []*os.File
+	tempDir string
+}
+
+// OpenImage resolves the image described by spec, extracts its layers and
+// constructs its root file system.
+//
+// The returned image must be closed when the program does not need to access
+// its file system anymore.
+func OpenImage(ctx context.Context, spec ImageSpec) (*Image, error) {
+	platform := spec.Platform
+	if platform == nil {
+		platform = &DefaultImagePlatform
+	}
+
+	store, err := oci.NewFromFS(ctx, os.DirFS(spec.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec.Layout, err)
+	}
+
+	desc, err := oras.Resolve(ctx, store, spec.Reference, oras.ResolveOptions{
+		TargetPlatform: platform,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	image, layers, err := container.FetchImage(ctx, store, desc.Digest.String(), platform)
+	if err != nil {
+		return nil, err
+	}
+	if len(image.RootFS.DiffIDs) != len(layers) {
+		return nil, fmt.Errorf("%s: image has %d layers but %d diff ids", spec.Reference, len(layers), len(image.RootFS.DiffIDs))
+	}
+
+	img := &Image{
+		Descriptor: desc,
+		Config:     image.Config,
+	}
+	success := false
+	defer func() {
+		if !success {
+			img.Close()
+		}
+	}()
+
+	layersPath := spec.LayersPath
+	if layersPath == "" {
+		img.tempDir, err = os.MkdirTemp("", "timecraft-layers-")
+		if err != nil {
+			return nil, err
+		}
+		layersPath = img.tempDir
+	} else if err := createDirectory(layersPath); err != nil {
+		return nil, err
+	}
+
+	img.files, err = container.RootFS(ctx, layersPath, store, image, layers)
+	if err != nil {
+		return nil, err
+	}
+
+	fsLayers := make([]sandbox.FileSystem, len(img.files))
+	for i, f := range img.files {
+		fsys, err := tarfs.OpenFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		fsLayers[i] = fsys
+	}
+
+	img.FS = ocifs.New(fsLayers...)
+	success = true
+	return img, nil
+}
+
+// Close releases the resources held by the image.
+func (img *Image) Close() error {
+	for _, f := range img.files {
+		f.Close()
+	}
+	img.files = nil
+	if img.tempDir != "" {
+		os.RemoveAll(img.tempDir)
+		img.tempDir = ""
+	}
+	return nil
+}
+
+// ReadModule reads the WebAssembly module at the given path of the image file
+// system. Relative paths are resolved from the image working directory.
+func (img *Image) ReadModule(modulePath string) ([]byte, error) {
+	if !path.IsAbs(modulePath) {
+		modulePath = path.Join("/", img.Config.WorkingDir, modulePath)
+	}
+	return sandbox.ReadFile(img.FS, modulePath, 0)
+}
+
+// Command returns the module path and arguments that the image runs when
+// given the list of arguments. Following the OCI conventions, the arguments
+// replace the default command of the image when they are not empty.
+func (img *Image) Command(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		args = img.Config.Cmd
+	}
+	command := append(append([]string{}, img.Config.Entrypoint...), args...)
+	if len(command) == 0 {
+		return "", nil, errors.New("image has no entrypoint or command")
+	}
+	return command[0], command[1:], nil
+}
+
+// Environ returns the image environment variables, followed by env.
+func (img *Image) Environ(env []string) []string {
+	environ := append([]string{}, img.Config.Env...)
+	if workingDir := img.Config.WorkingDir; workingDir != "" {
+		environ = append(environ, "PWD="+workingDir)
+	}
+	return append(environ, env...)
+}
+
+// Digest returns the hash of the image manifest.
+func (img *Image) Digest() format.Hash {
+	return format.ParseHash(img.Descriptor.Digest.String())
+}
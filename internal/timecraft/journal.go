@@ -0,0 +1,372 @@
+package timecraft
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TaskJournal is a durable journal of the tasks submitted to a TaskScheduler.
+//
+// The journal is a local file where an entry is appended each time a task is
+// submitted, completes, or is discarded. Entries for submitted tasks are
+// synced to disk before the tasks are queued.
+//
+// When the journal is opened, the tasks that were not discarded are loaded
+// from the file, which is then compacted to only retain those tasks. The
+// tasks are recovered by a call to TaskScheduler.Recover: tasks that were
+// queued or executing are executed again, providing at-least-once semantics.
+//
+// The file is also compacted while the journal is open, once the number of
+// discarded tasks exceeds the number of live tasks and reaches a threshold.
+// Tasks which were not discarded, such as the tasks with an idempotency key
+// of processes which exited, remain in the file until they are recovered.
+type TaskJournal struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	tasks []*journalTask
+
+	// Tasks which were not discarded, in the order they were submitted. The
+	// entries of discarded tasks are set to nil until the next compaction.
+	live     []*journalTask
+	index    map[TaskID]int
+	discards int
+}
+
+// journalCompactionThreshold is the minimum number of discarded tasks which
+// trigger a compaction of the journal while it is open.
+const journalCompactionThreshold = 1000
+
+type journalTask struct {
+	submit   journalEntry
+	complete *journalEntry
+}
+
+// Operations recorded in the entries of the journal.
+const (
+	journalSubmit   = "submit"
+	journalComplete = "complete"
+	journalDiscard  = "discard"
+)
+
+type journalEntry struct {
+	Op string
+	ID TaskID
+
+	// Fields of submit entries.
+	Creator      ProcessID      `json:",omitempty"`
+	CreatedAt    time.Time      `json:",omitempty"`
+	Module       *journalModule `json:",omitempty"`
+	Options      *TaskOptions   `json:",omitempty"`
+	HTTPRequest  *HTTPRequest   `json:",omitempty"`
+	FunctionCall *FunctionCall  `json:",omitempty"`
+
+	// Fields of complete entries.
+	State          TaskState       `json:",omitempty"`
+	Error          string          `json:",omitempty"`
+	HTTPResponse   *HTTPResponse   `json:",omitempty"`
+	FunctionResult *FunctionResult `json:",omitempty"`
+}
+
+// journalModule is the part of a ModuleSpec that is recorded in the journal.
+// The other fields are inherited from the module spec passed to Recover.
+type journalModule struct {
+	Path          string
+	Function      string         `json:",omitempty"`
+	Args          []string       `json:",omitempty"`
+	Env           []string       `json:",omitempty"`
+	OutboundProxy *journalModule `json:",omitempty"`
+}
+
+func makeJournalModule(moduleSpec *ModuleSpec) *journalModule {
+	m := &journalModule{
+		Path:     moduleSpec.Path,
+		Function: moduleSpec.Function,
+		Args:     moduleSpec.Args,
+		Env:      moduleSpec.Env,
+	}
+	if moduleSpec.OutboundProxy != nil {
+		m.OutboundProxy = makeJournalModule(moduleSpec.OutboundProxy)
+	}
+	return m
+}
+
+func (m *journalModule) moduleSpec(parent ModuleSpec) ModuleSpec {
+	child := parent
+	child.Path = m.Path
+	child.Function = m.Function
+	child.Args = m.Args
+	child.Env = m.Env
+	child.OutboundProxy = nil
+	if m.OutboundProxy != nil {
+		proxy := m.OutboundProxy.moduleSpec(parent)
+		child.OutboundProxy = &proxy
+	}
+	restrictSubprocess(&child)
+	return child
+}
+
+// OpenTaskJournal opens the task journal at the given path, creating it if it
+// does not exist.
+func OpenTaskJournal(path string) (*TaskJournal, error) {
+	tasks, err := readTaskJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := compactTaskJournal(path, tasks)
+	if err != nil {
+		return nil, err
+	}
+	j := &TaskJournal{
+		path:  path,
+		file:  f,
+		tasks: tasks,
+		live:  slices.Clone(tasks),
+		index: make(map[TaskID]int, len(tasks)),
+	}
+	for i, task := range tasks {
+		j.index[task.submit.ID] = i
+	}
+	return j, nil
+}
+
+func readTaskJournal(path string) ([]*journalTask, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var tasks []*journalTask
+	var index = map[TaskID]int{}
+	var r = bufio.NewReader(f)
+
+	for lineno := 1; ; lineno++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				// The last entry may have been partially written if the
+				// process crashed, it is discarded since the operation it
+				// recorded was never acknowledged.
+				break
+			}
+			return nil, err
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: malformed task journal entry: %w", path, lineno, err)
+		}
+
+		switch entry.Op {
+		case journalSubmit:
+			index[entry.ID] = len(tasks)
+			tasks = append(tasks, &journalTask{submit: entry})
+		case journalComplete:
+			if i, ok := index[entry.ID]; ok {
+				tasks[i].complete = &entry
+			}
+		case journalDiscard:
+			if i, ok := index[entry.ID]; ok {
+				tasks[i] = nil
+				delete(index, entry.ID)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: invalid task journal operation: %q", path, lineno, entry.Op)
+		}
+	}
+
+	live := tasks[:0]
+	for _, task := range tasks {
+		if task != nil {
+			live = append(live, task)
+		}
+	}
+	return live, nil
+}
+
+// compactTaskJournal rewrites the journal at path with the entries of the
+// given tasks, and returns the new file opened for appending entries.
+func compactTaskJournal(path string, tasks []*journalTask) (*os.File, error) {
+	b := new(bytes.Buffer)
+	for _, task := range tasks {
+		if err := appendJournalEntry(b, &task.submit); err != nil {
+			return nil, err
+		}
+		if task.complete != nil {
+			if err := appendJournalEntry(b, task.complete); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The journal contains the environment and inputs of tasks, which may
+	// hold secrets, so it is only readable by the owner.
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// The file remains open after the rename, so entries appended to it go
+	// to the new journal.
+	if err := os.Rename(tmp, path); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func appendJournalEntry(b *bytes.Buffer, entry *journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b.Write(line)
+	b.WriteByte('\n')
+	return nil
+}
+
+// Close closes the journal.
+func (j *TaskJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// recover returns the tasks loaded when the journal was opened. The tasks are
+// returned only once.
+func (j *TaskJournal) recover() []*journalTask {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	tasks := j.tasks
+	j.tasks = nil
+	return tasks
+}
+
+func (j *TaskJournal) submit(task *TaskInfo) error {
+	entry := &journalEntry{
+		Op:        journalSubmit,
+		ID:        task.id,
+		Creator:   task.creator,
+		CreatedAt: task.createdAt,
+		Module:    makeJournalModule(&task.moduleSpec),
+		Options:   &task.options,
+	}
+	switch input := task.input.(type) {
+	case *HTTPRequest:
+		entry.HTTPRequest = input
+	case *FunctionCall:
+		entry.FunctionCall = input
+	}
+	return j.append(entry, true, func() {
+		j.index[entry.ID] = len(j.live)
+		j.live = append(j.live, &journalTask{submit: *entry})
+	})
+}
+
+func (j *TaskJournal) complete(task *TaskInfo) error {
+	entry := &journalEntry{
+		Op:    journalComplete,
+		ID:    task.id,
+		State: task.state,
+	}
+	if task.err != nil {
+		entry.Error = task.err.Error()
+	}
+	switch output := task.output.(type) {
+	case *HTTPResponse:
+		entry.HTTPResponse = output
+	case *FunctionResult:
+		entry.FunctionResult = output
+	}
+	return j.append(entry, false, func() {
+		if i, ok := j.index[entry.ID]; ok {
+			j.live[i].complete = entry
+		}
+	})
+}
+
+func (j *TaskJournal) discard(id TaskID) error {
+	var compact bool
+	err := j.append(&journalEntry{Op: journalDiscard, ID: id}, false, func() {
+		if i, ok := j.index[id]; ok {
+			j.live[i] = nil
+			delete(j.index, id)
+			j.discards++
+		}
+		compact = j.discards >= journalCompactionThreshold && j.discards > len(j.index)
+	})
+	if err != nil || !compact {
+		return err
+	}
+	return j.compact()
+}
+
+// append writes an entry to the journal, and calls update to apply it to the
+// live tasks once it was written.
+func (j *TaskJournal) append(entry *journalEntry, sync bool, update func()) error {
+	b := new(bytes.Buffer)
+	if err := appendJournalEntry(b, entry); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(b.Bytes()); err != nil {
+		return err
+	}
+	if sync {
+		if err := j.file.Sync(); err != nil {
+			return err
+		}
+	}
+	update()
+	return nil
+}
+
+// compact rewrites the journal file to only retain the live tasks.
+func (j *TaskJournal) compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	live := j.live[:0]
+	for _, task := range j.live {
+		if task != nil {
+			j.index[task.submit.ID] = len(live)
+			live = append(live, task)
+		}
+	}
+	clear(j.live[len(live):])
+	j.live = live
+	j.discards = 0
+
+	f, err := compactTaskJournal(j.path, j.live)
+	if err != nil {
+		return err
+	}
+	j.file.Close()
+	j.file = f
+	return nil
+}
@@ -0,0 +1,325 @@
+package timecraft
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+func TestTaskJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+	assert.Equal(t, len(j.recover()), 0)
+
+	var tasks []*TaskInfo
+	for i := 0; i < 3; i++ {
+		task := &TaskInfo{
+			id:         uuid.New(),
+			creator:    uuid.New(),
+			createdAt:  time.Now().Truncate(time.Second),
+			moduleSpec: ModuleSpec{Path: "app.wasm", Args: []string{"serve"}},
+			input: &HTTPRequest{
+				Method:  "POST",
+				Path:    "/tasks",
+				Headers: http.Header{"Content-Type": {"text/plain"}},
+				Body:    []byte("hello"),
+				Port:    3000,
+			},
+			options: TaskOptions{Priority: HighPriority}.withDefaults(),
+		}
+		assert.OK(t, j.submit(task))
+		tasks = append(tasks, task)
+	}
+
+	tasks[0].state = Success
+	tasks[0].output = &HTTPResponse{StatusCode: 200, Body: []byte("world")}
+	assert.OK(t, j.complete(tasks[0]))
+	assert.OK(t, j.discard(tasks[1].id))
+	assert.OK(t, j.Close())
+
+	// Simulate a crash while an entry was being written.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	assert.OK(t, err)
+	_, err = f.WriteString(`{"Op":"submit","ID":`)
+	assert.OK(t, err)
+	assert.OK(t, f.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+
+	recovered := j.recover()
+	assert.Equal(t, len(recovered), 2)
+	assert.Equal(t, recovered[0].submit.ID, tasks[0].id)
+	assert.Equal(t, recovered[0].complete.State, Success)
+	assert.Equal(t, string(recovered[0].complete.HTTPResponse.Body), "world")
+	assert.Equal(t, recovered[1].submit.ID, tasks[2].id)
+	assert.Equal(t, recovered[1].submit.Creator, tasks[2].creator)
+	assert.True(t, recovered[1].submit.CreatedAt.Equal(tasks[2].createdAt))
+	assert.Equal(t, recovered[1].submit.Options.Priority, HighPriority)
+	assert.Equal(t, recovered[1].submit.HTTPRequest.Headers.Get("Content-Type"), "text/plain")
+	assert.Equal(t, recovered[1].complete, nil)
+
+	// The journal was compacted when opened.
+	b, err := os.ReadFile(path)
+	assert.OK(t, err)
+	assert.Equal(t, bytes.Count(b, []byte("\n")), 3)
+}
+
+func TestTaskSchedulerRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+	task := &TaskInfo{
+		id:         uuid.New(),
+		createdAt:  time.Now(),
+		state:      Error,
+		err:        errors.New("gone"),
+		moduleSpec: ModuleSpec{Path: "task.wasm", Env: []string{"A=1"}},
+		input:      &HTTPRequest{Method: "GET", Path: "/", Port: 3000},
+		options:    TaskOptions{IdempotencyKey: "task-1"},
+	}
+	assert.OK(t, j.submit(task))
+	assert.OK(t, j.complete(task))
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+
+	s := &TaskScheduler{Journal: j}
+	defer s.Close()
+
+	parent := ModuleSpec{Path: "app.wasm", Stdin: os.Stdin, HostNetworkBinding: true}
+	assert.Equal(t, s.Recover(parent, nil), 1)
+
+	recovered, ok := s.Lookup(task.id)
+	assert.True(t, ok)
+	assert.Equal(t, recovered.state, Error)
+	assert.Equal(t, recovered.err.Error(), "gone")
+	assert.Equal(t, recovered.moduleSpec.Path, "task.wasm")
+	assert.True(t, recovered.moduleSpec.Stdin == nil)
+	assert.False(t, recovered.moduleSpec.HostNetworkBinding)
+
+	// Submitting the task again with the same idempotency key claims the
+	// recovered task, and delivers its completion notification.
+	completions := make(chan TaskID)
+	taskID, err := s.Submit(ModuleSpec{}, nil, &HTTPRequest{}, TaskOptions{IdempotencyKey: "task-1"}, uuid.New(), completions)
+	assert.OK(t, err)
+	assert.Equal(t, taskID, task.id)
+	assert.Equal(t, <-completions, task.id)
+
+	assert.True(t, s.Discard(task.id))
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+	assert.Equal(t, len(j.recover()), 0)
+}
+
+func TestTaskJournalFunctionCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+	task := &TaskInfo{
+		id:         uuid.New(),
+		createdAt:  time.Now(),
+		state:      Success,
+		moduleSpec: ModuleSpec{Path: "task.wasm"},
+		input:      &FunctionCall{Function: "run", Args: []string{"-n", "1"}, Stdin: []byte("in")},
+		output:     &FunctionResult{ExitCode: 2, Stdout: []byte("out"), Stderr: []byte("err")},
+	}
+	assert.OK(t, j.submit(task))
+	assert.OK(t, j.complete(task))
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+
+	recovered := j.recover()
+	assert.Equal(t, len(recovered), 1)
+	assert.Equal(t, recovered[0].submit.FunctionCall.Function, "run")
+	assert.Equal(t, len(recovered[0].submit.FunctionCall.Args), 2)
+	assert.Equal(t, string(recovered[0].submit.FunctionCall.Stdin), "in")
+	assert.Equal(t, recovered[0].complete.FunctionResult.ExitCode, 2)
+	assert.Equal(t, string(recovered[0].complete.FunctionResult.Stdout), "out")
+	assert.Equal(t, string(recovered[0].complete.FunctionResult.Stderr), "err")
+}
+
+func TestTaskSchedulerRecoverUnclaimed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+	var tasks []*TaskInfo
+	for _, key := range []string{"", "task-1"} {
+		task := &TaskInfo{
+			id:         uuid.New(),
+			createdAt:  time.Now(),
+			state:      Success,
+			moduleSpec: ModuleSpec{Path: "task.wasm"},
+			input:      &FunctionCall{},
+			output:     &FunctionResult{},
+			options:    TaskOptions{IdempotencyKey: key},
+		}
+		assert.OK(t, j.submit(task))
+		assert.OK(t, j.complete(task))
+		tasks = append(tasks, task)
+	}
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+
+	s := &TaskScheduler{Journal: j, ClaimTimeout: 10 * time.Millisecond}
+	defer s.Close()
+	assert.Equal(t, s.Recover(ModuleSpec{}, nil), 2)
+
+	// Tasks without an idempotency key cannot be claimed, they are discarded
+	// as soon as they are complete.
+	_, ok := s.Lookup(tasks[0].id)
+	assert.False(t, ok)
+
+	// Other tasks are discarded once the claim timeout has elapsed.
+	_, ok = s.Lookup(tasks[1].id)
+	assert.True(t, ok)
+	for ok {
+		time.Sleep(time.Millisecond)
+		_, ok = s.Lookup(tasks[1].id)
+	}
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+	assert.Equal(t, len(j.recover()), 0)
+}
+
+func TestTaskGroupCloseJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+
+	s := &TaskScheduler{Journal: j}
+	defer s.Close()
+
+	g := NewTaskGroup(s)
+	for _, key := range []string{"", "task-1"} {
+		_, err := g.Submit(ModuleSpec{}, nil, nil, TaskOptions{IdempotencyKey: key}, uuid.New())
+		assert.OK(t, err)
+		<-g.Poll()
+	}
+	assert.OK(t, g.Close())
+	assert.OK(t, j.Close())
+
+	// Only the task with an idempotency key is left in the journal, since
+	// the other one could never be claimed after a restart.
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+	recovered := j.recover()
+	assert.Equal(t, len(recovered), 1)
+	assert.Equal(t, recovered[0].submit.Options.IdempotencyKey, "task-1")
+}
+
+func TestTaskGroupClaimRecovered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+	task := &TaskInfo{
+		id:         uuid.New(),
+		createdAt:  time.Now(),
+		state:      Success,
+		moduleSpec: ModuleSpec{Path: "task.wasm"},
+		input:      &FunctionCall{},
+		output:     &FunctionResult{},
+		options:    TaskOptions{IdempotencyKey: "task-1"},
+	}
+	assert.OK(t, j.submit(task))
+	assert.OK(t, j.complete(task))
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+
+	s := &TaskScheduler{Journal: j}
+	defer s.Close()
+	assert.Equal(t, s.Recover(ModuleSpec{}, nil), 1)
+
+	g1, g2 := NewTaskGroup(s), NewTaskGroup(s)
+	defer g1.Close()
+	defer g2.Close()
+
+	// The first group to submit the key claims the recovered task, the
+	// other group gets a new task.
+	taskID, err := g1.Submit(ModuleSpec{}, nil, nil, task.options, uuid.New())
+	assert.OK(t, err)
+	assert.Equal(t, taskID, task.id)
+	assert.Equal(t, <-g1.Poll(), task.id)
+
+	taskID, err = g2.Submit(ModuleSpec{}, nil, nil, task.options, uuid.New())
+	assert.OK(t, err)
+	assert.NotEqual(t, taskID, task.id)
+	assert.Equal(t, <-g2.Poll(), taskID)
+}
+
+func TestTaskJournalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal")
+
+	j, err := OpenTaskJournal(path)
+	assert.OK(t, err)
+
+	newTask := func() *TaskInfo {
+		return &TaskInfo{
+			id:         uuid.New(),
+			createdAt:  time.Now(),
+			state:      Success,
+			moduleSpec: ModuleSpec{Path: "task.wasm"},
+			input:      &FunctionCall{},
+			output:     &FunctionResult{},
+		}
+	}
+
+	live := newTask()
+	assert.OK(t, j.submit(live))
+
+	for i := 0; i < journalCompactionThreshold; i++ {
+		task := newTask()
+		assert.OK(t, j.submit(task))
+		assert.OK(t, j.complete(task))
+		assert.OK(t, j.discard(task.id))
+	}
+
+	// The journal was compacted once enough tasks were discarded, and
+	// entries are appended to the compacted file.
+	b, err := os.ReadFile(path)
+	assert.OK(t, err)
+	assert.Equal(t, bytes.Count(b, []byte("\n")), 1)
+
+	assert.OK(t, j.complete(live))
+	assert.OK(t, j.Close())
+
+	j, err = OpenTaskJournal(path)
+	assert.OK(t, err)
+	defer j.Close()
+
+	recovered := j.recover()
+	assert.Equal(t, len(recovered), 1)
+	assert.Equal(t, recovered[0].submit.ID, live.id)
+	assert.Equal(t, recovered[0].complete.State, Success)
+}
@@ -1,12 +1,15 @@
 package timecraft
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
 	"time"
 
 	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
 )
 
 // ModuleSpec is the details about what WebAssembly module to execute,
@@ -25,6 +28,19 @@ type ModuleSpec struct {
 	// Env is the environment variables to pass to the module.
 	Env []string
 
+	// Fork is an optional ID of a recorded process to fork the module from.
+	// When set, the module code, function, arguments, and environment are
+	// those of the recorded process, and Path is ignored. The log of the
+	// recorded process is replayed, then the execution continues live after
+	// the last record. If the recorded process was started from an image,
+	// Image must be set to the same image.
+	Fork *ProcessID
+
+	// Image is an optional OCI image providing the root file system of the
+	// module. When Path is empty, the module path and default arguments are
+	// taken from the image entrypoint and command.
+	Image *ImageSpec
+
 	// Dirs is a set of directories to make available to the module.
 	Dirs []string
 
@@ -47,6 +63,15 @@ type ModuleSpec struct {
 	// made by the module.
 	Trace io.Writer
 
+	// Observer is an optional function invoked after each system call made
+	// by the module.
+	Observer func(context.Context, wasicall.Syscall)
+
+	// OnExit is an optional function invoked with the error returned by the
+	// module when it exits, after its output was written to Stdout and Stderr.
+	// It is not inherited by subprocesses.
+	OnExit func(error)
+
 	// Allow the module to bind to the host network when opening listening
 	// sockets.
 	HostNetworkBinding bool
@@ -60,12 +85,17 @@ type ModuleSpec struct {
 // Key is a string that uniquely identifies the ModuleSpec.
 func (m *ModuleSpec) Key() string {
 	// TODO: we need a hashable key, but ModuleSpec has nested slices. We also
-	//  need a string key for the singleflight library. This isn't very
-	//  efficient, doesn't consider all fields, and doesn't handle fields that
-	//  need canonicalization (e.g. sorting of environment variables)
+	//  need a string key to index the process pools of the task scheduler.
+	//  This isn't very efficient, doesn't consider all fields, and doesn't
+	//  handle fields that need canonicalization (e.g. sorting of environment
+	//  variables)
 
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf(":%d:%s", len(m.Path), m.Path))
+	if m.Image != nil {
+		image := m.Image.String()
+		b.WriteString(fmt.Sprintf(":%d:%s", len(image), image))
+	}
 	b.WriteString(fmt.Sprintf(":%d", len(m.Args)))
 	for _, arg := range m.Args {
 		b.WriteString(fmt.Sprintf(":%d:%s", len(arg), arg))
@@ -83,6 +113,16 @@ type LogSpec struct {
 	StartTime   time.Time
 	Compression timemachine.Compression
 	BatchSize   int
+
+	// Size and age after which the log rolls over to a new segment. Zero
+	// values disable the limits.
+	SegmentSize     int64
+	SegmentDuration time.Duration
+
+	// File descriptors and path prefixes for which the data read or written
+	// is elided from the log (see wasicall.ElideFDs and wasicall.ElidePaths).
+	ElideFDs   []wasi.FD
+	ElidePaths []string
 }
 
 func (l *LogSpec) Fork() *LogSpec {
@@ -90,8 +130,12 @@ func (l *LogSpec) Fork() *LogSpec {
 		return nil
 	}
 	return &LogSpec{
-		StartTime:   time.Now(),
-		BatchSize:   l.BatchSize,
-		Compression: l.Compression,
+		StartTime:       time.Now(),
+		BatchSize:       l.BatchSize,
+		Compression:     l.Compression,
+		SegmentSize:     l.SegmentSize,
+		SegmentDuration: l.SegmentDuration,
+		ElideFDs:        l.ElideFDs,
+		ElidePaths:      l.ElidePaths,
 	}
 }
@@ -0,0 +1,250 @@
+package timecraft
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TaskPoolConfig configures the pools of processes that a TaskScheduler starts
+// to execute tasks. Tasks submitted with the same ModuleSpec are executed by
+// the same pool of processes.
+type TaskPoolConfig struct {
+	// MinSize is the number of processes that a pool retains when idle. Pools
+	// scale down to zero processes when MinSize is zero.
+	MinSize int
+
+	// MaxSize is the maximum number of processes in a pool (default to 4).
+	MaxSize int
+
+	// ScaleUpThreshold is the time that a queued task may wait for a process
+	// to become available before the pool starts a new process (default to
+	// 100ms).
+	ScaleUpThreshold time.Duration
+
+	// IdleTimeout is the time after which a process which has not executed
+	// any tasks is stopped, unless it would shrink its pool below MinSize
+	// (default to 1 minute).
+	IdleTimeout time.Duration
+}
+
+const (
+	defaultPoolMaxSize          = 4
+	defaultPoolScaleUpThreshold = 100 * time.Millisecond
+	defaultPoolIdleTimeout      = 1 * time.Minute
+)
+
+func (c TaskPoolConfig) withDefaults() TaskPoolConfig {
+	c.MinSize = max(c.MinSize, 0)
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultPoolMaxSize
+	}
+	c.MaxSize = max(c.MaxSize, c.MinSize, 1)
+	if c.ScaleUpThreshold <= 0 {
+		c.ScaleUpThreshold = defaultPoolScaleUpThreshold
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultPoolIdleTimeout
+	}
+	return c
+}
+
+// poolManager is the subset of the ProcessManager API used by process pools.
+type poolManager interface {
+	Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentID *ProcessID) (ProcessID, error)
+	Lookup(processID ProcessID) (ProcessInfo, bool)
+	Stop(processID ProcessID) bool
+}
+
+// processPool is a pool of processes started from the same module spec.
+//
+// Tasks are balanced across the processes of the pool with a least
+// outstanding tasks policy: a task is assigned to an idle process when there
+// is one. When all the processes are busy, the task waits for one of them to
+// become idle, and the pool starts a new process if the task has been queued
+// for longer than the scale up threshold. Once the pool has reached its
+// maximum size, tasks are assigned to the process with the fewest tasks in
+// flight.
+type processPool struct {
+	config  TaskPoolConfig
+	manager poolManager
+
+	mu        sync.Mutex
+	processes []*poolProcess
+	starting  int
+	// Closed and replaced when the state of the pool changes, to wake up the
+	// tasks waiting for a process.
+	changed chan struct{}
+}
+
+type poolProcess struct {
+	ProcessInfo
+	inflight int
+	lastUsed time.Time
+}
+
+func newProcessPool(config TaskPoolConfig, manager poolManager) *processPool {
+	return &processPool{
+		config:  config,
+		manager: manager,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire selects a process to execute the task, starting new processes when
+// needed. The process must be released when the task is complete.
+func (p *processPool) acquire(ctx context.Context, task *TaskInfo) (*poolProcess, error) {
+	for {
+		p.mu.Lock()
+		p.prune()
+
+		process := p.leastOutstanding()
+		size := len(p.processes) + p.starting
+		waited := time.Since(task.createdAt)
+
+		if process != nil && process.inflight == 0 {
+			process.inflight++
+			p.mu.Unlock()
+			return process, nil
+		}
+
+		if size < p.config.MaxSize {
+			if size < p.config.MinSize || size == 0 || waited >= p.config.ScaleUpThreshold {
+				return p.start(task)
+			}
+		} else if process != nil {
+			process.inflight++
+			p.mu.Unlock()
+			return process, nil
+		}
+
+		changed := p.changed
+		p.mu.Unlock()
+
+		// When the pool can still grow, wake up once the task has been queued
+		// for long enough to start a new process.
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if size < p.config.MaxSize {
+			timer = time.NewTimer(p.config.ScaleUpThreshold - waited)
+			timeout = timer.C
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := context.Cause(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// start starts a new process for the task. The method must be called with
+// the mutex held, which it releases.
+func (p *processPool) start(task *TaskInfo) (*poolProcess, error) {
+	p.starting++
+	p.mu.Unlock()
+
+	process, err := p.startProcess(task)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.notify()
+
+	p.starting--
+	if err != nil {
+		return nil, err
+	}
+	pp := &poolProcess{ProcessInfo: process, inflight: 1}
+	p.processes = append(p.processes, pp)
+	return pp, nil
+}
+
+func (p *processPool) startProcess(task *TaskInfo) (ProcessInfo, error) {
+	// The log spec is forked because the process manager reuses the process
+	// ID of log specs, and a task may start more than one process.
+	processID, err := p.manager.Start(task.moduleSpec, task.logSpec.Fork(), nil)
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	process, ok := p.manager.Lookup(processID)
+	if !ok {
+		return ProcessInfo{}, errors.New("failed to start process")
+	}
+	return process, nil
+}
+
+// release must be called when a task acquired from the pool is complete.
+func (p *processPool) release(process *poolProcess) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	process.inflight--
+	process.lastUsed = time.Now()
+	p.notify()
+}
+
+// scaleDown stops the processes which have been idle for longer than the idle
+// timeout.
+func (p *processPool) scaleDown(now time.Time) {
+	var idle []ProcessID
+
+	p.mu.Lock()
+	p.prune()
+	processes := p.processes[:0]
+	for _, process := range p.processes {
+		if process.inflight == 0 &&
+			now.Sub(process.lastUsed) >= p.config.IdleTimeout &&
+			len(p.processes)-len(idle) > p.config.MinSize {
+			idle = append(idle, process.ID)
+		} else {
+			processes = append(processes, process)
+		}
+	}
+	clear(p.processes[len(processes):])
+	p.processes = processes
+	p.mu.Unlock()
+
+	for _, processID := range idle {
+		p.manager.Stop(processID)
+	}
+}
+
+// prune removes the processes which have exited. The method must be called
+// with the mutex held.
+func (p *processPool) prune() {
+	processes := p.processes[:0]
+	for _, process := range p.processes {
+		if _, ok := p.manager.Lookup(process.ID); ok {
+			processes = append(processes, process)
+		}
+	}
+	if len(processes) < len(p.processes) {
+		clear(p.processes[len(processes):])
+		p.processes = processes
+	}
+}
+
+// leastOutstanding returns the process with the fewest tasks in flight, or
+// nil if the pool is empty. The method must be called with the mutex held.
+func (p *processPool) leastOutstanding() (process *poolProcess) {
+	for _, pp := range p.processes {
+		if process == nil || pp.inflight < process.inflight {
+			process = pp
+		}
+	}
+	return process
+}
+
+// notify wakes up the tasks waiting for a process. The method must be called
+// with the mutex held.
+func (p *processPool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
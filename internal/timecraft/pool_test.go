@@ -0,0 +1,135 @@
+package timecraft
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+type fakePoolManager struct {
+	mu        sync.Mutex
+	processes map[ProcessID]bool
+	started   int
+}
+
+func (m *fakePoolManager) Start(ModuleSpec, *LogSpec, *ProcessID) (ProcessID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.processes == nil {
+		m.processes = map[ProcessID]bool{}
+	}
+	processID := uuid.New()
+	m.processes[processID] = true
+	m.started++
+	return processID, nil
+}
+
+func (m *fakePoolManager) Lookup(processID ProcessID) (ProcessInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return ProcessInfo{ID: processID}, m.processes[processID]
+}
+
+func (m *fakePoolManager) Stop(processID ProcessID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ok := m.processes[processID]
+	delete(m.processes, processID)
+	return ok
+}
+
+func (m *fakePoolManager) count() (started, alive int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started, len(m.processes)
+}
+
+func TestProcessPool(t *testing.T) {
+	ctx := context.Background()
+	manager := &fakePoolManager{}
+	pool := newProcessPool(TaskPoolConfig{
+		MinSize:          1,
+		MaxSize:          2,
+		ScaleUpThreshold: 10 * time.Millisecond,
+		IdleTimeout:      time.Minute,
+	}.withDefaults(), manager)
+
+	now := time.Now()
+	task := &TaskInfo{createdAt: now}
+
+	// The first task starts a process, and the next task reuses it once it
+	// has become idle.
+	p1, err := pool.acquire(ctx, task)
+	assert.OK(t, err)
+	pool.release(p1)
+	p2, err := pool.acquire(ctx, task)
+	assert.OK(t, err)
+	assert.Equal(t, p2, p1)
+
+	// A task queued for longer than the scale up threshold starts a new
+	// process while the first one is busy.
+	p3, err := pool.acquire(ctx, &TaskInfo{createdAt: now.Add(-time.Second)})
+	assert.OK(t, err)
+	assert.NotEqual(t, p3.ID, p1.ID)
+
+	// Once the pool has reached its maximum size, tasks are assigned to the
+	// process with the least outstanding tasks.
+	pool.release(p1)
+	p4, err := pool.acquire(ctx, &TaskInfo{createdAt: now.Add(-time.Second)})
+	assert.OK(t, err)
+	assert.Equal(t, p4, p1)
+	p5, err := pool.acquire(ctx, &TaskInfo{createdAt: now.Add(-time.Second)})
+	assert.OK(t, err)
+	assert.Equal(t, p5.inflight, 2)
+
+	started, _ := manager.count()
+	assert.Equal(t, started, 2)
+
+	// Busy processes are not stopped.
+	pool.scaleDown(time.Now().Add(time.Hour))
+	_, alive := manager.count()
+	assert.Equal(t, alive, 2)
+
+	// Idle processes are stopped, down to the minimum size of the pool.
+	pool.release(p3)
+	pool.release(p4)
+	pool.release(p5)
+	pool.scaleDown(time.Now().Add(time.Hour))
+	_, alive = manager.count()
+	assert.Equal(t, alive, 1)
+}
+
+func TestProcessPoolWait(t *testing.T) {
+	manager := &fakePoolManager{}
+	pool := newProcessPool(TaskPoolConfig{
+		MaxSize:          2,
+		ScaleUpThreshold: time.Hour,
+	}.withDefaults(), manager)
+
+	p1, err := pool.acquire(context.Background(), &TaskInfo{createdAt: time.Now()})
+	assert.OK(t, err)
+
+	// The task waits for the busy process rather than starting a new one,
+	// until its context is canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.acquire(ctx, &TaskInfo{createdAt: time.Now()})
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	// The task is assigned the process once it is released.
+	done := make(chan *poolProcess)
+	go func() {
+		p, _ := pool.acquire(context.Background(), &TaskInfo{createdAt: time.Now()})
+		done <- p
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pool.release(p1)
+	assert.Equal(t, <-done, p1)
+
+	started, _ := manager.count()
+	assert.Equal(t, started, 1)
+}
@@ -125,11 +125,61 @@ func NewProcessManager(ctx context.Context, registry *timemachine.Registry, runt
 // successfully, any errors that occur during execution must be retrieved
 // via Wait or WaitAll.
 func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentID *ProcessID) (ProcessID, error) {
+	var image *Image
+	if moduleSpec.Image != nil {
+		var err error
+		image, err = OpenImage(pm.ctx, *moduleSpec.Image)
+		if err != nil {
+			return ProcessID{}, fmt.Errorf("could not open image '%s': %w", moduleSpec.Image, err)
+		}
+		defer func() {
+			if image != nil {
+				image.Close()
+			}
+		}()
+		// The path and arguments of forks are those recorded for the parent
+		// process, which already include the image defaults.
+		if moduleSpec.Path == "" && moduleSpec.Fork == nil {
+			moduleSpec.Path, moduleSpec.Args, err = image.Command(moduleSpec.Args)
+			if err != nil {
+				return ProcessID{}, fmt.Errorf("could not run image '%s': %w", moduleSpec.Image, err)
+			}
+		}
+		moduleSpec.Env = image.Environ(moduleSpec.Env)
+	}
+
+	var parent *fork
+	if moduleSpec.Fork != nil {
+		var err error
+		parent, err = openFork(pm.ctx, pm.registry, *moduleSpec.Fork)
+		if err != nil {
+			return ProcessID{}, err
+		}
+		defer func() {
+			if parent != nil {
+				parent.records.Close()
+			}
+		}()
+		if err := parent.checkImage(image); err != nil {
+			return ProcessID{}, err
+		}
+		moduleSpec.Path = parent.args[0]
+		moduleSpec.Args = parent.args[1:]
+		moduleSpec.Env = parent.env
+		moduleSpec.Function = parent.function
+	}
+
 	wasmPath := moduleSpec.Path
 	wasmName := filepath.Base(wasmPath)
-	wasmCode, err := os.ReadFile(wasmPath)
-	if err != nil {
-		return ProcessID{}, fmt.Errorf("could not read wasm file '%s': %w", wasmPath, err)
+	var wasmCode []byte
+	var err error
+	if parent != nil {
+		wasmCode = parent.code
+	} else {
+		wasmCode, err = readModule(image, wasmPath)
+		if err != nil {
+			return ProcessID{}, fmt.Errorf("could not read wasm file '%s': %w", wasmPath, err)
+		}
 	}
 	function := moduleSpec.Function
 	wasmModule, err := pm.runtime.CompileModule(pm.ctx, wasmCode)
@@ -214,6 +264,10 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 		sandbox.Network(netns),
 	}
 
+	if image != nil {
+		options = append(options, sandbox.Mount("/", image.FS))
+	}
+
 	for _, dir := range moduleSpec.Dirs {
 		options = append(options, sandbox.Mount(dir, sandbox.DirFS(dir)))
 	}
@@ -236,8 +290,22 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 	}
 
 	var system wasi.System = guest
-	var logSegment io.WriteCloser
-	var recordWriter *timemachine.LogRecordWriter
+	if parent != nil {
+		replay := wasicall.NewReplay(parent.records)
+		if moduleSpec.Stdout != nil {
+			replay.Stdout = moduleSpec.Stdout
+		}
+		if moduleSpec.Stderr != nil {
+			replay.Stderr = moduleSpec.Stderr
+		}
+		stderr, forkedFrom := moduleSpec.Stderr, parent.parentID
+		system = wasicall.NewResumeSystem(replay, system, func(ctx context.Context, err error) {
+			if err != nil && stderr != nil {
+				fmt.Fprintf(stderr, "warning: some file descriptors of process %s could not be reopened after forking:\n%s\n", forkedFrom, err)
+			}
+		})
+	}
+	var recordWriter *timemachine.LogSegmentWriter
 	var processID ProcessID
 	if logSpec != nil && logSpec.ProcessID != (ProcessID{}) {
 		processID = logSpec.ProcessID
@@ -272,12 +340,20 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 			return ProcessID{}, err
 		}
 
-		config, err := pm.registry.CreateConfig(pm.ctx, &format.Config{
+		processConfig := &format.Config{
 			Runtime: runtime,
 			Modules: []*format.Descriptor{module},
 			Args:    append([]string{wasmName}, moduleSpec.Args...),
 			Env:     moduleSpec.Env,
-		})
+		}
+		if image != nil {
+			processConfig.Image, err = imageDescriptor(image, moduleSpec.Image)
+			if err != nil {
+				return ProcessID{}, err
+			}
+		}
+
+		config, err := pm.registry.CreateConfig(pm.ctx, processConfig)
 		if err != nil {
 			return ProcessID{}, err
 		}
@@ -286,25 +362,36 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 			ID:        logSpec.ProcessID,
 			StartTime: logSpec.StartTime,
 			Config:    config,
+			ParentID:  moduleSpec.Fork,
 		})
 		if err != nil {
 			return ProcessID{}, err
 		}
 
-		if err := pm.registry.CreateLogManifest(pm.ctx, logSpec.ProcessID, &format.Manifest{
+		manifest := &format.Manifest{
+			ProcessID: logSpec.ProcessID,
 			Process:   process,
 			StartTime: logSpec.StartTime,
-		}); err != nil {
+		}
+		if err := pm.registry.CreateLogManifest(pm.ctx, logSpec.ProcessID, manifest); err != nil {
 			return ProcessID{}, err
 		}
 
-		// TODO: create a writer that writes to many segments
-		logSegment, err = pm.registry.CreateLogSegment(pm.ctx, logSpec.ProcessID, 0)
+		recordWriter, err = pm.registry.CreateLogSegmentWriter(pm.ctx, manifest, logSpec.BatchSize, logSpec.Compression, timemachine.LogSegmentPolicy{
+			MaxSize:     logSpec.SegmentSize,
+			MaxDuration: logSpec.SegmentDuration,
+		})
 		if err != nil {
 			return ProcessID{}, err
 		}
-		logWriter := timemachine.NewLogWriter(logSegment)
-		recordWriter = timemachine.NewLogRecordWriter(logWriter, logSpec.BatchSize, logSpec.Compression)
+
+		var recorderOptions []wasicall.RecorderOption
+		if len(logSpec.ElideFDs) != 0 {
+			recorderOptions = append(recorderOptions, wasicall.ElideFDs(logSpec.ElideFDs...))
+		}
+		if len(logSpec.ElidePaths) != 0 {
+			recorderOptions = append(recorderOptions, wasicall.ElidePaths(logSpec.ElidePaths...))
+		}
 
 		var b timemachine.RecordBuilder
 		system = wasicall.NewRecorder(system, func(id wasicall.SyscallID, syscallBytes []byte) {
@@ -315,11 +402,15 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 			if err := recordWriter.WriteRecord(&b); err != nil {
 				panic(err) // caught/handled by wazero
 			}
-		})
+		}, recorderOptions...)
 	} else {
 		processID = uuid.New()
 	}
 
+	if moduleSpec.Observer != nil {
+		system = wasicall.NewObserver(system, nil, moduleSpec.Observer)
+	}
+
 	if moduleSpec.Trace != nil {
 		system = wasi.Trace(moduleSpec.Trace, system)
 	}
@@ -375,6 +466,7 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 	server := pm.serverFactory.NewServer(pm.ctx, processID, moduleSpec, logSpec)
 	serverListener, err := guest.Listen(pm.ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", timecraftServicePort))
 	if err != nil {
+		cancel(err)
 		return ProcessID{}, err
 	}
 	go func() {
@@ -412,6 +504,13 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 	pm.processes[processID] = process
 	pm.mu.Unlock()
 
+	// Ownership of the image is transferred to the goroutine running the
+	// module, which closes it after the module exits.
+	processImage := image
+	image = nil
+	processParent := parent
+	parent = nil
+
 	// Run the module in the background, and tidy up once complete.
 	pm.group.Go(func() error {
 		err := runModule(ctx, pm.runtime, wasmModule, function)
@@ -429,11 +528,22 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 		_ = group.Wait()
 
 		if logSpec != nil {
-			recordWriter.Flush()
-			logSegment.Close()
+			recordWriter.Close()
+		}
+
+		if processImage != nil {
+			processImage.Close()
+		}
+
+		if processParent != nil {
+			processParent.records.Close()
 		}
 
 		netns.Detach()
+
+		if moduleSpec.OnExit != nil {
+			moduleSpec.OnExit(err)
+		}
 		return err
 	})
 
@@ -441,6 +551,13 @@ func (pm *ProcessManager) Start(moduleSpec ModuleSpec, logSpec *LogSpec, parentI
 	return processID, nil
 }
 
+func readModule(image *Image, wasmPath string) ([]byte, error) {
+	if image != nil {
+		return image.ReadModule(wasmPath)
+	}
+	return os.ReadFile(wasmPath)
+}
+
 func copyAndClose(w io.Writer, r io.ReadCloser) error {
 	defer r.Close()
 	_, err := io.Copy(w, r)
@@ -481,6 +598,21 @@ func (pm *ProcessManager) Wait(processID ProcessID) error {
 	return err
 }
 
+// Stop stops a process.
+//
+// The method does not wait for the process to exit, use Wait to block until
+// it has. The return flag is false if the process was not found.
+func (pm *ProcessManager) Stop(processID ProcessID) bool {
+	pm.mu.Lock()
+	p, ok := pm.processes[processID]
+	pm.mu.Unlock()
+
+	if ok {
+		p.cancel(nil)
+	}
+	return ok
+}
+
 // WaitAll blocks until all processes have exited.
 func (pm *ProcessManager) WaitAll() error {
 	return pm.group.Wait()
@@ -0,0 +1,214 @@
+package timecraft
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// taskQueue is a priority queue of tasks.
+//
+// Tasks with a higher priority are dequeued first, and tasks with the same
+// priority are dequeued in the order they were pushed to the queue. Tasks
+// pushed with a not-before time are held back until that time is reached.
+//
+// Tasks which remain in the queue for longer than their queue timeout are
+// removed and passed to the expire function, even if they are never popped.
+type taskQueue struct {
+	mu      sync.Mutex
+	ready   readyTasks
+	delayed delayedTasks
+	seq     uint64
+	expire  func(*TaskInfo)
+	// Closed and replaced when tasks are pushed to the queue, to wake up the
+	// goroutines waiting to pop tasks.
+	changed chan struct{}
+}
+
+func newTaskQueue(expire func(*TaskInfo)) *taskQueue {
+	return &taskQueue{expire: expire, changed: make(chan struct{})}
+}
+
+// push adds a task to the queue. The task cannot be popped before notBefore,
+// and expires if it is not popped within its queue timeout of that time.
+//
+// The deadline is set when the task is first pushed; tasks pushed again to be
+// retried keep it, so the queue timeout bounds the time from the first push
+// to the start of the last attempt.
+func (q *taskQueue) push(task *TaskInfo, notBefore time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if notBefore.Before(now) {
+		notBefore = now
+	}
+
+	if task.queueDeadline.IsZero() {
+		task.queueDeadline = notBefore.Add(task.options.QueueTimeout)
+	}
+
+	q.seq++
+	queued := &queuedTask{
+		task:      task,
+		seq:       q.seq,
+		notBefore: notBefore,
+		deadline:  task.queueDeadline,
+	}
+	if notBefore.After(now) {
+		queued.delayed = true
+		heap.Push(&q.delayed, queued)
+	} else {
+		heap.Push(&q.ready, queued)
+	}
+	queued.timer = time.AfterFunc(queued.deadline.Sub(now), func() {
+		if q.remove(queued) {
+			q.expire(task)
+		}
+	})
+
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// pop removes the task with the highest priority from the queue, blocking
+// until a task is available or the context is canceled.
+//
+// Tasks which reached their queue timeout are expired instead of returned.
+func (q *taskQueue) pop(ctx context.Context) (*TaskInfo, error) {
+	for {
+		q.mu.Lock()
+		now := time.Now()
+		for len(q.delayed.queuedTasks) > 0 && !q.delayed.queuedTasks[0].notBefore.After(now) {
+			queued := heap.Pop(&q.delayed).(*queuedTask)
+			queued.delayed = false
+			heap.Push(&q.ready, queued)
+		}
+		if len(q.ready.queuedTasks) > 0 {
+			queued := heap.Pop(&q.ready).(*queuedTask)
+			q.mu.Unlock()
+
+			// The task was removed from the heap so the timer cannot expire
+			// it anymore, it is expired here if its deadline has passed.
+			queued.timer.Stop()
+			if now.After(queued.deadline) {
+				q.expire(queued.task)
+				continue
+			}
+			return queued.task, nil
+		}
+		// When delayed tasks are queued, wake up when the first one is ready.
+		var timer *time.Timer
+		var wakeup <-chan time.Time
+		if len(q.delayed.queuedTasks) > 0 {
+			timer = time.NewTimer(q.delayed.queuedTasks[0].notBefore.Sub(now))
+			wakeup = timer.C
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		var err error
+		select {
+		case <-changed:
+		case <-wakeup:
+		case <-ctx.Done():
+			err = context.Cause(ctx)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// close stops the expiration of the tasks remaining in the queue.
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, tasks := range []*queuedTasks{&q.ready.queuedTasks, &q.delayed.queuedTasks} {
+		for _, queued := range *tasks {
+			queued.timer.Stop()
+			queued.index = -1
+		}
+		*tasks = nil
+	}
+}
+
+// remove removes a task from the queue, returning false if it was already
+// popped.
+func (q *taskQueue) remove(queued *queuedTask) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if queued.index < 0 {
+		return false
+	}
+	if queued.delayed {
+		heap.Remove(&q.delayed, queued.index)
+	} else {
+		heap.Remove(&q.ready, queued.index)
+	}
+	return true
+}
+
+type queuedTask struct {
+	task      *TaskInfo
+	seq       uint64
+	notBefore time.Time
+	deadline  time.Time
+	timer     *time.Timer
+	delayed   bool
+	index     int
+}
+
+type queuedTasks []*queuedTask
+
+func (h queuedTasks) Len() int { return len(h) }
+
+func (h queuedTasks) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *queuedTasks) Push(x any) {
+	queued := x.(*queuedTask)
+	queued.index = len(*h)
+	*h = append(*h, queued)
+}
+
+func (h *queuedTasks) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	x.index = -1
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// readyTasks is a heap of the tasks which can be popped, ordered by priority.
+type readyTasks struct{ queuedTasks }
+
+func (h readyTasks) Less(i, j int) bool {
+	ti, tj := h.queuedTasks[i], h.queuedTasks[j]
+	if pi, pj := ti.task.options.Priority, tj.task.options.Priority; pi != pj {
+		return pi > pj
+	}
+	return ti.seq < tj.seq
+}
+
+// delayedTasks is a heap of the tasks held back until their not-before time.
+type delayedTasks struct{ queuedTasks }
+
+func (h delayedTasks) Less(i, j int) bool {
+	ti, tj := h.queuedTasks[i], h.queuedTasks[j]
+	if !ti.notBefore.Equal(tj.notBefore) {
+		return ti.notBefore.Before(tj.notBefore)
+	}
+	return ti.seq < tj.seq
+}
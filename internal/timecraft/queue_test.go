@@ -0,0 +1,105 @@
+package timecraft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+func TestTaskQueue(t *testing.T) {
+	q := newTaskQueue(func(task *TaskInfo) { t.Errorf("task expired: %p", task) })
+	defer q.close()
+
+	var tasks []*TaskInfo
+	for _, priority := range []TaskPriority{NormalPriority, LowPriority, HighPriority, NormalPriority, HighPriority} {
+		task := &TaskInfo{options: TaskOptions{Priority: priority, QueueTimeout: time.Minute}}
+		tasks = append(tasks, task)
+		q.push(task, time.Time{})
+	}
+
+	ctx := context.Background()
+	for _, i := range []int{2, 4, 0, 3, 1} {
+		task, err := q.pop(ctx)
+		assert.OK(t, err)
+		assert.Equal(t, task, tasks[i])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := q.pop(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	// Goroutines waiting on an empty queue are woken up by pushes.
+	done := make(chan *TaskInfo)
+	go func() {
+		task, _ := q.pop(context.Background())
+		done <- task
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.push(tasks[0], time.Time{})
+	assert.Equal(t, <-done, tasks[0])
+}
+
+func TestTaskQueueNotBefore(t *testing.T) {
+	q := newTaskQueue(func(task *TaskInfo) { t.Errorf("task expired: %p", task) })
+	defer q.close()
+
+	options := TaskOptions{Priority: HighPriority, QueueTimeout: time.Minute}
+	delayed := &TaskInfo{options: options}
+	q.push(delayed, time.Now().Add(50*time.Millisecond))
+
+	// Tasks are not popped before their not-before time, even if they have
+	// a higher priority than the tasks which are ready.
+	options.Priority = LowPriority
+	ready := &TaskInfo{options: options}
+	q.push(ready, time.Time{})
+
+	ctx := context.Background()
+	task, err := q.pop(ctx)
+	assert.OK(t, err)
+	assert.Equal(t, task, ready)
+
+	start := time.Now()
+	task, err = q.pop(ctx)
+	assert.OK(t, err)
+	assert.Equal(t, task, delayed)
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("delayed task popped too early: %s", elapsed)
+	}
+}
+
+func TestTaskQueueExpire(t *testing.T) {
+	expired := make(chan *TaskInfo, 2)
+	q := newTaskQueue(func(task *TaskInfo) { expired <- task })
+	defer q.close()
+
+	// Tasks expire when they reach their queue timeout, even if they are
+	// never popped because tasks with a higher priority keep being queued.
+	low := &TaskInfo{options: TaskOptions{Priority: LowPriority, QueueTimeout: 10 * time.Millisecond}}
+	q.push(low, time.Time{})
+
+	// The queue timeout of delayed tasks starts at their not-before time.
+	delayed := &TaskInfo{options: TaskOptions{Priority: LowPriority, QueueTimeout: 10 * time.Millisecond}}
+	q.push(delayed, time.Now().Add(30*time.Millisecond))
+
+	assert.Equal(t, <-expired, low)
+	assert.Equal(t, <-expired, delayed)
+
+	// Tasks pushed again to be retried keep the deadline of their first push.
+	start := time.Now()
+	retried := &TaskInfo{options: TaskOptions{QueueTimeout: 100 * time.Millisecond}}
+	q.push(retried, time.Time{})
+	popped, err := q.pop(context.Background())
+	assert.OK(t, err)
+	assert.Equal(t, popped, retried)
+	q.push(retried, time.Now().Add(80*time.Millisecond))
+	assert.Equal(t, <-expired, retried)
+	assert.True(t, time.Since(start) < 150*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.pop(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
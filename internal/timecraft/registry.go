@@ -2,17 +2,21 @@ package timecraft
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/stealthrocket/timecraft/internal/object"
+	"github.com/stealthrocket/timecraft/internal/print/human"
 	"github.com/stealthrocket/timecraft/internal/timemachine"
 )
 
 // CreateRegistry creates a timemachine registry.
 func CreateRegistry(config *Config) (*timemachine.Registry, error) {
 	location, ok := config.Registry.Location.Value()
-	if ok {
+	if ok && !isS3Location(location) {
 		path, err := location.Resolve()
 		if err != nil {
 			return nil, err
@@ -29,15 +33,15 @@ func OpenRegistry(config *Config) (*timemachine.Registry, error) {
 	store := object.EmptyStore()
 	location, ok := config.Registry.Location.Value()
 	if ok {
-		path, err := location.Resolve()
-		if err != nil {
-			return nil, err
+		var err error
+		if isS3Location(location) {
+			store, err = openS3Store(string(location))
+		} else {
+			store, err = openDirStore(location)
 		}
-		dir, err := object.DirStore(path)
 		if err != nil {
 			return nil, err
 		}
-		store = dir
 	}
 	registry := &timemachine.Registry{
 		Store: store,
@@ -45,6 +49,52 @@ func OpenRegistry(config *Config) (*timemachine.Registry, error) {
 	return registry, nil
 }
 
+func openDirStore(location human.Path) (object.Store, error) {
+	path, err := location.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	return object.DirStore(path)
+}
+
+func isS3Location(location human.Path) bool {
+	return strings.HasPrefix(string(location), "s3://")
+}
+
+// openS3Store opens an object store from a location of the form
+// s3://bucket/prefix.
+//
+// The region, endpoint, and credentials are configured with the standard AWS
+// environment variables.
+func openS3Store(location string) (object.Store, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("malformed registry location: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("malformed registry location: %q (missing bucket name)", location)
+	}
+	return object.S3Store(object.S3Config{
+		Bucket:          u.Host,
+		Prefix:          u.Path,
+		Region:          getenv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		Endpoint:        getenv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	})
+}
+
+// getenv returns the value of the first environment variable which is set.
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func createDirectory(path string) error {
 	if err := os.MkdirAll(path, 0777); err != nil {
 		if !errors.Is(err, fs.ErrExist) {
@@ -2,7 +2,10 @@ package timecraft
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,9 +26,11 @@ type Replay struct {
 	runtime   wazero.Runtime
 	processID uuid.UUID
 
-	stdout io.Writer
-	stderr io.Writer
-	trace  io.Writer
+	stdout  io.Writer
+	stderr  io.Writer
+	trace   io.Writer
+	policy  wasicall.ReplayPolicy
+	content fs.FS
 }
 
 // NewReplay creates a Replay for a WebAssembly modules with a recorded trace
@@ -54,6 +59,18 @@ func (r *Replay) SetTrace(w io.Writer) {
 	r.trace = w
 }
 
+// SetPolicy sets the policy used to match the system calls made by the module
+// with the records of the log.
+func (r *Replay) SetPolicy(policy wasicall.ReplayPolicy) {
+	r.policy = policy
+}
+
+// SetContent sets the file system that the data elided from the log is read
+// from, rooted at the root directory of the module.
+func (r *Replay) SetContent(content fs.FS) {
+	r.content = content
+}
+
 // Replay replays process execution.
 func (r *Replay) Replay(ctx context.Context) error {
 	moduleCode, function, err := r.ModuleCode(ctx)
@@ -72,24 +89,56 @@ func (r *Replay) Replay(ctx context.Context) error {
 
 // ModuleCode reads the module's WebAssembly code.
 func (r *Replay) ModuleCode(ctx context.Context) ([]byte, string, error) {
-	manifest, err := r.registry.LookupLogManifest(ctx, r.processID)
+	manifest, processConfig, err := r.config(ctx)
 	if err != nil {
 		return nil, "", err
 	}
 	fn := lookupFunction(manifest)
-	process, err := r.registry.LookupProcess(ctx, manifest.Process.Digest)
+	module, err := r.registry.LookupModule(ctx, processConfig.Modules[0].Digest)
 	if err != nil {
 		return nil, "", err
 	}
-	processConfig, err := r.registry.LookupConfig(ctx, process.Config.Digest)
+	return module.Code, fn, nil
+}
+
+// OpenImage opens the OCI image that the process was started from, which
+// holds the root file system that the process saw. The method returns nil if
+// the process was not started from an image. The image must be closed by the
+// caller.
+func (r *Replay) OpenImage(ctx context.Context, layersPath string) (*Image, error) {
+	_, processConfig, err := r.config(ctx)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	module, err := r.registry.LookupModule(ctx, processConfig.Modules[0].Digest)
+	if processConfig.Image == nil {
+		return nil, nil
+	}
+	spec, err := recordedImageSpec(processConfig.Image)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	return module.Code, fn, nil
+	spec.LayersPath = layersPath
+	image, err := OpenImage(ctx, *spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not open image '%s': %w", spec, err)
+	}
+	return image, nil
+}
+
+func (r *Replay) config(ctx context.Context) (*format.Manifest, *format.Config, error) {
+	manifest, err := r.registry.LookupLogManifest(ctx, r.processID)
+	if err != nil {
+		return nil, nil, err
+	}
+	process, err := r.registry.LookupProcess(ctx, manifest.Process.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	processConfig, err := r.registry.LookupConfig(ctx, process.Config.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, processConfig, nil
 }
 
 func lookupFunction(m *format.Manifest) string {
@@ -108,8 +157,7 @@ func (r *Replay) RecordReader(ctx context.Context) (records stream.ReadCloser[ti
 	if err != nil {
 		return nil, time.Time{}, err
 	}
-	// TODO: return a reader that reads from many segments
-	logSegment, err := r.registry.ReadLogSegment(ctx, r.processID, 0)
+	logSegment, err := r.registry.ReadLog(ctx, manifest)
 	if err != nil {
 		return nil, time.Time{}, err
 	}
@@ -122,9 +170,16 @@ func (r *Replay) RecordReader(ctx context.Context) (records stream.ReadCloser[ti
 // a pre-compiled module.
 func (r *Replay) ReplayRecordsModule(ctx context.Context, function string, compiledModule wazero.CompiledModule, records stream.Reader[timemachine.Record]) error {
 	replay := wasicall.NewReplay(records)
+	replay.Policy = r.policy
+	replay.Content = r.content
 	defer replay.Close(ctx)
 
-	system := wasicall.NewFallbackSystem(replay, wasicall.NewExitSystem(0))
+	// Retain the last system call made by the module to report what it was
+	// doing if the replay diverges from the log.
+	var lastSyscall wasicall.Syscall
+	observer := wasicall.NewObserver(replay, func(ctx context.Context, s wasicall.Syscall) { lastSyscall = s }, nil)
+
+	system := wasicall.NewFallbackSystem(observer, wasicall.NewExitSystem(0))
 
 	if r.stdout != nil {
 		replay.Stdout = r.stdout
@@ -140,7 +195,13 @@ func (r *Replay) ReplayRecordsModule(ctx context.Context, function string, compi
 	hostModuleInstance := wazergo.MustInstantiate(ctx, r.runtime, hostModule, wasi_snapshot_preview1.WithWASI(system))
 	ctx = wazergo.WithModuleInstance(ctx, hostModuleInstance)
 
-	return runModule(ctx, r.runtime, compiledModule, function)
+	err := runModule(ctx, r.runtime, compiledModule, function)
+
+	var divergence *wasicall.DivergenceError
+	if errors.As(err, &divergence) {
+		return newDivergenceError(divergence, lastSyscall)
+	}
+	return err
 }
 
 // ReplayRecords replays process execution using the specified records.
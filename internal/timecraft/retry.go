@@ -8,14 +8,19 @@ import (
 
 func retry(ctx context.Context, maxAttempts int, minDelay, maxDelay time.Duration, fn func() bool) {
 	for attempt := 1; fn() && attempt < maxAttempts; attempt++ {
-		delay := minDelay * time.Duration(math.Pow(2, float64(attempt-1)))
-		if delay > maxDelay {
-			delay = maxDelay
-		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(delay):
+		case <-time.After(retryDelay(attempt, minDelay, maxDelay)):
 		}
 	}
 }
+
+// retryDelay returns the exponential backoff delay after the given attempt.
+func retryDelay(attempt int, minDelay, maxDelay time.Duration) time.Duration {
+	delay := minDelay * time.Duration(math.Pow(2, float64(attempt-1)))
+	if delay > maxDelay || delay < 0 {
+		delay = maxDelay
+	}
+	return delay
+}
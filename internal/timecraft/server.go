@@ -98,6 +98,20 @@ func (s *Server) subprocessModuleSpec(r *v1.ModuleSpec) ModuleSpec {
 	// Inherit and then override the parent's env.
 	child.Env = append(child.Env[:len(child.Env):len(child.Env)], r.Env...)
 
+	restrictSubprocess(&child)
+
+	// Assign an optional outbound proxy module spec.
+	if r.OutboundProxy != nil {
+		proxy := s.subprocessModuleSpec(r.OutboundProxy)
+		child.OutboundProxy = &proxy
+	}
+
+	return child
+}
+
+// restrictSubprocess removes the capabilities of a parent module spec which
+// are not inherited by its subprocesses.
+func restrictSubprocess(child *ModuleSpec) {
 	// Stdout/stderr are inherited from the parent, but stdin is disabled.
 	child.Stdin = nil
 
@@ -112,13 +126,8 @@ func (s *Server) subprocessModuleSpec(r *v1.ModuleSpec) ModuleSpec {
 	child.Dials = nil
 	child.Listens = nil
 
-	// Assign an optional outbound proxy module spec.
-	if r.OutboundProxy != nil {
-		proxy := s.subprocessModuleSpec(r.OutboundProxy)
-		child.OutboundProxy = &proxy
-	}
-
-	return child
+	// Exit notifications are specific to the parent process.
+	child.OnExit = nil
 }
 
 func (s *Server) submitTask(req *v1.TaskRequest) (TaskID, error) {
@@ -136,10 +145,40 @@ func (s *Server) submitTask(req *v1.TaskRequest) (TaskID, error) {
 			httpRequest.Headers[h.Name] = append(httpRequest.Headers[h.Name], h.Value)
 		}
 		input = httpRequest
+	case *v1.TaskRequest_FunctionCall:
+		input = &FunctionCall{
+			Function: in.FunctionCall.Function,
+			Args:     in.FunctionCall.Args,
+			Stdin:    in.FunctionCall.Stdin,
+		}
+	}
+
+	options := TaskOptions{
+		ExecutionTimeout: time.Duration(req.ExecutionTimeoutNs),
+		QueueTimeout:     time.Duration(req.QueueTimeoutNs),
+		IdempotencyKey:   req.IdempotencyKey,
+	}
+	if r := req.RetryPolicy; r != nil {
+		options.Retry = RetryPolicy{
+			MaxAttempts: int(r.MaxAttempts),
+			MinDelay:    time.Duration(r.MinDelayNs),
+			MaxDelay:    time.Duration(r.MaxDelayNs),
+		}
+	}
+	switch req.Priority {
+	case v1.TaskPriority_TASK_PRIORITY_UNSPECIFIED:
+	case v1.TaskPriority_TASK_PRIORITY_LOW:
+		options.Priority = LowPriority
+	case v1.TaskPriority_TASK_PRIORITY_NORMAL:
+		options.Priority = NormalPriority
+	case v1.TaskPriority_TASK_PRIORITY_HIGH:
+		options.Priority = HighPriority
+	default:
+		return TaskID{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid task priority: %v", req.Priority))
 	}
 
 	moduleSpec := s.subprocessModuleSpec(req.Module)
-	taskID, err := s.tasks.Submit(moduleSpec, s.logSpec.Fork(), input, s.processID)
+	taskID, err := s.tasks.Submit(moduleSpec, s.logSpec.Fork(), input, options, s.processID)
 	if err != nil {
 		return TaskID{}, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to submit task: %w", err))
 	}
@@ -160,15 +199,25 @@ func (s *Server) LookupTasks(ctx context.Context, req *connect.Request[v1.Lookup
 	return res, nil
 }
 
-func (s *Server) lookupTask(taskID TaskID) (res *v1.TaskResponse) {
-	res = &v1.TaskResponse{
-		TaskId: taskID.String(),
-	}
+func (s *Server) lookupTask(taskID TaskID) *v1.TaskResponse {
 	task, ok := s.tasks.Lookup(taskID)
 	if !ok {
-		res.State = v1.TaskState_TASK_STATE_ERROR
-		res.ErrorMessage = "task not found"
-		return
+		return taskNotFound(taskID)
+	}
+	return makeTaskResponse(&task)
+}
+
+func taskNotFound(taskID TaskID) *v1.TaskResponse {
+	return &v1.TaskResponse{
+		TaskId:       taskID.String(),
+		State:        v1.TaskState_TASK_STATE_ERROR,
+		ErrorMessage: "task not found",
+	}
+}
+
+func makeTaskResponse(task *TaskInfo) (res *v1.TaskResponse) {
+	res = &v1.TaskResponse{
+		TaskId: task.id.String(),
 	}
 	res.State = v1.TaskState(task.state)
 	if task.processID != (ProcessID{}) {
@@ -190,6 +239,12 @@ func (s *Server) lookupTask(taskID TaskID) (res *v1.TaskResponse) {
 			}
 		}
 		res.Output = &v1.TaskResponse_HttpResponse{HttpResponse: httpResponse}
+	case *FunctionResult:
+		res.Output = &v1.TaskResponse_FunctionResult{FunctionResult: &v1.FunctionResult{
+			ExitCode: int32(output.ExitCode),
+			Stdout:   output.Stdout,
+			Stderr:   output.Stderr,
+		}}
 	}
 	return
 }
@@ -227,6 +282,68 @@ poll_loop:
 	return res, nil
 }
 
+func (s *Server) WatchTasks(ctx context.Context, req *connect.Request[v1.WatchTasksRequest], stream *connect.ServerStream[v1.WatchTasksResponse]) error {
+	taskIDs := make([]TaskID, len(req.Msg.TaskId))
+	for i, rawTaskID := range req.Msg.TaskId {
+		taskID, err := uuid.Parse(rawTaskID)
+		if err != nil {
+			return connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("task ID at index %d is invalid: %w", i, err))
+		}
+		taskIDs[i] = taskID
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel)
+	defer stop()
+
+	watcher := s.tasks.Watch(taskIDs)
+	defer watcher.Close()
+
+	// When watching specific tasks, the stream ends once they are all
+	// complete. Otherwise, it lasts until the request is canceled.
+	pending := make(map[TaskID]struct{}, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if _, ok := s.tasks.Lookup(taskID); ok {
+			pending[taskID] = struct{}{}
+			continue
+		}
+		if err := stream.Send(&v1.WatchTasksResponse{Response: taskNotFound(taskID)}); err != nil {
+			return err
+		}
+	}
+	if len(taskIDs) != 0 && len(pending) == 0 {
+		return nil
+	}
+
+	for {
+		task, err := watcher.Next(ctx)
+		if err != nil {
+			return nil
+		}
+		if len(taskIDs) != 0 {
+			if _, ok := pending[task.id]; !ok {
+				continue
+			}
+		}
+		// Tasks discarded while they are watched are reported as not found,
+		// which is also their final state.
+		response := makeTaskResponse(&task)
+		if task.discarded {
+			response = taskNotFound(task.id)
+		}
+		if err := stream.Send(&v1.WatchTasksResponse{Response: response}); err != nil {
+			return err
+		}
+		if len(taskIDs) != 0 && (task.discarded || task.state == Error || task.state == Success) {
+			delete(pending, task.id)
+			if len(pending) == 0 {
+				return nil
+			}
+		}
+	}
+}
+
 func (s *Server) DiscardTasks(ctx context.Context, req *connect.Request[v1.DiscardTasksRequest]) (*connect.Response[v1.DiscardTasksResponse], error) {
 	for i, rawTaskID := range req.Msg.TaskId {
 		taskID, err := uuid.Parse(rawTaskID)
@@ -13,23 +13,31 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/sync/singleflight"
 )
 
-// Task scheduler configuration.
-// TODO: make this configurable via TaskScheduler and/or via Submit(..)
+// Task scheduler configuration. The timeouts and retry delays are defaults
+// that can be overridden for each task via TaskOptions.
 const (
-	// executionTimeout is the time limit for tasks once they're executing.
-	executionTimeout = 1 * time.Minute
+	// defaultExecutionTimeout is the time limit for each attempt at executing
+	// a task.
+	defaultExecutionTimeout = 1 * time.Minute
 
-	// expiryTimeout is the maximum amount of time a task can be
+	// defaultQueueTimeout is the maximum amount of time a task can be
 	// queued for before it expires.
-	expiryTimeout = 10 * time.Minute
+	defaultQueueTimeout = 10 * time.Minute
 
-	// threadCount controls the number of threads responsible for executing
-	// tasks in the background. This is the maximum concurrency for task
-	// execution.
-	threadCount = 8
+	// defaultRetryMinDelay and defaultRetryMaxDelay bound the exponential
+	// backoff between attempts at executing a task.
+	defaultRetryMinDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay = 5 * time.Second
+
+	// defaultClaimTimeout is the amount of time that recovered tasks are
+	// retained after they complete, waiting to be claimed.
+	defaultClaimTimeout = 10 * time.Minute
+
+	// defaultThreadCount is the number of threads responsible for executing
+	// tasks in the background.
+	defaultThreadCount = 8
 )
 
 // TaskScheduler schedules tasks across processes.
@@ -41,13 +49,30 @@ const (
 type TaskScheduler struct {
 	ProcessManager *ProcessManager
 
-	queue chan<- *TaskInfo
+	// Pool configures the pools of processes that execute tasks. Each module
+	// spec gets its own pool of processes, and tasks are balanced across the
+	// processes of a pool.
+	Pool TaskPoolConfig
+
+	// Journal is an optional journal where tasks are recorded so they can be
+	// recovered after a restart (see Recover).
+	Journal *TaskJournal
+
+	// ClaimTimeout is the amount of time that recovered tasks are retained
+	// after they complete if they are not claimed (default to 10 minutes).
+	ClaimTimeout time.Duration
+
+	// ThreadCount is the number of threads responsible for executing tasks
+	// in the background, which is the maximum concurrency for task execution
+	// (default to 8).
+	ThreadCount int
+
+	queue *taskQueue
 	tasks map[TaskID]*TaskInfo
+	keys  map[taskKey]TaskID
+	pools map[string]*processPool
 
-	// TODO: for now tasks are handled by exactly one process. Add a pool of
-	//  processes and then load balance tasks across them
-	processes map[string]ProcessID
-	pinit     singleflight.Group
+	watchers map[*TaskWatcher]struct{}
 
 	once   sync.Once
 	ctx    context.Context
@@ -56,6 +81,14 @@ type TaskScheduler struct {
 	mu     sync.Mutex
 }
 
+// taskKey is the scope of idempotency keys. Keys of tasks submitted by a task
+// group are local to the group, and keys of recovered tasks have no group
+// until the tasks are claimed.
+type taskKey struct {
+	group *TaskGroup
+	key   string
+}
+
 // TaskID is a task identifier.
 type TaskID = uuid.UUID
 
@@ -88,17 +121,98 @@ type TaskInfo struct {
 	creator     ProcessID
 	createdAt   time.Time
 	state       TaskState
+	attempts    int
+	unclaimed   bool
+	discarded   bool
+	group       *TaskGroup
 	processID   ProcessID
 	moduleSpec  ModuleSpec
 	logSpec     *LogSpec
 	input       TaskInput
+	options     TaskOptions
 	output      TaskOutput
 	err         error
 	ctx         context.Context
 	cancel      context.CancelFunc
 	completions chan<- TaskID
+	// Set when the task is first queued, and guarded by the mutex of the
+	// queue.
+	queueDeadline time.Time
+}
+
+// TaskOptions are options for the execution of a task.
+type TaskOptions struct {
+	// ExecutionTimeout is the time limit for each attempt at executing the
+	// task (default to 1 minute).
+	ExecutionTimeout time.Duration
+
+	// QueueTimeout is the maximum amount of time the task can be queued for
+	// before it expires (default to 10 minutes). The timeout does not restart
+	// when the task is queued again to be retried.
+	QueueTimeout time.Duration
+
+	// Retry is the policy for retrying the task when an attempt at executing
+	// it fails with an error.
+	Retry RetryPolicy
+
+	// Priority is the priority class of the task (default to NormalPriority).
+	Priority TaskPriority
+
+	// IdempotencyKey is an optional key identifying the task. Submitting a
+	// task with the same key as a task which has not been discarded returns
+	// the existing task instead of creating a new one. Keys are local to the
+	// task group which submits the tasks (see TaskScheduler.Submit).
+	IdempotencyKey string
+}
+
+func (o TaskOptions) withDefaults() TaskOptions {
+	if o.ExecutionTimeout <= 0 {
+		o.ExecutionTimeout = defaultExecutionTimeout
+	}
+	if o.QueueTimeout <= 0 {
+		o.QueueTimeout = defaultQueueTimeout
+	}
+	if o.Retry.MinDelay <= 0 {
+		o.Retry.MinDelay = defaultRetryMinDelay
+	}
+	if o.Retry.MaxDelay <= 0 {
+		o.Retry.MaxDelay = max(defaultRetryMaxDelay, o.Retry.MinDelay)
+	}
+	if o.Priority == 0 {
+		o.Priority = NormalPriority
+	}
+	return o
+}
+
+// RetryPolicy is the policy for retrying tasks.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts at executing the task.
+	// The task is attempted once when MaxAttempts is zero.
+	MaxAttempts int
+
+	// MinDelay and MaxDelay bound the exponential backoff between attempts
+	// (default to 500ms and 5s).
+	MinDelay time.Duration
+	MaxDelay time.Duration
 }
 
+// TaskPriority is the priority class of a task.
+//
+// Queued tasks with a higher priority are scheduled before tasks with a lower
+// priority.
+type TaskPriority int
+
+const (
+	// LowPriority is the priority class of background tasks.
+	LowPriority TaskPriority = iota + 1
+
+	// NormalPriority is the default priority class.
+	NormalPriority
+
+	// HighPriority is the priority class of latency sensitive tasks.
+	HighPriority
+)
+
 // TaskInput is input for a task.
 type TaskInput interface {
 	taskInput()
@@ -114,11 +228,24 @@ type TaskOutput interface {
 // The method returns a TaskID that can be passed to Lookup to query the task
 // status and fetch task output.
 //
+// The options control the timeouts, retries and priority of the task. Zero
+// values are replaced by defaults.
+//
 // The method accepts an optional channel that receives a completion
 // notification once the task is complete (succeeds, or fails permanently).
+// When the task already exists because it was submitted with the same
+// idempotency key, the channel replaces the one of the previous submission,
+// and receives a notification immediately if the task is already complete.
+// Tasks submitted by a task group can only be found by submissions of the
+// same group, while recovered tasks are claimed by the first submission with
+// their idempotency key.
 //
 // Once a task is complete, it must be discarded via Discard.
-func (s *TaskScheduler) Submit(moduleSpec ModuleSpec, logSpec *LogSpec, input TaskInput, processID ProcessID, completions chan<- TaskID) (TaskID, error) {
+func (s *TaskScheduler) Submit(moduleSpec ModuleSpec, logSpec *LogSpec, input TaskInput, options TaskOptions, processID ProcessID, completions chan<- TaskID) (TaskID, error) {
+	return s.submit(moduleSpec, logSpec, input, options, processID, completions, nil)
+}
+
+func (s *TaskScheduler) submit(moduleSpec ModuleSpec, logSpec *LogSpec, input TaskInput, options TaskOptions, processID ProcessID, completions chan<- TaskID, group *TaskGroup) (TaskID, error) {
 	s.once.Do(s.init)
 
 	task := &TaskInfo{
@@ -129,20 +256,139 @@ func (s *TaskScheduler) Submit(moduleSpec ModuleSpec, logSpec *LogSpec, input Ta
 		moduleSpec:  moduleSpec,
 		logSpec:     logSpec,
 		input:       input,
+		options:     options.withDefaults(),
 		completions: completions,
+		group:       group,
 	}
 
 	task.ctx, task.cancel = context.WithCancel(s.ctx)
 
+	var existing *TaskInfo
+	var complete bool
 	s.synchronize(func() {
+		if key := task.options.IdempotencyKey; key != "" {
+			k := taskKey{group, key}
+			id, ok := s.keys[k]
+			if !ok {
+				// Recovered tasks have no group until they are claimed by
+				// the first submission with the same key.
+				if id, ok = s.keys[taskKey{key: key}]; ok && !s.tasks[id].unclaimed {
+					ok = false
+				}
+			}
+			if ok {
+				existing = s.tasks[id]
+				if existing.unclaimed {
+					delete(s.keys, taskKey{key: key})
+					s.keys[k] = id
+					existing.group = group
+					existing.unclaimed = false
+				}
+				if completions != nil {
+					existing.completions = completions
+				}
+				complete = existing.state == Error || existing.state == Success
+				return
+			}
+			s.keys[k] = task.id
+		}
 		s.tasks[task.id] = task
+		s.transition(task, Queued)
 	})
 
-	s.queue <- task
+	if existing != nil {
+		task.cancel()
+		if complete && completions != nil {
+			s.notify(existing.id, completions)
+		}
+		return existing.id, nil
+	}
+
+	if s.Journal != nil {
+		if err := s.Journal.submit(task); err != nil {
+			s.discard(task.id, false)
+			return TaskID{}, err
+		}
+	}
+
+	s.queue.push(task, time.Time{})
 
 	return task.id, nil
 }
 
+// Recover recovers the tasks recorded in the journal.
+//
+// Tasks that were queued or executing when the journal was last written are
+// queued again. Their module spec is derived from the given module spec, which
+// is usually the spec of the main module, and their log spec is forked from
+// logSpec. Tasks that had completed retain their state and output.
+//
+// Recovered tasks do not belong to any task group until they are claimed by
+// a submission with the same idempotency key. Once complete, unclaimed tasks
+// are retained for the duration of the claim timeout before they are
+// discarded, or discarded immediately if they have no idempotency key since
+// they cannot be claimed. The method returns the number of tasks recovered.
+func (s *TaskScheduler) Recover(moduleSpec ModuleSpec, logSpec *LogSpec) int {
+	s.once.Do(s.init)
+
+	if s.Journal == nil {
+		return 0
+	}
+
+	recovered := s.Journal.recover()
+	for _, r := range recovered {
+		task := &TaskInfo{
+			id:         r.submit.ID,
+			creator:    r.submit.Creator,
+			createdAt:  r.submit.CreatedAt,
+			state:      Queued,
+			moduleSpec: r.submit.Module.moduleSpec(moduleSpec),
+			logSpec:    logSpec.Fork(),
+			unclaimed:  true,
+		}
+		if r.submit.Options != nil {
+			task.options = *r.submit.Options
+		}
+		task.options = task.options.withDefaults()
+
+		switch {
+		case r.submit.HTTPRequest != nil:
+			task.input = r.submit.HTTPRequest
+		case r.submit.FunctionCall != nil:
+			task.input = r.submit.FunctionCall
+		}
+
+		if c := r.complete; c != nil {
+			task.state = c.State
+			if c.Error != "" {
+				task.err = errors.New(c.Error)
+			}
+			switch {
+			case c.HTTPResponse != nil:
+				task.output = c.HTTPResponse
+			case c.FunctionResult != nil:
+				task.output = c.FunctionResult
+			}
+		}
+
+		task.ctx, task.cancel = context.WithCancel(s.ctx)
+
+		s.synchronize(func() {
+			s.tasks[task.id] = task
+			if key := task.options.IdempotencyKey; key != "" {
+				s.keys[taskKey{key: key}] = task.id
+			}
+		})
+
+		if task.state == Queued {
+			s.queue.push(task, time.Time{})
+		} else {
+			s.discardUnclaimed(task)
+		}
+	}
+	return len(recovered)
+}
+
 // Lookup looks up a task by ID.
 func (s *TaskScheduler) Lookup(id TaskID) (task TaskInfo, ok bool) {
 	s.synchronize(func() {
@@ -155,111 +401,207 @@ func (s *TaskScheduler) Lookup(id TaskID) (task TaskInfo, ok bool) {
 }
 
 // Discard discards a task by ID.
-func (s *TaskScheduler) Discard(id TaskID) (ok bool) {
+func (s *TaskScheduler) Discard(id TaskID) bool {
+	return s.discard(id, true)
+}
+
+// discard removes a task from the scheduler. When journal is false, a task
+// with an idempotency key is left in the journal so it can be recovered and
+// claimed the next time the journal is opened. Tasks without an idempotency
+// key cannot be claimed, they are always discarded from the journal.
+func (s *TaskScheduler) discard(id TaskID, journal bool) bool {
+	return s.discardIf(id, journal, func(*TaskInfo) bool { return true })
+}
+
+// discardUnclaimed discards a recovered task which completed if it was not
+// claimed within the claim timeout.
+func (s *TaskScheduler) discardUnclaimed(task *TaskInfo) {
+	unclaimed := func(t *TaskInfo) bool { return t == task && t.unclaimed }
+
+	if task.options.IdempotencyKey == "" {
+		s.discardIf(task.id, true, unclaimed)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		timer := time.NewTimer(s.ClaimTimeout)
+		defer timer.Stop()
+		select {
+		case <-s.ctx.Done():
+		case <-timer.C:
+			s.discardIf(task.id, true, unclaimed)
+		}
+	}()
+}
+
+func (s *TaskScheduler) discardIf(id TaskID, journal bool, cond func(*TaskInfo) bool) (ok bool) {
+	var task *TaskInfo
 	s.synchronize(func() {
-		var task *TaskInfo
-		if task, ok = s.tasks[id]; ok {
+		if task, ok = s.tasks[id]; ok && cond(task) {
 			task.cancel()
+			task.discarded = true
+			s.notifyWatchers(task)
 			delete(s.tasks, id)
+			k := taskKey{task.group, task.options.IdempotencyKey}
+			if k.key != "" && s.keys[k] == id {
+				delete(s.keys, k)
+			}
+		} else {
+			ok = false
 		}
 	})
+	if ok && (journal || task.options.IdempotencyKey == "") && s.Journal != nil {
+		// If the entry cannot be written, the task is recovered and can be
+		// discarded again the next time the journal is opened.
+		_ = s.Journal.discard(id)
+	}
 	return
 }
 
 func (s *TaskScheduler) init() {
 	s.tasks = map[TaskID]*TaskInfo{}
-	s.processes = map[string]ProcessID{}
+	s.keys = map[taskKey]TaskID{}
+	s.pools = map[string]*processPool{}
+	s.watchers = map[*TaskWatcher]struct{}{}
+	s.Pool = s.Pool.withDefaults()
+	if s.ClaimTimeout <= 0 {
+		s.ClaimTimeout = defaultClaimTimeout
+	}
+	if s.ThreadCount <= 0 {
+		s.ThreadCount = defaultThreadCount
+	}
 
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 
-	queue := make(chan *TaskInfo)
-	s.queue = queue
+	s.queue = newTaskQueue(func(task *TaskInfo) {
+		s.completeTask(task, errors.New("task expired"), nil)
+	})
 
-	s.wg.Add(threadCount)
-	for i := 0; i < threadCount; i++ {
-		go s.scheduleLoop(queue)
+	s.wg.Add(s.ThreadCount + 1)
+	for i := 0; i < s.ThreadCount; i++ {
+		go s.scheduleLoop()
 	}
+	go s.scaleDownLoop()
 }
 
-func (s *TaskScheduler) scheduleLoop(queue <-chan *TaskInfo) {
+func (s *TaskScheduler) scaleDownLoop() {
 	defer s.wg.Done()
 
+	ticker := time.NewTicker(s.Pool.IdleTimeout / 2)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
-		case task := <-queue:
-			s.scheduleTask(task)
+		case now := <-ticker.C:
+			s.scaleDown(now)
 		}
 	}
 }
 
-func (s *TaskScheduler) scheduleTask(task *TaskInfo) {
-	if time.Since(task.createdAt) > expiryTimeout {
-		s.completeTask(task, errors.New("task expired"), nil)
-		return
+func (s *TaskScheduler) scaleDown(now time.Time) {
+	var pools []*processPool
+	s.synchronize(func() {
+		pools = make([]*processPool, 0, len(s.pools))
+		for _, pool := range s.pools {
+			pools = append(pools, pool)
+		}
+	})
+	for _, pool := range pools {
+		pool.scaleDown(now)
 	}
+}
 
+func (s *TaskScheduler) pool(moduleSpec *ModuleSpec) (pool *processPool) {
+	key := moduleSpec.Key()
 	s.synchronize(func() {
-		task.state = Initializing
+		var ok bool
+		if pool, ok = s.pools[key]; !ok {
+			pool = newProcessPool(s.Pool, s.ProcessManager)
+			s.pools[key] = pool
+		}
 	})
+	return pool
+}
 
-	key := task.moduleSpec.Key()
+func (s *TaskScheduler) scheduleLoop() {
+	defer s.wg.Done()
 
-	initResult, err, _ := s.pinit.Do(key, func() (any, error) {
-		var processID ProcessID
-		var process ProcessInfo
-		var ok bool
-		s.synchronize(func() {
-			task.state = Initializing
-			processID, ok = s.processes[key]
-		})
-		if ok {
-			// Check that the process is still alive.
-			process, ok = s.ProcessManager.Lookup(processID)
+	for {
+		task, err := s.queue.pop(s.ctx)
+		if err != nil {
+			return
 		}
+		s.scheduleTask(task)
+	}
+}
 
-		if !ok {
-			var err error
-			processID, err = s.ProcessManager.Start(task.moduleSpec, task.logSpec, nil)
-			if err != nil {
-				return nil, err
-			}
-			process, ok = s.ProcessManager.Lookup(processID)
-			if !ok {
-				return nil, errors.New("failed to start process")
+// scheduleTask executes one attempt at a task. When the attempt fails and the
+// retry policy allows it, the task is queued again after the backoff delay
+// instead of holding on to the scheduling thread while waiting.
+func (s *TaskScheduler) scheduleTask(task *TaskInfo) {
+	output, err := s.executeTask(task)
+
+	if err != nil && task.ctx.Err() == nil {
+		var attempt int
+		policy := task.options.Retry
+		s.synchronize(func() {
+			task.attempts++
+			attempt = task.attempts
+			if attempt < policy.MaxAttempts {
+				s.transition(task, Queued)
 			}
-			s.synchronize(func() {
-				s.processes[key] = processID
-			})
+		})
+		if attempt < policy.MaxAttempts {
+			delay := retryDelay(attempt, policy.MinDelay, policy.MaxDelay)
+			s.queue.push(task, time.Now().Add(delay))
+			return
 		}
-		return process, nil
-	})
-	if err != nil {
-		s.completeTask(task, err, nil)
-		return
 	}
-	process := initResult.(ProcessInfo)
 
+	s.completeTask(task, err, output)
+}
+
+func (s *TaskScheduler) executeTask(task *TaskInfo) (TaskOutput, error) {
 	s.synchronize(func() {
-		task.state = Executing
-		task.processID = process.ID
+		s.transition(task, Initializing)
 	})
 
 	switch input := task.input.(type) {
 	case *HTTPRequest:
-		s.executeHTTPTask(&process, task, input)
+		pool := s.pool(&task.moduleSpec)
+
+		process, err := pool.acquire(task.ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		defer pool.release(process)
+
+		s.executing(task, process.ID)
+		return s.executeHTTPTask(&process.ProcessInfo, task, input)
+	case *FunctionCall:
+		return s.executeFunctionTask(task, input)
 	default:
-		s.completeTask(task, errors.New("invalid task input"), nil)
+		return nil, errors.New("invalid task input")
 	}
 }
 
-func (s *TaskScheduler) executeHTTPTask(process *ProcessInfo, task *TaskInfo, request *HTTPRequest) {
+func (s *TaskScheduler) executing(task *TaskInfo, processID ProcessID) {
+	s.synchronize(func() {
+		task.processID = processID
+		s.transition(task, Executing)
+	})
+}
+
+func (s *TaskScheduler) executeHTTPTask(process *ProcessInfo, task *TaskInfo, request *HTTPRequest) (TaskOutput, error) {
 	client := http.Client{
 		Transport: &http.Transport{
 			DialContext: process.DialContext,
 		},
-		Timeout: executionTimeout,
+		Timeout: task.options.ExecutionTimeout,
 	}
 
 	request.Headers.Set("User-Agent", "timecraft "+Version())
@@ -282,42 +624,121 @@ func (s *TaskScheduler) executeHTTPTask(process *ProcessInfo, task *TaskInfo, re
 
 	res, err := client.Do(req)
 	if err != nil {
-		s.completeTask(task, err, nil)
-		return
+		return nil, err
 	}
 	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		s.completeTask(task, err, nil)
-		return
+		return nil, err
 	}
 
-	s.completeTask(task, nil, &HTTPResponse{
+	return &HTTPResponse{
 		StatusCode: res.StatusCode,
 		Headers:    res.Header,
 		Body:       body,
-	})
+	}, nil
+}
+
+// executeFunctionTask starts a new process to run the function of the task,
+// and waits for it to exit. Unlike HTTP tasks, function calls are not executed
+// by the pool of processes since the process exits when the function returns.
+func (s *TaskScheduler) executeFunctionTask(task *TaskInfo, call *FunctionCall) (TaskOutput, error) {
+	ctx, cancel := context.WithTimeout(task.ctx, task.options.ExecutionTimeout)
+	defer cancel()
+
+	stdout := &lockedBuffer{limit: maxFunctionOutputSize}
+	stderr := &lockedBuffer{limit: maxFunctionOutputSize}
+	exit := make(chan error, 1)
+
+	moduleSpec := task.moduleSpec
+	if call.Function != "" {
+		moduleSpec.Function = call.Function
+	}
+	if len(call.Args) != 0 {
+		moduleSpec.Args = call.Args
+	}
+	moduleSpec.Stdin = bytes.NewReader(call.Stdin)
+	moduleSpec.Stdout = stdout
+	moduleSpec.Stderr = stderr
+	moduleSpec.OnExit = func(err error) { exit <- err }
+
+	processID, err := s.ProcessManager.Start(moduleSpec, task.logSpec.Fork(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.executing(task, processID)
+
+	select {
+	case err = <-exit:
+	case <-ctx.Done():
+		s.ProcessManager.Stop(processID)
+		return nil, context.Cause(ctx)
+	}
+
+	result := &FunctionResult{
+		Stdout: stdout.Bytes(),
+		Stderr: stderr.Bytes(),
+	}
+	var exitErr ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = int(exitErr)
+	default:
+		return nil, err
+	}
+	return result, nil
 }
 
 func (s *TaskScheduler) completeTask(task *TaskInfo, err error, output TaskOutput) {
+	var completions chan<- TaskID
+	var unclaimed bool
 	s.synchronize(func() {
 		if err != nil {
-			task.state = Error
 			task.err = err
+			s.transition(task, Error)
 		} else {
-			task.state = Success
 			task.output = output
+			s.transition(task, Success)
 		}
+		completions = task.completions
+		unclaimed = task.unclaimed
 	})
 
-	if task.completions != nil {
+	// Tasks canceled because they were discarded or because the scheduler was
+	// closed are not recorded as complete, they are executed again if they
+	// are recovered from the journal.
+	if s.Journal != nil && task.ctx.Err() == nil {
+		// If the entry cannot be written, the task is executed again when it
+		// is recovered, which is allowed by at-least-once semantics.
+		_ = s.Journal.complete(task)
+	}
+
+	if unclaimed && task.ctx.Err() == nil {
+		s.discardUnclaimed(task)
+	}
+
+	if completions != nil {
 		select {
 		case <-s.ctx.Done():
-		case task.completions <- task.id:
+		case completions <- task.id:
 		}
 	}
 }
 
+// notify asynchronously sends a completion notification for a task which is
+// already complete.
+func (s *TaskScheduler) notify(id TaskID, completions chan<- TaskID) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-s.ctx.Done():
+		case completions <- id:
+		}
+	}()
+}
+
 func (s *TaskScheduler) synchronize(fn func()) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -330,6 +751,7 @@ func (s *TaskScheduler) Close() error {
 	s.once.Do(s.init)
 
 	s.cancel()
+	s.queue.close()
 	s.wg.Wait()
 	return nil
 }
@@ -359,14 +781,17 @@ func NewTaskGroup(s *TaskScheduler) *TaskGroup {
 // Submit submits a task for execution.
 //
 // See TaskScheduler.Submit for more information.
-func (g *TaskGroup) Submit(moduleSpec ModuleSpec, logSpec *LogSpec, input TaskInput, processID ProcessID) (TaskID, error) {
-	taskID, err := g.scheduler.Submit(moduleSpec, logSpec, input, processID, g.completions)
+func (g *TaskGroup) Submit(moduleSpec ModuleSpec, logSpec *LogSpec, input TaskInput, options TaskOptions, processID ProcessID) (TaskID, error) {
+	// The lock is held while the task is submitted so that the completion
+	// notifications received from Poll are for tasks that Lookup can find.
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	taskID, err := g.scheduler.submit(moduleSpec, logSpec, input, options, processID, g.completions, g)
 	if err != nil {
 		return TaskID{}, err
 	}
-	g.mu.Lock()
 	g.tasks[taskID] = struct{}{}
-	g.mu.Unlock()
 	return taskID, nil
 }
 
@@ -390,6 +815,15 @@ func (g *TaskGroup) Poll() <-chan TaskID {
 	return g.completions
 }
 
+// Watch returns a watcher which receives the state transitions of tasks in
+// the group. When ids is empty, the watcher receives the transitions of all
+// tasks in the group, including tasks submitted after the call.
+//
+// See TaskScheduler.Watch for more information.
+func (g *TaskGroup) Watch(ids []TaskID) *TaskWatcher {
+	return g.scheduler.watch(ids, g)
+}
+
 // Discard discards a task by ID.
 //
 // See TaskScheduler.Discard for more information.
@@ -410,8 +844,11 @@ func (g *TaskGroup) Close() error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// Tasks with an idempotency key are not discarded from the journal of the
+	// scheduler, so they can be recovered and claimed again if the host
+	// restarts.
 	for taskID := range g.tasks {
-		g.scheduler.Discard(taskID)
+		g.scheduler.discard(taskID, false)
 	}
 	g.tasks = nil
 
@@ -0,0 +1,162 @@
+package timecraft
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stealthrocket/timecraft/internal/assert"
+	"github.com/stealthrocket/timecraft/internal/object"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/tetratelabs/wazero"
+)
+
+func newTaskScheduler(t *testing.T) *TaskScheduler {
+	ctx := context.Background()
+
+	runtime := wazero.NewRuntime(ctx)
+	t.Cleanup(func() { runtime.Close(ctx) })
+
+	s := &TaskScheduler{}
+	serverFactory := &ServerFactory{Scheduler: s}
+	registry := &timemachine.Registry{Store: object.EmptyStore()}
+	processManager := NewProcessManager(ctx, registry, runtime, serverFactory, nil)
+	serverFactory.ProcessManager = processManager
+	s.ProcessManager = processManager
+
+	t.Cleanup(func() {
+		s.Close()
+		processManager.Close()
+	})
+	return s
+}
+
+func TestTaskSchedulerFunctionCall(t *testing.T) {
+	s := newTaskScheduler(t)
+	g := NewTaskGroup(s)
+	defer g.Close()
+
+	moduleSpec := ModuleSpec{Path: "../../testdata/go/sleep.wasm"}
+	tests := []struct {
+		scenario string
+		args     []string
+		timeout  time.Duration
+		check    func(*testing.T, TaskInfo)
+	}{
+		{
+			scenario: "the output of the function is captured",
+			args:     []string{"1ms"},
+			check: func(t *testing.T, task TaskInfo) {
+				assert.Equal(t, task.state, Success)
+				result := task.output.(*FunctionResult)
+				assert.Equal(t, result.ExitCode, 0)
+				assert.Equal(t, string(result.Stdout), "sleeping for 1ms\n")
+				assert.Equal(t, string(result.Stderr), "")
+			},
+		},
+
+		{
+			scenario: "functions exiting with a non-zero code are successful",
+			args:     []string{"nope"},
+			check: func(t *testing.T, task TaskInfo) {
+				assert.Equal(t, task.state, Success)
+				result := task.output.(*FunctionResult)
+				assert.Equal(t, result.ExitCode, 1)
+				assert.Equal(t, string(result.Stdout), "")
+				assert.Equal(t, string(result.Stderr), `ERR: nope: time: invalid duration "nope"`)
+			},
+		},
+
+		{
+			scenario: "the process is stopped when the execution timeout expires",
+			args:     []string{"1m"},
+			timeout:  time.Second,
+			check: func(t *testing.T, task TaskInfo) {
+				assert.Equal(t, task.state, Error)
+				assert.True(t, errors.Is(task.err, context.DeadlineExceeded))
+
+				// The process is stopped asynchronously, it exits the next
+				// time it calls into the host.
+				deadline := time.Now().Add(5 * time.Second)
+				for {
+					if _, ok := s.ProcessManager.Lookup(task.processID); !ok {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatal("the process was not stopped")
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			start := time.Now()
+			options := TaskOptions{ExecutionTimeout: test.timeout}
+			taskID, err := g.Submit(moduleSpec, nil, &FunctionCall{Args: test.args}, options, uuid.New())
+			assert.OK(t, err)
+			assert.Equal(t, <-g.Poll(), taskID)
+
+			if elapsed := time.Since(start); elapsed > 30*time.Second {
+				t.Errorf("task took too long to complete: %s", elapsed)
+			}
+
+			task, ok := g.Lookup(taskID)
+			assert.True(t, ok)
+			test.check(t, task)
+			assert.True(t, g.Discard(taskID))
+		})
+	}
+}
+
+func TestLockedBufferLimit(t *testing.T) {
+	b := &lockedBuffer{limit: 8}
+	for _, s := range []string{"hello", "world", "!"} {
+		n, err := b.Write([]byte(s))
+		assert.OK(t, err)
+		assert.Equal(t, n, len(s))
+	}
+	assert.Equal(t, string(b.Bytes()), "hellowor")
+}
+
+func TestTaskGroupIdempotencyKey(t *testing.T) {
+	s := &TaskScheduler{}
+	defer s.Close()
+
+	g1, g2 := NewTaskGroup(s), NewTaskGroup(s)
+	defer g2.Close()
+
+	// Tasks without an input fail as soon as they are executed.
+	options := TaskOptions{IdempotencyKey: "task-1"}
+
+	taskID1, err := g1.Submit(ModuleSpec{}, nil, nil, options, uuid.New())
+	assert.OK(t, err)
+	assert.Equal(t, <-g1.Poll(), taskID1)
+
+	// Submitting the same key in the same group yields the same task, and
+	// delivers its completion again.
+	taskID, err := g1.Submit(ModuleSpec{}, nil, nil, options, uuid.New())
+	assert.OK(t, err)
+	assert.Equal(t, taskID, taskID1)
+	assert.Equal(t, <-g1.Poll(), taskID1)
+
+	// Keys are local to groups, the other group gets a task of its own.
+	taskID2, err := g2.Submit(ModuleSpec{}, nil, nil, options, uuid.New())
+	assert.OK(t, err)
+	assert.NotEqual(t, taskID2, taskID1)
+	assert.Equal(t, <-g2.Poll(), taskID2)
+
+	_, ok := g2.Lookup(taskID1)
+	assert.False(t, ok)
+
+	// Closing a group does not discard the tasks of other groups.
+	assert.OK(t, g1.Close())
+	_, ok = s.Lookup(taskID1)
+	assert.False(t, ok)
+	_, ok = g2.Lookup(taskID2)
+	assert.True(t, ok)
+}
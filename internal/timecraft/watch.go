@@ -0,0 +1,140 @@
+package timecraft
+
+import (
+	"context"
+	"sync"
+)
+
+// TaskWatcher receives the state transitions of tasks.
+//
+// Transitions are buffered by the watcher so that slow consumers do not block
+// the execution of tasks. Watchers must be closed when they are no longer
+// needed to stop buffering transitions.
+//
+// When a watched task is discarded, the watcher receives a last snapshot of
+// the task with the discarded flag set.
+type TaskWatcher struct {
+	scheduler *TaskScheduler
+	ids       map[TaskID]struct{}
+	group     *TaskGroup
+
+	mu    sync.Mutex
+	queue []TaskInfo
+	ready chan struct{}
+}
+
+// Watch returns a watcher which receives the state transitions of the tasks
+// with the given IDs, or of all tasks if ids is empty.
+//
+// The watcher first receives the current state of the tasks, then each state
+// transition as it happens.
+func (s *TaskScheduler) Watch(ids []TaskID) *TaskWatcher {
+	return s.watch(ids, nil)
+}
+
+// watch creates a watcher which only receives the transitions of tasks in the
+// given group, or of all tasks if the group is nil.
+func (s *TaskScheduler) watch(ids []TaskID, group *TaskGroup) *TaskWatcher {
+	s.once.Do(s.init)
+
+	w := &TaskWatcher{
+		scheduler: s,
+		group:     group,
+		ready:     make(chan struct{}, 1),
+	}
+	if len(ids) != 0 {
+		w.ids = make(map[TaskID]struct{}, len(ids))
+		for _, id := range ids {
+			w.ids[id] = struct{}{}
+		}
+	}
+
+	s.synchronize(func() {
+		if w.ids != nil {
+			for id := range w.ids {
+				if task, ok := s.tasks[id]; ok {
+					w.push(task)
+				}
+			}
+		} else {
+			for _, task := range s.tasks {
+				w.push(task)
+			}
+		}
+		s.watchers[w] = struct{}{}
+	})
+	return w
+}
+
+// transition changes the state of a task and notifies the watchers. It must
+// be called while holding the scheduler lock.
+func (s *TaskScheduler) transition(task *TaskInfo, state TaskState) {
+	task.state = state
+	// Discarded tasks may still complete when their execution is canceled,
+	// but watchers already received their last snapshot.
+	if !task.discarded {
+		s.notifyWatchers(task)
+	}
+}
+
+// notifyWatchers pushes a snapshot of the task to the watchers. It must be
+// called while holding the scheduler lock.
+func (s *TaskScheduler) notifyWatchers(task *TaskInfo) {
+	for w := range s.watchers {
+		w.push(task)
+	}
+}
+
+func (w *TaskWatcher) push(task *TaskInfo) {
+	if w.group != nil && task.group != w.group {
+		return
+	}
+	if w.ids != nil {
+		if _, ok := w.ids[task.id]; !ok {
+			return
+		}
+	}
+
+	w.mu.Lock()
+	w.queue = append(w.queue, *task) // copy
+	w.mu.Unlock()
+
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until the next state transition is available, and returns a
+// snapshot of the task after the transition. The method returns an error if
+// the context is canceled before a transition is available.
+func (w *TaskWatcher) Next(ctx context.Context) (TaskInfo, error) {
+	for {
+		w.mu.Lock()
+		if len(w.queue) != 0 {
+			task := w.queue[0]
+			w.queue[0] = TaskInfo{}
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+			return task, nil
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return TaskInfo{}, context.Cause(ctx)
+		case <-w.ready:
+		}
+	}
+}
+
+// Close stops the watcher from receiving state transitions.
+func (w *TaskWatcher) Close() error {
+	w.scheduler.synchronize(func() {
+		delete(w.scheduler.watchers, w)
+	})
+	w.mu.Lock()
+	w.queue = nil
+	w.mu.Unlock()
+	return nil
+}
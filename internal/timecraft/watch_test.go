@@ -0,0 +1,114 @@
+package timecraft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stealthrocket/timecraft/internal/assert"
+)
+
+func TestTaskWatcher(t *testing.T) {
+	s := &TaskScheduler{}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g1, g2 := NewTaskGroup(s), NewTaskGroup(s)
+	defer g1.Close()
+	defer g2.Close()
+
+	// Tasks expire before they are executed, they transition from queued to
+	// the error state.
+	options := TaskOptions{QueueTimeout: time.Nanosecond}
+
+	w := g1.Watch(nil)
+	defer w.Close()
+
+	taskID, err := g1.Submit(ModuleSpec{}, nil, &HTTPRequest{}, options, uuid.New())
+	assert.OK(t, err)
+	_, err = g2.Submit(ModuleSpec{}, nil, &HTTPRequest{}, options, uuid.New())
+	assert.OK(t, err)
+
+	for _, state := range []TaskState{Queued, Error} {
+		task, err := w.Next(ctx)
+		assert.OK(t, err)
+		assert.Equal(t, task.id, taskID)
+		assert.Equal(t, task.state, state)
+	}
+	assert.Equal(t, <-g1.Poll(), taskID)
+
+	// Watching a task that is already complete yields its current state.
+	w2 := g1.Watch([]TaskID{taskID})
+	defer w2.Close()
+
+	task, err := w2.Next(ctx)
+	assert.OK(t, err)
+	assert.Equal(t, task.state, Error)
+	assert.Equal(t, task.err.Error(), "task expired")
+
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = w.Next(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
+
+func TestTaskWatcherDiscard(t *testing.T) {
+	s := &TaskScheduler{}
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g1, g2 := NewTaskGroup(s), NewTaskGroup(s)
+	defer g1.Close()
+	defer g2.Close()
+
+	w := g1.Watch(nil)
+	defer w.Close()
+
+	// The tasks are added without being queued so they remain in the queued
+	// state until they are discarded.
+	queue := func(g *TaskGroup) *TaskInfo {
+		task := &TaskInfo{id: uuid.New(), group: g}
+		task.ctx, task.cancel = context.WithCancel(ctx)
+		g.tasks[task.id] = struct{}{}
+		s.synchronize(func() {
+			s.tasks[task.id] = task
+			s.transition(task, Queued)
+		})
+		return task
+	}
+
+	taskID := queue(g1).id
+
+	// Transitions of tasks in other groups are not buffered by the watcher.
+	for i := 0; i < 10; i++ {
+		queue(g2)
+	}
+	w.mu.Lock()
+	assert.Equal(t, len(w.queue), 1)
+	w.mu.Unlock()
+
+	task, err := w.Next(ctx)
+	assert.OK(t, err)
+	assert.Equal(t, task.id, taskID)
+	assert.Equal(t, task.state, Queued)
+	assert.False(t, task.discarded)
+
+	// Discarding a task yields a last snapshot of the task, even though it
+	// never completed.
+	assert.True(t, g1.Discard(taskID))
+
+	task, err = w.Next(ctx)
+	assert.OK(t, err)
+	assert.Equal(t, task.id, taskID)
+	assert.True(t, task.discarded)
+
+	ctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = w.Next(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
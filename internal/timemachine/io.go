@@ -3,6 +3,9 @@ package timemachine
 import (
 	"bufio"
 	"io"
+	"sort"
+
+	"github.com/stealthrocket/timecraft/internal/stream"
 )
 
 type bufferedReadSeeker struct {
@@ -39,6 +42,98 @@ func (r *bufferedReadSeeker) Seek(offset int64, whence int) (int64, error) {
 	return offset, nil
 }
 
+// segmentedReadSeeker exposes a sequence of log segments as a single
+// contiguous stream of bytes. Since log segments are made of a sequence of
+// record batches, the concatenation of the segments is a valid log which can
+// be consumed by a LogReader.
+//
+// Segments are opened lazily when the read offset reaches them.
+type segmentedReadSeeker struct {
+	open    func(segmentIndex int) (io.ReadSeekCloser, error)
+	offsets []int64 // start offset of each segment, plus the total size
+	index   int
+	offset  int64
+	current io.ReadSeekCloser
+}
+
+func newSegmentedReadSeeker(sizes []int64, open func(int) (io.ReadSeekCloser, error)) *segmentedReadSeeker {
+	offsets := make([]int64, len(sizes)+1)
+	for i, size := range sizes {
+		offsets[i+1] = offsets[i] + size
+	}
+	return &segmentedReadSeeker{open: open, offsets: offsets}
+}
+
+func (r *segmentedReadSeeker) size() int64 {
+	return r.offsets[len(r.offsets)-1]
+}
+
+func (r *segmentedReadSeeker) Read(b []byte) (int, error) {
+	for r.index < len(r.offsets)-1 {
+		if r.current == nil {
+			f, err := r.open(r.index)
+			if err != nil {
+				return 0, err
+			}
+			if skip := r.offset - r.offsets[r.index]; skip > 0 {
+				if _, err := f.Seek(skip, io.SeekStart); err != nil {
+					f.Close()
+					return 0, err
+				}
+			}
+			r.current = f
+		}
+		n, err := r.current.Read(b)
+		r.offset += int64(n)
+		if err == io.EOF {
+			err = nil
+			if n == 0 {
+				r.current.Close()
+				r.current = nil
+				r.index++
+				continue
+			}
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+func (r *segmentedReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekEnd {
+		offset, whence = r.size()+offset, io.SeekStart
+	}
+	offset, err := stream.Seek(r.offset, r.size(), offset, whence)
+	if err != nil {
+		return -1, err
+	}
+	index := sort.Search(len(r.offsets)-1, func(i int) bool {
+		return r.offsets[i+1] > offset
+	})
+	if index == r.index && r.current != nil {
+		if _, err := r.current.Seek(offset-r.offsets[index], io.SeekStart); err != nil {
+			return -1, err
+		}
+	} else {
+		r.closeCurrent()
+	}
+	r.index, r.offset = index, offset
+	return offset, nil
+}
+
+func (r *segmentedReadSeeker) Close() error {
+	r.closeCurrent()
+	return nil
+}
+
+func (r *segmentedReadSeeker) closeCurrent() {
+	if r.current != nil {
+		r.current.Close()
+		r.current = nil
+	}
+}
+
 var (
-	_ io.ReadSeeker = (*bufferedReadSeeker)(nil)
+	_ io.ReadSeeker     = (*bufferedReadSeeker)(nil)
+	_ io.ReadSeekCloser = (*segmentedReadSeeker)(nil)
 )
@@ -2,6 +2,7 @@ package timemachine
 
 import (
 	"bytes"
+	"io"
 	"testing"
 	"testing/iotest"
 
@@ -13,3 +14,29 @@ func TestBufferedReadSeeker(t *testing.T) {
 	reader := newBufferedReadSeeker(bytes.NewReader(content), 999)
 	assert.OK(t, iotest.TestReader(reader, content))
 }
+
+func TestSegmentedReadSeeker(t *testing.T) {
+	content := bytes.Repeat([]byte("1234567890"), 10e3)
+	sizes := []int64{0, 1, 999, 10e3, 0, 42, 10e3 * 10}
+
+	var segments [][]byte
+	offset := int64(0)
+	for i, size := range sizes {
+		if i == len(sizes)-1 {
+			size = int64(len(content)) - offset
+			sizes[i] = size
+		}
+		segments = append(segments, content[offset:offset+size])
+		offset += size
+	}
+
+	reader := newSegmentedReadSeeker(sizes, func(i int) (io.ReadSeekCloser, error) {
+		return nopCloser{bytes.NewReader(segments[i])}, nil
+	})
+	defer reader.Close()
+	assert.OK(t, iotest.TestReader(reader, content))
+}
+
+type nopCloser struct{ io.ReadSeeker }
+
+func (nopCloser) Close() error { return nil }
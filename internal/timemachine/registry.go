@@ -140,8 +140,7 @@ func (reg *Registry) LookupProcess(ctx context.Context, hash format.Hash) (*form
 }
 
 func (reg *Registry) LookupRecord(ctx context.Context, process *format.Manifest, offset int64) (Record, error) {
-	// TODO: handle multiple log segments
-	logSegment, err := reg.ReadLogSegment(ctx, process.ProcessID, 0)
+	logSegment, err := reg.ReadLog(ctx, process)
 	if err != nil {
 		return Record{}, err
 	}
@@ -201,6 +200,13 @@ func (reg *Registry) LookupResource(ctx context.Context, hash format.Hash) (io.R
 	return reg.Store.ReadObject(ctx, reg.objectKey(hash))
 }
 
+// DeleteResource removes the object with the given hash from the registry.
+//
+// Deleting an object which does not exist is not an error.
+func (reg *Registry) DeleteResource(ctx context.Context, hash format.Hash) error {
+	return reg.Store.DeleteObject(ctx, reg.objectKey(hash))
+}
+
 func (reg *Registry) ListModules(ctx context.Context, timeRange TimeRange, tags ...object.Tag) stream.ReadCloser[*format.Descriptor] {
 	return reg.listObjects(ctx, format.TypeTimecraftModule, timeRange, tags)
 }
@@ -440,10 +446,13 @@ func (reg *Registry) CreateLogSegment(ctx context.Context, processID format.UUID
 type logSegmentWriter struct {
 	writer io.WriteCloser
 	done   <-chan struct{}
+	size   int64
 }
 
 func (w *logSegmentWriter) Write(b []byte) (int, error) {
-	return w.writer.Write(b)
+	n, err := w.writer.Write(b)
+	w.size += int64(n)
+	return n, err
 }
 
 func (w *logSegmentWriter) Close() error {
@@ -452,14 +461,125 @@ func (w *logSegmentWriter) Close() error {
 	return err
 }
 
+// LogSegmentPolicy configures when a LogSegmentWriter seals the current log
+// segment and rolls over to a new one.
+//
+// Zero values disable the corresponding limit; when both limits are zero, all
+// records are written to a single log segment.
+type LogSegmentPolicy struct {
+	// Size after which a log segment is sealed. Segments are only sealed on
+	// record batch boundaries, so they may exceed this size by up to one
+	// record batch.
+	MaxSize int64
+	// Duration after which a log segment is sealed.
+	MaxDuration time.Duration
+}
+
+// LogSegmentWriter writes records to the log of a process, rolling over to new
+// log segments according to a LogSegmentPolicy.
+//
+// Each time a segment is sealed, the log manifest of the process is updated to
+// list it, which allows programs to consume the sealed segments of a process
+// while it is still being recorded.
+type LogSegmentWriter struct {
+	reg      *Registry
+	ctx      context.Context
+	manifest format.Manifest
+	policy   LogSegmentPolicy
+	records  *LogRecordWriter
+	segment  *logSegmentWriter
+	number   int
+	created  time.Time
+}
+
+// CreateLogSegmentWriter creates a writer of records to the log segments of
+// the process described by the manifest.
+//
+// The first log segment is created immediately, the following ones are
+// created when records are written after sealing the previous segment.
+func (reg *Registry) CreateLogSegmentWriter(ctx context.Context, manifest *format.Manifest, batchSize int, compression Compression, policy LogSegmentPolicy) (*LogSegmentWriter, error) {
+	w := &LogSegmentWriter{
+		reg:      reg,
+		ctx:      ctx,
+		manifest: *manifest,
+		policy:   policy,
+		number:   len(manifest.Segments),
+	}
+	w.manifest.Segments = slices.Clone(manifest.Segments)
+	if err := w.createSegment(); err != nil {
+		return nil, err
+	}
+	w.records = NewLogRecordWriter(NewLogWriter(w.segment), batchSize, compression)
+	return w, nil
+}
+
+// WriteRecord writes a record to the log, sealing the current log segment if
+// it reached the limits of the segment policy.
+func (w *LogSegmentWriter) WriteRecord(record *RecordBuilder) error {
+	if w.segment == nil {
+		if err := w.createSegment(); err != nil {
+			return err
+		}
+		w.records.LogWriter.Reset(w.segment)
+	}
+	if err := w.records.WriteRecord(record); err != nil {
+		return err
+	}
+	if w.policy.MaxDuration > 0 && time.Since(w.created) >= w.policy.MaxDuration {
+		return w.sealSegment()
+	}
+	if w.policy.MaxSize > 0 && w.segment.size >= w.policy.MaxSize {
+		return w.sealSegment()
+	}
+	return nil
+}
+
+// Close flushes buffered records and seals the last log segment.
+func (w *LogSegmentWriter) Close() error {
+	if w.segment == nil {
+		return nil
+	}
+	return w.sealSegment()
+}
+
+func (w *LogSegmentWriter) createSegment() error {
+	segment, err := w.reg.CreateLogSegment(w.ctx, w.manifest.ProcessID, w.number)
+	if err != nil {
+		return err
+	}
+	w.segment = segment.(*logSegmentWriter)
+	w.created = time.Now()
+	return nil
+}
+
+func (w *LogSegmentWriter) sealSegment() error {
+	segment := w.segment
+	w.segment = nil
+
+	if err := w.records.Flush(); err != nil {
+		segment.Close()
+		return err
+	}
+	if err := segment.Close(); err != nil {
+		return err
+	}
+
+	w.manifest.Segments = append(w.manifest.Segments, format.LogSegment{
+		Number:    w.number,
+		Size:      segment.size,
+		CreatedAt: w.created,
+	})
+	w.number++
+	return w.reg.CreateLogManifest(w.ctx, w.manifest.ProcessID, &w.manifest)
+}
+
 func (reg *Registry) ListRecords(ctx context.Context, processID format.UUID, timeRange TimeRange) stream.ReadCloser[Record] {
 	manifest, err := reg.LookupLogManifest(ctx, processID)
 	if err != nil {
 		return stream.ErrCloser[Record](err)
 	}
 
-	// TODO: handle multiple log segments
-	logSegment, err := reg.ReadLogSegment(ctx, processID, 0)
+	logSegment, err := reg.ReadLog(ctx, manifest)
 	if err != nil {
 		return stream.ErrCloser[Record](err)
 	}
@@ -576,6 +696,41 @@ func (reg *Registry) ReadLogSegment(ctx context.Context, processID format.UUID,
 	return r, err
 }
 
+// ReadLog returns a reader exposing the content of all the log segments of the
+// process described by manifest as a single contiguous log, which allows a
+// LogReader to transparently read records across segment boundaries.
+func (reg *Registry) ReadLog(ctx context.Context, manifest *format.Manifest) (io.ReadSeekCloser, error) {
+	if len(manifest.Segments) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoLogRecords, manifest.ProcessID)
+	}
+	sizes := make([]int64, len(manifest.Segments))
+	for i, segment := range manifest.Segments {
+		sizes[i] = segment.Size
+	}
+	return newSegmentedReadSeeker(sizes, func(i int) (io.ReadSeekCloser, error) {
+		return reg.ReadLogSegment(ctx, manifest.ProcessID, manifest.Segments[i].Number)
+	}), nil
+}
+
+// DeleteLog removes the log segments and manifest of a process.
+//
+// The manifest is removed last so the process remains visible in the registry
+// if an error occurs while deleting its log segments.
+func (reg *Registry) DeleteLog(ctx context.Context, processID format.UUID) error {
+	r := reg.ListLogSegments(ctx, processID)
+	segments, err := stream.ReadAll[format.LogSegment](r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if err := reg.Store.DeleteObject(ctx, reg.logKey(processID, segment.Number)); err != nil {
+			return err
+		}
+	}
+	return reg.Store.DeleteObject(ctx, reg.manifestKey(processID))
+}
+
 func (reg *Registry) logKey(processID format.UUID, segmentNumber int) string {
 	return fmt.Sprintf("log/%s/data/%08X", processID, segmentNumber)
 }
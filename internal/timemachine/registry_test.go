@@ -2,6 +2,7 @@ package timemachine_test
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/stealthrocket/timecraft/format"
 	"github.com/stealthrocket/timecraft/internal/assert"
 	"github.com/stealthrocket/timecraft/internal/object"
+	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timemachine"
 )
 
@@ -74,6 +76,97 @@ func TestRegistry(t *testing.T) {
 				},
 			})
 	})
+
+	t.Run("LogSegments", func(t *testing.T) {
+		ctx := context.Background()
+
+		store, err := object.DirStore(t.TempDir())
+		assert.OK(t, err)
+		reg := &timemachine.Registry{
+			Store: store,
+		}
+
+		processID := uuid.New()
+		startTime := time.Unix(1685053878, 0).UTC()
+		manifest := &format.Manifest{
+			ProcessID: processID,
+			StartTime: startTime,
+		}
+		assert.OK(t, reg.CreateLogManifest(ctx, processID, manifest))
+
+		w, err := reg.CreateLogSegmentWriter(ctx, manifest, 10, timemachine.Snappy, timemachine.LogSegmentPolicy{
+			MaxSize: 1,
+		})
+		assert.OK(t, err)
+
+		const numRecords = 95
+		var b timemachine.RecordBuilder
+		for i := 0; i < numRecords; i++ {
+			b.Reset(startTime)
+			b.SetTimestamp(startTime.Add(time.Duration(i) * time.Millisecond))
+			b.SetFunctionID(i)
+			b.SetFunctionCall([]byte("function call"))
+			assert.OK(t, w.WriteRecord(&b))
+		}
+		assert.OK(t, w.Close())
+
+		m, err := reg.LookupLogManifest(ctx, processID)
+		assert.OK(t, err)
+		assert.Equal(t, len(m.Segments), 10)
+
+		records, err := stream.ReadAll[timemachine.Record](reg.ListRecords(ctx, processID, timemachine.TimeRange{}))
+		assert.OK(t, err)
+		assert.Equal(t, len(records), numRecords)
+		for i, r := range records {
+			assert.Equal(t, r.Offset, int64(i))
+			assert.Equal(t, r.FunctionID, i)
+		}
+
+		r, err := reg.LookupRecord(ctx, m, 42)
+		assert.OK(t, err)
+		assert.Equal(t, r.Offset, 42)
+		assert.Equal(t, r.FunctionID, 42)
+	})
+
+	t.Run("DeleteLog", func(t *testing.T) {
+		ctx := context.Background()
+
+		store, err := object.DirStore(t.TempDir())
+		assert.OK(t, err)
+		reg := &timemachine.Registry{
+			Store: store,
+		}
+
+		processID := uuid.New()
+		manifest := &format.Manifest{
+			ProcessID: processID,
+			StartTime: time.Unix(1685053878, 0).UTC(),
+		}
+		assert.OK(t, reg.CreateLogManifest(ctx, processID, manifest))
+
+		w, err := reg.CreateLogSegmentWriter(ctx, manifest, 1, timemachine.Snappy, timemachine.LogSegmentPolicy{
+			MaxSize: 1,
+		})
+		assert.OK(t, err)
+
+		var b timemachine.RecordBuilder
+		for i := 0; i < 3; i++ {
+			b.Reset(manifest.StartTime)
+			b.SetTimestamp(manifest.StartTime)
+			b.SetFunctionCall([]byte("function call"))
+			assert.OK(t, w.WriteRecord(&b))
+		}
+		assert.OK(t, w.Close())
+
+		assert.OK(t, reg.DeleteLog(ctx, processID))
+
+		_, err = reg.LookupLogManifest(ctx, processID)
+		assert.True(t, errors.Is(err, timemachine.ErrNoLogRecords))
+
+		manifests, err := stream.ReadAll[*format.Manifest](reg.ListLogManifests(ctx))
+		assert.OK(t, err)
+		assert.Equal(t, len(manifests), 0)
+	})
 }
 
 type resource interface {
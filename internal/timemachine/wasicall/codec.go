@@ -1327,6 +1327,9 @@ func decodeIOVecs(buffer []byte, iovecs []IOVec) (_ []IOVec, _ []byte, err error
 	if count, buffer, err = decodeU32(buffer); err != nil {
 		return
 	}
+	if count&elidedIOVecs != 0 {
+		return decodeElidedIOVecs(buffer, count&^elidedIOVecs, iovecs)
+	}
 	if uint32(cap(iovecs)) < count {
 		iovecs = make([]IOVec, count)
 	} else {
@@ -0,0 +1,179 @@
+package wasicall
+
+import (
+	"crypto/sha256"
+	"io"
+
+	. "github.com/stealthrocket/wasi-go"
+)
+
+// Elision describes the payload of a system call which was elided from a
+// record. Only the size and a digest of the payload are stored in the log.
+//
+// The payload of reads and writes on files opened under one of the path
+// prefixes configured on the recorder carry the path and offset of the file
+// where the data was read from or written to, which allows reading back the
+// data from a copy of the file.
+type Elision struct {
+	Digest [sha256.Size]byte
+	Path   string
+	Offset FileSize
+}
+
+// elidedIOVecs is set on the count of iovecs to indicate that the payload was
+// elided. It is followed by the length of each iovec, the SHA-256 digest of
+// the concatenated payload, and the path and offset of the file.
+const elidedIOVecs = 1 << 31
+
+// EncodeFDReadElided is like EncodeFDRead but only retains the size and
+// digest of the data read.
+func (c *Codec) EncodeFDReadElided(buffer []byte, fd FD, iovecs []IOVec, size Size, errno Errno, path string, offset FileSize) []byte {
+	buffer = encodeErrno(buffer, errno)
+	buffer = encodeFD(buffer, fd)
+	buffer = encodeElidedIOVecs(buffer, iovecs, size, path, offset)
+	return encodeSize(buffer, size)
+}
+
+// EncodeFDPreadElided is like EncodeFDPread but only retains the size and
+// digest of the data read.
+func (c *Codec) EncodeFDPreadElided(buffer []byte, fd FD, iovecs []IOVec, offset FileSize, size Size, errno Errno, path string) []byte {
+	buffer = encodeErrno(buffer, errno)
+	buffer = encodeFD(buffer, fd)
+	buffer = encodeElidedIOVecs(buffer, iovecs, size, path, offset)
+	buffer = encodeFileSize(buffer, offset)
+	return encodeSize(buffer, size)
+}
+
+// EncodeFDWriteElided is like EncodeFDWrite but only retains the size and
+// digest of the data written.
+func (c *Codec) EncodeFDWriteElided(buffer []byte, fd FD, iovecs []IOVec, size Size, errno Errno, path string, offset FileSize) []byte {
+	buffer = encodeErrno(buffer, errno)
+	buffer = encodeFD(buffer, fd)
+	buffer = encodeElidedIOVecs(buffer, iovecs, iovecsSize(iovecs), path, offset)
+	return encodeSize(buffer, size)
+}
+
+// EncodeFDPwriteElided is like EncodeFDPwrite but only retains the size and
+// digest of the data written.
+func (c *Codec) EncodeFDPwriteElided(buffer []byte, fd FD, iovecs []IOVec, offset FileSize, size Size, errno Errno, path string) []byte {
+	buffer = encodeErrno(buffer, errno)
+	buffer = encodeFD(buffer, fd)
+	buffer = encodeElidedIOVecs(buffer, iovecs, iovecsSize(iovecs), path, offset)
+	buffer = encodeFileSize(buffer, offset)
+	return encodeSize(buffer, size)
+}
+
+// DecodeElision decodes the description of the elided payload of a FDRead,
+// FDPread, FDWrite, or FDPwrite record. The method returns false if the
+// payload of the record was not elided.
+func (c *Codec) DecodeElision(buffer []byte) (e Elision, elided bool, err error) {
+	if _, buffer, err = decodeErrno(buffer); err != nil {
+		return
+	}
+	if _, buffer, err = decodeFD(buffer); err != nil {
+		return
+	}
+	var count uint32
+	if count, buffer, err = decodeU32(buffer); err != nil {
+		return
+	}
+	if count&elidedIOVecs == 0 {
+		return
+	}
+	count &^= elidedIOVecs
+	if uint32(len(buffer)) < 4*count {
+		err = io.ErrShortBuffer
+		return
+	}
+	buffer = buffer[4*count:]
+	if len(buffer) < len(e.Digest) {
+		err = io.ErrShortBuffer
+		return
+	}
+	buffer = buffer[copy(e.Digest[:], buffer):]
+	if e.Path, buffer, err = decodeString(buffer); err != nil {
+		return
+	}
+	e.Offset, _, err = decodeFileSize(buffer)
+	return e, err == nil, err
+}
+
+func encodeElidedIOVecs(buffer []byte, iovecs []IOVec, size Size, path string, offset FileSize) []byte {
+	if int32(size) < 0 {
+		size = 0
+	}
+	h := sha256.New()
+	lengths := make([]uint32, 0, 8)
+	for remaining := size; remaining > 0 && len(lengths) < len(iovecs); {
+		iovec := iovecs[len(lengths)]
+		if remaining < Size(len(iovec)) {
+			iovec = iovec[:remaining]
+		}
+		h.Write(iovec)
+		lengths = append(lengths, uint32(len(iovec)))
+		remaining -= Size(len(iovec))
+	}
+	buffer = encodeU32(buffer, uint32(len(lengths))|elidedIOVecs)
+	for _, length := range lengths {
+		buffer = encodeU32(buffer, length)
+	}
+	buffer = h.Sum(buffer)
+	buffer = encodeString(buffer, path)
+	return encodeFileSize(buffer, offset)
+}
+
+// decodeElidedIOVecs decodes iovecs which were elided from the record. The
+// iovecs have the lengths of those recorded, and are filled with zeros.
+func decodeElidedIOVecs(buffer []byte, count uint32, iovecs []IOVec) (_ []IOVec, _ []byte, err error) {
+	if uint32(cap(iovecs)) < count {
+		iovecs = make([]IOVec, count)
+	} else {
+		iovecs = iovecs[:count]
+	}
+	size := 0
+	lengths := buffer
+	for i := uint32(0); i < count; i++ {
+		var length uint32
+		if length, buffer, err = decodeU32(buffer); err != nil {
+			return
+		}
+		size += int(length)
+	}
+	data := make([]byte, size)
+	for i := uint32(0); i < count; i++ {
+		length, _, _ := decodeU32(lengths[4*i:])
+		iovecs[i], data = data[:length:length], data[length:]
+	}
+	if len(buffer) < sha256.Size {
+		return nil, buffer, io.ErrShortBuffer
+	}
+	buffer = buffer[sha256.Size:]
+	if _, buffer, err = decodeString(buffer); err != nil {
+		return
+	}
+	if _, buffer, err = decodeFileSize(buffer); err != nil {
+		return
+	}
+	return iovecs, buffer, nil
+}
+
+// digestIOVecs returns the SHA-256 digest of the first size bytes of iovecs.
+func digestIOVecs(iovecs []IOVec, size Size) (digest [sha256.Size]byte) {
+	h := sha256.New()
+	for _, iovec := range iovecs {
+		if size < Size(len(iovec)) {
+			iovec = iovec[:size]
+		}
+		h.Write(iovec)
+		size -= Size(len(iovec))
+	}
+	h.Sum(digest[:0])
+	return digest
+}
+
+func iovecsSize(iovecs []IOVec) (size Size) {
+	for _, iovec := range iovecs {
+		size += Size(len(iovec))
+	}
+	return size
+}
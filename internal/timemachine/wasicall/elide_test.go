@@ -0,0 +1,169 @@
+package wasicall
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/sandbox"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/wasi-go"
+)
+
+func TestElide(t *testing.T) {
+	ctx := context.Background()
+
+	recordTo := func(records *[]timemachine.Record) func(SyscallID, []byte) {
+		return func(id SyscallID, b []byte) {
+			*records = append(*records, timemachine.Record{
+				Offset:       int64(len(*records)),
+				FunctionID:   int(id),
+				FunctionCall: slices.Clone(b),
+			})
+		}
+	}
+
+	replayCalls := func(system wasi.System, calls func(wasi.System)) (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = e.(error)
+			}
+		}()
+		calls(system)
+		return nil
+	}
+
+	t.Run("data read from files under elided paths is read back from the content directory", func(t *testing.T) {
+		const rootFD = 3
+		const data = "hello world!"
+		tmp := t.TempDir()
+		if err := os.Mkdir(filepath.Join(tmp, "static"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, "static", "data.txt"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		system, err := sandbox.NewSystem(sandbox.Mount("/", sandbox.DirFS(tmp)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer system.Close(ctx)
+
+		calls := func(s wasi.System) {
+			if _, errno := s.FDPreStatDirName(ctx, rootFD); errno != wasi.ESUCCESS {
+				t.Fatal(errno)
+			}
+			fd, errno := s.PathOpen(ctx, rootFD, 0, "static/data.txt", 0, wasi.FileRights, 0, 0)
+			if errno != wasi.ESUCCESS {
+				t.Fatal(errno)
+			}
+			buf1, buf2 := make([]byte, 5), make([]byte, 64)
+			if n, errno := s.FDRead(ctx, fd, []wasi.IOVec{buf1}); n != 5 || errno != wasi.ESUCCESS {
+				t.Fatalf("fd_read: %d, %v", n, errno)
+			}
+			if n, errno := s.FDRead(ctx, fd, []wasi.IOVec{buf2}); n != 7 || errno != wasi.ESUCCESS {
+				t.Fatalf("fd_read: %d, %v", n, errno)
+			}
+			if got := string(buf1) + string(buf2[:7]); got != data {
+				t.Errorf("unexpected data read: got %q, expect %q", got, data)
+			}
+			if n, errno := s.FDPread(ctx, fd, []wasi.IOVec{buf2}, 6); n != 6 || errno != wasi.ESUCCESS {
+				t.Fatalf("fd_pread: %d, %v", n, errno)
+			} else if string(buf2[:n]) != "world!" {
+				t.Errorf("unexpected data read: got %q, expect %q", buf2[:n], "world!")
+			}
+		}
+
+		var records []timemachine.Record
+		calls(NewRecorder(system, recordTo(&records), ElidePaths("/static")))
+
+		var codec Codec
+		for _, record := range records {
+			switch SyscallID(record.FunctionID) {
+			case FDRead, FDPread:
+				e, elided, err := codec.DecodeElision(record.FunctionCall)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !elided {
+					t.Errorf("record %d: data was not elided", record.Offset)
+				}
+				if e.Path != "/static/data.txt" {
+					t.Errorf("record %d: unexpected path: %q", record.Offset, e.Path)
+				}
+			}
+		}
+
+		replay := NewReplay(stream.NewReader(records...))
+		replay.Content = os.DirFS(tmp)
+		defer replay.Close(ctx)
+		if err := replayCalls(replay, calls); err != nil {
+			t.Fatal(err)
+		}
+
+		// Without content, the replay cannot fill the buffers of the guest.
+		replay = NewReplay(stream.NewReader(records...))
+		var contentError *ElidedContentError
+		if err := replayCalls(replay, calls); !errors.As(err, &contentError) {
+			t.Fatalf("unexpected replay error: %v", err)
+		}
+
+		// Changes to the content are detected.
+		if err := os.WriteFile(filepath.Join(tmp, "static", "data.txt"), []byte("HELLO WORLD!"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		replay = NewReplay(stream.NewReader(records...))
+		replay.Content = os.DirFS(tmp)
+		defer replay.Close(ctx)
+		if err := replayCalls(replay, calls); !errors.As(err, &contentError) {
+			t.Fatalf("unexpected replay error: %v", err)
+		}
+	})
+
+	t.Run("data written to elided file descriptors is verified by digest", func(t *testing.T) {
+		var records []timemachine.Record
+		recorder := NewRecorder(NewErrnoSystem(wasi.ESUCCESS), recordTo(&records), ElideFDs(1))
+		recorder.FDWrite(ctx, 1, []wasi.IOVec{[]byte("hello "), []byte("world!")})
+		recorder.FDWrite(ctx, 2, []wasi.IOVec{[]byte("error")})
+
+		var codec Codec
+		if _, elided, _ := codec.DecodeElision(records[0].FunctionCall); !elided {
+			t.Error("data written to fd 1 was not elided")
+		}
+		if _, elided, _ := codec.DecodeElision(records[1].FunctionCall); elided {
+			t.Error("data written to fd 2 was elided")
+		}
+
+		// Readers see zero bytes of the same size as the data.
+		_, syscall, err := NewReader(stream.NewReader(records...)).ReadSyscall()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if iovecs := syscall.(*FDWriteSyscall).IOVecs; iovecsSize(iovecs) != 12 {
+			t.Errorf("unexpected size of elided iovecs: %d", iovecsSize(iovecs))
+		}
+
+		replay := NewReplay(stream.NewReader(records...))
+		err = replayCalls(replay, func(s wasi.System) {
+			s.FDWrite(ctx, 1, []wasi.IOVec{[]byte("hello world!")})
+			s.FDWrite(ctx, 2, []wasi.IOVec{[]byte("error")})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		replay = NewReplay(stream.NewReader(records...))
+		err = replayCalls(replay, func(s wasi.System) {
+			s.FDWrite(ctx, 1, []wasi.IOVec{[]byte("HELLO WORLD!")})
+		})
+		var divergence *DivergenceError
+		if !errors.As(err, &divergence) {
+			t.Fatalf("unexpected replay error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package wasicall
+
+import "fmt"
+
+// Encoder encodes syscalls to the representation used in records of the time
+// machine log, it is the inverse of Decoder.
+//
+// Encoder is useful to convert syscalls observed on a live system (e.g. with
+// NewObserver) to records which can be consumed by the same code paths as
+// recorded logs.
+type Encoder struct {
+	codec Codec
+}
+
+// Encode appends the encoded representation of syscall to buffer and returns
+// the resulting slice. An error is returned if the syscall is unknown.
+func (e *Encoder) Encode(buffer []byte, syscall Syscall) ([]byte, error) {
+	switch s := syscall.(type) {
+	case *ArgsSizesGetSyscall:
+		return e.codec.EncodeArgsSizesGet(buffer, s.ArgCount, s.StringBytes, s.Errno), nil
+	case *ArgsGetSyscall:
+		return e.codec.EncodeArgsGet(buffer, s.Args, s.Errno), nil
+	case *EnvironSizesGetSyscall:
+		return e.codec.EncodeEnvironSizesGet(buffer, s.EnvCount, s.StringBytes, s.Errno), nil
+	case *EnvironGetSyscall:
+		return e.codec.EncodeEnvironGet(buffer, s.Env, s.Errno), nil
+	case *ClockResGetSyscall:
+		return e.codec.EncodeClockResGet(buffer, s.ClockID, s.Timestamp, s.Errno), nil
+	case *ClockTimeGetSyscall:
+		return e.codec.EncodeClockTimeGet(buffer, s.ClockID, s.Precision, s.Timestamp, s.Errno), nil
+	case *FDAdviseSyscall:
+		return e.codec.EncodeFDAdvise(buffer, s.FD, s.Offset, s.Length, s.Advice, s.Errno), nil
+	case *FDAllocateSyscall:
+		return e.codec.EncodeFDAllocate(buffer, s.FD, s.Offset, s.Length, s.Errno), nil
+	case *FDCloseSyscall:
+		return e.codec.EncodeFDClose(buffer, s.FD, s.Errno), nil
+	case *FDDataSyncSyscall:
+		return e.codec.EncodeFDDataSync(buffer, s.FD, s.Errno), nil
+	case *FDStatGetSyscall:
+		return e.codec.EncodeFDStatGet(buffer, s.FD, s.Stat, s.Errno), nil
+	case *FDStatSetFlagsSyscall:
+		return e.codec.EncodeFDStatSetFlags(buffer, s.FD, s.Flags, s.Errno), nil
+	case *FDStatSetRightsSyscall:
+		return e.codec.EncodeFDStatSetRights(buffer, s.FD, s.RightsBase, s.RightsInheriting, s.Errno), nil
+	case *FDFileStatGetSyscall:
+		return e.codec.EncodeFDFileStatGet(buffer, s.FD, s.Stat, s.Errno), nil
+	case *FDFileStatSetSizeSyscall:
+		return e.codec.EncodeFDFileStatSetSize(buffer, s.FD, s.Size, s.Errno), nil
+	case *FDFileStatSetTimesSyscall:
+		return e.codec.EncodeFDFileStatSetTimes(buffer, s.FD, s.AccessTime, s.ModifyTime, s.Flags, s.Errno), nil
+	case *FDPreadSyscall:
+		return e.codec.EncodeFDPread(buffer, s.FD, s.IOVecs, s.Offset, s.Size, s.Errno), nil
+	case *FDPreStatGetSyscall:
+		return e.codec.EncodeFDPreStatGet(buffer, s.FD, s.Stat, s.Errno), nil
+	case *FDPreStatDirNameSyscall:
+		return e.codec.EncodeFDPreStatDirName(buffer, s.FD, s.Name, s.Errno), nil
+	case *FDPwriteSyscall:
+		return e.codec.EncodeFDPwrite(buffer, s.FD, s.IOVecs, s.Offset, s.Size, s.Errno), nil
+	case *FDReadSyscall:
+		return e.codec.EncodeFDRead(buffer, s.FD, s.IOVecs, s.Size, s.Errno), nil
+	case *FDReadDirSyscall:
+		return e.codec.EncodeFDReadDir(buffer, s.FD, s.Entries, s.Cookie, s.BufferSizeBytes, s.Errno), nil
+	case *FDRenumberSyscall:
+		return e.codec.EncodeFDRenumber(buffer, s.From, s.To, s.Errno), nil
+	case *FDSeekSyscall:
+		return e.codec.EncodeFDSeek(buffer, s.FD, s.Offset, s.Whence, s.Size, s.Errno), nil
+	case *FDSyncSyscall:
+		return e.codec.EncodeFDSync(buffer, s.FD, s.Errno), nil
+	case *FDTellSyscall:
+		return e.codec.EncodeFDTell(buffer, s.FD, s.Size, s.Errno), nil
+	case *FDWriteSyscall:
+		return e.codec.EncodeFDWrite(buffer, s.FD, s.IOVecs, s.Size, s.Errno), nil
+	case *PathCreateDirectorySyscall:
+		return e.codec.EncodePathCreateDirectory(buffer, s.FD, s.Path, s.Errno), nil
+	case *PathFileStatGetSyscall:
+		return e.codec.EncodePathFileStatGet(buffer, s.FD, s.LookupFlags, s.Path, s.Stat, s.Errno), nil
+	case *PathFileStatSetTimesSyscall:
+		return e.codec.EncodePathFileStatSetTimes(buffer, s.FD, s.LookupFlags, s.Path, s.AccessTime, s.ModifyTime, s.Flags, s.Errno), nil
+	case *PathLinkSyscall:
+		return e.codec.EncodePathLink(buffer, s.OldFD, s.OldFlags, s.OldPath, s.NewFD, s.NewPath, s.Errno), nil
+	case *PathOpenSyscall:
+		return e.codec.EncodePathOpen(buffer, s.FD, s.DirFlags, s.Path, s.OpenFlags, s.RightsBase, s.RightsInheriting, s.FDFlags, s.NewFD, s.Errno), nil
+	case *PathReadLinkSyscall:
+		return e.codec.EncodePathReadLink(buffer, s.FD, s.Path, s.Output, s.Errno), nil
+	case *PathRemoveDirectorySyscall:
+		return e.codec.EncodePathRemoveDirectory(buffer, s.FD, s.Path, s.Errno), nil
+	case *PathRenameSyscall:
+		return e.codec.EncodePathRename(buffer, s.FD, s.OldPath, s.NewFD, s.NewPath, s.Errno), nil
+	case *PathSymlinkSyscall:
+		return e.codec.EncodePathSymlink(buffer, s.OldPath, s.FD, s.NewPath, s.Errno), nil
+	case *PathUnlinkFileSyscall:
+		return e.codec.EncodePathUnlinkFile(buffer, s.FD, s.Path, s.Errno), nil
+	case *PollOneOffSyscall:
+		return e.codec.EncodePollOneOff(buffer, s.Subscriptions, s.Events, s.Errno), nil
+	case *ProcExitSyscall:
+		return e.codec.EncodeProcExit(buffer, s.ExitCode, s.Errno), nil
+	case *ProcRaiseSyscall:
+		return e.codec.EncodeProcRaise(buffer, s.Signal, s.Errno), nil
+	case *SchedYieldSyscall:
+		return e.codec.EncodeSchedYield(buffer, s.Errno), nil
+	case *RandomGetSyscall:
+		return e.codec.EncodeRandomGet(buffer, s.B, s.Errno), nil
+	case *SockAcceptSyscall:
+		return e.codec.EncodeSockAccept(buffer, s.FD, s.Flags, s.NewFD, s.Peer, s.Addr, s.Errno), nil
+	case *SockShutdownSyscall:
+		return e.codec.EncodeSockShutdown(buffer, s.FD, s.Flags, s.Errno), nil
+	case *SockRecvSyscall:
+		return e.codec.EncodeSockRecv(buffer, s.FD, s.IOVecs, s.IFlags, s.Size, s.OFlags, s.Errno), nil
+	case *SockSendSyscall:
+		return e.codec.EncodeSockSend(buffer, s.FD, s.IOVecs, s.IFlags, s.Size, s.Errno), nil
+	case *SockOpenSyscall:
+		return e.codec.EncodeSockOpen(buffer, s.Family, s.SocketType, s.Protocol, s.RightsBase, s.RightsInheriting, s.FD, s.Errno), nil
+	case *SockBindSyscall:
+		return e.codec.EncodeSockBind(buffer, s.FD, s.Bind, s.Addr, s.Errno), nil
+	case *SockConnectSyscall:
+		return e.codec.EncodeSockConnect(buffer, s.FD, s.Peer, s.Addr, s.Errno), nil
+	case *SockListenSyscall:
+		return e.codec.EncodeSockListen(buffer, s.FD, s.Backlog, s.Errno), nil
+	case *SockSendToSyscall:
+		return e.codec.EncodeSockSendTo(buffer, s.FD, s.IOVecs, s.IFlags, s.Addr, s.Size, s.Errno), nil
+	case *SockRecvFromSyscall:
+		return e.codec.EncodeSockRecvFrom(buffer, s.FD, s.IOVecs, s.IFlags, s.Size, s.OFlags, s.Addr, s.Errno), nil
+	case *SockGetOptSyscall:
+		return e.codec.EncodeSockGetOpt(buffer, s.FD, s.Option, s.Value, s.Errno), nil
+	case *SockSetOptSyscall:
+		return e.codec.EncodeSockSetOpt(buffer, s.FD, s.Option, s.Value, s.Errno), nil
+	case *SockLocalAddressSyscall:
+		return e.codec.EncodeSockLocalAddress(buffer, s.FD, s.Addr, s.Errno), nil
+	case *SockRemoteAddressSyscall:
+		return e.codec.EncodeSockRemoteAddress(buffer, s.FD, s.Addr, s.Errno), nil
+	case *SockAddressInfoSyscall:
+		return e.codec.EncodeSockAddressInfo(buffer, s.Name, s.Service, s.Hints, s.Res, s.Errno), nil
+	default:
+		return buffer, fmt.Errorf("unknown syscall %d", syscall.ID())
+	}
+}
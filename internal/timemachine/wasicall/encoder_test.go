@@ -0,0 +1,42 @@
+package wasicall
+
+import (
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+)
+
+func TestEncoder(t *testing.T) {
+	var encoder Encoder
+
+	for _, syscall := range syscalls {
+		t.Run(syscallString(syscall), func(t *testing.T) {
+			functionCall, err := encoder.Encode(nil, syscall)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := timemachine.Record{
+				FunctionID:   int(syscall.ID()),
+				FunctionCall: functionCall,
+			}
+
+			reader := NewReader(stream.NewReader(record))
+			_, recordSyscall, err := reader.ReadSyscall()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSyscallEqual(t, recordSyscall, syscall)
+		})
+	}
+}
+
+type unknownSyscall struct{ *ArgsGetSyscall }
+
+func TestEncoderUnknownSyscall(t *testing.T) {
+	var encoder Encoder
+
+	if _, err := encoder.Encode(nil, unknownSyscall{&ArgsGetSyscall{}}); err == nil {
+		t.Fatal("expected an error encoding an unknown syscall")
+	}
+}
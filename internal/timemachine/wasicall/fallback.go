@@ -11,17 +11,20 @@ import (
 // primary system, and then forwarded to the secondary system when the primary
 // returns wasi.ENOSYS.
 func NewFallbackSystem(primary, secondary System) System {
-	return &fallbackSystem{primary, secondary}
+	return &fallbackSystem{primary, secondary, isENOSYS}
 }
 
 type fallbackSystem struct {
 	primary   System
 	secondary System
+	fallback  func(Errno) bool
 }
 
+func isENOSYS(errno Errno) bool { return errno == ENOSYS }
+
 func (f *fallbackSystem) ArgsSizesGet(ctx context.Context) (int, int, Errno) {
 	argCount, stringBytes, errno := f.primary.ArgsSizesGet(ctx)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ArgsSizesGet(ctx)
 	}
 	return argCount, stringBytes, errno
@@ -29,7 +32,7 @@ func (f *fallbackSystem) ArgsSizesGet(ctx context.Context) (int, int, Errno) {
 
 func (f *fallbackSystem) ArgsGet(ctx context.Context) ([]string, Errno) {
 	args, errno := f.primary.ArgsGet(ctx)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ArgsGet(ctx)
 	}
 	return args, errno
@@ -37,7 +40,7 @@ func (f *fallbackSystem) ArgsGet(ctx context.Context) ([]string, Errno) {
 
 func (f *fallbackSystem) EnvironSizesGet(ctx context.Context) (int, int, Errno) {
 	envCount, stringBytes, errno := f.primary.EnvironSizesGet(ctx)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.EnvironSizesGet(ctx)
 	}
 	return envCount, stringBytes, errno
@@ -45,7 +48,7 @@ func (f *fallbackSystem) EnvironSizesGet(ctx context.Context) (int, int, Errno)
 
 func (f *fallbackSystem) EnvironGet(ctx context.Context) ([]string, Errno) {
 	env, errno := f.primary.EnvironGet(ctx)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.EnvironGet(ctx)
 	}
 	return env, errno
@@ -53,7 +56,7 @@ func (f *fallbackSystem) EnvironGet(ctx context.Context) ([]string, Errno) {
 
 func (f *fallbackSystem) ClockResGet(ctx context.Context, id ClockID) (Timestamp, Errno) {
 	precision, errno := f.primary.ClockResGet(ctx, id)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ClockResGet(ctx, id)
 	}
 	return precision, errno
@@ -61,7 +64,7 @@ func (f *fallbackSystem) ClockResGet(ctx context.Context, id ClockID) (Timestamp
 
 func (f *fallbackSystem) ClockTimeGet(ctx context.Context, id ClockID, precision Timestamp) (Timestamp, Errno) {
 	timestamp, errno := f.primary.ClockTimeGet(ctx, id, precision)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ClockTimeGet(ctx, id, precision)
 	}
 	return timestamp, errno
@@ -69,7 +72,7 @@ func (f *fallbackSystem) ClockTimeGet(ctx context.Context, id ClockID, precision
 
 func (f *fallbackSystem) FDAdvise(ctx context.Context, fd FD, offset FileSize, length FileSize, advice Advice) Errno {
 	errno := f.primary.FDAdvise(ctx, fd, offset, length, advice)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDAdvise(ctx, fd, offset, length, advice)
 	}
 	return errno
@@ -77,7 +80,7 @@ func (f *fallbackSystem) FDAdvise(ctx context.Context, fd FD, offset FileSize, l
 
 func (f *fallbackSystem) FDAllocate(ctx context.Context, fd FD, offset FileSize, length FileSize) Errno {
 	errno := f.primary.FDAllocate(ctx, fd, offset, length)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDAllocate(ctx, fd, offset, length)
 	}
 	return errno
@@ -85,7 +88,7 @@ func (f *fallbackSystem) FDAllocate(ctx context.Context, fd FD, offset FileSize,
 
 func (f *fallbackSystem) FDClose(ctx context.Context, fd FD) Errno {
 	errno := f.primary.FDClose(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDClose(ctx, fd)
 	}
 	return errno
@@ -93,7 +96,7 @@ func (f *fallbackSystem) FDClose(ctx context.Context, fd FD) Errno {
 
 func (f *fallbackSystem) FDDataSync(ctx context.Context, fd FD) Errno {
 	errno := f.primary.FDDataSync(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDDataSync(ctx, fd)
 	}
 	return errno
@@ -101,7 +104,7 @@ func (f *fallbackSystem) FDDataSync(ctx context.Context, fd FD) Errno {
 
 func (f *fallbackSystem) FDStatGet(ctx context.Context, fd FD) (FDStat, Errno) {
 	stat, errno := f.primary.FDStatGet(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDStatGet(ctx, fd)
 	}
 	return stat, errno
@@ -109,7 +112,7 @@ func (f *fallbackSystem) FDStatGet(ctx context.Context, fd FD) (FDStat, Errno) {
 
 func (f *fallbackSystem) FDStatSetFlags(ctx context.Context, fd FD, flags FDFlags) Errno {
 	errno := f.primary.FDStatSetFlags(ctx, fd, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDStatSetFlags(ctx, fd, flags)
 	}
 	return errno
@@ -117,7 +120,7 @@ func (f *fallbackSystem) FDStatSetFlags(ctx context.Context, fd FD, flags FDFlag
 
 func (f *fallbackSystem) FDStatSetRights(ctx context.Context, fd FD, rightsBase, rightsInheriting Rights) Errno {
 	errno := f.primary.FDStatSetRights(ctx, fd, rightsBase, rightsInheriting)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDStatSetRights(ctx, fd, rightsBase, rightsInheriting)
 	}
 	return errno
@@ -125,7 +128,7 @@ func (f *fallbackSystem) FDStatSetRights(ctx context.Context, fd FD, rightsBase,
 
 func (f *fallbackSystem) FDFileStatGet(ctx context.Context, fd FD) (FileStat, Errno) {
 	stat, errno := f.primary.FDFileStatGet(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDFileStatGet(ctx, fd)
 	}
 	return stat, errno
@@ -133,7 +136,7 @@ func (f *fallbackSystem) FDFileStatGet(ctx context.Context, fd FD) (FileStat, Er
 
 func (f *fallbackSystem) FDFileStatSetSize(ctx context.Context, fd FD, size FileSize) Errno {
 	errno := f.primary.FDFileStatSetSize(ctx, fd, size)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDFileStatSetSize(ctx, fd, size)
 	}
 	return errno
@@ -141,7 +144,7 @@ func (f *fallbackSystem) FDFileStatSetSize(ctx context.Context, fd FD, size File
 
 func (f *fallbackSystem) FDFileStatSetTimes(ctx context.Context, fd FD, accessTime, modifyTime Timestamp, flags FSTFlags) Errno {
 	errno := f.primary.FDFileStatSetTimes(ctx, fd, accessTime, modifyTime, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDFileStatSetTimes(ctx, fd, accessTime, modifyTime, flags)
 	}
 	return errno
@@ -149,7 +152,7 @@ func (f *fallbackSystem) FDFileStatSetTimes(ctx context.Context, fd FD, accessTi
 
 func (f *fallbackSystem) FDPread(ctx context.Context, fd FD, iovecs []IOVec, offset FileSize) (Size, Errno) {
 	size, errno := f.primary.FDPread(ctx, fd, iovecs, offset)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDPread(ctx, fd, iovecs, offset)
 	}
 	return size, errno
@@ -157,7 +160,7 @@ func (f *fallbackSystem) FDPread(ctx context.Context, fd FD, iovecs []IOVec, off
 
 func (f *fallbackSystem) FDPreStatGet(ctx context.Context, fd FD) (PreStat, Errno) {
 	stat, errno := f.primary.FDPreStatGet(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDPreStatGet(ctx, fd)
 	}
 	return stat, errno
@@ -165,7 +168,7 @@ func (f *fallbackSystem) FDPreStatGet(ctx context.Context, fd FD) (PreStat, Errn
 
 func (f *fallbackSystem) FDPreStatDirName(ctx context.Context, fd FD) (string, Errno) {
 	name, errno := f.primary.FDPreStatDirName(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDPreStatDirName(ctx, fd)
 	}
 	return name, errno
@@ -173,7 +176,7 @@ func (f *fallbackSystem) FDPreStatDirName(ctx context.Context, fd FD) (string, E
 
 func (f *fallbackSystem) FDPwrite(ctx context.Context, fd FD, iovecs []IOVec, offset FileSize) (Size, Errno) {
 	size, errno := f.primary.FDPwrite(ctx, fd, iovecs, offset)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDPwrite(ctx, fd, iovecs, offset)
 	}
 	return size, errno
@@ -181,7 +184,7 @@ func (f *fallbackSystem) FDPwrite(ctx context.Context, fd FD, iovecs []IOVec, of
 
 func (f *fallbackSystem) FDRead(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno) {
 	size, errno := f.primary.FDRead(ctx, fd, iovecs)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDRead(ctx, fd, iovecs)
 	}
 	return size, errno
@@ -189,7 +192,7 @@ func (f *fallbackSystem) FDRead(ctx context.Context, fd FD, iovecs []IOVec) (Siz
 
 func (f *fallbackSystem) FDReadDir(ctx context.Context, fd FD, entries []DirEntry, cookie DirCookie, bufferSizeBytes int) (int, Errno) {
 	count, errno := f.primary.FDReadDir(ctx, fd, entries, cookie, bufferSizeBytes)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDReadDir(ctx, fd, entries, cookie, bufferSizeBytes)
 	}
 	return count, errno
@@ -197,7 +200,7 @@ func (f *fallbackSystem) FDReadDir(ctx context.Context, fd FD, entries []DirEntr
 
 func (f *fallbackSystem) FDRenumber(ctx context.Context, from, to FD) Errno {
 	errno := f.primary.FDRenumber(ctx, from, to)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDRenumber(ctx, from, to)
 	}
 	return errno
@@ -205,7 +208,7 @@ func (f *fallbackSystem) FDRenumber(ctx context.Context, from, to FD) Errno {
 
 func (f *fallbackSystem) FDSeek(ctx context.Context, fd FD, offset FileDelta, whence Whence) (FileSize, Errno) {
 	size, errno := f.primary.FDSeek(ctx, fd, offset, whence)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDSeek(ctx, fd, offset, whence)
 	}
 	return size, errno
@@ -213,7 +216,7 @@ func (f *fallbackSystem) FDSeek(ctx context.Context, fd FD, offset FileDelta, wh
 
 func (f *fallbackSystem) FDSync(ctx context.Context, fd FD) Errno {
 	errno := f.primary.FDSync(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDSync(ctx, fd)
 	}
 	return errno
@@ -221,7 +224,7 @@ func (f *fallbackSystem) FDSync(ctx context.Context, fd FD) Errno {
 
 func (f *fallbackSystem) FDTell(ctx context.Context, fd FD) (FileSize, Errno) {
 	size, errno := f.primary.FDTell(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDTell(ctx, fd)
 	}
 	return size, errno
@@ -229,7 +232,7 @@ func (f *fallbackSystem) FDTell(ctx context.Context, fd FD) (FileSize, Errno) {
 
 func (f *fallbackSystem) FDWrite(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno) {
 	size, errno := f.primary.FDWrite(ctx, fd, iovecs)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.FDWrite(ctx, fd, iovecs)
 	}
 	return size, errno
@@ -237,7 +240,7 @@ func (f *fallbackSystem) FDWrite(ctx context.Context, fd FD, iovecs []IOVec) (Si
 
 func (f *fallbackSystem) PathCreateDirectory(ctx context.Context, fd FD, path string) Errno {
 	errno := f.primary.PathCreateDirectory(ctx, fd, path)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathCreateDirectory(ctx, fd, path)
 	}
 	return errno
@@ -245,7 +248,7 @@ func (f *fallbackSystem) PathCreateDirectory(ctx context.Context, fd FD, path st
 
 func (f *fallbackSystem) PathFileStatGet(ctx context.Context, fd FD, lookupFlags LookupFlags, path string) (FileStat, Errno) {
 	stat, errno := f.primary.PathFileStatGet(ctx, fd, lookupFlags, path)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathFileStatGet(ctx, fd, lookupFlags, path)
 	}
 	return stat, errno
@@ -253,7 +256,7 @@ func (f *fallbackSystem) PathFileStatGet(ctx context.Context, fd FD, lookupFlags
 
 func (f *fallbackSystem) PathFileStatSetTimes(ctx context.Context, fd FD, lookupFlags LookupFlags, path string, accessTime, modifyTime Timestamp, flags FSTFlags) Errno {
 	errno := f.primary.PathFileStatSetTimes(ctx, fd, lookupFlags, path, accessTime, modifyTime, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathFileStatSetTimes(ctx, fd, lookupFlags, path, accessTime, modifyTime, flags)
 	}
 	return errno
@@ -261,7 +264,7 @@ func (f *fallbackSystem) PathFileStatSetTimes(ctx context.Context, fd FD, lookup
 
 func (f *fallbackSystem) PathLink(ctx context.Context, oldFD FD, oldFlags LookupFlags, oldPath string, newFD FD, newPath string) Errno {
 	errno := f.primary.PathLink(ctx, oldFD, oldFlags, oldPath, newFD, newPath)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathLink(ctx, oldFD, oldFlags, oldPath, newFD, newPath)
 	}
 	return errno
@@ -269,7 +272,7 @@ func (f *fallbackSystem) PathLink(ctx context.Context, oldFD FD, oldFlags Lookup
 
 func (f *fallbackSystem) PathOpen(ctx context.Context, fd FD, dirFlags LookupFlags, path string, openFlags OpenFlags, rightsBase, rightsInheriting Rights, fdFlags FDFlags) (FD, Errno) {
 	newfd, errno := f.primary.PathOpen(ctx, fd, dirFlags, path, openFlags, rightsBase, rightsInheriting, fdFlags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathOpen(ctx, fd, dirFlags, path, openFlags, rightsBase, rightsInheriting, fdFlags)
 	}
 	return newfd, errno
@@ -277,7 +280,7 @@ func (f *fallbackSystem) PathOpen(ctx context.Context, fd FD, dirFlags LookupFla
 
 func (f *fallbackSystem) PathReadLink(ctx context.Context, fd FD, path string, buffer []byte) (int, Errno) {
 	n, errno := f.primary.PathReadLink(ctx, fd, path, buffer)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathReadLink(ctx, fd, path, buffer)
 	}
 	return n, errno
@@ -285,7 +288,7 @@ func (f *fallbackSystem) PathReadLink(ctx context.Context, fd FD, path string, b
 
 func (f *fallbackSystem) PathRemoveDirectory(ctx context.Context, fd FD, path string) Errno {
 	errno := f.primary.PathRemoveDirectory(ctx, fd, path)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathRemoveDirectory(ctx, fd, path)
 	}
 	return errno
@@ -293,7 +296,7 @@ func (f *fallbackSystem) PathRemoveDirectory(ctx context.Context, fd FD, path st
 
 func (f *fallbackSystem) PathRename(ctx context.Context, fd FD, oldPath string, newFD FD, newPath string) Errno {
 	errno := f.primary.PathRename(ctx, fd, oldPath, newFD, newPath)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathRename(ctx, fd, oldPath, newFD, newPath)
 	}
 	return errno
@@ -301,7 +304,7 @@ func (f *fallbackSystem) PathRename(ctx context.Context, fd FD, oldPath string,
 
 func (f *fallbackSystem) PathSymlink(ctx context.Context, oldPath string, fd FD, newPath string) Errno {
 	errno := f.primary.PathSymlink(ctx, oldPath, fd, newPath)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathSymlink(ctx, oldPath, fd, newPath)
 	}
 	return errno
@@ -309,7 +312,7 @@ func (f *fallbackSystem) PathSymlink(ctx context.Context, oldPath string, fd FD,
 
 func (f *fallbackSystem) PathUnlinkFile(ctx context.Context, fd FD, path string) Errno {
 	errno := f.primary.PathUnlinkFile(ctx, fd, path)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PathUnlinkFile(ctx, fd, path)
 	}
 	return errno
@@ -317,7 +320,7 @@ func (f *fallbackSystem) PathUnlinkFile(ctx context.Context, fd FD, path string)
 
 func (f *fallbackSystem) PollOneOff(ctx context.Context, subscriptions []Subscription, events []Event) (int, Errno) {
 	count, errno := f.primary.PollOneOff(ctx, subscriptions, events)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.PollOneOff(ctx, subscriptions, events)
 	}
 	return count, errno
@@ -325,7 +328,7 @@ func (f *fallbackSystem) PollOneOff(ctx context.Context, subscriptions []Subscri
 
 func (f *fallbackSystem) ProcExit(ctx context.Context, exitCode ExitCode) Errno {
 	errno := f.primary.ProcExit(ctx, exitCode)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ProcExit(ctx, exitCode)
 	}
 	return errno
@@ -333,7 +336,7 @@ func (f *fallbackSystem) ProcExit(ctx context.Context, exitCode ExitCode) Errno
 
 func (f *fallbackSystem) ProcRaise(ctx context.Context, signal Signal) Errno {
 	errno := f.primary.ProcRaise(ctx, signal)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.ProcRaise(ctx, signal)
 	}
 	return errno
@@ -341,7 +344,7 @@ func (f *fallbackSystem) ProcRaise(ctx context.Context, signal Signal) Errno {
 
 func (f *fallbackSystem) SchedYield(ctx context.Context) Errno {
 	errno := f.primary.SchedYield(ctx)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SchedYield(ctx)
 	}
 	return errno
@@ -349,7 +352,7 @@ func (f *fallbackSystem) SchedYield(ctx context.Context) Errno {
 
 func (f *fallbackSystem) RandomGet(ctx context.Context, b []byte) Errno {
 	errno := f.primary.RandomGet(ctx, b)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.RandomGet(ctx, b)
 	}
 	return errno
@@ -357,7 +360,7 @@ func (f *fallbackSystem) RandomGet(ctx context.Context, b []byte) Errno {
 
 func (f *fallbackSystem) SockAccept(ctx context.Context, fd FD, flags FDFlags) (FD, SocketAddress, SocketAddress, Errno) {
 	newfd, peer, addr, errno := f.primary.SockAccept(ctx, fd, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockAccept(ctx, fd, flags)
 	}
 	return newfd, peer, addr, errno
@@ -365,7 +368,7 @@ func (f *fallbackSystem) SockAccept(ctx context.Context, fd FD, flags FDFlags) (
 
 func (f *fallbackSystem) SockRecv(ctx context.Context, fd FD, iovecs []IOVec, iflags RIFlags) (Size, ROFlags, Errno) {
 	size, oflags, errno := f.primary.SockRecv(ctx, fd, iovecs, iflags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockRecv(ctx, fd, iovecs, iflags)
 	}
 	return size, oflags, errno
@@ -373,7 +376,7 @@ func (f *fallbackSystem) SockRecv(ctx context.Context, fd FD, iovecs []IOVec, if
 
 func (f *fallbackSystem) SockSend(ctx context.Context, fd FD, iovecs []IOVec, flags SIFlags) (Size, Errno) {
 	size, errno := f.primary.SockSend(ctx, fd, iovecs, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockSend(ctx, fd, iovecs, flags)
 	}
 	return size, errno
@@ -381,7 +384,7 @@ func (f *fallbackSystem) SockSend(ctx context.Context, fd FD, iovecs []IOVec, fl
 
 func (f *fallbackSystem) SockShutdown(ctx context.Context, fd FD, flags SDFlags) Errno {
 	errno := f.primary.SockShutdown(ctx, fd, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockShutdown(ctx, fd, flags)
 	}
 	return errno
@@ -389,7 +392,7 @@ func (f *fallbackSystem) SockShutdown(ctx context.Context, fd FD, flags SDFlags)
 
 func (f *fallbackSystem) SockOpen(ctx context.Context, family ProtocolFamily, socketType SocketType, protocol Protocol, rightsBase, rightsInheriting Rights) (newfd FD, errno Errno) {
 	newfd, errno = f.primary.SockOpen(ctx, family, socketType, protocol, rightsBase, rightsInheriting)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockOpen(ctx, family, socketType, protocol, rightsBase, rightsInheriting)
 	}
 	return newfd, errno
@@ -397,7 +400,7 @@ func (f *fallbackSystem) SockOpen(ctx context.Context, family ProtocolFamily, so
 
 func (f *fallbackSystem) SockBind(ctx context.Context, fd FD, bind SocketAddress) (addr SocketAddress, errno Errno) {
 	addr, errno = f.primary.SockBind(ctx, fd, bind)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockBind(ctx, fd, bind)
 	}
 	return addr, errno
@@ -405,7 +408,7 @@ func (f *fallbackSystem) SockBind(ctx context.Context, fd FD, bind SocketAddress
 
 func (f *fallbackSystem) SockConnect(ctx context.Context, fd FD, peer SocketAddress) (addr SocketAddress, errno Errno) {
 	addr, errno = f.primary.SockConnect(ctx, fd, peer)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockConnect(ctx, fd, peer)
 	}
 	return addr, errno
@@ -413,7 +416,7 @@ func (f *fallbackSystem) SockConnect(ctx context.Context, fd FD, peer SocketAddr
 
 func (f *fallbackSystem) SockListen(ctx context.Context, fd FD, backlog int) (errno Errno) {
 	errno = f.primary.SockListen(ctx, fd, backlog)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockListen(ctx, fd, backlog)
 	}
 	return errno
@@ -421,7 +424,7 @@ func (f *fallbackSystem) SockListen(ctx context.Context, fd FD, backlog int) (er
 
 func (f *fallbackSystem) SockSendTo(ctx context.Context, fd FD, iovecs []IOVec, flags SIFlags, addr SocketAddress) (size Size, errno Errno) {
 	size, errno = f.primary.SockSendTo(ctx, fd, iovecs, flags, addr)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockSendTo(ctx, fd, iovecs, flags, addr)
 	}
 	return size, errno
@@ -429,7 +432,7 @@ func (f *fallbackSystem) SockSendTo(ctx context.Context, fd FD, iovecs []IOVec,
 
 func (f *fallbackSystem) SockRecvFrom(ctx context.Context, fd FD, iovecs []IOVec, flags RIFlags) (size Size, oflags ROFlags, addr SocketAddress, errno Errno) {
 	size, oflags, addr, errno = f.primary.SockRecvFrom(ctx, fd, iovecs, flags)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockRecvFrom(ctx, fd, iovecs, flags)
 	}
 	return size, oflags, addr, errno
@@ -437,7 +440,7 @@ func (f *fallbackSystem) SockRecvFrom(ctx context.Context, fd FD, iovecs []IOVec
 
 func (f *fallbackSystem) SockGetOpt(ctx context.Context, fd FD, option SocketOption) (value SocketOptionValue, errno Errno) {
 	value, errno = f.primary.SockGetOpt(ctx, fd, option)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockGetOpt(ctx, fd, option)
 	}
 	return value, errno
@@ -445,7 +448,7 @@ func (f *fallbackSystem) SockGetOpt(ctx context.Context, fd FD, option SocketOpt
 
 func (f *fallbackSystem) SockSetOpt(ctx context.Context, fd FD, option SocketOption, value SocketOptionValue) (errno Errno) {
 	errno = f.primary.SockSetOpt(ctx, fd, option, value)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockSetOpt(ctx, fd, option, value)
 	}
 	return errno
@@ -453,7 +456,7 @@ func (f *fallbackSystem) SockSetOpt(ctx context.Context, fd FD, option SocketOpt
 
 func (f *fallbackSystem) SockLocalAddress(ctx context.Context, fd FD) (addr SocketAddress, errno Errno) {
 	addr, errno = f.primary.SockLocalAddress(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockLocalAddress(ctx, fd)
 	}
 	return addr, errno
@@ -461,7 +464,7 @@ func (f *fallbackSystem) SockLocalAddress(ctx context.Context, fd FD) (addr Sock
 
 func (f *fallbackSystem) SockRemoteAddress(ctx context.Context, fd FD) (addr SocketAddress, errno Errno) {
 	addr, errno = f.primary.SockRemoteAddress(ctx, fd)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockRemoteAddress(ctx, fd)
 	}
 	return addr, errno
@@ -469,7 +472,7 @@ func (f *fallbackSystem) SockRemoteAddress(ctx context.Context, fd FD) (addr Soc
 
 func (f *fallbackSystem) SockAddressInfo(ctx context.Context, name, service string, hints AddressInfo, results []AddressInfo) (n int, errno Errno) {
 	n, errno = f.primary.SockAddressInfo(ctx, name, service, hints, results)
-	if errno == ENOSYS {
+	if f.fallback(errno) {
 		return f.secondary.SockAddressInfo(ctx, name, service, hints, results)
 	}
 	return n, errno
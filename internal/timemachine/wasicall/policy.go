@@ -0,0 +1,81 @@
+package wasicall
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReplayPolicy is a set of flags configuring how a Replay matches the system
+// calls made by the module with the records of the log.
+//
+// The lenient policies allow replaying logs recorded with a different version
+// of the guest toolchain, which may change the incidental system calls made by
+// the module (e.g. the runtime yielding or reading the clock more often).
+type ReplayPolicy uint
+
+// ReplayStrict requires the system calls to be made in the order that they
+// were recorded, and with the same parameters.
+const ReplayStrict ReplayPolicy = 0
+
+const (
+	// ReplayIgnoreClockAndRandomParams disables the validation of parameters
+	// of clock_res_get, clock_time_get, and random_get. The results found in
+	// the log are returned regardless of the clock or buffer size requested
+	// by the module.
+	ReplayIgnoreClockAndRandomParams ReplayPolicy = 1 << iota
+
+	// ReplaySkipExtraCalls allows the module to make calls to sched_yield and
+	// clock_time_get which are not found in the log. These calls do not
+	// consume records; sched_yield succeeds and clock_time_get returns the
+	// last time replayed for the clock.
+	ReplaySkipExtraCalls
+)
+
+var replayPolicies = [...]struct {
+	name   string
+	policy ReplayPolicy
+}{
+	{"ignore-clock-random-params", ReplayIgnoreClockAndRandomParams},
+	{"skip-extra-calls", ReplaySkipExtraCalls},
+}
+
+// ParseReplayPolicy parses a comma-separated list of replay policy names, as
+// returned by ReplayPolicy.String.
+func ParseReplayPolicy(s string) (ReplayPolicy, error) {
+	var p ReplayPolicy
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "strict" {
+			continue
+		}
+		found := false
+		for _, r := range replayPolicies {
+			if r.name == name {
+				p |= r.policy
+				found = true
+				break
+			}
+		}
+		if !found {
+			return p, fmt.Errorf("unsupported replay policy: %q (expected strict, ignore-clock-random-params, or skip-extra-calls)", name)
+		}
+	}
+	return p, nil
+}
+
+func (p ReplayPolicy) String() string {
+	if p == ReplayStrict {
+		return "strict"
+	}
+	var names []string
+	for _, r := range replayPolicies {
+		if p&r.policy != 0 {
+			names = append(names, r.name)
+			p &^= r.policy
+		}
+	}
+	if p != 0 {
+		names = append(names, fmt.Sprintf("ReplayPolicy(%d)", uint(p)))
+	}
+	return strings.Join(names, ",")
+}
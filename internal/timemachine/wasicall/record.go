@@ -2,6 +2,8 @@ package wasicall
 
 import (
 	"context"
+	"path"
+	"strings"
 
 	. "github.com/stealthrocket/wasi-go"
 )
@@ -10,8 +12,43 @@ import (
 //
 // The provided write function must consume the write immediately as it's
 // reused across function calls.
-func NewRecorder(system System, write func(SyscallID, []byte)) System {
-	return &recorderSystem{system: system, write: write}
+func NewRecorder(system System, write func(SyscallID, []byte), options ...RecorderOption) System {
+	r := &recorderSystem{system: system, write: write}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// RecorderOption configures a recorder created by NewRecorder.
+type RecorderOption func(*recorderSystem)
+
+// ElideFDs configures the recorder to elide the data written to the given file
+// descriptors. Only the size and a digest of the data are recorded, which
+// retains the ability to verify that a replay writes the same data.
+func ElideFDs(fds ...FD) RecorderOption {
+	return func(r *recorderSystem) {
+		if r.elideFDs == nil {
+			r.elideFDs = make(map[FD]struct{})
+		}
+		for _, fd := range fds {
+			r.elideFDs[fd] = struct{}{}
+		}
+	}
+}
+
+// ElidePaths configures the recorder to elide the data read from and written
+// to files opened under the given path prefixes. The path and offset of the
+// data is recorded so a replay can read the data back from a copy of the files.
+func ElidePaths(prefixes ...string) RecorderOption {
+	return func(r *recorderSystem) {
+		for _, prefix := range prefixes {
+			r.elidePaths = append(r.elidePaths, path.Clean("/"+prefix))
+		}
+		if r.paths == nil {
+			r.paths = make(map[FD]string)
+		}
+	}
 }
 
 type recorderSystem struct {
@@ -19,6 +56,41 @@ type recorderSystem struct {
 	write  func(SyscallID, []byte)
 	codec  Codec
 	buffer []byte
+
+	elideFDs   map[FD]struct{}
+	elidePaths []string
+	// Paths of the directories and files opened by the module, only tracked
+	// when paths are elided.
+	paths map[FD]string
+}
+
+// elidedPath returns the path of the file opened at fd if its data must be
+// elided from the log.
+func (r *recorderSystem) elidedPath(fd FD) (string, bool) {
+	filePath, ok := r.paths[fd]
+	if !ok {
+		return "", false
+	}
+	for _, prefix := range r.elidePaths {
+		if prefix == "/" || filePath == prefix || strings.HasPrefix(filePath, prefix+"/") {
+			return filePath, true
+		}
+	}
+	return "", false
+}
+
+// elidedFile returns the path of the file opened at fd and the current offset
+// in the file if its data must be elided from the log.
+func (r *recorderSystem) elidedFile(ctx context.Context, fd FD) (string, FileSize, bool) {
+	filePath, ok := r.elidedPath(fd)
+	if !ok {
+		return "", 0, false
+	}
+	offset, errno := r.system.FDTell(ctx, fd)
+	if errno != ESUCCESS {
+		return "", 0, false
+	}
+	return filePath, offset, true
 }
 
 func (r *recorderSystem) ArgsSizesGet(ctx context.Context) (int, int, Errno) {
@@ -72,6 +144,9 @@ func (r *recorderSystem) FDAllocate(ctx context.Context, fd FD, offset FileSize,
 func (r *recorderSystem) FDClose(ctx context.Context, fd FD) Errno {
 	errno := r.system.FDClose(ctx, fd)
 	r.write(FDClose, r.codec.EncodeFDClose(r.buffer[:0], fd, errno))
+	if errno == ESUCCESS && r.paths != nil {
+		delete(r.paths, fd)
+	}
 	return errno
 }
 
@@ -119,7 +194,11 @@ func (r *recorderSystem) FDFileStatSetTimes(ctx context.Context, fd FD, accessTi
 
 func (r *recorderSystem) FDPread(ctx context.Context, fd FD, iovecs []IOVec, offset FileSize) (Size, Errno) {
 	size, errno := r.system.FDPread(ctx, fd, iovecs, offset)
-	r.write(FDPread, r.codec.EncodeFDPread(r.buffer[:0], fd, iovecs, offset, size, errno))
+	if filePath, ok := r.elidedPath(fd); ok {
+		r.write(FDPread, r.codec.EncodeFDPreadElided(r.buffer[:0], fd, iovecs, offset, size, errno, filePath))
+	} else {
+		r.write(FDPread, r.codec.EncodeFDPread(r.buffer[:0], fd, iovecs, offset, size, errno))
+	}
 	return size, errno
 }
 
@@ -132,18 +211,30 @@ func (r *recorderSystem) FDPreStatGet(ctx context.Context, fd FD) (PreStat, Errn
 func (r *recorderSystem) FDPreStatDirName(ctx context.Context, fd FD) (string, Errno) {
 	name, errno := r.system.FDPreStatDirName(ctx, fd)
 	r.write(FDPreStatDirName, r.codec.EncodeFDPreStatDirName(r.buffer[:0], fd, name, errno))
+	if errno == ESUCCESS && r.paths != nil {
+		r.paths[fd] = path.Clean("/" + name)
+	}
 	return name, errno
 }
 
 func (r *recorderSystem) FDPwrite(ctx context.Context, fd FD, iovecs []IOVec, offset FileSize) (Size, Errno) {
 	n, errno := r.system.FDPwrite(ctx, fd, iovecs, offset)
-	r.write(FDPwrite, r.codec.EncodeFDPwrite(r.buffer[:0], fd, iovecs, offset, n, errno))
+	if filePath, ok := r.elidedPath(fd); ok {
+		r.write(FDPwrite, r.codec.EncodeFDPwriteElided(r.buffer[:0], fd, iovecs, offset, n, errno, filePath))
+	} else {
+		r.write(FDPwrite, r.codec.EncodeFDPwrite(r.buffer[:0], fd, iovecs, offset, n, errno))
+	}
 	return n, errno
 }
 
 func (r *recorderSystem) FDRead(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno) {
+	filePath, offset, elided := r.elidedFile(ctx, fd)
 	n, errno := r.system.FDRead(ctx, fd, iovecs)
-	r.write(FDRead, r.codec.EncodeFDRead(r.buffer[:0], fd, iovecs, n, errno))
+	if elided {
+		r.write(FDRead, r.codec.EncodeFDReadElided(r.buffer[:0], fd, iovecs, n, errno, filePath, offset))
+	} else {
+		r.write(FDRead, r.codec.EncodeFDRead(r.buffer[:0], fd, iovecs, n, errno))
+	}
 	return n, errno
 }
 
@@ -161,6 +252,14 @@ func (r *recorderSystem) FDReadDir(ctx context.Context, fd FD, entries []DirEntr
 func (r *recorderSystem) FDRenumber(ctx context.Context, from, to FD) Errno {
 	errno := r.system.FDRenumber(ctx, from, to)
 	r.write(FDRenumber, r.codec.EncodeFDRenumber(r.buffer[:0], from, to, errno))
+	if errno == ESUCCESS && r.paths != nil {
+		if filePath, ok := r.paths[from]; ok {
+			r.paths[to] = filePath
+		} else {
+			delete(r.paths, to)
+		}
+		delete(r.paths, from)
+	}
 	return errno
 }
 
@@ -183,8 +282,16 @@ func (r *recorderSystem) FDTell(ctx context.Context, fd FD) (FileSize, Errno) {
 }
 
 func (r *recorderSystem) FDWrite(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno) {
+	filePath, offset, elided := r.elidedFile(ctx, fd)
+	if _, ok := r.elideFDs[fd]; ok {
+		elided = true
+	}
 	n, errno := r.system.FDWrite(ctx, fd, iovecs)
-	r.write(FDWrite, r.codec.EncodeFDWrite(r.buffer[:0], fd, iovecs, n, errno))
+	if elided {
+		r.write(FDWrite, r.codec.EncodeFDWriteElided(r.buffer[:0], fd, iovecs, n, errno, filePath, offset))
+	} else {
+		r.write(FDWrite, r.codec.EncodeFDWrite(r.buffer[:0], fd, iovecs, n, errno))
+	}
 	return n, errno
 }
 
@@ -212,9 +319,14 @@ func (r *recorderSystem) PathLink(ctx context.Context, oldFD FD, oldFlags Lookup
 	return errno
 }
 
-func (r *recorderSystem) PathOpen(ctx context.Context, fd FD, dirFlags LookupFlags, path string, openFlags OpenFlags, rightsBase, rightsInheriting Rights, fdFlags FDFlags) (FD, Errno) {
-	newfd, errno := r.system.PathOpen(ctx, fd, dirFlags, path, openFlags, rightsBase, rightsInheriting, fdFlags)
-	r.write(PathOpen, r.codec.EncodePathOpen(r.buffer[:0], fd, dirFlags, path, openFlags, rightsBase, rightsInheriting, fdFlags, newfd, errno))
+func (r *recorderSystem) PathOpen(ctx context.Context, fd FD, dirFlags LookupFlags, filePath string, openFlags OpenFlags, rightsBase, rightsInheriting Rights, fdFlags FDFlags) (FD, Errno) {
+	newfd, errno := r.system.PathOpen(ctx, fd, dirFlags, filePath, openFlags, rightsBase, rightsInheriting, fdFlags)
+	r.write(PathOpen, r.codec.EncodePathOpen(r.buffer[:0], fd, dirFlags, filePath, openFlags, rightsBase, rightsInheriting, fdFlags, newfd, errno))
+	if errno == ESUCCESS && r.paths != nil {
+		if dir, ok := r.paths[fd]; ok {
+			r.paths[newfd] = path.Join(dir, filePath)
+		}
+	}
 	return newfd, errno
 }
 
@@ -3,9 +3,13 @@ package wasicall
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"path"
+	"strings"
 
 	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timemachine"
@@ -26,35 +30,54 @@ import (
 // WebAssembly module will be halted. The following errors may occur:
 //   - ReadError: there was an error reading from the log
 //   - DecodeError: there was an error decoding a write from the log
+//   - ElidedContentError: the data of a read was elided from the log, and
+//     could not be read back from the Content file system
 //   - UnexpectedSyscallError: a system call was made that did not match the
 //     next write in the log
-//   - UnexpectedSyscallParamError: a system call was made with input that
-//     did not match the next write in the log
+//   - DivergenceError: a system call was made that did not match the next
+//     write in the log
 //
-// The error may also be a compound error, indicating that multiple errors
-// were encountered. In this case, the error will implement
-// interface{ Unwrap() []error }.
+// A DivergenceError wraps either an UnexpectedSyscallError, when the system
+// call was not the one recorded in the log, or one or more
+// UnexpectedSyscallParamError when the system call was made with input that
+// did not match the record. In the latter case, the wrapped error may be a
+// compound error implementing interface{ Unwrap() []error }.
 type Replay struct {
 	Stdout io.Writer
 	Stderr io.Writer
 
+	// Policy configures how system calls are matched with the records of the
+	// log. The default is ReplayStrict.
+	Policy ReplayPolicy
+
+	// Content is the file system that the data of reads elided from the log
+	// is read from, rooted at the root directory of the module. The replay
+	// fails when reaching such records if Content is nil.
+	Content fs.FS
+	files   map[string]fs.File
+
 	stdout FD
 	stderr FD
 
 	records stream.Iterator[timemachine.Record]
+	eof     bool
+
+	// Ring buffer retaining copies of the last records read from the log,
+	// used to report the context of divergences.
+	history [replayHistory + 1]timemachine.Record
+	count   int
 
 	// Codec is used to encode and decode system call inputs and outputs.
 	// It's not configurable at this time.
 	codec Codec
 
-	// In strict mode, the replay will ensure that system calls are called
-	// with the same params as those stored on the records. It's not
-	// configurable at this time.
-	//
-	// TODO: consider separating the replay from validation, e.g. by making
-	//  Codec an interface, and then having a ValidatingCodec wrapper that
-	//  validates during decode
-	strict bool
+	// Set when the next record was read from the log without being consumed
+	// by a system call.
+	peeked bool
+
+	// Last timestamps returned by clock_time_get, used to answer the calls
+	// skipped with the ReplaySkipExtraCalls policy.
+	clocks [4]Timestamp
 
 	// Cache for decoded slices.
 	args          []string
@@ -67,6 +90,10 @@ type Replay struct {
 
 const noneFD = ^FD(0)
 
+// replayHistory is the number of records replayed before a divergence which
+// are reported in DivergenceError.
+const replayHistory = 10
+
 var _ System = (*Replay)(nil)
 
 // NewReplay creates a Replay.
@@ -76,24 +103,151 @@ func NewReplay(records stream.Reader[timemachine.Record]) *Replay {
 		Stderr: io.Discard,
 		stdout: 1,
 		stderr: 2,
-		strict: true,
 	}
 	r.records.Reset(records)
 	return r
 }
 
+// EOF returns true if all the records of the log have been replayed.
+func (r *Replay) EOF() bool {
+	return r.eof
+}
+
 func (r *Replay) readRecord(syscall SyscallID) (timemachine.Record, bool) {
+	record, ok := r.peekRecord()
+	if !ok {
+		return record, false
+	}
+	r.peeked = false
+	if recordSyscall := SyscallID(record.FunctionID); recordSyscall != syscall {
+		r.diverge(record, &UnexpectedSyscallError{recordSyscall, syscall})
+	}
+	return record, true
+}
+
+// peekRecord reads the next record from the log without consuming it.
+func (r *Replay) peekRecord() (timemachine.Record, bool) {
+	if r.peeked {
+		return r.history[(r.count-1)%len(r.history)], true
+	}
 	if !r.records.Next() {
 		if err := r.records.Err(); err != nil {
 			panic(&ReadError{err})
 		}
+		r.eof = true
 		return timemachine.Record{}, false
 	}
-	record := r.records.Value()
-	if recordSyscall := SyscallID(record.FunctionID); recordSyscall != syscall {
-		panic(&UnexpectedSyscallError{recordSyscall, syscall})
+	r.peeked = true
+	return r.remember(r.records.Value()), true
+}
+
+// skip returns true if the system call was not recorded in the log at this
+// point, and the policy allows skipping it.
+func (r *Replay) skip(syscall SyscallID) bool {
+	if r.Policy&ReplaySkipExtraCalls == 0 {
+		return false
 	}
-	return record, true
+	record, ok := r.peekRecord()
+	return ok && SyscallID(record.FunctionID) != syscall
+}
+
+// strict returns true if the parameters of the system call must match those
+// of the record.
+func (r *Replay) strict(syscall SyscallID) bool {
+	switch syscall {
+	case ClockResGet, ClockTimeGet, RandomGet:
+		return r.Policy&ReplayIgnoreClockAndRandomParams == 0
+	default:
+		return true
+	}
+}
+
+// remember copies the record to the history, the returned record remains valid
+// after reading the next records from the log.
+func (r *Replay) remember(record timemachine.Record) timemachine.Record {
+	slot := &r.history[r.count%len(r.history)]
+	slot.Offset = record.Offset
+	slot.Time = record.Time
+	slot.FunctionID = record.FunctionID
+	slot.FunctionCall = append(slot.FunctionCall[:0], record.FunctionCall...)
+	r.count++
+	return *slot
+}
+
+func (r *Replay) diverge(record timemachine.Record, err error) {
+	n := min(r.count, len(r.history)) - 1
+	history := make([]timemachine.Record, n)
+	for i := range history {
+		history[i] = r.history[(r.count-1-n+i)%len(r.history)]
+	}
+	panic(&DivergenceError{Record: record, History: history, Err: err})
+}
+
+func (r *Replay) decodeElision(record timemachine.Record) (Elision, bool) {
+	elision, elided, err := r.codec.DecodeElision(record.FunctionCall)
+	if err != nil {
+		panic(&DecodeError{record, err})
+	}
+	return elision, elided
+}
+
+// readElided fills iovecs with the data elided from the record, read back
+// from the content file system.
+func (r *Replay) readElided(record timemachine.Record, elision Elision, iovecs []IOVec, size Size) {
+	if elision.Path == "" {
+		panic(&ElidedContentError{record, "", errors.New("the data read was elided from the log and cannot be replayed")})
+	}
+	if r.Content == nil {
+		panic(&ElidedContentError{record, elision.Path, errors.New("the data read was elided from the log and no content directory was provided")})
+	}
+	f, err := r.openContent(elision.Path)
+	if err != nil {
+		panic(&ElidedContentError{record, elision.Path, err})
+	}
+	readerAt, ok := f.(io.ReaderAt)
+	if !ok {
+		panic(&ElidedContentError{record, elision.Path, errors.New("file does not support reading at an offset")})
+	}
+	offset, remaining := int64(elision.Offset), size
+	for _, iovec := range iovecs {
+		if remaining == 0 {
+			break
+		}
+		if remaining < Size(len(iovec)) {
+			iovec = iovec[:remaining]
+		}
+		n, err := readerAt.ReadAt(iovec, offset)
+		if n < len(iovec) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			panic(&ElidedContentError{record, elision.Path, err})
+		}
+		offset += int64(n)
+		remaining -= Size(n)
+	}
+	if digestIOVecs(iovecs, size) != elision.Digest {
+		panic(&ElidedContentError{record, elision.Path, errors.New("the content of the file differs from the data recorded in the log")})
+	}
+}
+
+func (r *Replay) openContent(filePath string) (fs.File, error) {
+	if f, ok := r.files[filePath]; ok {
+		return f, nil
+	}
+	name := strings.TrimPrefix(path.Clean(filePath), "/")
+	if name == "" {
+		name = "."
+	}
+	f, err := r.Content.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if r.files == nil {
+		r.files = make(map[string]fs.File)
+	}
+	r.files[filePath] = f
+	return f, nil
 }
 
 func (r *Replay) ArgsSizesGet(ctx context.Context) (int, int, Errno) {
@@ -155,13 +309,25 @@ func (r *Replay) ClockResGet(ctx context.Context, id ClockID) (Timestamp, Errno)
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && id != recordID {
-		panic(&UnexpectedSyscallParamError{ClockResGet, "id", id, recordID})
+	if r.strict(ClockResGet) && id != recordID {
+		r.diverge(record, &UnexpectedSyscallParamError{ClockResGet, "id", id, recordID})
 	}
 	return timestamp, errno
 }
 
 func (r *Replay) ClockTimeGet(ctx context.Context, id ClockID, precision Timestamp) (Timestamp, Errno) {
+	if r.skip(ClockTimeGet) {
+		if int(id) >= len(r.clocks) {
+			return 0, EINVAL
+		}
+		if id == Realtime && r.clocks[id] == 0 {
+			// The real time clock was not read yet, the time at which the
+			// next record was written approximates it.
+			record, _ := r.peekRecord()
+			return Timestamp(record.Time.UnixNano()), ESUCCESS
+		}
+		return r.clocks[id], ESUCCESS
+	}
 	record, ok := r.readRecord(ClockTimeGet)
 	if !ok {
 		return 0, ENOSYS
@@ -170,7 +336,7 @@ func (r *Replay) ClockTimeGet(ctx context.Context, id ClockID, precision Timesta
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(ClockTimeGet) {
 		var mismatch []error
 		if id != recordID {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{ClockTimeGet, "id", id, recordID})
@@ -179,9 +345,12 @@ func (r *Replay) ClockTimeGet(ctx context.Context, id ClockID, precision Timesta
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{ClockTimeGet, "precision", precision, recordPrecision})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
+	if errno == ESUCCESS && int(recordID) < len(r.clocks) {
+		r.clocks[recordID] = timestamp
+	}
 	return timestamp, errno
 }
 
@@ -194,7 +363,7 @@ func (r *Replay) FDAdvise(ctx context.Context, fd FD, offset FileSize, length Fi
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDAdvise) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDAdvise, "fd", fd, recordFD})
@@ -209,7 +378,7 @@ func (r *Replay) FDAdvise(ctx context.Context, fd FD, offset FileSize, length Fi
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDAdvise, "advice", advice, recordAdvice})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -224,7 +393,7 @@ func (r *Replay) FDAllocate(ctx context.Context, fd FD, offset FileSize, length
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDAllocate) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDAllocate, "fd", fd, recordFD})
@@ -236,7 +405,7 @@ func (r *Replay) FDAllocate(ctx context.Context, fd FD, offset FileSize, length
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDAllocate, "length", length, recordLength})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -251,8 +420,8 @@ func (r *Replay) FDClose(ctx context.Context, fd FD) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDClose, "fd", fd, recordFD})
+	if r.strict(FDClose) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDClose, "fd", fd, recordFD})
 	}
 	switch fd {
 	case r.stdout:
@@ -272,8 +441,8 @@ func (r *Replay) FDDataSync(ctx context.Context, fd FD) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDDataSync, "fd", fd, recordFD})
+	if r.strict(FDDataSync) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDDataSync, "fd", fd, recordFD})
 	}
 	return errno
 }
@@ -287,8 +456,8 @@ func (r *Replay) FDStatGet(ctx context.Context, fd FD) (FDStat, Errno) {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDStatGet, "fd", fd, recordFD})
+	if r.strict(FDStatGet) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDStatGet, "fd", fd, recordFD})
 	}
 	return stat, errno
 }
@@ -302,7 +471,7 @@ func (r *Replay) FDStatSetFlags(ctx context.Context, fd FD, flags FDFlags) Errno
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDStatSetFlags) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDStatSetFlags, "fd", fd, recordFD})
@@ -311,7 +480,7 @@ func (r *Replay) FDStatSetFlags(ctx context.Context, fd FD, flags FDFlags) Errno
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDStatSetFlags, "flags", flags, recordFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -326,7 +495,7 @@ func (r *Replay) FDStatSetRights(ctx context.Context, fd FD, rightsBase, rightsI
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDStatSetRights) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDStatSetRights, "fd", fd, recordFD})
@@ -338,7 +507,7 @@ func (r *Replay) FDStatSetRights(ctx context.Context, fd FD, rightsBase, rightsI
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDStatSetRights, "rightsInheriting", rightsInheriting, recordRightsInheriting})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -353,8 +522,8 @@ func (r *Replay) FDFileStatGet(ctx context.Context, fd FD) (FileStat, Errno) {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDFileStatGet, "fd", fd, recordFD})
+	if r.strict(FDFileStatGet) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDFileStatGet, "fd", fd, recordFD})
 	}
 	return stat, errno
 }
@@ -368,7 +537,7 @@ func (r *Replay) FDFileStatSetSize(ctx context.Context, fd FD, size FileSize) Er
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDFileStatSetSize) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDFileStatSetSize, "fd", fd, recordFD})
@@ -377,7 +546,7 @@ func (r *Replay) FDFileStatSetSize(ctx context.Context, fd FD, size FileSize) Er
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDFileStatSetSize, "size", size, recordSize})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -392,7 +561,7 @@ func (r *Replay) FDFileStatSetTimes(ctx context.Context, fd FD, accessTime, modi
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDFileStatSetTimes) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDFileStatSetTimes, "fd", fd, recordFD})
@@ -407,7 +576,7 @@ func (r *Replay) FDFileStatSetTimes(ctx context.Context, fd FD, accessTime, modi
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDFileStatSetTimes, "flags", flags, recordFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -423,7 +592,7 @@ func (r *Replay) FDPread(ctx context.Context, fd FD, iovecs []IOVec, offset File
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(FDPread) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPread, "fd", fd, recordFD})
@@ -435,11 +604,15 @@ func (r *Replay) FDPread(ctx context.Context, fd FD, iovecs []IOVec, offset File
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPread, "offset", offset, recordOffset})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
-	for i := range recordIOVecs {
-		copy(iovecs[i], recordIOVecs[i])
+	if elision, elided := r.decodeElision(record); elided {
+		r.readElided(record, elision, iovecs, size)
+	} else {
+		for i := range recordIOVecs {
+			copy(iovecs[i], recordIOVecs[i])
+		}
 	}
 	return size, errno
 }
@@ -453,8 +626,8 @@ func (r *Replay) FDPreStatGet(ctx context.Context, fd FD) (PreStat, Errno) {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDPreStatGet, "fd", fd, recordFD})
+	if r.strict(FDPreStatGet) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDPreStatGet, "fd", fd, recordFD})
 	}
 	return stat, errno
 }
@@ -468,8 +641,8 @@ func (r *Replay) FDPreStatDirName(ctx context.Context, fd FD) (string, Errno) {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDPreStatDirName, "fd", fd, recordFD})
+	if r.strict(FDPreStatDirName) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDPreStatDirName, "fd", fd, recordFD})
 	}
 	return name, errno
 }
@@ -484,19 +657,23 @@ func (r *Replay) FDPwrite(ctx context.Context, fd FD, iovecs []IOVec, offset Fil
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(FDPwrite) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPwrite, "fd", fd, recordFD})
 		}
-		if !equalIovecs(iovecs, recordIOVecs) {
+		if elision, elided := r.decodeElision(record); elided {
+			if !equalIovecsDigest(iovecs, recordIOVecs, elision.Digest) {
+				mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPwrite, "iovecs", iovecs, recordIOVecs})
+			}
+		} else if !equalIovecs(iovecs, recordIOVecs) {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPwrite, "iovecs", iovecs, recordIOVecs})
 		}
 		if offset != recordOffset {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDPwrite, "offset", offset, recordOffset})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return size, errno
@@ -512,7 +689,7 @@ func (r *Replay) FDRead(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(FDRead) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDRead, "fd", fd, recordFD})
@@ -521,11 +698,15 @@ func (r *Replay) FDRead(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errno
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDRead, "iovecs", iovecs, recordIOVecs})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
-	for i := range recordIOVecs {
-		copy(iovecs[i], recordIOVecs[i])
+	if elision, elided := r.decodeElision(record); elided {
+		r.readElided(record, elision, iovecs, size)
+	} else {
+		for i := range recordIOVecs {
+			copy(iovecs[i], recordIOVecs[i])
+		}
 	}
 	return size, errno
 }
@@ -540,7 +721,7 @@ func (r *Replay) FDReadDir(ctx context.Context, fd FD, entries []DirEntry, cooki
 		panic(&DecodeError{record, err})
 	}
 	r.entries = recordEntries
-	if r.strict {
+	if r.strict(FDReadDir) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDReadDir, "fd", fd, recordFD})
@@ -555,7 +736,7 @@ func (r *Replay) FDReadDir(ctx context.Context, fd FD, entries []DirEntry, cooki
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDReadDir, "bufferSizeBytes", bufferSizeBytes, recordBufferSizeBytes})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	copy(entries, recordEntries)
@@ -571,7 +752,7 @@ func (r *Replay) FDRenumber(ctx context.Context, from, to FD) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDRenumber) {
 		var mismatch []error
 		if from != recordFrom {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDRenumber, "from", from, recordFrom})
@@ -580,7 +761,7 @@ func (r *Replay) FDRenumber(ctx context.Context, from, to FD) Errno {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDRenumber, "to", to, recordTo})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	if errno == ESUCCESS {
@@ -603,7 +784,7 @@ func (r *Replay) FDSeek(ctx context.Context, fd FD, offset FileDelta, whence Whe
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(FDSeek) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDSeek, "fd", fd, recordFD})
@@ -615,7 +796,7 @@ func (r *Replay) FDSeek(ctx context.Context, fd FD, offset FileDelta, whence Whe
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDSeek, "whence", whence, recordWhence})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return size, errno
@@ -630,8 +811,8 @@ func (r *Replay) FDSync(ctx context.Context, fd FD) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDSync, "fd", fd, recordFD})
+	if r.strict(FDSync) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDSync, "fd", fd, recordFD})
 	}
 	return errno
 }
@@ -645,8 +826,8 @@ func (r *Replay) FDTell(ctx context.Context, fd FD) (FileSize, Errno) {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{FDTell, "fd", fd, recordFD})
+	if r.strict(FDTell) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{FDTell, "fd", fd, recordFD})
 	}
 	return size, errno
 }
@@ -661,16 +842,20 @@ func (r *Replay) FDWrite(ctx context.Context, fd FD, iovecs []IOVec) (Size, Errn
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(FDWrite) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDWrite, "fd", fd, recordFD})
 		}
-		if !equalIovecs(iovecs, recordIOVecs) {
+		if elision, elided := r.decodeElision(record); elided {
+			if !equalIovecsDigest(iovecs, recordIOVecs, elision.Digest) {
+				mismatch = append(mismatch, &UnexpectedSyscallParamError{FDWrite, "iovecs", iovecs, recordIOVecs})
+			}
+		} else if !equalIovecs(iovecs, recordIOVecs) {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{FDWrite, "iovecs", iovecs, recordIOVecs})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	if fd != noneFD {
@@ -703,7 +888,7 @@ func (r *Replay) PathCreateDirectory(ctx context.Context, fd FD, path string) Er
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathCreateDirectory) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathCreateDirectory, "fd", fd, recordFD})
@@ -712,7 +897,7 @@ func (r *Replay) PathCreateDirectory(ctx context.Context, fd FD, path string) Er
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathCreateDirectory, "path", path, recordPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -727,7 +912,7 @@ func (r *Replay) PathFileStatGet(ctx context.Context, fd FD, lookupFlags LookupF
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathFileStatGet) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathFileStatGet, "fd", fd, recordFD})
@@ -739,7 +924,7 @@ func (r *Replay) PathFileStatGet(ctx context.Context, fd FD, lookupFlags LookupF
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathFileStatGet, "path", path, recordPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return stat, errno
@@ -754,7 +939,7 @@ func (r *Replay) PathFileStatSetTimes(ctx context.Context, fd FD, lookupFlags Lo
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathFileStatSetTimes) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathFileStatSetTimes, "fd", fd, recordFD})
@@ -775,7 +960,7 @@ func (r *Replay) PathFileStatSetTimes(ctx context.Context, fd FD, lookupFlags Lo
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathFileStatSetTimes, "path", path, recordPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -790,7 +975,7 @@ func (r *Replay) PathLink(ctx context.Context, oldFD FD, oldFlags LookupFlags, o
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathLink) {
 		var mismatch []error
 		if oldFD != recordOldFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathLink, "oldFD", oldFD, recordOldFD})
@@ -808,7 +993,7 @@ func (r *Replay) PathLink(ctx context.Context, oldFD FD, oldFlags LookupFlags, o
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathLink, "newPath", newPath, recordNewPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -823,7 +1008,7 @@ func (r *Replay) PathOpen(ctx context.Context, fd FD, dirFlags LookupFlags, path
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathOpen) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathOpen, "fd", fd, recordFD})
@@ -847,7 +1032,7 @@ func (r *Replay) PathOpen(ctx context.Context, fd FD, dirFlags LookupFlags, path
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathOpen, "fdFlags", fdFlags, recordFDFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return newfd, errno
@@ -862,7 +1047,7 @@ func (r *Replay) PathReadLink(ctx context.Context, fd FD, path string, buffer []
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathReadLink) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathReadLink, "fd", fd, recordFD})
@@ -874,7 +1059,7 @@ func (r *Replay) PathReadLink(ctx context.Context, fd FD, path string, buffer []
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathReadLink, "buffer", buffer, result})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	copy(buffer, result)
@@ -890,7 +1075,7 @@ func (r *Replay) PathRemoveDirectory(ctx context.Context, fd FD, path string) Er
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathRemoveDirectory) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathRemoveDirectory, "fd", fd, recordFD})
@@ -899,7 +1084,7 @@ func (r *Replay) PathRemoveDirectory(ctx context.Context, fd FD, path string) Er
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathRemoveDirectory, "path", path, recordPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -914,7 +1099,7 @@ func (r *Replay) PathRename(ctx context.Context, fd FD, oldPath string, newFD FD
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathRename) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathRename, "fd", fd, recordFD})
@@ -929,7 +1114,7 @@ func (r *Replay) PathRename(ctx context.Context, fd FD, oldPath string, newFD FD
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathRename, "newPath", newPath, recordNewPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -944,7 +1129,7 @@ func (r *Replay) PathSymlink(ctx context.Context, oldPath string, fd FD, newPath
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathSymlink) {
 		var mismatch []error
 		if oldPath != recordOldPath {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathSymlink, "oldPath", oldPath, recordOldPath})
@@ -956,7 +1141,7 @@ func (r *Replay) PathSymlink(ctx context.Context, oldPath string, fd FD, newPath
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathSymlink, "newPath", newPath, recordNewPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -971,7 +1156,7 @@ func (r *Replay) PathUnlinkFile(ctx context.Context, fd FD, path string) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(PathUnlinkFile) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathUnlinkFile, "fd", fd, recordFD})
@@ -980,7 +1165,7 @@ func (r *Replay) PathUnlinkFile(ctx context.Context, fd FD, path string) Errno {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathUnlinkFile, "path", path, recordPath})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -997,7 +1182,7 @@ func (r *Replay) PollOneOff(ctx context.Context, subscriptions []Subscription, e
 	}
 	r.subscriptions = recordSubscriptions
 	r.events = recordEvents
-	if r.strict {
+	if r.strict(PollOneOff) {
 		var mismatch []error
 		if !equalSubscriptions(subscriptions, recordSubscriptions) {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PollOneOff, "subscriptions", subscriptions, recordSubscriptions})
@@ -1006,7 +1191,7 @@ func (r *Replay) PollOneOff(ctx context.Context, subscriptions []Subscription, e
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PollOneOff, "events", events, recordEvents})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	copy(events, recordEvents)
@@ -1022,8 +1207,8 @@ func (r *Replay) ProcExit(ctx context.Context, exitCode ExitCode) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && exitCode != recordExitCode {
-		panic(&UnexpectedSyscallParamError{ProcExit, "exitCode", exitCode, recordExitCode})
+	if r.strict(ProcExit) && exitCode != recordExitCode {
+		r.diverge(record, &UnexpectedSyscallParamError{ProcExit, "exitCode", exitCode, recordExitCode})
 	}
 	_ = errno
 	panic(sys.NewExitError(uint32(exitCode)))
@@ -1038,13 +1223,16 @@ func (r *Replay) ProcRaise(ctx context.Context, signal Signal) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && signal != recordSignal {
-		panic(&UnexpectedSyscallParamError{ProcRaise, "signal", signal, recordSignal})
+	if r.strict(ProcRaise) && signal != recordSignal {
+		r.diverge(record, &UnexpectedSyscallParamError{ProcRaise, "signal", signal, recordSignal})
 	}
 	return errno
 }
 
 func (r *Replay) SchedYield(ctx context.Context) Errno {
+	if r.skip(SchedYield) {
+		return ESUCCESS
+	}
 	record, ok := r.readRecord(SchedYield)
 	if !ok {
 		return ENOSYS
@@ -1065,8 +1253,8 @@ func (r *Replay) RandomGet(ctx context.Context, buffer []byte) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && len(buffer) != len(recordBuffer) {
-		panic(&UnexpectedSyscallParamError{RandomGet, "buffer", buffer, recordBuffer})
+	if r.strict(RandomGet) && len(buffer) != len(recordBuffer) {
+		r.diverge(record, &UnexpectedSyscallParamError{RandomGet, "buffer", buffer, recordBuffer})
 	}
 	copy(buffer, recordBuffer)
 	return errno
@@ -1081,7 +1269,7 @@ func (r *Replay) SockAccept(ctx context.Context, fd FD, flags FDFlags) (FD, Sock
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockAccept) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockAccept, "fd", fd, recordFD})
@@ -1090,7 +1278,7 @@ func (r *Replay) SockAccept(ctx context.Context, fd FD, flags FDFlags) (FD, Sock
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockAccept, "flags", flags, recordFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return newfd, peer, addr, errno
@@ -1105,7 +1293,7 @@ func (r *Replay) SockShutdown(ctx context.Context, fd FD, flags SDFlags) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockShutdown) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockShutdown, "fd", fd, recordFD})
@@ -1114,7 +1302,7 @@ func (r *Replay) SockShutdown(ctx context.Context, fd FD, flags SDFlags) Errno {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockShutdown, "flags", flags, recordFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -1130,7 +1318,7 @@ func (r *Replay) SockRecv(ctx context.Context, fd FD, iovecs []IOVec, iflags RIF
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(SockRecv) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockRecv, "fd", fd, recordFD})
@@ -1142,7 +1330,7 @@ func (r *Replay) SockRecv(ctx context.Context, fd FD, iovecs []IOVec, iflags RIF
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockRecv, "iflags", iflags, recordIFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	for i := range recordIOVecs {
@@ -1160,7 +1348,7 @@ func (r *Replay) SockSend(ctx context.Context, fd FD, iovecs []IOVec, iflags SIF
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockSend) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSend, "fd", fd, recordFD})
@@ -1172,7 +1360,7 @@ func (r *Replay) SockSend(ctx context.Context, fd FD, iovecs []IOVec, iflags SIF
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSend, "iflags", iflags, recordIFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return size, errno
@@ -1187,7 +1375,7 @@ func (r *Replay) SockOpen(ctx context.Context, protocolFamily ProtocolFamily, so
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockOpen) {
 		var mismatch []error
 		if protocolFamily != recordProtocolFamily {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockOpen, "protocolFamily", protocolFamily, recordProtocolFamily})
@@ -1205,7 +1393,7 @@ func (r *Replay) SockOpen(ctx context.Context, protocolFamily ProtocolFamily, so
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockOpen, "rightsInheriting", rightsInheriting, recordRightsInheriting})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return newfd, errno
@@ -1220,7 +1408,7 @@ func (r *Replay) SockBind(ctx context.Context, fd FD, bind SocketAddress) (Socke
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockBind) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockBind, "fd", fd, recordFD})
@@ -1229,7 +1417,7 @@ func (r *Replay) SockBind(ctx context.Context, fd FD, bind SocketAddress) (Socke
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockBind, "bind", bind, recordBind})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return addr, errno
@@ -1244,7 +1432,7 @@ func (r *Replay) SockConnect(ctx context.Context, fd FD, peer SocketAddress) (So
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockConnect) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockConnect, "fd", fd, recordFD})
@@ -1253,7 +1441,7 @@ func (r *Replay) SockConnect(ctx context.Context, fd FD, peer SocketAddress) (So
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockConnect, "peer", peer, recordPeer})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return addr, errno
@@ -1268,7 +1456,7 @@ func (r *Replay) SockListen(ctx context.Context, fd FD, backlog int) Errno {
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockListen) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockListen, "fd", fd, recordFD})
@@ -1277,7 +1465,7 @@ func (r *Replay) SockListen(ctx context.Context, fd FD, backlog int) Errno {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockListen, "backlog", backlog, recordBacklog})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -1293,7 +1481,7 @@ func (r *Replay) SockSendTo(ctx context.Context, fd FD, iovecs []IOVec, iflags S
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(SockSendTo) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSendTo, "fd", fd, recordFD})
@@ -1308,7 +1496,7 @@ func (r *Replay) SockSendTo(ctx context.Context, fd FD, iovecs []IOVec, iflags S
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSendTo, "addr", addr, recordAddr})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return size, errno
@@ -1324,7 +1512,7 @@ func (r *Replay) SockRecvFrom(ctx context.Context, fd FD, iovecs []IOVec, iflags
 		panic(&DecodeError{record, err})
 	}
 	r.iovecs = recordIOVecs
-	if r.strict {
+	if r.strict(SockRecvFrom) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockRecvFrom, "fd", fd, recordFD})
@@ -1336,7 +1524,7 @@ func (r *Replay) SockRecvFrom(ctx context.Context, fd FD, iovecs []IOVec, iflags
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockRecvFrom, "iflags", iflags, recordIFlags})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	for i := range recordIOVecs {
@@ -1354,7 +1542,7 @@ func (r *Replay) SockGetOpt(ctx context.Context, fd FD, option SocketOption) (So
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockGetOpt) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockGetOpt, "fd", fd, recordFD})
@@ -1363,7 +1551,7 @@ func (r *Replay) SockGetOpt(ctx context.Context, fd FD, option SocketOption) (So
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockGetOpt, "option", option, recordOption})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return value, errno
@@ -1378,7 +1566,7 @@ func (r *Replay) SockSetOpt(ctx context.Context, fd FD, option SocketOption, val
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict {
+	if r.strict(SockSetOpt) {
 		var mismatch []error
 		if fd != recordFD {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSetOpt, "fd", fd, recordFD})
@@ -1390,7 +1578,7 @@ func (r *Replay) SockSetOpt(ctx context.Context, fd FD, option SocketOption, val
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockSetOpt, "value", value, recordValue})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	return errno
@@ -1405,8 +1593,8 @@ func (r *Replay) SockLocalAddress(ctx context.Context, fd FD) (SocketAddress, Er
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{SockLocalAddress, "fd", fd, recordFD})
+	if r.strict(SockLocalAddress) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{SockLocalAddress, "fd", fd, recordFD})
 	}
 	return addr, errno
 }
@@ -1420,8 +1608,8 @@ func (r *Replay) SockRemoteAddress(ctx context.Context, fd FD) (SocketAddress, E
 	if err != nil {
 		panic(&DecodeError{record, err})
 	}
-	if r.strict && fd != recordFD {
-		panic(&UnexpectedSyscallParamError{SockRemoteAddress, "fd", fd, recordFD})
+	if r.strict(SockRemoteAddress) && fd != recordFD {
+		r.diverge(record, &UnexpectedSyscallParamError{SockRemoteAddress, "fd", fd, recordFD})
 	}
 	return addr, errno
 }
@@ -1436,7 +1624,7 @@ func (r *Replay) SockAddressInfo(ctx context.Context, name, service string, hint
 		panic(&DecodeError{record, err})
 	}
 	r.addrinfo = results
-	if r.strict {
+	if r.strict(SockAddressInfo) {
 		var mismatch []error
 		if name != recordName {
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{SockAddressInfo, "name", name, recordName})
@@ -1451,7 +1639,7 @@ func (r *Replay) SockAddressInfo(ctx context.Context, name, service string, hint
 			mismatch = append(mismatch, &UnexpectedSyscallParamError{PathReadLink, "results", buffer, results})
 		}
 		if len(mismatch) > 0 {
-			panic(errors.Join(mismatch...))
+			r.diverge(record, errors.Join(mismatch...))
 		}
 	}
 	copy(buffer, results)
@@ -1459,11 +1647,17 @@ func (r *Replay) SockAddressInfo(ctx context.Context, name, service string, hint
 }
 
 func (r *Replay) Close(ctx context.Context) error {
+	for name, f := range r.files {
+		f.Close()
+		delete(r.files, name)
+	}
 	return nil
 }
 
 type ReadError struct{ error }
 
+func (e *ReadError) Unwrap() error { return e.error }
+
 type DecodeError struct {
 	Record timemachine.Record
 	error
@@ -1480,6 +1674,41 @@ func (e *UnexpectedSyscallParamError) Error() string {
 	return fmt.Sprintf("expected %s.%s of %v, got %v", e.Syscall, e.Name, e.Expect, e.Actual)
 }
 
+// ElidedContentError is the error that the replay panics with when the data
+// elided from a record cannot be read back from the content file system.
+type ElidedContentError struct {
+	Record timemachine.Record
+	Path   string
+	Err    error
+}
+
+func (e *ElidedContentError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("record %d: %v", e.Record.Offset, e.Err)
+	}
+	return fmt.Sprintf("record %d: %s: %v", e.Record.Offset, e.Path, e.Err)
+}
+
+func (e *ElidedContentError) Unwrap() error { return e.Err }
+
+// DivergenceError is the error that the replay panics with when a system call
+// does not match the next record of the log.
+type DivergenceError struct {
+	// The record that the system call was expected to match.
+	Record timemachine.Record
+	// The records replayed before the divergence, in the order they were
+	// read from the log.
+	History []timemachine.Record
+	// The mismatch between the system call and the record.
+	Err error
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("replay diverged from the log at record %d: %v", e.Record.Offset, e.Err)
+}
+
+func (e *DivergenceError) Unwrap() error { return e.Err }
+
 type UnexpectedSyscallError struct {
 	Recorded SyscallID
 	Observed SyscallID
@@ -1505,6 +1734,13 @@ func equalIovecsShapePrefix(iovecs, prefix []IOVec, size Size) bool {
 	return len(iovecs[last]) >= len(prefix[last])
 }
 
+// equalIovecsDigest compares iovecs with those of a record where the data was
+// elided, only the total size and the digest of the data can be compared.
+func equalIovecsDigest(iovecs, recordIOVecs []IOVec, digest [sha256.Size]byte) bool {
+	size := iovecsSize(iovecs)
+	return size == iovecsSize(recordIOVecs) && digestIOVecs(iovecs, size) == digest
+}
+
 func equalIovecs(a, b []IOVec) bool {
 	if len(a) != len(b) {
 		return false
@@ -2,7 +2,10 @@ package wasicall
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timemachine"
@@ -49,3 +52,183 @@ func testReplay(t *testing.T, syscall Syscall, wrapSystem func(s wasi.System) wa
 
 	assertSyscallEqual(t, syscallWithResults, syscall)
 }
+
+func TestReplayDivergence(t *testing.T) {
+	ctx := context.Background()
+
+	var records []timemachine.Record
+	recorder := NewRecorder(NewErrnoSystem(wasi.ESUCCESS), func(id SyscallID, b []byte) {
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			FunctionID:   int(id),
+			FunctionCall: slices.Clone(b),
+		})
+	})
+	for i := 0; i < 2*replayHistory; i++ {
+		recorder.SchedYield(ctx)
+	}
+	recorder.FDClose(ctx, 42)
+
+	replay := NewReplay(stream.NewReader(records...))
+	for i := 0; i < 2*replayHistory; i++ {
+		replay.SchedYield(ctx)
+	}
+
+	err := func() (err error) {
+		defer func() { err = recover().(error) }()
+		replay.FDClose(ctx, 21)
+		return nil
+	}()
+
+	var divergence *DivergenceError
+	if !errors.As(err, &divergence) {
+		t.Fatalf("unexpected replay error: %v", err)
+	}
+	if divergence.Record.Offset != 2*replayHistory {
+		t.Errorf("unexpected record offset: got %d, expect %d", divergence.Record.Offset, 2*replayHistory)
+	}
+	if len(divergence.History) != replayHistory {
+		t.Fatalf("unexpected history length: got %d, expect %d", len(divergence.History), replayHistory)
+	}
+	for i, record := range divergence.History {
+		if offset := int64(replayHistory + i); record.Offset != offset {
+			t.Errorf("unexpected offset of history record %d: got %d, expect %d", i, record.Offset, offset)
+		}
+	}
+
+	var mismatch *UnexpectedSyscallParamError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("unexpected divergence error: %v", divergence.Err)
+	}
+	if mismatch.Name != "fd" || mismatch.Actual != wasi.FD(21) || mismatch.Expect != wasi.FD(42) {
+		t.Errorf("unexpected mismatch: %v", mismatch)
+	}
+}
+
+func TestReplayPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	var records []timemachine.Record
+	recorder := NewRecorder(NewErrnoSystem(wasi.ESUCCESS), func(id SyscallID, b []byte) {
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			FunctionID:   int(id),
+			FunctionCall: slices.Clone(b),
+		})
+	})
+	recorder.ClockTimeGet(ctx, wasi.Monotonic, 1)
+	recorder.RandomGet(ctx, make([]byte, 4))
+	recorder.FDClose(ctx, 3)
+
+	// The calls made by a module built with a different toolchain, which
+	// reads the clock with another precision, yields, and reads more
+	// random bytes.
+	replayCalls := func(system wasi.System) {
+		system.ClockTimeGet(ctx, wasi.Monotonic, 1000)
+		system.SchedYield(ctx)
+		system.ClockTimeGet(ctx, wasi.Monotonic, 1000)
+		system.RandomGet(ctx, make([]byte, 8))
+		system.FDClose(ctx, 3)
+	}
+
+	tests := []struct {
+		policy  ReplayPolicy
+		diverge bool
+	}{
+		{ReplayStrict, true},
+		{ReplayIgnoreClockAndRandomParams, true},
+		{ReplaySkipExtraCalls, true},
+		{ReplayIgnoreClockAndRandomParams | ReplaySkipExtraCalls, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			replay := NewReplay(stream.NewReader(records...))
+			replay.Policy = test.policy
+
+			err := func() (err error) {
+				defer func() {
+					if e := recover(); e != nil {
+						err = e.(error)
+					}
+				}()
+				replayCalls(replay)
+				return nil
+			}()
+
+			var divergence *DivergenceError
+			if diverge := errors.As(err, &divergence); diverge != test.diverge {
+				t.Fatalf("unexpected replay error: %v", err)
+			}
+			if !test.diverge {
+				if _, ok := replay.peekRecord(); ok {
+					t.Fatal("the replay did not consume all the records")
+				}
+			}
+		})
+	}
+}
+
+func TestReplaySkippedClockTimeGet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	var encoder Encoder
+	var records []timemachine.Record
+	for _, syscall := range []Syscall{
+		&RandomGetSyscall{B: make([]byte, 4)},
+		&ClockTimeGetSyscall{ClockID: wasi.Realtime, Precision: 1, Timestamp: wasi.Timestamp(now.Add(time.Minute).UnixNano())},
+		&FDCloseSyscall{FD: 3},
+	} {
+		functionCall, err := encoder.Encode(nil, syscall)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, timemachine.Record{
+			Offset:       int64(len(records)),
+			Time:         now.Add(time.Duration(len(records)) * time.Second),
+			FunctionID:   int(syscall.ID()),
+			FunctionCall: functionCall,
+		})
+	}
+
+	replay := NewReplay(stream.NewReader(records...))
+	replay.Policy = ReplaySkipExtraCalls
+
+	// Before the clock was read, skipped calls return the time at which the
+	// next record was written.
+	t0, errno := replay.ClockTimeGet(ctx, wasi.Realtime, 1)
+	if errno != wasi.ESUCCESS || t0 != wasi.Timestamp(now.UnixNano()) {
+		t.Fatalf("unexpected time of skipped call: %d (%s)", t0, errno)
+	}
+	replay.RandomGet(ctx, make([]byte, 4))
+	t1, _ := replay.ClockTimeGet(ctx, wasi.Realtime, 1)
+
+	// After the clock was read, skipped calls return the last time read.
+	t2, errno := replay.ClockTimeGet(ctx, wasi.Realtime, 1)
+	if errno != wasi.ESUCCESS || t2 != t1 {
+		t.Fatalf("unexpected time of skipped call: %d (%s)", t2, errno)
+	}
+	replay.FDClose(ctx, 3)
+}
+
+func TestParseReplayPolicy(t *testing.T) {
+	for _, policy := range []ReplayPolicy{
+		ReplayStrict,
+		ReplayIgnoreClockAndRandomParams,
+		ReplaySkipExtraCalls,
+		ReplayIgnoreClockAndRandomParams | ReplaySkipExtraCalls,
+	} {
+		p, err := ParseReplayPolicy(policy.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != policy {
+			t.Errorf("unexpected policy: got %v, expect %v", p, policy)
+		}
+	}
+
+	if _, err := ParseReplayPolicy("lenient"); err == nil {
+		t.Error("expected an error parsing an unknown replay policy")
+	}
+}
@@ -0,0 +1,252 @@
+package wasicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	. "github.com/stealthrocket/wasi-go"
+)
+
+// NewResumeSystem creates a wasi.System which replays the records of a log,
+// then hands off the execution to a live system when the records run out.
+//
+// The file descriptors opened by the guest during the replay do not exist in
+// the live system. The resume system keeps track of the files and sockets
+// opened, renumbered and closed while replaying, and reconstructs the file
+// descriptor table in the live system before forwarding the first system call
+// to it. Each descriptor is re-opened with the same number:
+//   - files and directories are opened again at the same path, relative to
+//     the same preopen, and seeked to their last known offset
+//   - sockets are opened again and bound, listened or connected to the same
+//     addresses
+//
+// Connections accepted from a listening socket cannot be reconstructed, and
+// remain closed in the live system. The live system must be configured with
+// the same preopens as the recorded process.
+//
+// The resume function is called when the system goes live, with the errors
+// that occurred while reconstructing the file descriptors, or nil if all the
+// descriptors were successfully reconstructed. It may be nil.
+func NewResumeSystem(replay *Replay, live System, resume func(context.Context, error)) System {
+	r := &resumeSystem{
+		live:   live,
+		files:  make(map[FD]*resumeFile),
+		resume: resume,
+	}
+	r.fallbackSystem = fallbackSystem{
+		primary:   NewObserver(replay, nil, r.observe),
+		secondary: NewObserver(live, r.handoff, nil),
+		fallback: func(errno Errno) bool {
+			return errno == ENOSYS && replay.EOF()
+		},
+	}
+	return r
+}
+
+type resumeSystem struct {
+	fallbackSystem
+
+	live    System
+	files   map[FD]*resumeFile
+	resume  func(context.Context, error)
+	resumed bool
+}
+
+type resumeFileType int
+
+const (
+	resumePath resumeFileType = iota
+	resumeSocket
+)
+
+type resumeFile struct {
+	typ resumeFileType
+
+	// Files and directories.
+	dir              FD
+	dirFlags         LookupFlags
+	path             string
+	openFlags        OpenFlags
+	rightsBase       Rights
+	rightsInheriting Rights
+	fdFlags          FDFlags
+	offset           FileSize
+
+	// Sockets.
+	family     ProtocolFamily
+	socketType SocketType
+	protocol   Protocol
+	bind       SocketAddress
+	peer       SocketAddress
+	listen     bool
+	backlog    int
+}
+
+func (r *resumeSystem) observe(ctx context.Context, s Syscall) {
+	if s.Error() != ESUCCESS {
+		return
+	}
+	switch s := s.(type) {
+	case *PathOpenSyscall:
+		f := &resumeFile{
+			typ:              resumePath,
+			dir:              s.FD,
+			dirFlags:         s.DirFlags,
+			path:             s.Path,
+			openFlags:        s.OpenFlags &^ (OpenExclusive | OpenTruncate),
+			rightsBase:       s.RightsBase,
+			rightsInheriting: s.RightsInheriting,
+			fdFlags:          s.FDFlags,
+		}
+		// Paths opened relative to a directory opened during the replay are
+		// flattened so they remain valid after the directory is closed.
+		if dir, ok := r.files[s.FD]; ok && dir.typ == resumePath {
+			f.dir = dir.dir
+			f.path = path.Join(dir.path, s.Path)
+		}
+		r.files[s.NewFD] = f
+	case *SockOpenSyscall:
+		r.files[s.FD] = &resumeFile{
+			typ:              resumeSocket,
+			family:           s.Family,
+			socketType:       s.SocketType,
+			protocol:         s.Protocol,
+			rightsBase:       s.RightsBase,
+			rightsInheriting: s.RightsInheriting,
+		}
+	case *SockAcceptSyscall:
+		delete(r.files, s.NewFD)
+	case *FDCloseSyscall:
+		delete(r.files, s.FD)
+	case *FDRenumberSyscall:
+		if f, ok := r.files[s.From]; ok {
+			r.files[s.To] = f
+		} else {
+			delete(r.files, s.To)
+		}
+		delete(r.files, s.From)
+	case *FDStatSetFlagsSyscall:
+		if f, ok := r.files[s.FD]; ok {
+			f.fdFlags = s.Flags
+		}
+	case *FDReadSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumePath {
+			f.offset += FileSize(s.Size)
+		}
+	case *FDWriteSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumePath && !f.fdFlags.Has(Append) {
+			f.offset += FileSize(s.Size)
+		}
+	case *FDSeekSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumePath {
+			f.offset = s.Size
+		}
+	case *SockBindSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumeSocket {
+			f.bind = cloneSocketAddress(s.Addr)
+		}
+	case *SockListenSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumeSocket {
+			f.listen, f.backlog = true, s.Backlog
+		}
+	case *SockConnectSyscall:
+		if f, ok := r.files[s.FD]; ok && f.typ == resumeSocket {
+			f.peer = cloneSocketAddress(s.Peer)
+		}
+	}
+}
+
+func (r *resumeSystem) handoff(ctx context.Context, _ Syscall) {
+	if r.resumed {
+		return
+	}
+	r.resumed = true
+
+	fds := make([]FD, 0, len(r.files))
+	for fd := range r.files {
+		fds = append(fds, fd)
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i] < fds[j] })
+
+	var errs []error
+	for _, fd := range fds {
+		if err := r.restore(ctx, fd, r.files[fd]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.files = nil
+
+	if r.resume != nil {
+		r.resume(ctx, errors.Join(errs...))
+	}
+}
+
+func (r *resumeSystem) restore(ctx context.Context, fd FD, f *resumeFile) error {
+	var liveFD FD
+	var errno Errno
+
+	switch f.typ {
+	case resumePath:
+		liveFD, errno = r.live.PathOpen(ctx, f.dir, f.dirFlags, f.path, f.openFlags, f.rightsBase, f.rightsInheriting, f.fdFlags)
+		if errno != ESUCCESS {
+			return fmt.Errorf("resuming fd %d: path_open %q: %w", fd, f.path, errno)
+		}
+	case resumeSocket:
+		liveFD, errno = r.live.SockOpen(ctx, f.family, f.socketType, f.protocol, f.rightsBase, f.rightsInheriting)
+		if errno != ESUCCESS {
+			return fmt.Errorf("resuming fd %d: sock_open: %w", fd, errno)
+		}
+	}
+
+	if liveFD != fd {
+		if errno := r.live.FDRenumber(ctx, liveFD, fd); errno != ESUCCESS {
+			r.live.FDClose(ctx, liveFD)
+			return fmt.Errorf("resuming fd %d: fd_renumber from %d: %w", fd, liveFD, errno)
+		}
+	}
+
+	switch f.typ {
+	case resumePath:
+		if f.offset != 0 && !f.fdFlags.Has(Append) && !f.openFlags.Has(OpenDirectory) {
+			if _, errno := r.live.FDSeek(ctx, fd, FileDelta(f.offset), SeekStart); errno != ESUCCESS {
+				return fmt.Errorf("resuming fd %d: fd_seek %d: %w", fd, f.offset, errno)
+			}
+		}
+	case resumeSocket:
+		if f.bind != nil {
+			if _, errno := r.live.SockBind(ctx, fd, f.bind); errno != ESUCCESS {
+				return fmt.Errorf("resuming fd %d: sock_bind %s: %w", fd, f.bind, errno)
+			}
+		}
+		if f.listen {
+			if errno := r.live.SockListen(ctx, fd, f.backlog); errno != ESUCCESS {
+				return fmt.Errorf("resuming fd %d: sock_listen: %w", fd, errno)
+			}
+		}
+		if f.peer != nil {
+			if _, errno := r.live.SockConnect(ctx, fd, f.peer); errno != ESUCCESS && errno != EINPROGRESS {
+				return fmt.Errorf("resuming fd %d: sock_connect %s: %w", fd, f.peer, errno)
+			}
+		}
+	}
+	return nil
+}
+
+func cloneSocketAddress(addr SocketAddress) SocketAddress {
+	switch a := addr.(type) {
+	case *Inet4Address:
+		c := *a
+		return &c
+	case *Inet6Address:
+		c := *a
+		return &c
+	case *UnixAddress:
+		c := *a
+		return &c
+	default:
+		return addr
+	}
+}
@@ -0,0 +1,112 @@
+package wasicall
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stealthrocket/timecraft/internal/sandbox"
+	"github.com/stealthrocket/timecraft/internal/stream"
+	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/wasi-go"
+)
+
+func TestResume(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("recorded ENOSYS errors are replayed", func(t *testing.T) {
+		var records []timemachine.Record
+		recorder := NewRecorder(NewErrnoSystem(wasi.ENOSYS), func(id SyscallID, b []byte) {
+			records = append(records, timemachine.Record{FunctionID: int(id), FunctionCall: slices.Clone(b)})
+		})
+		recorder.SchedYield(ctx)
+
+		system := NewResumeSystem(NewReplay(stream.NewReader(records...)), NewErrnoSystem(wasi.ESUCCESS), nil)
+		if errno := system.SchedYield(ctx); errno != wasi.ENOSYS {
+			t.Fatalf("unexpected replayed errno: got %v, expect %v", errno, wasi.ENOSYS)
+		}
+		if errno := system.SchedYield(ctx); errno != wasi.ESUCCESS {
+			t.Fatalf("unexpected live errno: got %v, expect %v", errno, wasi.ESUCCESS)
+		}
+	})
+
+	t.Run("files opened during the replay are reopened", func(t *testing.T) {
+		const rootFD = 3
+		tmp := t.TempDir()
+
+		newSystem := func() *sandbox.System {
+			s, err := sandbox.NewSystem(sandbox.Mount("/", sandbox.DirFS(tmp)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}
+
+		var records []timemachine.Record
+		recorded := newSystem()
+		recorder := NewRecorder(recorded, func(id SyscallID, b []byte) {
+			records = append(records, timemachine.Record{FunctionID: int(id), FunctionCall: slices.Clone(b)})
+		})
+		// The directory file descriptor is closed before the end of the
+		// replay, the file must be reopened relative to the preopen.
+		if errno := recorder.PathCreateDirectory(ctx, rootFD, "dir"); errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		dirFD, errno := recorder.PathOpen(ctx, rootFD, 0, "dir", wasi.OpenDirectory, wasi.DirectoryRights, wasi.DirectoryRights|wasi.FileRights, 0)
+		if errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		fileFD, errno := recorder.PathOpen(ctx, dirFD, 0, "data.txt", wasi.OpenCreate|wasi.OpenExclusive, wasi.FileRights, 0, 0)
+		if errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		if _, errno := recorder.FDWrite(ctx, fileFD, []wasi.IOVec{[]byte("hello ")}); errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		if errno := recorder.FDClose(ctx, dirFD); errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		// Renumber the file descriptor to a value which is not going to be
+		// allocated by the live system.
+		if errno := recorder.FDRenumber(ctx, fileFD, 10); errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		recorder.Close(ctx)
+
+		resumed := false
+		system := NewResumeSystem(NewReplay(stream.NewReader(records...)), newSystem(), func(ctx context.Context, err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			resumed = true
+		})
+		defer system.Close(ctx)
+
+		system.PathCreateDirectory(ctx, rootFD, "dir")
+		system.PathOpen(ctx, rootFD, 0, "dir", wasi.OpenDirectory, wasi.DirectoryRights, wasi.DirectoryRights|wasi.FileRights, 0)
+		system.PathOpen(ctx, dirFD, 0, "data.txt", wasi.OpenCreate|wasi.OpenExclusive, wasi.FileRights, 0, 0)
+		system.FDWrite(ctx, fileFD, []wasi.IOVec{[]byte("hello ")})
+		system.FDClose(ctx, dirFD)
+		system.FDRenumber(ctx, fileFD, 10)
+
+		if _, errno := system.FDWrite(ctx, 10, []wasi.IOVec{[]byte("world!")}); errno != wasi.ESUCCESS {
+			t.Fatal(errno)
+		}
+		if !resumed {
+			t.Fatal("the system did not go live")
+		}
+		if _, errno := system.FDStatGet(ctx, dirFD); errno != wasi.EBADF {
+			t.Fatalf("unexpected fd_fdstat_get errno: got %v, expect %v", errno, wasi.EBADF)
+		}
+
+		b, err := os.ReadFile(filepath.Join(tmp, "dir", "data.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "hello world!" {
+			t.Fatalf("unexpected file content: got %q, expect %q", b, "hello world!")
+		}
+	})
+}
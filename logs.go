@@ -76,7 +76,7 @@ func logs(ctx context.Context, args []string) error {
 		startTime = human.Time(manifest.StartTime)
 	}
 
-	logSegment, err := registry.ReadLogSegment(ctx, processID, 0)
+	logSegment, err := registry.ReadLog(ctx, manifest)
 	if err != nil {
 		return err
 	}
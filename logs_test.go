@@ -39,4 +39,16 @@ var logs = tests{
 		assert.Equal(t, stdout, text[1:])
 		assert.Equal(t, stderr, "")
 	},
+
+	"the output elided from the log is not printed": func(t *testing.T) {
+		stdout, stderr, exitCode := timecraft(t, "run", "--record-elide-fd", "1", "./testdata/go/echo.wasm", "-n", text)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, text[1:])
+		processID := strings.TrimSpace(stderr)
+
+		stdout, stderr, exitCode = timecraft(t, "logs", processID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "")
+		assert.Equal(t, stderr, "")
+	},
 }
@@ -17,7 +17,10 @@ import (
 
 func TestTimecraft(t *testing.T) {
 	t.Setenv("TIMECRAFT_TEST_CACHE", t.TempDir())
+	t.Run("delete", del.run)
+	t.Run("diff", diff.run)
 	t.Run("export", export.run)
+	t.Run("gc", gc.run)
 	t.Run("get", get.run)
 	t.Run("help", help.run)
 	t.Run("logs", logs.run)
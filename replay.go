@@ -3,30 +3,92 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/stealthrocket/timecraft/internal/debug/debugger"
+	"github.com/stealthrocket/timecraft/internal/print/human"
+	"github.com/stealthrocket/timecraft/internal/print/jsonprint"
+	"github.com/stealthrocket/timecraft/internal/print/textprint"
+	"github.com/stealthrocket/timecraft/internal/print/yamlprint"
+	"github.com/stealthrocket/timecraft/internal/sandbox"
+	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/tetratelabs/wazero/experimental"
 )
 
 const replayUsage = `
 Usage:	timecraft replay [options] <process id>
 
+   The replay command re-executes a recorded process, feeding the guest module
+   with the results of the host function calls found in its log.
+
+   With --until, the replay pauses before the host function call consuming the
+   record at the given breakpoint, and starts a debugger reading commands from
+   stdin. The breakpoint is either a record offset, a duration relative to the
+   process start time (e.g. 1.5s), or an absolute point in time. The debugger
+   allows inspecting the linear memory, globals, and call stack of the guest,
+   then stepping through host function calls or resuming the replay. Type
+   "help" at the debugger prompt for the list of commands.
+
+   If the module makes a host function call which does not match the log, the
+   replay stops and the command outputs a report of the divergence, including
+   the recorded and actual calls, and the last calls replayed before reaching
+   the record. The command then exits with status code 3. In text format, the
+   report is written to stderr; in json or yaml format, it is written to stdout
+   and is usually combined with --quiet.
+
+   When the log was recorded with elided file content (see the
+   --record-elide-path option of timecraft run), the --content option gives
+   the path of a directory containing a copy of the files as seen by the guest
+   module, from which the data is read back during the replay. When the
+   process was started from an OCI image, the data is read from the root file
+   system of the image by default.
+
+   The --policy option relaxes the matching of host function calls with the
+   records of the log, which allows replaying logs recorded with a different
+   version of the guest toolchain. The value is a comma-separated list of:
+
+     strict                      Calls must match the log exactly (default)
+     ignore-clock-random-params  Ignore the parameters of clock and random calls
+     skip-extra-calls            Allow sched_yield and clock_time_get calls
+                                 which are not in the log
+
 Options:
-   -c, --config path  Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
-   -h, --help         Show this usage information
-   -q, --quiet        Do not output the recording of stdout/stderr during the replay
-   -T, --trace        Enable strace-like logging of host function calls
+   -c, --config path    Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+       --content dir    Directory to read the data elided from the log from (default to none)
+   -h, --help           Show this usage information
+   -o, --output format  Output format of divergence reports, one of: text, json, yaml
+   -p, --policy list    Policy for matching host function calls with the log (default to strict)
+   -q, --quiet          Do not output the recording of stdout/stderr during the replay
+   -T, --trace          Enable strace-like logging of host function calls
+       --until break    Pause the replay at a record offset or time and start a debugger
 `
 
+// divergenceExitCode is the exit code of the replay command when the replay
+// diverged from the log.
+const divergenceExitCode = 3
+
 func replay(ctx context.Context, args []string) error {
 	var (
-		quiet = false
-		trace = false
+		output  = outputFormat("text")
+		policy  = replayPolicy(wasicall.ReplayStrict)
+		quiet   = false
+		trace   = false
+		until   breakpoint
+		content human.Path
 	)
 
 	flagSet := newFlagSet("timecraft replay", replayUsage)
+	customVar(flagSet, &output, "o", "output")
+	customVar(flagSet, &policy, "p", "policy")
 	boolVar(flagSet, &quiet, "q", "quiet")
 	boolVar(flagSet, &trace, "T", "trace")
+	customVar(flagSet, &until, "until")
+	customVar(flagSet, &content, "content")
 
 	args, err := parseFlags(flagSet, args)
 	if err != nil {
@@ -62,5 +124,122 @@ func replay(ctx context.Context, args []string) error {
 	if trace {
 		replay.SetTrace(os.Stderr)
 	}
-	return replay.Replay(ctx)
+	replay.SetPolicy(wasicall.ReplayPolicy(policy))
+	if content != "" {
+		path, err := content.Resolve()
+		if err != nil {
+			return err
+		}
+		replay.SetContent(os.DirFS(path))
+	} else {
+		var layersPath string
+		if cachePath, ok := config.Cache.Location.Value(); ok {
+			path, err := cachePath.Resolve()
+			if err != nil {
+				return err
+			}
+			layersPath = filepath.Join(path, "layers")
+		}
+		// The image is only needed if data was elided from the log, so the
+		// replay is still attempted if it cannot be opened.
+		image, err := replay.OpenImage(ctx, layersPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		} else if image != nil {
+			defer image.Close()
+			replay.SetContent(sandbox.FS(image.FS))
+		}
+	}
+	if until == "" {
+		return reportDivergence(replay.Replay(ctx), output)
+	}
+
+	moduleCode, function, err := replay.ModuleCode(ctx)
+	if err != nil {
+		return err
+	}
+	records, startTime, err := replay.RecordReader(ctx)
+	if err != nil {
+		return err
+	}
+	defer records.Close()
+
+	bp, err := debugger.ParseBreakpoint(string(until), startTime)
+	if err != nil {
+		return err
+	}
+	dbg := debugger.New(records, bp, os.Stdin, os.Stderr)
+
+	ctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, dbg)
+
+	compiledModule, err := runtime.CompileModule(ctx, moduleCode)
+	if err != nil {
+		return err
+	}
+	defer compiledModule.Close(ctx)
+
+	err = replay.ReplayRecordsModule(ctx, function, compiledModule, dbg)
+	if errors.Is(err, debugger.ErrQuit) {
+		return nil
+	}
+	if err == nil && !dbg.Reached() {
+		perrorf("warning: the replay completed before reaching the breakpoint (%s)", bp)
+	}
+	return reportDivergence(err, output)
+}
+
+// reportDivergence outputs the report of a replay which diverged from the log,
+// other errors are returned unchanged.
+func reportDivergence(err error, output outputFormat) error {
+	var divergence *timecraft.DivergenceError
+	if !errors.As(err, &divergence) {
+		return err
+	}
+
+	var writer stream.WriteCloser[*divergenceReport]
+	switch output {
+	case "json":
+		writer = jsonprint.NewWriter[*divergenceReport](os.Stdout)
+	case "yaml":
+		writer = yamlprint.NewWriter[*divergenceReport](os.Stdout)
+	default:
+		writer = textprint.NewWriter[*divergenceReport](os.Stderr)
+	}
+	defer writer.Close()
+
+	if _, err := writer.Write([]*divergenceReport{{divergence}}); err != nil {
+		return err
+	}
+	return exitCode(divergenceExitCode)
+}
+
+type divergenceReport struct {
+	*timecraft.DivergenceError `yaml:",inline"`
+}
+
+func (r *divergenceReport) Format(w fmt.State, _ rune) {
+	fmt.Fprintf(w, "The replay diverged from the log at record %d (%s):\n", r.Offset, r.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "  %s\n", r.Reason)
+	fmt.Fprintf(w, "\n")
+	for _, call := range r.History {
+		fmt.Fprintf(w, "  #%d %s\n", call.Offset, call)
+	}
+	fmt.Fprintf(w, "- #%d %s\n", r.Expect.Offset, r.Expect)
+	if r.Actual != nil {
+		fmt.Fprintf(w, "+ #%d %s\n", r.Actual.Offset, r.Actual)
+	}
+}
+
+type breakpoint string
+
+func (b breakpoint) String() string {
+	return string(b)
+}
+
+func (b *breakpoint) Set(value string) error {
+	if value == "" {
+		return errors.New("malformed breakpoint: empty string")
+	}
+	*b = breakpoint(value)
+	return nil
 }
@@ -1,10 +1,16 @@
 package main_test
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stealthrocket/timecraft/internal/assert"
+	"gopkg.in/yaml.v3"
 )
 
 var replay = tests{
@@ -22,6 +28,169 @@ var replay = tests{
 		assert.Equal(t, stderr, "")
 	},
 
+	"replays pause at the breakpoint and start the debugger": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+
+		setStdin(t, "stack\nmemory 0 16\nglobals\nrecord\ncontinue\n")
+		replay, stderr, exitCode := timecraft(t, "replay", "--until", "0", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.HasPrefix(t, stderr, "replay paused before calling wasi_snapshot_preview1.")
+		assert.True(t, strings.Contains(stderr, "\nrecord 0: "))
+		assert.True(t, strings.Contains(stderr, "(timecraft) #0 "))
+		assert.True(t, strings.Contains(stderr, "(timecraft) 00000000  "))
+		assert.True(t, strings.Contains(stderr, "(timecraft) global[0] "))
+	},
+
+	"quitting the debugger stops the replay": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+
+		setStdin(t, "step\nquit\n")
+		_, stderr, exitCode := timecraft(t, "replay", "-q", "--until", "0", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, strings.Count(stderr, "replay paused before calling "), 2)
+	},
+
+	"replays warn when the breakpoint is never reached": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+
+		setStdin(t, "")
+		_, stderr, exitCode := timecraft(t, "replay", "-q", "--until", "1000000", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.HasPrefix(t, stderr, "warning: the replay completed before reaching the breakpoint (record 1000000)")
+	},
+
+	"replays report divergences from the log": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/echo.wasm", "-n", "hello")
+		assert.Equal(t, exitCode, 0)
+		processID = strings.TrimSpace(processID)
+
+		// Replace the module in the registry so the system calls made during
+		// the replay differ from those recorded in the log.
+		replaceModule(t, "./testdata/go/echo.wasm", "./testdata/go/urandom.wasm")
+
+		stdout, stderr, exitCode := timecraft(t, "replay", "-q", processID)
+		assert.Equal(t, exitCode, 3)
+		assert.Equal(t, stdout, "")
+		assert.HasPrefix(t, stderr, "The replay diverged from the log at record ")
+		assert.True(t, strings.Contains(stderr, "\n- #"))
+		assert.True(t, strings.Contains(stderr, "\n+ #"))
+
+		stdout, stderr, exitCode = timecraft(t, "replay", "-q", "-o", "json", processID)
+		assert.Equal(t, exitCode, 3)
+		assert.Equal(t, stderr, "")
+
+		var report struct {
+			Offset int64 `json:"offset"`
+			Expect struct {
+				Offset  int64  `json:"offset"`
+				Syscall string `json:"syscall"`
+				Params  string `json:"params"`
+			} `json:"expect"`
+			Actual struct {
+				Syscall string `json:"syscall"`
+			} `json:"actual"`
+			History []struct {
+				Offset int64 `json:"offset"`
+			} `json:"history"`
+		}
+		assert.OK(t, json.Unmarshal([]byte(stdout), &report))
+		assert.Equal(t, report.Expect.Offset, report.Offset)
+		assert.Equal(t, report.Expect.Syscall, "FDWrite")
+		assert.Equal(t, report.Expect.Params, `1, "hello"`)
+		assert.NotEqual(t, report.Actual.Syscall, "")
+		assert.NotEqual(t, len(report.History), 0)
+		assert.Equal(t, report.History[len(report.History)-1].Offset, report.Offset-1)
+	},
+
+	"replays accept lenient matching policies": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+
+		replay, stderr, exitCode := timecraft(t, "replay", "-p", "ignore-clock-random-params,skip-extra-calls", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.Equal(t, stderr, "")
+	},
+
+	"replays reject unknown matching policies": func(t *testing.T) {
+		_, stderr, exitCode := timecraft(t, "replay", "--policy", "lenient", "a47b1c1e-4f5c-4b8a-9d9f-2e5b5c7e7d3a")
+		assert.Equal(t, exitCode, 2)
+		assert.HasPrefix(t, stderr, `invalid value "lenient" for flag -policy: unsupported replay policy: "lenient"`)
+	},
+
+	"replays verify the data written to elided file descriptors": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "--record-elide-fd", "1", "--", "./testdata/go/echo.wasm", "hello", "world")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "hello world\n")
+
+		replay, stderr, exitCode := timecraft(t, "replay", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.Equal(t, stderr, "")
+	},
+
+	"replays read the data elided from the log from the content directory": func(t *testing.T) {
+		_, processID, exitCode := timecraft(t, "run", "--record-elide-path", "/dev", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+		processID = strings.TrimSpace(processID)
+
+		_, stderr, exitCode := timecraft(t, "replay", "-q", processID)
+		assert.Equal(t, exitCode, 1)
+		assert.True(t, strings.Contains(stderr, "/dev/urandom: the data read was elided from the log and no content directory was provided"))
+
+		// The content of the files must match the data recorded in the log.
+		content := t.TempDir()
+		assert.OK(t, os.Mkdir(filepath.Join(content, "dev"), 0755))
+		assert.OK(t, os.WriteFile(filepath.Join(content, "dev", "urandom"), make([]byte, 4096), 0644))
+
+		_, stderr, exitCode = timecraft(t, "replay", "-q", "--content", content, processID)
+		assert.Equal(t, exitCode, 1)
+		assert.True(t, strings.Contains(stderr, "/dev/urandom: the content of the file differs from the data recorded in the log"))
+	},
+
+	"replays succeed when the content directory holds the data elided from the log": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run", "--record-elide-path", "/dev", "--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+		processID = strings.TrimSpace(processID)
+
+		// The program prints the random bytes that it read, which are the
+		// content of the file at the time it was recorded.
+		random, err := hex.DecodeString(strings.TrimSpace(stdout))
+		assert.OK(t, err)
+
+		content := t.TempDir()
+		assert.OK(t, os.Mkdir(filepath.Join(content, "dev"), 0755))
+		assert.OK(t, os.WriteFile(filepath.Join(content, "dev", "urandom"), random, 0644))
+
+		replay, stderr, exitCode := timecraft(t, "replay", "--content", content, processID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.Equal(t, stderr, "")
+	},
+
+	"replays read the data elided from the log from the image of the process": func(t *testing.T) {
+		random := make([]byte, 32)
+		for i := range random {
+			random[i] = byte(i)
+		}
+		layout := makeImageLayoutWithFiles(t, "testdata/go/urandom.wasm", "latest", map[string][]byte{
+			"dev/urandom": random,
+		})
+
+		stdout, processID, exitCode := timecraft(t, "run", "--record-elide-path", "/dev", "--image", layout+":latest")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, hex.EncodeToString(random)+"\n")
+
+		replay, stderr, exitCode := timecraft(t, "replay", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.Equal(t, stderr, "")
+	},
+
 	"standard output is printed during replays": func(t *testing.T) {
 		stdout, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/urandom.wasm")
 		assert.Equal(t, exitCode, 0)
@@ -43,6 +212,19 @@ var replay = tests{
 		assert.Equal(t, stderr, "")
 	},
 
+	"replays read records across log segments": func(t *testing.T) {
+		stdout, processID, exitCode := timecraft(t, "run",
+			"--record-batch-size", "1",
+			"--record-segment-size", "1B",
+			"--", "./testdata/go/urandom.wasm")
+		assert.Equal(t, exitCode, 0)
+
+		replay, stderr, exitCode := timecraft(t, "replay", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, stdout)
+		assert.Equal(t, stderr, "")
+	},
+
 	"guest can interact with host via gRPC": func(t *testing.T) {
 		stdout, processID, exitCode := timecraft(t, "run", "--", "./testdata/go/grpc.wasm")
 		assert.Equal(t, exitCode, 0)
@@ -78,3 +260,56 @@ var replay = tests{
 		assert.Equal(t, replay, stdout)
 	},
 }
+
+func setStdin(t *testing.T, input string) {
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultStdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = defaultStdin
+		f.Close()
+	})
+}
+
+func replaceModule(t *testing.T, oldPath, newPath string) {
+	oldCode, err := os.ReadFile(oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCode, err := os.ReadFile(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(os.Getenv("TIMECRAFTCONFIG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config configuration
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := filepath.Glob(filepath.Join(config.Registry.Location, "obj", "sha256:*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range objects {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(b, oldCode) {
+			if err := os.WriteFile(path, newCode, 0666); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("module %s not found in the registry", oldPath)
+}
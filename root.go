@@ -26,12 +26,16 @@ import (
 	"runtime"
 	"runtime/pprof"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stealthrocket/timecraft/format"
 	"github.com/stealthrocket/timecraft/internal/print/human"
 	"github.com/stealthrocket/timecraft/internal/timecraft"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
+	"github.com/stealthrocket/wasi-go"
 	"golang.org/x/exp/maps"
 )
 
@@ -112,12 +116,18 @@ func Root(ctx context.Context, args ...string) int {
 	switch cmd {
 	case "config":
 		err = config(ctx, args)
+	case "delete":
+		err = del(ctx, args)
 	case "describe":
 		err = describe(ctx, args)
+	case "diff":
+		err = diff(ctx, args)
 	case "export":
 		err = export(ctx, args)
 	case "get":
 		err = get(ctx, args)
+	case "gc":
+		err = gc(ctx, args)
 	case "help":
 		err = help(ctx, args)
 	case "logs":
@@ -191,6 +201,21 @@ func (s *sockets) Set(value string) error {
 	return setEnum(s, "sockets extension", value, "none", "auto", "path_open", "wasmedgev1", "wasmedgev2")
 }
 
+type imagePlatform ocispec.Platform
+
+func (p imagePlatform) String() string {
+	return timecraft.FormatImagePlatform(ocispec.Platform(p))
+}
+
+func (p *imagePlatform) Set(value string) error {
+	platform, err := timecraft.ParseImagePlatform(value)
+	if err != nil {
+		return err
+	}
+	*p = imagePlatform(platform)
+	return nil
+}
+
 type outputFormat string
 
 func (o outputFormat) String() string {
@@ -201,6 +226,33 @@ func (o *outputFormat) Set(value string) error {
 	return setEnum(o, "output format", value, "text", "json", "yaml")
 }
 
+type replayPolicy wasicall.ReplayPolicy
+
+func (p replayPolicy) String() string {
+	return wasicall.ReplayPolicy(p).String()
+}
+
+func (p *replayPolicy) Set(value string) error {
+	policy, err := wasicall.ParseReplayPolicy(value)
+	if err != nil {
+		return err
+	}
+	*p = replayPolicy(policy)
+	return nil
+}
+
+type processIDFlag format.UUID
+
+func (p processIDFlag) String() string {
+	return format.UUID(p).String()
+}
+
+func (p *processIDFlag) Set(value string) error {
+	processID, err := parseProcessID(value)
+	*p = processIDFlag(processID)
+	return err
+}
+
 type stringList []string
 
 func (s stringList) String() string {
@@ -212,6 +264,21 @@ func (s *stringList) Set(value string) error {
 	return nil
 }
 
+type fdList []wasi.FD
+
+func (l fdList) String() string {
+	return fmt.Sprintf("%v", []wasi.FD(l))
+}
+
+func (l *fdList) Set(value string) error {
+	fd, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || fd < 0 {
+		return fmt.Errorf("malformed file descriptor: %q", value)
+	}
+	*l = append(*l, wasi.FD(fd))
+	return nil
+}
+
 type stringMap map[string]string
 
 func (m stringMap) String() string {
@@ -289,6 +356,13 @@ func boolVar(f *flag.FlagSet, dst *bool, name string, alias ...string) {
 	}
 }
 
+func stringVar(f *flag.FlagSet, dst *string, name string, alias ...string) {
+	f.StringVar(dst, name, *dst, "")
+	for _, name := range alias {
+		f.StringVar(dst, name, *dst, "")
+	}
+}
+
 func customVar(f *flag.FlagSet, dst flag.Value, name string, alias ...string) {
 	f.Var(dst, name, "")
 	for _, name := range alias {
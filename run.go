@@ -5,38 +5,64 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/google/uuid"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stealthrocket/timecraft/internal/chaos"
 	"github.com/stealthrocket/timecraft/internal/print/human"
+	"github.com/stealthrocket/timecraft/internal/stream"
 	"github.com/stealthrocket/timecraft/internal/timecraft"
 	"github.com/stealthrocket/timecraft/internal/timemachine"
+	"github.com/stealthrocket/timecraft/internal/timemachine/wasicall"
 	"github.com/stealthrocket/wasi-go"
 )
 
 const runUsage = `
 Usage:	timecraft run [options] [--] <module> [args...]
+	timecraft run [options] --image <layout>:<tag> [--] [args...]
+	timecraft run [options] [--image <layout>:<tag>] --fork <process id>
+
+   With --fork, the module, arguments, and environment of a recorded process
+   are used to start a new process. The log of the recorded process is replayed
+   first, then the execution continues live past the last record. Files and
+   sockets opened during the replay are reopened in the new process, which is
+   recorded as a child of the original process. The directories exposed to the
+   guest module should match those of the recorded process. When the recorded
+   process was started from an OCI image, the same image must be passed with
+   --image; its digest is checked against the one recorded for the process.
 
 Options:
-   -C, --chaotic ratio            Enable artificial fault injection when running the module (raio is a decimal value between 0 and 1)
-   -c, --config path              Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
-   -D, --dial addr                Expose a socket connected to the specified address
-       --dir dir                  Expose a directory to the guest module
-   -e, --env name=value           Pass an environment variable to the guest module
-   -f, --function function        Exported function to call in the guest module (_start if empty)
-       --fly-blind                Disable recording of the guest module execution
-   -h, --help                     Show this usage information
-   -L, --listen addr              Expose a socket listening on the specified address
-       --restrict                 Do not automatically expose the environment and root directory to the guest module
-   -S, --sockets extension        Enable a sockets extension, one of none, auto, path_open, wasmedgev1, wasmedgev2 (default to auto)
-       --record-batch-size size   Number of records written per batch (default to 4096)
-       --record-compression type  Compression to use when writing records, either snappy or zstd (default to zstd)
-   -T, --trace                    Enable strace-like logging of host function calls
+   -C, --chaotic ratio                 Enable artificial fault injection when running the module (raio is a decimal value between 0 and 1)
+   -c, --config path                   Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
+   -D, --dial addr                     Expose a socket connected to the specified address
+       --dir dir                       Expose a directory to the guest module
+   -e, --env name=value                Pass an environment variable to the guest module
+   -f, --function function             Exported function to call in the guest module (_start if empty)
+       --fly-blind                     Disable recording of the guest module execution
+       --fork id                       Replay the recording of a process, then continue its execution live
+   -h, --help                          Show this usage information
+       --image layout:tag              Run the entrypoint of an OCI image stored in a local image layout directory
+       --image-platform os/arch        Platform of the image to run (default to wasip1/wasm)
+   -L, --listen addr                   Expose a socket listening on the specified address
+       --restrict                      Do not automatically expose the environment and root directory to the guest module
+   -S, --sockets extension             Enable a sockets extension, one of none, auto, path_open, wasmedgev1, wasmedgev2 (default to auto)
+       --task-journal path             Record submitted tasks to a journal file, and recover the tasks that it contains when starting
+       --record-batch-size size        Number of records written per batch (default to 4096)
+       --record-compression type       Compression to use when writing records, either snappy or zstd (default to zstd)
+       --record-elide-fd fd            Record only the size and digest of data written to a file descriptor
+       --record-elide-path path        Record only the size and digest of data read from or written to files under a path
+       --record-segment-duration time  Duration after which the log rolls over to a new segment (default to none)
+       --record-segment-size size      Size after which the log rolls over to a new segment (default to none)
+   -T, --trace                         Enable strace-like logging of host function calls
+       --trace-layer layer             Decode and print a tracing layer while the module runs, either network or request
+       --trace-output path             Path of the file to write the live trace to (default to stderr)
 `
 
 func run(ctx context.Context, args []string) error {
@@ -45,13 +71,23 @@ func run(ctx context.Context, args []string) error {
 		listens     stringList
 		dials       stringList
 		dirs        stringList
+		elideFDs    fdList
+		elidePaths  stringList
+		image       human.Path
+		fork        processIDFlag
+		platform    = imagePlatform(timecraft.DefaultImagePlatform)
 		chaotic     = human.Ratio(0)
 		batchSize   = human.Count(4096)
 		compression = compression("zstd")
+		segmentSize = human.Bytes(0)
+		segmentTime = human.Duration(0)
 		sockets     = sockets("auto")
 		flyBlind    = false
 		restrict    = false
 		trace       = false
+		traceLayer  = ""
+		traceOutput = human.Path("")
+		taskJournal = human.Path("")
 	)
 
 	flagSet := newFlagSet("timecraft run", runUsage)
@@ -59,27 +95,78 @@ func run(ctx context.Context, args []string) error {
 	customVar(flagSet, &listens, "L", "listen")
 	customVar(flagSet, &dials, "D", "dial")
 	customVar(flagSet, &dirs, "dir")
+	customVar(flagSet, &image, "image")
+	customVar(flagSet, &fork, "fork")
+	customVar(flagSet, &platform, "image-platform")
 	customVar(flagSet, &sockets, "S", "sockets")
 	customVar(flagSet, &chaotic, "C", "chaotic")
 	boolVar(flagSet, &trace, "T", "trace")
+	stringVar(flagSet, &traceLayer, "trace-layer")
+	customVar(flagSet, &traceOutput, "trace-output")
 	boolVar(flagSet, &flyBlind, "fly-blind")
 	boolVar(flagSet, &restrict, "restrict")
 	customVar(flagSet, &batchSize, "record-batch-size")
 	customVar(flagSet, &compression, "record-compression")
+	customVar(flagSet, &elideFDs, "record-elide-fd")
+	customVar(flagSet, &elidePaths, "record-elide-path")
+	customVar(flagSet, &segmentSize, "record-segment-size")
+	customVar(flagSet, &segmentTime, "record-segment-duration")
+	customVar(flagSet, &taskJournal, "task-journal")
 
 	if err := flagSet.Parse(args); err != nil {
 		return err
 	}
 	args = flagSet.Args()
-	if len(args) == 0 {
-		return errors.New(`missing "--" separator before the module path`)
+
+	var liveLayer *layer
+	if traceLayer != "" {
+		l, err := findLayer(traceLayer)
+		if err != nil {
+			return err
+		}
+		switch l.typ {
+		case "network", "request":
+			liveLayer = l
+		default:
+			return fmt.Errorf(`tracing layer '%s' cannot be used with --trace-layer (must be network or request)`, l.typ)
+		}
+	} else if traceOutput != "" {
+		return errors.New(`--trace-output cannot be used without --trace-layer`)
 	}
 
 	var wasmPath string
-	wasmPath, args = args[0], args[1:]
+	var imageSpec *timecraft.ImageSpec
+	var forkID *timecraft.ProcessID
+	if image != "" {
+		path, err := image.Resolve()
+		if err != nil {
+			return err
+		}
+		spec, err := timecraft.ParseImageSpec(path)
+		if err != nil {
+			return err
+		}
+		imageSpec = &spec
+		imageSpec.Platform = (*ocispec.Platform)(&platform)
+	}
+	if fork != (processIDFlag{}) {
+		if len(args) != 0 {
+			return errors.New(`unexpected module path or arguments with --fork (they are taken from the recorded process)`)
+		}
+		forkID = (*timecraft.ProcessID)(&fork)
+	} else if imageSpec == nil {
+		if len(args) == 0 {
+			return errors.New(`missing "--" separator before the module path`)
+		}
+		wasmPath, args = args[0], args[1:]
+	}
 
-	if !restrict {
-		envs = append(os.Environ(), envs...)
+	// When running an image, the environment and root directory are provided
+	// by the image instead of the host.
+	if !restrict && imageSpec == nil {
+		if forkID == nil {
+			envs = append(os.Environ(), envs...)
+		}
 		dirs = append([]string{"/"}, dirs...)
 	}
 
@@ -101,6 +188,18 @@ func run(ctx context.Context, args []string) error {
 	defer runtime.Close(ctx)
 
 	scheduler := &timecraft.TaskScheduler{}
+	if taskJournal != "" {
+		path, err := taskJournal.Resolve()
+		if err != nil {
+			return err
+		}
+		journal, err := timecraft.OpenTaskJournal(path)
+		if err != nil {
+			return err
+		}
+		defer journal.Close()
+		scheduler.Journal = journal
+	}
 	defer scheduler.Close()
 
 	serverFactory := &timecraft.ServerFactory{
@@ -123,6 +222,16 @@ func run(ctx context.Context, args []string) error {
 		}
 	}
 
+	if imageSpec != nil {
+		if cachePath, ok := config.Cache.Location.Value(); ok {
+			path, err := cachePath.Resolve()
+			if err != nil {
+				return err
+			}
+			imageSpec.LayersPath = filepath.Join(path, "layers")
+		}
+	}
+
 	processManager := timecraft.NewProcessManager(ctx, registry, runtime, serverFactory, adapter)
 	defer processManager.Close()
 
@@ -131,6 +240,8 @@ func run(ctx context.Context, args []string) error {
 
 	moduleSpec := timecraft.ModuleSpec{
 		Path:    wasmPath,
+		Fork:    forkID,
+		Image:   imageSpec,
 		Args:    args,
 		Env:     envs,
 		Dirs:    dirs,
@@ -149,13 +260,41 @@ func run(ctx context.Context, args []string) error {
 		moduleSpec.Trace = os.Stderr
 	}
 
+	var live *liveTrace
+	if liveLayer != nil {
+		var output io.Writer = os.Stderr
+		if traceOutput != "" {
+			path, err := traceOutput.Resolve()
+			if err != nil {
+				return err
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			output = f
+		}
+		live = startLiveTrace(liveLayer, output)
+		moduleSpec.Observer = live.observe
+	}
+
 	var logSpec *timecraft.LogSpec
 	if !flyBlind {
 		logSpec = &timecraft.LogSpec{
 			ProcessID: uuid.New(),
 			StartTime: time.Now(),
 			BatchSize: int(batchSize),
+
+			SegmentSize:     int64(segmentSize),
+			SegmentDuration: time.Duration(segmentTime),
+
+			ElidePaths: append(config.Record.Elide.Paths, elidePaths...),
 		}
+		for _, fd := range config.Record.Elide.FDs {
+			logSpec.ElideFDs = append(logSpec.ElideFDs, wasi.FD(fd))
+		}
+		logSpec.ElideFDs = append(logSpec.ElideFDs, elideFDs...)
 
 		switch compression {
 		case "snappy":
@@ -171,9 +310,70 @@ func run(ctx context.Context, args []string) error {
 		fmt.Fprintf(os.Stderr, "%s\n", logSpec.ProcessID)
 	}
 
+	// Tasks recovered from the journal are executed by subprocesses of the
+	// main module, they must be recovered before the module starts so it can
+	// claim them by submitting them again.
+	scheduler.Recover(moduleSpec, logSpec)
+
 	processID, err := processManager.Start(moduleSpec, logSpec, nil)
 	if err != nil {
+		if live != nil {
+			live.close()
+		}
 		return err
 	}
-	return processManager.Wait(processID)
+	err = processManager.Wait(processID)
+	if live != nil {
+		if traceErr := live.close(); err == nil {
+			err = traceErr
+		}
+	}
+	return err
+}
+
+// liveTrace feeds the system calls of a running module to the decoders of a
+// tracing layer. Each system call is converted to a record as if it had been
+// read from the log of the process.
+type liveTrace struct {
+	records chan stream.Optional[timemachine.Record]
+	done    chan error
+	encoder wasicall.Encoder
+	offset  int64
+}
+
+func startLiveTrace(l *layer, w io.Writer) *liveTrace {
+	t := &liveTrace{
+		records: make(chan stream.Optional[timemachine.Record], 1024),
+		done:    make(chan error, 1),
+	}
+	go func() {
+		err := l.trace(w, "text", "%v", nil, stream.ChanReader(t.records))
+		// Drain the channel if the layer stopped early so the module is not
+		// blocked on its system calls.
+		for range t.records {
+		}
+		t.done <- err
+	}()
+	return t
+}
+
+func (t *liveTrace) observe(ctx context.Context, syscall wasicall.Syscall) {
+	functionCall, err := t.encoder.Encode(nil, syscall)
+	if err != nil {
+		// The tracing layers have no use for system calls that cannot be
+		// represented as records, they are skipped.
+		return
+	}
+	t.records <- stream.Opt(timemachine.Record{
+		Offset:       t.offset,
+		Time:         time.Now(),
+		FunctionID:   int(syscall.ID()),
+		FunctionCall: functionCall,
+	}, nil)
+	t.offset++
+}
+
+func (t *liveTrace) close() error {
+	close(t.records)
+	return <-t.done
 }
@@ -1,12 +1,27 @@
 package main_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stealthrocket/timecraft/internal/assert"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
 )
 
 var run = tests{
@@ -33,6 +48,115 @@ var run = tests{
 			t.Run(file, func(t *testing.T) { testRun(t, file) })
 		}
 	},
+
+	"run the entrypoint of an OCI image": func(t *testing.T) {
+		layout := makeImageLayout(t, "testdata/go/echo.wasm", "latest")
+
+		stdout, processID, exitCode := timecraft(t, "run", "--image", layout+":latest")
+		assert.Equal(t, stdout, "hello world\n")
+		assert.Equal(t, exitCode, 0)
+
+		stdout, _, exitCode = timecraft(t, "describe", "process", strings.TrimSpace(processID))
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(stdout, "Image:   sha256:"))
+	},
+
+	"the arguments passed to run replace the command of an OCI image": func(t *testing.T) {
+		layout := makeImageLayout(t, "testdata/go/echo.wasm", "v1")
+
+		stdout, _, exitCode := timecraft(t, "run", "--image", layout+":v1", "--", "-n", "bonjour")
+		assert.Equal(t, stdout, "bonjour")
+		assert.Equal(t, exitCode, 0)
+	},
+
+	"fork a recorded process into a new process": func(t *testing.T) {
+		stdout, parentID, exitCode := timecraft(t, "run", "--", "./testdata/go/echo.wasm", "-n", "hello")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "hello")
+		parentID = strings.TrimSpace(parentID)
+
+		fork, processID, exitCode := timecraft(t, "run", "--fork", parentID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, fork, "hello")
+		processID = strings.TrimSpace(processID)
+		assert.NotEqual(t, processID, parentID)
+
+		desc, _, exitCode := timecraft(t, "describe", "process", processID)
+		assert.Equal(t, exitCode, 0)
+		assert.True(t, strings.Contains(desc, "Parent:  "+parentID+"\n"))
+
+		replay, _, exitCode := timecraft(t, "replay", processID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, replay, "hello")
+	},
+
+	"fork a process started from an OCI image": func(t *testing.T) {
+		layout := makeImageLayout(t, "testdata/go/echo.wasm", "latest")
+
+		stdout, parentID, exitCode := timecraft(t, "run", "--image", layout+":latest", "--", "-n", "hello")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "hello")
+		parentID = strings.TrimSpace(parentID)
+
+		_, stderr, exitCode := timecraft(t, "run", "--fork", parentID)
+		assert.Equal(t, exitCode, 1)
+		assert.True(t, strings.Contains(stderr, "ERR: timecraft run: could not fork process "+parentID+": the process was started from image sha256:"))
+
+		other := makeImageLayout(t, "testdata/go/sleep.wasm", "latest")
+		_, stderr, exitCode = timecraft(t, "run", "--image", other+":latest", "--fork", parentID)
+		assert.Equal(t, exitCode, 1)
+		assert.True(t, strings.Contains(stderr, "does not match the digest"))
+
+		fork, _, exitCode := timecraft(t, "run", "--image", layout+":latest", "--fork", parentID)
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, fork, "hello")
+	},
+
+	"fork cannot be combined with a module path": func(t *testing.T) {
+		_, stderr, exitCode := timecraft(t, "run", "--fork", "a47b1c1e-4f5c-4b8a-9d9f-2e5b5c7e7d3a", "--", "./testdata/go/echo.wasm")
+		assert.Equal(t, exitCode, 1)
+		assert.HasPrefix(t, stderr, "ERR: timecraft run: unexpected module path or arguments with --fork")
+	},
+
+	"trace the requests of a module while it runs": func(t *testing.T) {
+		// Guest modules cannot reach the loopback interface of the host, the
+		// server must listen on an external address.
+		l, err := net.Listen("tcp", net.JoinHostPort(externalIP(t), "0"))
+		assert.OK(t, err)
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "hello")
+		}))
+		server.Listener = l
+		server.Start()
+		defer server.Close()
+
+		traceFile := filepath.Join(t.TempDir(), "trace.txt")
+		stdout, _, exitCode := timecraft(t, "run", "--trace-layer", "request", "--trace-output", traceFile, "--", "./testdata/go/get.wasm", server.URL+"/live")
+		assert.Equal(t, exitCode, 0)
+		assert.Equal(t, stdout, "hello\n")
+
+		trace, err := os.ReadFile(traceFile)
+		assert.OK(t, err)
+		assert.True(t, strings.Contains(string(trace), "GET /live => 200 OK"))
+	},
+
+	"live tracing only supports the network and request layers": func(t *testing.T) {
+		_, stderr, exitCode := timecraft(t, "run", "--trace-layer", "query", "--", "./testdata/go/sleep.wasm", "1ns")
+		assert.Equal(t, exitCode, 1)
+		assert.HasPrefix(t, stderr, "ERR: timecraft run: tracing layer 'query' cannot be used with --trace-layer")
+	},
+}
+
+func externalIP(t *testing.T) string {
+	addrs, err := net.InterfaceAddrs()
+	assert.OK(t, err)
+	for _, addr := range addrs {
+		if ip, ok := addr.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			return ip.IP.String()
+		}
+	}
+	t.Skip("no external network interface")
+	return ""
 }
 
 func testRun(t *testing.T, module string, args ...string) {
@@ -46,3 +170,92 @@ func testRun(t *testing.T, module string, args ...string) {
 
 	assert.Equal(t, exitCode, 0)
 }
+
+// makeImageLayout creates an OCI image layout containing a single image tagged
+// with the given name. The image has one layer holding the WebAssembly module
+// at /bin/<name>, which is used as the image entrypoint.
+func makeImageLayout(t *testing.T, module, tag string) string {
+	return makeImageLayoutWithFiles(t, module, tag, nil)
+}
+
+// makeImageLayoutWithFiles is like makeImageLayout but the layer of the image
+// also holds the given files, keyed by their path in a top-level directory.
+func makeImageLayoutWithFiles(t *testing.T, module, tag string, files map[string][]byte) string {
+	ctx := context.Background()
+
+	wasmCode, err := os.ReadFile(module)
+	assert.OK(t, err)
+
+	layer := new(bytes.Buffer)
+	tarball := tar.NewWriter(layer)
+	assert.OK(t, tarball.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     "bin/",
+		Mode:     0755,
+	}))
+	assert.OK(t, tarball.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "bin/" + filepath.Base(module),
+		Mode:     0755,
+		Size:     int64(len(wasmCode)),
+	}))
+	_, err = tarball.Write(wasmCode)
+	assert.OK(t, err)
+	dirs := map[string]bool{"bin": true}
+	for name, data := range files {
+		if dir := path.Dir(name); !dirs[dir] {
+			dirs[dir] = true
+			assert.OK(t, tarball.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     dir + "/",
+				Mode:     0755,
+			}))
+		}
+		assert.OK(t, tarball.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+		}))
+		_, err = tarball.Write(data)
+		assert.OK(t, err)
+	}
+	assert.OK(t, tarball.Close())
+
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer.Bytes())
+
+	config, err := json.Marshal(ocispec.Image{
+		Platform: ocispec.Platform{
+			OS:           "wasip1",
+			Architecture: "wasm",
+		},
+		Config: ocispec.ImageConfig{
+			Entrypoint: []string{"/bin/" + filepath.Base(module)},
+			Cmd:        []string{"hello", "world"},
+		},
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{layerDesc.Digest},
+		},
+	})
+	assert.OK(t, err)
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, config)
+
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	assert.OK(t, err)
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifest)
+
+	layout := t.TempDir()
+	store, err := oci.New(layout)
+	assert.OK(t, err)
+	assert.OK(t, store.Push(ctx, layerDesc, bytes.NewReader(layer.Bytes())))
+	assert.OK(t, store.Push(ctx, configDesc, bytes.NewReader(config)))
+	assert.OK(t, store.Push(ctx, manifestDesc, bytes.NewReader(manifest)))
+	assert.OK(t, store.Tag(ctx, manifestDesc, tag))
+	return layout
+}
@@ -150,6 +150,81 @@ func (c *Client) PollTasks(ctx context.Context, batchSize int, timeout time.Dura
 	return responses, nil
 }
 
+// WatchTasks streams the state transitions of tasks.
+//
+// The returned TaskWatcher first yields the current state of the tasks, then
+// each state transition as it happens. When taskIDs is empty, all the tasks
+// submitted by the process are watched, including tasks submitted after the
+// call, and the stream lasts until the context is canceled or the watcher is
+// closed. Otherwise, the stream ends once all the tasks are complete.
+//
+// Tasks discarded while they are watched are reported as failed with a "task
+// not found" error.
+func (c *Client) WatchTasks(ctx context.Context, taskIDs []TaskID) (*TaskWatcher, error) {
+	req := connect.NewRequest(&v1.WatchTasksRequest{
+		TaskId: make([]string, len(taskIDs)),
+	})
+	for i, taskID := range taskIDs {
+		req.Msg.TaskId[i] = string(taskID)
+	}
+	stream, err := c.grpcClient.WatchTasks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskWatcher{client: c, stream: stream}, nil
+}
+
+// TaskWatcher is an iterator over the state transitions of tasks.
+//
+//	w, err := client.WatchTasks(ctx, taskIDs)
+//	if err != nil {
+//		...
+//	}
+//	defer w.Close()
+//
+//	for w.Next() {
+//		res := w.Response()
+//		...
+//	}
+//	if err := w.Err(); err != nil {
+//		...
+//	}
+type TaskWatcher struct {
+	client   *Client
+	stream   *connect.ServerStreamForClient[v1.WatchTasksResponse]
+	response TaskResponse
+	err      error
+}
+
+// Next advances the watcher to the next task response, blocking until it is
+// available. It returns false when the stream ends or when an error occurs.
+func (w *TaskWatcher) Next() bool {
+	if w.err != nil || !w.stream.Receive() {
+		return false
+	}
+	w.response, w.err = w.client.makeTaskResponse(w.stream.Msg().Response)
+	return w.err == nil
+}
+
+// Response returns the current task response.
+func (w *TaskWatcher) Response() TaskResponse {
+	return w.response
+}
+
+// Err returns the error that caused Next to return false, or nil if the
+// stream ended normally.
+func (w *TaskWatcher) Err() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.stream.Err()
+}
+
+// Close closes the watcher.
+func (w *TaskWatcher) Close() error {
+	return w.stream.Close()
+}
+
 // DiscardTasks discards a batch of tasks by ID.
 func (c *Client) DiscardTasks(ctx context.Context, taskIDs []TaskID) error {
 	req := connect.NewRequest(&v1.DiscardTasksRequest{
@@ -164,7 +239,18 @@ func (c *Client) DiscardTasks(ctx context.Context, taskIDs []TaskID) error {
 
 func (c *Client) makeTaskRequest(req *TaskRequest) (*v1.TaskRequest, error) {
 	r := &v1.TaskRequest{
-		Module: c.makeModuleSpec(req.Module),
+		Module:             c.makeModuleSpec(req.Module),
+		ExecutionTimeoutNs: int64(req.ExecutionTimeout),
+		QueueTimeoutNs:     int64(req.QueueTimeout),
+		Priority:           v1.TaskPriority(req.Priority),
+		IdempotencyKey:     req.IdempotencyKey,
+	}
+	if req.Retry != (RetryPolicy{}) {
+		r.RetryPolicy = &v1.RetryPolicy{
+			MaxAttempts: int32(req.Retry.MaxAttempts),
+			MinDelayNs:  int64(req.Retry.MinDelay),
+			MaxDelayNs:  int64(req.Retry.MaxDelay),
+		}
 	}
 	switch in := req.Input.(type) {
 	case *HTTPRequest:
@@ -181,6 +267,12 @@ func (c *Client) makeTaskRequest(req *TaskRequest) (*v1.TaskRequest, error) {
 			Port:    int32(in.Port),
 			Headers: headers,
 		}}
+	case *FunctionCall:
+		r.Input = &v1.TaskRequest_FunctionCall{FunctionCall: &v1.FunctionCall{
+			Function: in.Function,
+			Args:     in.Args,
+			Stdin:    in.Stdin,
+		}}
 	default:
 		return nil, fmt.Errorf("invalid task input: %v", req.Input)
 	}
@@ -207,6 +299,12 @@ func (c *Client) makeTaskResponse(res *v1.TaskResponse) (TaskResponse, error) {
 			httpResponse.Headers[h.Name] = append(httpResponse.Headers[h.Name], h.Value)
 		}
 		taskResponse.Output = httpResponse
+	case *v1.TaskResponse_FunctionResult:
+		taskResponse.Output = &FunctionResult{
+			ExitCode: int(out.FunctionResult.ExitCode),
+			Stdout:   out.FunctionResult.Stdout,
+			Stderr:   out.FunctionResult.Stderr,
+		}
 	}
 	return taskResponse, nil
 }
@@ -1,6 +1,9 @@
 package timecraft
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // TaskID is a task identifier.
 type TaskID string
@@ -14,8 +17,61 @@ type TaskRequest struct {
 
 	// Input is input to the task.
 	Input TaskInput
+
+	// ExecutionTimeout is the time limit for each attempt at executing the
+	// task. The timecraft runtime uses a default of 1 minute when zero.
+	ExecutionTimeout time.Duration
+
+	// QueueTimeout is the maximum amount of time the task can be queued for
+	// before it expires. The timecraft runtime uses a default of 10 minutes
+	// when zero.
+	QueueTimeout time.Duration
+
+	// Retry is the policy for retrying the task when an attempt at executing
+	// it fails with an error.
+	Retry RetryPolicy
+
+	// Priority is the priority class of the task. The timecraft runtime
+	// uses NormalPriority when zero.
+	Priority TaskPriority
+
+	// IdempotencyKey is an optional key identifying the task. Submitting a
+	// task with the same key as a task which has not been discarded returns
+	// the identifier of the existing task. Keys are local to the process
+	// which submits the tasks. When the timecraft runtime is configured with
+	// a task journal, this allows reclaiming tasks after a restart.
+	IdempotencyKey string
+}
+
+// RetryPolicy is the policy for retrying tasks.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts at executing the task.
+	// The task is attempted once when MaxAttempts is zero.
+	MaxAttempts int
+
+	// MinDelay and MaxDelay bound the exponential backoff between attempts.
+	// The timecraft runtime uses defaults of 500ms and 5s when zero.
+	MinDelay time.Duration
+	MaxDelay time.Duration
 }
 
+// TaskPriority is the priority class of a task.
+//
+// Queued tasks with a higher priority are scheduled before tasks with a lower
+// priority.
+type TaskPriority int
+
+const (
+	// LowPriority is the priority class of background tasks.
+	LowPriority TaskPriority = iota + 1
+
+	// NormalPriority is the default priority class.
+	NormalPriority
+
+	// HighPriority is the priority class of latency sensitive tasks.
+	HighPriority
+)
+
 // TaskResponse is information about a task from the timecraft runtime.
 type TaskResponse struct {
 	// ID is the task identifier.
@@ -76,6 +132,20 @@ type HTTPRequest struct {
 
 func (*HTTPRequest) taskInput() {}
 
+// FunctionCall is a call to a function exported by the WebAssembly module of
+// the task.
+//
+// Each call runs in a new process, which exits once the function returns.
+// When not empty, Function and Args override those of the ModuleSpec of the
+// task. Stdin is the data that the module reads from its standard input.
+type FunctionCall struct {
+	Function string
+	Args     []string
+	Stdin    []byte
+}
+
+func (*FunctionCall) taskInput() {}
+
 // TaskOutput is output from a task.
 type TaskOutput interface{ taskOutput() }
 
@@ -88,6 +158,15 @@ type HTTPResponse struct {
 
 func (*HTTPResponse) taskOutput() {}
 
+// FunctionResult is the result of a function call.
+type FunctionResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+func (*FunctionResult) taskOutput() {}
+
 // ModuleSpec is a WebAssembly module specification.
 type ModuleSpec struct {
 	Path          string
@@ -1,7 +1,8 @@
 from .client import Client
 from .client import TaskRequest, TaskResponse, TaskInput, TaskOutput
-from .client import TaskState, TaskID
+from .client import TaskState, TaskID, TaskPriority, RetryPolicy
 from .client import HTTPRequest, HTTPResponse, Header
+from .client import FunctionCall, FunctionResult
 from .client import ProcessID, ModuleSpec
 
 from .server import serve_forever
@@ -9,7 +10,8 @@ from .server import serve_forever
 
 __all__ = ['Client',
            'TaskRequest', 'TaskResponse', 'TaskInput', 'TaskOutput',
-           'TaskState', 'TaskID',
+           'TaskState', 'TaskID', 'TaskPriority', 'RetryPolicy',
            'HTTPRequest', 'HTTPResponse', 'Header',
+           'FunctionCall', 'FunctionResult',
            'ProcessID', 'ModuleSpec',
            'serve_forever']
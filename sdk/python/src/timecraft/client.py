@@ -1,5 +1,7 @@
 import base64
+import json
 import logging
+import struct
 from typing import Optional
 from pprint import pprint
 from enum import Enum
@@ -25,6 +27,13 @@ class TaskState(Enum):
     SUCCESS = "TASK_STATE_SUCCESS"
 
 
+class TaskPriority(Enum):
+    UNSPECIFIED = "TASK_PRIORITY_UNSPECIFIED"
+    LOW = "TASK_PRIORITY_LOW"
+    NORMAL = "TASK_PRIORITY_NORMAL"
+    HIGH = "TASK_PRIORITY_HIGH"
+
+
 ProcessID = str
 Header = dict[str, str]
 TaskID = str
@@ -52,10 +61,22 @@ class TaskOutput:
         raise NotImplementedError
 
 
+@dataclass
+class RetryPolicy:
+    max_attempts: int = 0
+    min_delay_ns: int = 0
+    max_delay_ns: int = 0
+
+
 @dataclass
 class TaskRequest:
     module: ModuleSpec
     input: TaskInput
+    execution_timeout_ns: int = 0
+    queue_timeout_ns: int = 0
+    retry_policy: Optional[RetryPolicy] = None
+    priority: TaskPriority = TaskPriority.UNSPECIFIED
+    idempotency_key: Optional[str] = None
 
 
 @dataclass
@@ -97,6 +118,24 @@ class HTTPRequest(TaskInput):
         }
 
 
+@dataclass
+class FunctionCall(TaskInput):
+    function: Optional[str] = None
+    args: list[str] = field(default_factory=list)
+    stdin: bytes = b""
+
+    def serialize(self):
+        function_call = {
+            "args": self.args,
+            "stdin": base64.b64encode(self.stdin).decode("utf-8"),
+        }
+        if self.function is not None:
+            function_call["function"] = self.function
+        return {
+            "functionCall": function_call
+        }
+
+
 def zipheader(lst):
     return dict((x["name"], x["value"]) for x in lst)
 
@@ -115,6 +154,20 @@ class HTTPResponse(TaskOutput):
         return cls(**data)
 
 
+@dataclass
+class FunctionResult(TaskOutput):
+    exit_code: int = 0
+    stdout: bytes = b""
+    stderr: bytes = b""
+
+    @classmethod
+    def deserialize(cls, data):
+        remap(data, "exitCode", "exit_code")
+        remap(data, "stdout", "stdout", base64.b64decode)
+        remap(data, "stderr", "stderr", base64.b64decode)
+        return cls(**data)
+
+
 ClientLogger = None
 
 
@@ -175,7 +228,18 @@ class Client:
         for t in tasks:
             task_request = {
                 "module": dataclasses.asdict(t.module),
+                "executionTimeoutNs": t.execution_timeout_ns,
+                "queueTimeoutNs": t.queue_timeout_ns,
+                "priority": t.priority.value,
             }
+            if t.idempotency_key is not None:
+                task_request["idempotencyKey"] = t.idempotency_key
+            if t.retry_policy is not None:
+                task_request["retryPolicy"] = {
+                    "maxAttempts": t.retry_policy.max_attempts,
+                    "minDelayNs": t.retry_policy.min_delay_ns,
+                    "maxDelayNs": t.retry_policy.max_delay_ns,
+                }
             task_request.update(t.input.serialize())
             requests.append(task_request)
 
@@ -211,6 +275,66 @@ class Client:
             responses.append(TaskResponse(**r))
         return responses
 
+    def watch_tasks(self, tasks: Optional[list[TaskID]] = None):
+        """
+        Generator of the state transitions of tasks.
+
+        The current state of the tasks is yielded first, then each state
+        transition as it happens. When no tasks are given, all the tasks
+        submitted by the process are watched, including tasks submitted
+        after the call, and the generator never returns. Otherwise, it
+        returns once all the tasks are complete.
+
+        Tasks discarded while they are watched are reported as failed with
+        a "task not found" error.
+        """
+        watch_tasks_request = {
+            "taskId": tasks or [],
+        }
+        for out in self._stream("WatchTasks", watch_tasks_request):
+            r = out["response"]
+            self._remap_task(r)
+            yield TaskResponse(**r)
+
+    def _stream(self, endpoint, payload):
+        # Server streaming RPCs use the Connect protocol, where each message
+        # is prefixed by a flags byte and its length. The stream ends with a
+        # message carrying the end-of-stream flag and an optional error.
+        body = json.dumps(payload).encode("utf-8")
+        r = self.session.post(
+            self._root + endpoint,
+            data=struct.pack(">BI", 0, len(body)) + body,
+            headers={"Content-Type": "application/connect+json"},
+            stream=True,
+        )
+        with r:
+            r.raise_for_status()
+            while True:
+                flags, length = struct.unpack(">BI", self._read_exact(r.raw, 5))
+                if flags & 0x01:
+                    # Compression is not negotiated by the client, so the
+                    # server is not expected to compress messages.
+                    raise RuntimeError("unsupported compressed stream message")
+                message = json.loads(self._read_exact(r.raw, length))
+                if flags & 0x02:
+                    if "error" in message:
+                        error = message["error"]
+                        raise RuntimeError(f"{error.get('code')}: {error.get('message')}")
+                    return
+                yield message
+
+    @staticmethod
+    def _read_exact(raw, size: int) -> bytes:
+        # Reads of the raw response may return less data than requested, the
+        # message is incomplete only if the stream ended.
+        data = b""
+        while len(data) < size:
+            chunk = raw.read(size - len(data))
+            if not chunk:
+                raise EOFError("unexpected end of stream")
+            data += chunk
+        return data
+
     def discard_tasks(self, tasks: list[TaskID]):
         self._rpc("DiscardTasks", {"taskId": tasks})
 
@@ -226,4 +350,5 @@ class Client:
         remap(r, "errorMessage", "error")
         remap(r, "processId", "process_id", ProcessID)
         remap(r, "httpResponse", "output", HTTPResponse.deserialize)
+        remap(r, "functionResult", "output", FunctionResult.deserialize)
         remap(r, "taskId", "id", TaskID)
@@ -21,9 +21,24 @@ import (
 const traceUsage = `
 Usage:	timecraft trace <layer> <process id> [options]
 
+   Filter expressions compare fields of the trace with a value, and may be
+   combined with the and, or, and not operators. The comparison operators
+   are =, !=, <, <=, >, >=, ~ (regular expression match), and !~:
+
+   $ timecraft trace request <process id> -f 'http.status>=500 and http.path~"^/api"'
+   $ timecraft trace network <process id> -f 'net.peer=10.0.0.5:5432'
+
+   Events of the network layer have the net.proto, net.type, net.fd, net.size,
+   net.errno, net.addr, and net.peer fields, and dns.name for DNS lookups.
+   Exchanges of the request and query layers have the proto, span, error,
+   net.src, net.dst, net.addr, net.peer, http.method, http.path, http.host,
+   http.version, http.status, http.header.<name>, grpc.status, sql.query, and
+   redis.command fields. All values have a time field.
+
 Options:
    -c, --config             Path to the timecraft configuration file (overrides TIMECRAFTCONFIG)
    -d, --duration duration  Duration of the trace (default to the process uptime)
+   -f, --filter expr        Only show values matching a filter expression (network, request, and query layers)
    -h, --help               Show this usage information
    -o, --output format      Output format, one of: text, json, yaml
    -t, --start-time time    Time at which the trace starts (default to 1 minute)
@@ -33,19 +48,39 @@ Options:
 type layer struct {
 	typ   string
 	alt   []string
-	trace func(io.Writer, outputFormat, string, stream.Reader[tracing.Event]) error
+	trace func(io.Writer, outputFormat, string, tracing.Filter, stream.Reader[timemachine.Record]) error
+	// Whether the layer supports filter expressions.
+	filter bool
 }
 
 var layers = [...]layer{
 	{
-		typ:   "network",
-		alt:   []string{"net", "nets", "networks"},
-		trace: traceNetwork,
+		typ:    "network",
+		alt:    []string{"net", "nets", "networks"},
+		trace:  traceNetwork,
+		filter: true,
+	},
+	{
+		typ:    "request",
+		alt:    []string{"req", "reqs", "requests"},
+		trace:  traceRequest,
+		filter: true,
+	},
+	{
+		typ:    "query",
+		alt:    []string{"queries", "sql"},
+		trace:  traceQuery,
+		filter: true,
+	},
+	{
+		typ:   "dns",
+		alt:   []string{"lookup", "lookups"},
+		trace: traceDNS,
 	},
 	{
-		typ:   "request",
-		alt:   []string{"req", "reqs", "requests"},
-		trace: traceRequest,
+		typ:   "filesystem",
+		alt:   []string{"fs", "file", "files"},
+		trace: traceFilesystem,
 	},
 }
 
@@ -64,6 +99,7 @@ func trace(ctx context.Context, args []string) error {
 		startTime = human.Time{}
 		duration  = human.Duration(1 * time.Minute)
 		verbose   = false
+		filter    = ""
 	)
 
 	flagSet := newFlagSet("timecraft trace", traceUsage)
@@ -71,6 +107,7 @@ func trace(ctx context.Context, args []string) error {
 	customVar(flagSet, &duration, "d", "duration")
 	customVar(flagSet, &startTime, "t", "start-time")
 	boolVar(flagSet, &verbose, "v", "verbose")
+	stringVar(flagSet, &filter, "f", "filter")
 
 	args, err := parseFlags(flagSet, args)
 	if err != nil {
@@ -81,6 +118,9 @@ func trace(ctx context.Context, args []string) error {
 
    $ timecraft trace network <process id> ...
    $ timecraft trace request <process id> ...
+   $ timecraft trace query <process id> ...
+   $ timecraft trace dns <process id> ...
+   $ timecraft trace filesystem <process id> ...
 `)
 		return exitCode(2)
 	}
@@ -90,6 +130,17 @@ func trace(ctx context.Context, args []string) error {
 		perror(err)
 		return exitCode(2)
 	}
+	var filterExpr tracing.Filter
+	if filter != "" {
+		if !layer.filter {
+			perrorf(`The %s layer does not support filter expressions`, layer.typ)
+			return exitCode(2)
+		}
+		if filterExpr, err = tracing.ParseFilter(filter); err != nil {
+			perror(err)
+			return exitCode(2)
+		}
+	}
 	processID, err := parseProcessID(args[1])
 	if err != nil {
 		return err
@@ -115,7 +166,7 @@ func trace(ctx context.Context, args []string) error {
 	// 	End:   time.Time(startTime).Add(time.Duration(duration)),
 	// }
 
-	logSegment, err := registry.ReadLogSegment(ctx, processID, 0)
+	logSegment, err := registry.ReadLog(ctx, manifest)
 	if err != nil {
 		return err
 	}
@@ -129,51 +180,87 @@ func trace(ctx context.Context, args []string) error {
 		format = "%+v"
 	}
 
-	return layer.trace(os.Stdout, output, format, &tracing.EventReader{
-		Records: timemachine.NewLogRecordReader(logReader),
+	return layer.trace(os.Stdout, output, format, filterExpr, timemachine.NewLogRecordReader(logReader))
+}
+
+func traceNetwork(w io.Writer, output outputFormat, format string, filter tracing.Filter, records stream.Reader[timemachine.Record]) error {
+	return traceCopy(w, output, format, "", filterTrace[tracing.Event](&tracing.EventReader{
+		Records: records,
+	}, filter, tracing.EventFields))
+}
+
+func traceRequest(w io.Writer, output outputFormat, format string, filter tracing.Filter, records stream.Reader[timemachine.Record]) error {
+	return traceCopy(w, output, format, "\n", filterTrace[tracing.Exchange](&tracing.ExchangeReader{
+		Messages: &tracing.MessageReader{
+			Events: &tracing.EventReader{
+				Records: records,
+			},
+			Protos: []tracing.ConnProtocol{
+				tracing.HTTP1(),
+				tracing.HTTP2(),
+				tracing.Postgres(),
+				tracing.Redis(),
+			},
+		},
+	}, filter, tracing.ExchangeFields))
+}
+
+func traceQuery(w io.Writer, output outputFormat, format string, filter tracing.Filter, records stream.Reader[timemachine.Record]) error {
+	return traceCopy[tracing.Query](w, output, format, "\n", &tracing.QueryReader{
+		Exchanges: filterTrace[tracing.Exchange](&tracing.ExchangeReader{
+			Messages: &tracing.MessageReader{
+				Events: &tracing.EventReader{
+					Records: records,
+				},
+				Protos: []tracing.ConnProtocol{
+					tracing.Postgres(),
+				},
+			},
+		}, filter, tracing.ExchangeFields),
+	})
+}
+
+func traceDNS(w io.Writer, output outputFormat, format string, filter tracing.Filter, records stream.Reader[timemachine.Record]) error {
+	return traceCopy[tracing.DNSLookup](w, output, format, "\n", &tracing.DNSLookupReader{
+		Events: &tracing.EventReader{
+			Records: records,
+		},
+	})
+}
+
+func traceFilesystem(w io.Writer, output outputFormat, format string, filter tracing.Filter, records stream.Reader[timemachine.Record]) error {
+	return traceCopy[tracing.FileAccess](w, output, format, "\n", &tracing.FileAccessReader{
+		Records: records,
 	})
 }
 
-func traceNetwork(w io.Writer, output outputFormat, format string, events stream.Reader[tracing.Event]) error {
-	var writer stream.WriteCloser[tracing.Event]
+// traceCopy writes the values read from r to w in the output format. In the
+// text format, values are printed with format and separated by separator; a
+// final line break is added when the separator is not empty.
+func traceCopy[T any](w io.Writer, output outputFormat, format, separator string, r stream.Reader[T]) error {
+	var writer stream.WriteCloser[T]
 	switch output {
 	case "json":
-		writer = jsonprint.NewWriter[tracing.Event](w)
+		writer = jsonprint.NewWriter[T](w)
 	case "yaml":
-		writer = yamlprint.NewWriter[tracing.Event](w)
+		writer = yamlprint.NewWriter[T](w)
 	default:
-		writer = textprint.NewWriter[tracing.Event](w,
-			textprint.Format[tracing.Event](format),
-			textprint.Separator[tracing.Event](""),
+		writer = textprint.NewWriter[T](w,
+			textprint.Format[T](format),
+			textprint.Separator[T](separator),
 		)
+		if separator != "" {
+			defer fmt.Fprintln(w)
+		}
 	}
 	defer writer.Close()
-	_, err := stream.Copy[tracing.Event](writer, events)
+	_, err := stream.Copy[T](writer, r)
 	return err
 }
 
-func traceRequest(w io.Writer, output outputFormat, format string, events stream.Reader[tracing.Event]) error {
-	var writer stream.WriteCloser[tracing.Exchange]
-	switch output {
-	case "json":
-		writer = jsonprint.NewWriter[tracing.Exchange](w)
-	case "yaml":
-		writer = yamlprint.NewWriter[tracing.Exchange](w)
-	default:
-		writer = textprint.NewWriter[tracing.Exchange](w,
-			textprint.Format[tracing.Exchange](format),
-			textprint.Separator[tracing.Exchange]("\n"),
-		)
-		defer fmt.Println()
+func filterTrace[T any](r stream.Reader[T], filter tracing.Filter, fields func(*T) *tracing.Fields) stream.Reader[T] {
+	if filter == nil {
+		return r
 	}
-	defer writer.Close()
-	_, err := stream.Copy[tracing.Exchange](writer, &tracing.ExchangeReader{
-		Messages: &tracing.MessageReader{
-			Events: events,
-			Protos: []tracing.ConnProtocol{
-				tracing.HTTP1(),
-			},
-		},
-	})
-	return err
+	return &tracing.FilterReader[T]{Reader: r, Filter: filter, Fields: fields}
 }